@@ -0,0 +1,103 @@
+// carrier-sim replays sample carrier tracking webhooks against the order service.
+//
+//	go run ./cmd/carrier-sim -order-id <order id> -secret <webhook secret>
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+	"github.com/cgund98/go-eventsrc-example/internal/service/carriers"
+)
+
+//go:embed samples.json
+var defaultSamples []byte
+
+const orderIdPlaceholder = "{{order_id}}"
+
+func loadSamples(file string, orderId string) ([]json.RawMessage, error) {
+	data := defaultSamples
+	if file != "" {
+		var err error
+		data, err = os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read samples: %w", err)
+		}
+	}
+
+	data = []byte(strings.ReplaceAll(string(data), orderIdPlaceholder, orderId))
+
+	var samples []json.RawMessage
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return nil, fmt.Errorf("failed to parse samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+func send(client *http.Client, url string, secret string, body []byte) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(carriers.SignatureHeader, webhooks.Sign(secret, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+
+	return resp.StatusCode, strings.TrimSpace(string(respBody)), nil
+}
+
+func main() {
+	url := flag.String("url", "http://localhost:8080"+carriers.WebhookPath, "carrier webhook endpoint")
+	secret := flag.String("secret", os.Getenv("ORDER_SVC_CARRIERWEBHOOKSECRET"), "carrier webhook secret")
+	orderId := flag.String("order-id", "", "order to send the tracking events for")
+	file := flag.String("file", "", "JSON array of carrier payloads, defaults to the embedded samples")
+	delay := flag.Duration("delay", time.Second, "delay between payloads")
+	flag.Parse()
+
+	if *orderId == "" || *secret == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	samples, err := loadSamples(*file, *orderId)
+	if err != nil {
+		logging.Logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	for i, sample := range samples {
+		if i > 0 {
+			time.Sleep(*delay)
+		}
+
+		statusCode, respBody, err := send(client, *url, *secret, sample)
+		if err != nil {
+			logging.Logger.Error("failed to send carrier webhook", "error", err)
+			os.Exit(1)
+		}
+
+		logging.Logger.Info("Sent carrier webhook", "payload", string(sample), "status", statusCode, "response", respBody)
+	}
+}
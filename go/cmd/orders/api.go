@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	ordercons "github.com/cgund98/go-eventsrc-example/internal/entity/orders/consumers"
+	"github.com/cgund98/go-eventsrc-example/internal/service/admin"
+	"github.com/cgund98/go-eventsrc-example/internal/service/carriers"
+	"github.com/cgund98/go-eventsrc-example/internal/service/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/service/invoices"
+	"github.com/cgund98/go-eventsrc-example/internal/service/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/service/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/service/payments"
+	"github.com/cgund98/go-eventsrc-example/internal/service/pricing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/config"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/health"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/metrics"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/ratelimit"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+
+	"buf.build/go/protovalidate"
+	protovalidate_middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/protovalidate"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// initVerifier returns the verifier of the bearer tokens of requests, or nil if authentication is disabled.
+func initVerifier(config *config.Config) (*auth.Verifier, error) {
+	opts := auth.VerifierOptions{
+		Issuer:   config.AuthIssuer,
+		Audience: config.AuthAudience,
+		Leeway:   config.AuthLeeway,
+	}
+
+	switch {
+	case config.AuthJwksFile != "" && config.AuthHmacSecret != "":
+		return nil, fmt.Errorf("only one of the JWKS file and the HMAC secret can be set")
+	case config.AuthJwksFile != "":
+		keys, err := auth.LoadJWKS(config.AuthJwksFile)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewJWKSVerifier(keys, opts), nil
+	case config.AuthHmacSecret != "":
+		return auth.NewHMACVerifier([]byte(config.AuthHmacSecret), opts), nil
+	}
+
+	return nil, nil
+}
+
+// rateLimits returns the rate limits of the RPCs. A method can override its rate, its burst or both.
+func rateLimits(config *config.Config) grpcutils.RateLimits {
+	limits := grpcutils.RateLimits{
+		Default: ratelimit.Limit{Rate: config.RateLimitRate, Burst: config.RateLimitBurst},
+		Methods: map[string]ratelimit.Limit{},
+	}
+	override := func(method string) ratelimit.Limit {
+		if limit, ok := limits.Methods[method]; ok {
+			return limit
+		}
+		return limits.Default
+	}
+	for method, rate := range config.RateLimitMethodRates {
+		limit := override(method)
+		limit.Rate = rate
+		limits.Methods[method] = limit
+	}
+	for method, burst := range config.RateLimitMethodBursts {
+		limit := override(method)
+		limit.Burst = burst
+		limits.Methods[method] = limit
+	}
+
+	return limits
+}
+
+func runGRPCServer(ctx context.Context, a *app, verifier *auth.Verifier) error {
+	config := a.config
+	orderService := orders.NewOrderService(a.controller, a.sagaStore)
+	inventoryService := inventory.NewInventoryService(a.inventoryController)
+	pricingService := pricing.NewPricingService(a.pricingEngine, a.couponController)
+	ledgerService := ledger.NewLedgerService(a.ledgerController)
+	invoiceService := invoices.NewInvoiceService(a.invoiceController)
+
+	// Create a Protovalidate Validator
+	validator, err := protovalidate.New()
+	if err != nil {
+		return fmt.Errorf("unable to create protovalidate validator: %v", err)
+	}
+
+	// Use the protovalidate_middleware interceptor provided by grpc-ecosystem
+	interceptor := protovalidate_middleware.UnaryServerInterceptor(validator)
+
+	interceptors := []grpc.UnaryServerInterceptor{grpcutils.RequestIdInterceptor, grpcutils.LoggerInterceptor, grpcutils.MetricsInterceptor}
+	if verifier != nil {
+		interceptors = append(interceptors, grpcutils.AuthInterceptor(verifier, orders.Policy, inventory.Policy, pricing.Policy, ledger.Policy, invoices.Policy))
+	}
+	if config.RateLimitRate > 0 {
+		interceptors = append(interceptors, grpcutils.RateLimitInterceptor(ratelimit.NewLimiter(), rateLimits(config)))
+	} else {
+		logging.Logger.Warn("Rate limit is not set, rate limiting is disabled")
+	}
+	interceptors = append(interceptors, interceptor)
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(interceptors...),
+	)
+	pb.RegisterOrderServiceServer(server, orderService)
+	pb.RegisterInventoryServiceServer(server, inventoryService)
+	pb.RegisterPricingServiceServer(server, pricingService)
+	pb.RegisterLedgerServiceServer(server, ledgerService)
+	pb.RegisterInvoiceServiceServer(server, invoiceService)
+
+	// Report the readiness of every service through grpc.health.v1
+	services := []string{}
+	for service := range server.GetServiceInfo() {
+		services = append(services, service)
+	}
+	healthServer := grpcutils.NewHealthServer(a.checker, services...)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.GrpcPort))
+	if err != nil {
+		return fmt.Errorf("unable to listen on gRPC port: %v", err)
+	}
+	defer lis.Close()
+
+	// Stop accepting requests once the service stops, and let the requests in flight finish
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		healthServer.Shutdown()
+		stopGRPCServer(server, config.ShutdownTimeout)
+	}()
+
+	logging.Logger.Info("Starting gRPC server...", "address", lis.Addr().String())
+
+	if err := server.Serve(lis); err != nil {
+		logging.Logger.Error(fmt.Sprintf("failed to serve gRPC: %v", err))
+		return fmt.Errorf("failed to serve gRPC: %v", err)
+	}
+
+	<-stopped
+	logging.Logger.Info("Stopped gRPC server")
+	return nil
+}
+
+// stopGRPCServer stops the server once the requests in flight are handled, or cancels them after the timeout.
+func stopGRPCServer(server *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		logging.Logger.Warn("Timed out stopping the gRPC server, cancelling the requests in flight")
+		server.Stop()
+	}
+}
+
+// runAdminServer serves the admin API on its own port, so that it can be kept off the public network.
+// Every RPC is reserved to admins.
+func runAdminServer(ctx context.Context, a *app, verifier *auth.Verifier) error {
+	config := a.config
+	consumers := a.consumers()
+	projectionIndexer := consumers[slices.IndexFunc(consumers, func(consumer eventsrc.Consumer) bool {
+		return consumer.Name() == ordercons.ConsumerNameProjectionIndexer
+	})]
+	groups := eventsrc.NewConsumerGroups(a.kafkaClient, config.EventsTopic)
+	adminService := admin.NewAdminService(ctx, a.store, a.bus, a.consumerStates, groups, consumerNames(consumers), projectionIndexer)
+
+	validator, err := protovalidate.New()
+	if err != nil {
+		return fmt.Errorf("unable to create protovalidate validator: %v", err)
+	}
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			grpcutils.RequestIdInterceptor,
+			grpcutils.LoggerInterceptor,
+			grpcutils.MetricsInterceptor,
+			grpcutils.AuthInterceptor(verifier),
+			protovalidate_middleware.UnaryServerInterceptor(validator),
+		),
+	)
+	pb.RegisterAdminServiceServer(server, adminService)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.AdminGrpcPort))
+	if err != nil {
+		return fmt.Errorf("unable to listen on admin gRPC port: %v", err)
+	}
+	defer lis.Close()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		stopGRPCServer(server, config.ShutdownTimeout)
+	}()
+
+	logging.Logger.Info("Starting admin gRPC server...", "address", lis.Addr().String())
+
+	if err := server.Serve(lis); err != nil {
+		logging.Logger.Error(fmt.Sprintf("failed to serve admin gRPC: %v", err))
+		return fmt.Errorf("failed to serve admin gRPC: %v", err)
+	}
+
+	<-stopped
+	adminService.Wait()
+	logging.Logger.Info("Stopped admin gRPC server")
+	return nil
+}
+
+func runGatewayServer(ctx context.Context, a *app, webhookHandlers map[string]http.Handler) error {
+	config := a.config
+
+	// Create gRPC-Gateway mux with JSON marshaler that uses snake_case
+	gwmux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{},
+		}),
+		runtime.WithErrorHandler(grpcutils.GatewayErrorHandler),
+		runtime.WithIncomingHeaderMatcher(grpcutils.GatewayIncomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(grpcutils.GatewayOutgoingHeaderMatcher),
+	)
+
+	// gRPC server address for gateway to connect to
+	grpcAddr := fmt.Sprintf("localhost:%d", config.GrpcPort)
+
+	// The connections to the gRPC server are closed once the HTTP server is shut down, so that the
+	// requests in flight can finish
+	connCtx, closeConns := context.WithCancel(context.WithoutCancel(ctx))
+	defer closeConns()
+
+	// Register gRPC-Gateway handlers
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+	}
+	err := pb.RegisterOrderServiceHandlerFromEndpoint(connCtx, gwmux, grpcAddr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to register gateway handler: %v", err)
+	}
+	err = pb.RegisterInventoryServiceHandlerFromEndpoint(connCtx, gwmux, grpcAddr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to register inventory gateway handler: %v", err)
+	}
+	err = pb.RegisterPricingServiceHandlerFromEndpoint(connCtx, gwmux, grpcAddr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to register pricing gateway handler: %v", err)
+	}
+	err = pb.RegisterLedgerServiceHandlerFromEndpoint(connCtx, gwmux, grpcAddr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to register ledger gateway handler: %v", err)
+	}
+	err = pb.RegisterInvoiceServiceHandlerFromEndpoint(connCtx, gwmux, grpcAddr, opts)
+	if err != nil {
+		return fmt.Errorf("failed to register invoice gateway handler: %v", err)
+	}
+
+	// Mount the inbound webhooks next to the gateway
+	mux := http.NewServeMux()
+	mux.Handle("/", gwmux)
+	for path, handler := range webhookHandlers {
+		mux.Handle(path, handler)
+	}
+	mountOps(mux, a)
+
+	return serveHTTP(ctx, config, "gRPC-Gateway", otelhttp.NewHandler(grpcutils.RequestIdMiddleware(mux), "gateway"))
+}
+
+// runOpsServer serves the health and metrics endpoints on the HTTP port, for the roles without the gateway.
+func runOpsServer(ctx context.Context, a *app) error {
+	mux := http.NewServeMux()
+	mountOps(mux, a)
+
+	return serveHTTP(ctx, a.config, "ops", mux)
+}
+
+// mountOps mounts the health and metrics endpoints.
+func mountOps(mux *http.ServeMux, a *app) {
+	if a.config.MetricsPath != "" {
+		mux.Handle(a.config.MetricsPath, metrics.Handler())
+	}
+	mux.Handle(health.LivenessPath, health.LivenessHandler())
+	mux.Handle(health.ReadinessPath, health.ReadinessHandler(a.checker))
+}
+
+// serveHTTP serves the handler on the HTTP port until the service stops, then lets the requests in flight finish.
+func serveHTTP(ctx context.Context, config *config.Config, name string, handler http.Handler) error {
+	// Start HTTP server
+	addr := fmt.Sprintf(":%d", config.HttpPort)
+	logging.Logger.Info(fmt.Sprintf("Starting %s server...", name), "address", addr)
+
+	// Create HTTP server with context
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	// Stop accepting requests once the service stops, and let the requests in flight finish
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.ShutdownTimeout)
+		defer cancel()
+		shutdownErr <- server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to serve %s: %v", name, err)
+	}
+
+	if err := <-shutdownErr; err != nil {
+		return fmt.Errorf("failed to shut down %s server: %v", name, err)
+	}
+	logging.Logger.Info(fmt.Sprintf("Stopped %s server", name))
+	return nil
+}
+
+// initWebhookHandlers returns the inbound webhooks, which are only enabled when their secret is set.
+func initWebhookHandlers(a *app) map[string]http.Handler {
+	webhookReceipts := webhooks.NewPostgresReceiptStore(a.db)
+	handlers := map[string]http.Handler{}
+	if a.config.CarrierWebhookSecret != "" {
+		handlers[carriers.WebhookPath] = carriers.NewWebhookHandler(a.controller, webhookReceipts, a.tx, a.config.CarrierWebhookSecret)
+	} else {
+		logging.Logger.Warn("Carrier webhook secret is not set, carrier webhooks are disabled")
+	}
+	if a.config.PaymentWebhookSecret != "" {
+		handlers[payments.WebhookPath] = payments.NewWebhookHandler(a.controller, webhookReceipts, a.tx, a.config.PaymentWebhookSecret)
+	} else {
+		logging.Logger.Warn("Payment webhook secret is not set, payment webhooks are disabled")
+	}
+	return handlers
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	couponctrl "github.com/cgund98/go-eventsrc-example/internal/entity/coupons/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/fraud"
+	inventoryctrl "github.com/cgund98/go-eventsrc-example/internal/entity/inventory/controller"
+	invoiceent "github.com/cgund98/go-eventsrc-example/internal/entity/invoices"
+	invoicectrl "github.com/cgund98/go-eventsrc-example/internal/entity/invoices/controller"
+	ledgerent "github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	ledgerctrl "github.com/cgund98/go-eventsrc-example/internal/entity/ledger/controller"
+	orderent "github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	ordersagas "github.com/cgund98/go-eventsrc-example/internal/entity/orders/sagas"
+	pricingent "github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/config"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/health"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pii"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/saga"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/segmentio/kafka-go"
+)
+
+func initDB(config *config.Config) (*sqlx.DB, func(), error) {
+	// Start DB Connection
+	dbConnStr := fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s",
+		config.PostgresHost, config.PostgresPort, config.PostgresUser, config.PostgresPassword, config.PostgresDB,
+	)
+	if config.PostgresHost == "localhost" {
+		dbConnStr = fmt.Sprintf("%s sslmode=disable", dbConnStr)
+	}
+
+	db, err := sqlx.Connect("postgres", dbConnStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		if err := db.Close(); err != nil {
+			logging.Logger.Error(fmt.Sprintf("error closing db connection: %v", err))
+		}
+	}
+
+	return db, cleanup, nil
+}
+
+func initKafkaWriter(config *config.Config) (*kafka.Writer, func(), error) {
+	writer := kafka.NewWriter(kafka.WriterConfig{
+		Brokers: []string{kafkaBroker(config)},
+		Topic:   config.EventsTopic,
+	})
+
+	cleanup := func() {
+		if err := writer.Close(); err != nil {
+			logging.Logger.Error(fmt.Sprintf("error closing kafka connection: %v", err))
+		}
+	}
+
+	return writer, cleanup, nil
+}
+
+func kafkaBroker(config *config.Config) string {
+	return fmt.Sprintf("%s:%d", config.KafkaHost, config.KafkaPort)
+}
+
+func initTracing(config *config.Config) (func(), error) {
+	shutdownTracing, err := tracing.Init(context.Background(), tracing.Options{
+		ServiceName:  config.TracingServiceName,
+		Exporter:     config.TracingExporter,
+		File:         config.TracingFile,
+		OtlpEndpoint: config.TracingOtlpEndpoint,
+		OtlpInsecure: config.TracingOtlpInsecure,
+		SampleRatio:  config.TracingSampleRatio,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	flush := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logging.Logger.Error(fmt.Sprintf("error flushing traces: %v", err))
+		}
+	}
+	return flush, nil
+}
+
+// app holds the dependencies shared by the roles of the service.
+type app struct {
+	config  *config.Config
+	checker *health.Checker
+
+	db             *sqlx.DB
+	kafkaWriter    *kafka.Writer
+	kafkaClient    *kafka.Client
+	store          eventsrc.Store
+	bus            eventsrc.Bus
+	consumerStates eventsrc.ConsumerStateStore
+	tx             pg.Transactor
+	timerStore     timers.Store
+	sagaStore      saga.Store
+
+	controller          *orderctrl.Controller
+	inventoryController *inventoryctrl.Controller
+	pricingEngine       *pricingent.Engine
+	couponController    *couponctrl.Controller
+	ledgerController    *ledgerctrl.Controller
+	invoiceController   *invoicectrl.Controller
+	paymentSaga         *saga.Runner
+
+	closers []func()
+}
+
+// newApp connects to Postgres and Kafka and wires the controllers. The readiness checks of the
+// dependencies are added to the checker of the app.
+func newApp(config *config.Config) (*app, error) {
+	a := &app{config: config}
+
+	// Initialize tracing
+	flushTraces, err := initTracing(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize tracing: %w", err)
+	}
+	a.closers = append(a.closers, flushTraces)
+
+	// Initialize DB
+	db, closeDB, err := initDB(config)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("unable to initialize db: %w", err)
+	}
+	a.db = db
+	a.closers = append(a.closers, closeDB)
+
+	// Refuse to start on a schema the stores cannot use, e.g. before the migrations are applied
+	err = pg.VerifyTables(context.Background(), db, eventsrc.TableSchema(config.EventsTable), eventsrc.ConsumerStateTableSchema, orderent.ProjectionTableSchema)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("unexpected database schema, are the migrations applied (orders migrate up)? %w", err)
+	}
+
+	// Initialize Kafka
+	kafkaWriter, closeKafkaWriter, err := initKafkaWriter(config)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("unable to initialize kafka: %w", err)
+	}
+	a.kafkaWriter = kafkaWriter
+	a.closers = append(a.closers, closeKafkaWriter)
+
+	// Readiness checks, to which the roles add their own
+	a.checker = health.NewChecker(health.CheckerOptions{
+		Interval: &config.HealthCheckInterval,
+		Timeout:  &config.HealthCheckTimeout,
+	})
+	a.checker.Add("postgres", health.DB(db))
+	a.checker.Add("kafka", health.KafkaBroker(kafkaBroker(config), config.EventsTopic))
+	a.checker.Add("kafka_writer", health.KafkaWriter(kafkaWriter))
+
+	// Initialize abstractions
+	a.store = eventsrc.NewMeteredStore(eventsrc.NewTracedStore(eventsrc.NewPostgresStore(db, config.EventsTable)))
+	projectionRepo := orderent.NewMeteredProjectionRepo(orderent.NewTracedProjectionRepo(orderent.NewPgProjectionRepo(db)))
+	a.bus = eventsrc.NewMeteredBus(eventsrc.NewKafkaBus(kafkaWriter))
+	a.kafkaClient = &kafka.Client{Addr: kafka.TCP(kafkaBroker(config))}
+	a.consumerStates = eventsrc.NewPostgresConsumerStateStore(db)
+	a.tx = pg.NewDbTransactor(db)
+	producer := eventsrc.NewTransactionProducer(a.store, a.bus, a.tx)
+	a.timerStore = timers.NewPostgresStore(db)
+	a.sagaStore = saga.NewPostgresStore(db)
+
+	a.couponController = couponctrl.NewController(a.store, producer)
+	a.pricingEngine = pricingent.NewEngine(pricingent.NewPgPriceCatalog(db), a.couponController)
+	fraudScorer := fraud.NewRuleScorer(projectionRepo, fraud.Rules{
+		VelocityWindow:   config.FraudVelocityWindow,
+		VelocityLimit:    config.FraudVelocityLimit,
+		VelocityScore:    config.FraudVelocityScore,
+		AmountThresholds: config.FraudAmountThresholds,
+		AmountScore:      config.FraudAmountScore,
+	})
+	a.controller = orderctrl.NewController(a.store, producer, projectionRepo, a.tx, a.pricingEngine, a.couponController, pii.NewVault(pii.NewPgKeyStore(db)), config.OrderReturnWindow, fraudScorer, config.FraudHoldThreshold)
+	a.inventoryController = inventoryctrl.NewController(a.store, producer, config.InventoryBackorderEnabled)
+	a.ledgerController = ledgerctrl.NewController(a.store, producer, ledgerent.NewPgLedger(db), a.controller, ledgerent.CommissionRates{
+		DefaultBps: config.LedgerDefaultCommissionBps,
+		VendorBps:  config.LedgerCommissionBps,
+	})
+	a.invoiceController = invoicectrl.NewController(invoiceent.NewPgRepo(db), a.tx, a.controller)
+	a.paymentSaga = saga.NewRunner(ordersagas.NewPaymentSaga(a.controller, config.OrderPaymentTimeout, config.PaymentAsyncConfirmation), a.sagaStore, a.timerStore, a.tx)
+
+	return a, nil
+}
+
+// close closes the Kafka writer and the DB once nothing uses them, then flushes the traces left.
+func (a *app) close() {
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		a.closers[i]()
+	}
+	a.closers = nil
+}
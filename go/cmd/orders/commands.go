@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/config"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/migrations"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// task is a long-running part of a role, which returns once ctx is done.
+type task func(ctx context.Context) error
+
+// shutdownMargin is how long the tasks get to return after their own shutdown timeout, e.g. for a
+// server that was stopped forcefully to release its listener.
+const shutdownMargin = 5 * time.Second
+
+// startApp loads the config of a role and wires the app.
+func startApp(role string) (*app, error) {
+	config, err := config.LoadRoleConfig(role)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load config: %w", err)
+	}
+
+	a, err := newApp(config)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Logger.Info("Starting order service...", "role", role)
+	return a, nil
+}
+
+// serve runs the tasks of a role along with the readiness checks until the service stops or a task
+// fails, then closes the app.
+func serve(ctx context.Context, a *app, tasks ...task) error {
+	defer a.close()
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, t := range append(tasks, a.checker.Run) {
+		g.Go(func() error {
+			return t(ctx)
+		})
+	}
+
+	if err := waitForShutdown(ctx, g, a.config.ShutdownTimeout); err != nil {
+		return err
+	}
+
+	logging.Logger.Info("All servers shut down gracefully")
+	return nil
+}
+
+// waitForShutdown waits for the goroutines of the group to return once ctx is done. The goroutines stop
+// within timeout, so they are given timeout plus shutdownMargin before waiting is an error.
+// Cancellation is not an error, as it is how the goroutines are stopped.
+func waitForShutdown(ctx context.Context, g *errgroup.Group, timeout time.Duration) error {
+	deadline := timeout + shutdownMargin
+
+	done := make(chan error, 1)
+	go func() {
+		done <- g.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		logging.Logger.Info("Shutting down...", "timeout", timeout.String())
+		select {
+		case err = <-done:
+		case <-time.After(deadline):
+			return fmt.Errorf("timed out after %s waiting for the servers and consumers to stop", deadline)
+		}
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// apiTasks returns the tasks serving the gRPC API, the gRPC-Gateway and, when authentication is enabled,
+// the admin API.
+func apiTasks(a *app) ([]task, error) {
+	// Initialize authentication
+	verifier, err := initVerifier(a.config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize authentication: %w", err)
+	}
+	if verifier == nil {
+		logging.Logger.Warn("Neither a JWKS file nor an HMAC secret is set, authentication is disabled")
+	}
+
+	webhookHandlers := initWebhookHandlers(a)
+	tasks := []task{
+		func(ctx context.Context) error {
+			return runGRPCServer(ctx, a, verifier)
+		},
+		func(ctx context.Context) error {
+			return runGatewayServer(ctx, a, webhookHandlers)
+		},
+	}
+
+	// Admins cannot be told apart without authentication
+	if verifier == nil {
+		logging.Logger.Warn("Authentication is disabled, the admin API is not served")
+		return tasks, nil
+	}
+	tasks = append(tasks, func(ctx context.Context) error {
+		return runAdminServer(ctx, a, verifier)
+	})
+	return tasks, nil
+}
+
+// timerTask returns the task firing the due timers.
+func timerTask(a *app) task {
+	return func(ctx context.Context) error {
+		return runTimerPoller(ctx, a)
+	}
+}
+
+// opsTask returns the task serving the health and metrics endpoints.
+func opsTask(a *app) task {
+	return func(ctx context.Context) error {
+		return runOpsServer(ctx, a)
+	}
+}
+
+func runAll(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("all", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	a, err := startApp("all")
+	if err != nil {
+		return err
+	}
+
+	tasks, err := apiTasks(a)
+	if err != nil {
+		a.close()
+		return err
+	}
+	tasks = append(tasks, consumerTasks(a, a.consumers())...)
+	tasks = append(tasks, timerTask(a))
+
+	return serve(ctx, a, tasks...)
+}
+
+func runServeAPI(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("serve-api", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	a, err := startApp("serve-api")
+	if err != nil {
+		return err
+	}
+
+	tasks, err := apiTasks(a)
+	if err != nil {
+		a.close()
+		return err
+	}
+
+	return serve(ctx, a, tasks...)
+}
+
+func runConsume(ctx context.Context, args []string) error {
+	var names listFlag
+	flags := flag.NewFlagSet("consume", flag.ExitOnError)
+	flags.Var(&names, "consumer", "consumers to run, comma separated or repeated (default every consumer)")
+	_ = flags.Parse(args)
+
+	a, err := startApp("consume")
+	if err != nil {
+		return err
+	}
+
+	consumers, err := selectConsumers(a.consumers(), names)
+	if err != nil {
+		a.close()
+		return err
+	}
+
+	return serve(ctx, a, append(consumerTasks(a, consumers), opsTask(a))...)
+}
+
+func runPollTimers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("poll-timers", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	a, err := startApp("poll-timers")
+	if err != nil {
+		return err
+	}
+
+	return serve(ctx, a, timerTask(a), opsTask(a))
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: orders migrate [flags] up|down|status\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	dir := flags.String("dir", "", "directory of the migration scripts (default the scripts embedded in the binary)")
+	steps := flags.Int("steps", 1, "number of migrations undone by down")
+	_ = flags.Parse(args)
+
+	action := flags.Arg(0)
+	if action == "" {
+		action = "up"
+	}
+	if action != "up" && action != "down" && action != "status" {
+		flags.Usage()
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+
+	config, err := config.LoadRoleConfig("migrate")
+	if err != nil {
+		return fmt.Errorf("unable to load config: %w", err)
+	}
+
+	scripts, err := loadMigrations(*dir)
+	if err != nil {
+		return err
+	}
+
+	db, closeDB, err := initDB(config)
+	if err != nil {
+		return fmt.Errorf("unable to initialize db: %w", err)
+	}
+	defer closeDB()
+
+	migrator := migrations.NewMigrator(db, scripts)
+	switch action {
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		logging.Logger.Info("Undid migrations", "count", len(reverted))
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-45s %s\n", status.Script, appliedAt)
+		}
+
+	default:
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logging.Logger.Info("Database is up to date", "applied", len(applied), "migrations", len(scripts))
+	}
+
+	return nil
+}
+
+// loadMigrations loads the migration scripts of a directory, or the scripts embedded in the binary.
+func loadMigrations(dir string) ([]migrations.Migration, error) {
+	if dir == "" {
+		return migrations.Postgres()
+	}
+	return migrations.Load(os.DirFS(dir))
+}
+
+func runReplay(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	consumerName := flags.String("consumer", "", "consumer to replay the events to (required)")
+	aggregateType := flags.String("aggregate-type", "", "replay only the events of a type of aggregate, e.g. order")
+	aggregateId := flags.String("aggregate-id", "", "replay only the events of an aggregate, with -aggregate-type")
+	batchSize := flags.Uint("batch-size", eventsrc.DefaultReplayBatchSize, "number of events read from the store at once")
+	_ = flags.Parse(args)
+
+	if *consumerName == "" {
+		flags.Usage()
+		return fmt.Errorf("-consumer is required")
+	}
+
+	a, err := startApp("replay")
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	consumers, err := selectConsumers(a.consumers(), []string{*consumerName})
+	if err != nil {
+		return err
+	}
+
+	replayed, err := eventsrc.Replay(ctx, a.store, consumers[0], eventsrc.ReplayArgs{
+		AggregateType: *aggregateType,
+		AggregateId:   *aggregateId,
+		BatchSize:     *batchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("replayed %d events: %w", replayed, err)
+	}
+
+	logging.Logger.Info("Replayed events", "consumer", *consumerName, "count", replayed)
+	return nil
+}
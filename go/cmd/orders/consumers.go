@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	couponcons "github.com/cgund98/go-eventsrc-example/internal/entity/coupons/consumers"
+	inventorycons "github.com/cgund98/go-eventsrc-example/internal/entity/inventory/consumers"
+	invoicecons "github.com/cgund98/go-eventsrc-example/internal/entity/invoices/consumers"
+	ledgercons "github.com/cgund98/go-eventsrc-example/internal/entity/ledger/consumers"
+	ordercons "github.com/cgund98/go-eventsrc-example/internal/entity/orders/consumers"
+	ordertimeouts "github.com/cgund98/go-eventsrc-example/internal/entity/orders/timeouts"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// consumers returns the Kafka consumers of the service. Each one reads the events topic in a consumer
+// group named after it.
+func (a *app) consumers() []eventsrc.Consumer {
+	return []eventsrc.Consumer{
+		// Process manager that drives orders from placement to payment
+		a.paymentSaga,
+		ordercons.NewProjectionIndexerConsumer(a.controller),
+		// Schedules and cancels order timers
+		ordercons.NewTimerSchedulerConsumer(a.timerStore, a.tx, a.config.OrderShipmentOverdueAfter),
+		// Reserves, commits and releases stock for orders
+		inventorycons.NewStockReservationConsumer(a.inventoryController, a.controller),
+		// Records inventory events on orders
+		ordercons.NewStockStatusConsumer(a.controller),
+		// Releases the coupons of cancelled orders
+		couponcons.NewCouponRedemptionConsumer(a.couponController, a.controller),
+		// Posts the sales, refunds and payouts of vendors to the ledger
+		ledgercons.NewLedgerPostingConsumer(a.ledgerController),
+		// Issues the invoices and credit notes of orders
+		invoicecons.NewInvoicingConsumer(a.invoiceController),
+	}
+}
+
+// consumerNames returns the names of the consumers of the service.
+func consumerNames(consumers []eventsrc.Consumer) []string {
+	names := make([]string, len(consumers))
+	for i, consumer := range consumers {
+		names[i] = consumer.Name()
+	}
+	return names
+}
+
+// selectConsumers returns the consumers with the given names, or every consumer if there are none.
+func selectConsumers(consumers []eventsrc.Consumer, names []string) ([]eventsrc.Consumer, error) {
+	if len(names) == 0 {
+		return consumers, nil
+	}
+
+	selected := []eventsrc.Consumer{}
+	for _, name := range names {
+		index := slices.IndexFunc(consumers, func(consumer eventsrc.Consumer) bool { return consumer.Name() == name })
+		if index < 0 {
+			return nil, fmt.Errorf("unknown consumer %q, expected one of %s", name, strings.Join(consumerNames(consumers), ", "))
+		}
+		selected = append(selected, consumers[index])
+	}
+	return selected, nil
+}
+
+// runConsumer runs a consumer in its consumer group until the service stops. The consumer leaves its
+// group while it is paused through the admin API.
+func runConsumer(ctx context.Context, a *app, consumer eventsrc.Consumer, status *eventsrc.ConsumerStatus) error {
+	newReader := func() eventsrc.ReadCloser {
+		reader := kafka.NewReader(kafka.ReaderConfig{
+			Brokers: []string{kafkaBroker(a.config)},
+			Topic:   a.config.EventsTopic,
+			GroupID: consumer.Name(),
+		})
+		return meteredReader{MeteredReader: eventsrc.NewMeteredReader(reader, consumer.Name()), Closer: reader}
+	}
+
+	logging.Logger.Info("Starting consumer...", "consumer", consumer.Name())
+
+	return eventsrc.RunPausableKafkaConsumer(ctx, a.consumerStates, newReader, eventsrc.NewMeteredConsumer(consumer), eventsrc.RunPausableKafkaConsumerOptions{
+		RunKafkaConsumerOptions: eventsrc.RunKafkaConsumerOptions{Status: status},
+		PollInterval:            &a.config.ConsumerPausePollInterval,
+	})
+}
+
+// meteredReader meters a Kafka reader, which it closes.
+type meteredReader struct {
+	*eventsrc.MeteredReader
+	io.Closer
+}
+
+// consumerTasks returns the tasks running the consumers, whose status is checked for readiness.
+func consumerTasks(a *app, consumers []eventsrc.Consumer) []task {
+	tasks := []task{}
+	for _, consumer := range consumers {
+		status := eventsrc.NewConsumerStatus(a.config.HealthConsumerMaxFailures)
+		a.checker.Add("consumer:"+consumer.Name(), status.Check)
+		tasks = append(tasks, func(ctx context.Context) error {
+			return runConsumer(ctx, a, consumer, status)
+		})
+	}
+	return tasks
+}
+
+// runTimerPoller runs the poller that fires due timers.
+func runTimerPoller(ctx context.Context, a *app) error {
+	handlers := []timers.Handler{
+		ordertimeouts.NewShipmentOverdueHandler(a.controller),
+		a.paymentSaga,
+	}
+
+	poller := timers.NewPoller(a.timerStore, handlers, timers.PollerOptions{
+		PollInterval: &a.config.TimerPollInterval,
+	})
+	return poller.Run(ctx)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+)
+
+// command is a subcommand of the CLI, most of which run a role of the service.
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "all", description: "Run every role in a single process", run: runAll},
+	{name: "serve-api", description: "Serve the gRPC API and the gRPC-Gateway", run: runServeAPI},
+	{name: "consume", description: "Run Kafka consumers, every one unless -consumer is set", run: runConsume},
+	{name: "poll-timers", description: "Fire the due timers of orders and sagas", run: runPollTimers},
+	{name: "migrate", description: "Apply, undo or list the database migrations", run: runMigrate},
+	{name: "replay", description: "Replay the stored events to a consumer", run: runReplay},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: orders <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'orders <command> -h' for the flags of a command.\n")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	// Create context cancelled on SIGINT and SIGTERM for graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := cmd.run(ctx, os.Args[2:]); err != nil {
+		logging.Logger.Error(fmt.Sprintf("%s error: %v", cmd.name, err))
+		stop()
+		os.Exit(1)
+	}
+}
+
+// listFlag is a flag of comma separated values, which may also be repeated.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*f = append(*f, item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+)
+
+const (
+	ConsumerNameCouponRedemption = "coupon-redemption"
+)
+
+// CouponRedemptionConsumer gives back the coupon redeemed by an order when the order is cancelled,
+// so that cancelled orders do not count towards the usage limit of the coupon.
+type CouponRedemptionConsumer struct {
+	Controller      *controller.Controller
+	OrderController *orderctrl.Controller
+}
+
+func NewCouponRedemptionConsumer(controller *controller.Controller, orderController *orderctrl.Controller) *CouponRedemptionConsumer {
+	return &CouponRedemptionConsumer{
+		Controller:      controller,
+		OrderController: orderController,
+	}
+}
+
+func (c *CouponRedemptionConsumer) Name() string {
+	return ConsumerNameCouponRedemption
+}
+
+func (c *CouponRedemptionConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+
+	if args.AggregateType != orders.AggregateTypeOrder || args.EventType != orders.EventTypeOrderCancelled {
+		return nil
+	}
+
+	orderProjection, _, err := c.OrderController.GetProjection(ctx, args.AggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to get order projection: %w", err)
+	}
+	if orderProjection == nil {
+		return orderctrl.ErrOrderNotFound
+	}
+	if orderProjection.CouponCode == "" {
+		return nil
+	}
+
+	if err := c.Controller.Release(ctx, orderProjection.CouponCode, args.AggregateID); err != nil {
+		return fmt.Errorf("failed to release coupon: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Released coupon of cancelled order", "orderId", args.AggregateID, "couponCode", orderProjection.CouponCode)
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (c *Controller) CreateCoupon(ctx context.Context, req *pb.CreateCouponRequest) (*pb.CreateCouponResponse, error) {
+
+	projection, _, err := c.GetProjection(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if projection != nil {
+		return nil, ErrCouponAlreadyExists
+	}
+
+	// Create new event
+	couponCreatedEvent := &pb.CouponCreated{
+		Code:          req.Code,
+		Timestamp:     timestamppb.Now(),
+		Kind:          req.Kind,
+		PercentOffBps: req.PercentOffBps,
+		VendorId:      req.VendorId,
+		UsageLimit:    req.UsageLimit,
+	}
+	if req.Kind == pb.CouponKind_COUPON_KIND_FIXED {
+		if _, err := orders.ParseProtoMoney(req.AmountOff); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid amount off: %v", err)
+		}
+		couponCreatedEvent.AmountOff = req.AmountOff
+	}
+
+	couponCreatedEventBytes, err := proto.Marshal(couponCreatedEvent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal coupon created event: %w", err)
+	}
+
+	err = c.send(ctx, req.Code, 0, coupons.EventTypeCouponCreated, couponCreatedEventBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CreateCouponResponse{Code: req.Code}, nil
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrCouponNotFound = status.Errorf(codes.NotFound, "coupon not found")
+var ErrCouponAlreadyExists = status.Errorf(codes.AlreadyExists, "coupon already exists")
+var ErrCouponExhausted = status.Errorf(codes.FailedPrecondition, "coupon usage limit reached")
@@ -0,0 +1,20 @@
+package controller
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+)
+
+func (c *Controller) GetCoupon(ctx context.Context, req *pb.GetCouponRequest) (*pb.GetCouponResponse, error) {
+
+	projection, _, err := c.GetProjection(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if projection == nil {
+		return nil, ErrCouponNotFound
+	}
+
+	return &pb.GetCouponResponse{Coupon: projection.ToCouponDetails()}, nil
+}
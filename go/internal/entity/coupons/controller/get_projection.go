@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+)
+
+// GetProjection returns the projection of a coupon.
+// If no events are found, it returns nil, 0.
+func (c *Controller) GetProjection(ctx context.Context, code string) (*coupons.CouponProjection, int, error) {
+	events, err := c.store.ListByAggregateID(ctx, code, coupons.AggregateTypeCoupon)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list events for coupon %s: %w", code, err)
+	}
+
+	if len(events) == 0 {
+		return nil, 0, nil
+	}
+
+	projEvents := []coupons.SerializedEvent{}
+	currentSequenceNumber := 0
+	for _, event := range events {
+		projEvents = append(projEvents, coupons.SerializedEvent{
+			EventType: event.EventType,
+			EventData: event.Data,
+		})
+		currentSequenceNumber = max(currentSequenceNumber, event.SequenceNumber)
+	}
+
+	projection, err := coupons.ReduceToProjection(projEvents)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reduce to projection: %w", err)
+	}
+
+	return projection, currentSequenceNumber, nil
+}
+
+func (c *Controller) send(ctx context.Context, code string, seqNum int, eventType string, value []byte) error {
+	err := c.producer.Send(ctx, &eventsrc.SendArgs{
+		SequenceNumber: seqNum,
+		AggregateID:    code,
+		AggregateType:  coupons.AggregateTypeCoupon,
+		EventType:      eventType,
+		Value:          value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s event: %w", eventType, err)
+	}
+
+	return nil
+}
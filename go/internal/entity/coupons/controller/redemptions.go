@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Redeem redeems a coupon on an order. Returns ErrCouponExhausted if the usage limit is reached.
+// Redeeming a coupon on an order it is already redeemed on is a no-op.
+//
+// Concurrent redemptions append to the coupon stream with the same sequence number, so only
+// one of them can be persisted and the usage limit cannot be exceeded.
+func (c *Controller) Redeem(ctx context.Context, code string, orderId string) error {
+
+	projection, curSeqNum, err := c.GetProjection(ctx, code)
+	if err != nil {
+		return err
+	}
+	if projection == nil {
+		return ErrCouponNotFound
+	}
+
+	if projection.Redemptions[orderId] {
+		return nil
+	}
+	if projection.IsExhausted() {
+		return ErrCouponExhausted
+	}
+
+	// Create new event
+	couponRedeemedEvent := &pb.CouponRedeemed{
+		Code:      code,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+	}
+
+	couponRedeemedEventBytes, err := proto.Marshal(couponRedeemedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal coupon redeemed event: %w", err)
+	}
+
+	return c.send(ctx, code, curSeqNum+1, coupons.EventTypeCouponRedeemed, couponRedeemedEventBytes)
+}
+
+// Release gives back the redemption of a coupon by an order, e.g. when the order is cancelled.
+// Releasing a redemption that does not exist is a no-op.
+func (c *Controller) Release(ctx context.Context, code string, orderId string) error {
+
+	projection, curSeqNum, err := c.GetProjection(ctx, code)
+	if err != nil {
+		return err
+	}
+	if projection == nil {
+		return ErrCouponNotFound
+	}
+
+	if !projection.Redemptions[orderId] {
+		return nil
+	}
+
+	// Create new event
+	couponRedemptionReleasedEvent := &pb.CouponRedemptionReleased{
+		Code:      code,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+	}
+
+	couponRedemptionReleasedEventBytes, err := proto.Marshal(couponRedemptionReleasedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal coupon redemption released event: %w", err)
+	}
+
+	return c.send(ctx, code, curSeqNum+1, coupons.EventTypeCouponRedemptionReleased, couponRedemptionReleasedEventBytes)
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MockStore is a mock implementation of eventsrc.Store
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Persist(ctx context.Context, tx pg.Tx, args eventsrc.PersistEventArgs) (int, error) {
+	callArgs := m.Called(ctx, tx, args)
+	return callArgs.Int(0), callArgs.Error(1)
+}
+
+func (m *MockStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error {
+	callArgs := m.Called(ctx, tx, eventId)
+	return callArgs.Error(0)
+}
+
+func (m *MockStore) ListByAggregateID(ctx context.Context, aggregateID, aggregateType string) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, aggregateID, aggregateType)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+func (m *MockStore) ListEvents(ctx context.Context, args eventsrc.ListEventsArgs) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+// MockProducer is a mock implementation of eventsrc.Producer
+type MockProducer struct {
+	mock.Mock
+}
+
+func (m *MockProducer) Send(ctx context.Context, args *eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockProducer) SendAll(ctx context.Context, args []*eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+func couponEvent(seqNum int, eventType string, event proto.Message) eventsrc.Event {
+	data, _ := proto.Marshal(event)
+	return eventsrc.Event{EventType: eventType, Data: data, SequenceNumber: seqNum}
+}
+
+func couponCreated(usageLimit int32) eventsrc.Event {
+	return couponEvent(0, coupons.EventTypeCouponCreated, &pb.CouponCreated{Code: "SAVE10", Kind: pb.CouponKind_COUPON_KIND_PERCENTAGE, PercentOffBps: 1000, UsageLimit: usageLimit, Timestamp: timestamppb.Now()})
+}
+
+func couponRedeemed(seqNum int, orderId string) eventsrc.Event {
+	return couponEvent(seqNum, coupons.EventTypeCouponRedeemed, &pb.CouponRedeemed{Code: "SAVE10", OrderId: orderId, Timestamp: timestamppb.Now()})
+}
+
+func matchEvent(eventType string, seqNum int) interface{} {
+	return mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+		return args.EventType == eventType && args.SequenceNumber == seqNum && args.AggregateType == coupons.AggregateTypeCoupon && args.AggregateID == "SAVE10"
+	})
+}
+
+func newTestController(events []eventsrc.Event) (*Controller, *MockProducer) {
+	mockStore := &MockStore{}
+	mockStore.On("ListByAggregateID", mock.Anything, "SAVE10", coupons.AggregateTypeCoupon).Return(events, nil)
+	mockProducer := &MockProducer{}
+	return NewController(mockStore, mockProducer), mockProducer
+}
+
+func TestController_Redeem(t *testing.T) {
+	t.Run("redeems a coupon", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{couponCreated(2), couponRedeemed(1, "order-1")})
+		mockProducer.On("Send", mock.Anything, matchEvent(coupons.EventTypeCouponRedeemed, 2)).Return(nil)
+
+		err := controller.Redeem(context.Background(), "SAVE10", "order-2")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("already redeemed order is a no-op", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{couponCreated(1), couponRedeemed(1, "order-1")})
+
+		err := controller.Redeem(context.Background(), "SAVE10", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects when the usage limit is reached", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{couponCreated(1), couponRedeemed(1, "order-1")})
+
+		err := controller.Redeem(context.Background(), "SAVE10", "order-2")
+
+		assert.Equal(t, ErrCouponExhausted, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown coupon", func(t *testing.T) {
+		controller, _ := newTestController([]eventsrc.Event{})
+
+		err := controller.Redeem(context.Background(), "SAVE10", "order-1")
+
+		assert.Equal(t, ErrCouponNotFound, err)
+	})
+}
+
+func TestController_Release(t *testing.T) {
+	t.Run("releases a redemption", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{couponCreated(1), couponRedeemed(1, "order-1")})
+		mockProducer.On("Send", mock.Anything, matchEvent(coupons.EventTypeCouponRedemptionReleased, 2)).Return(nil)
+
+		err := controller.Release(context.Background(), "SAVE10", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("unknown redemption is a no-op", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{couponCreated(1)})
+
+		err := controller.Release(context.Background(), "SAVE10", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,9 @@
+package coupons
+
+const (
+	EventTypeCouponCreated            = "coupon_created"
+	EventTypeCouponRedeemed           = "coupon_redeemed"
+	EventTypeCouponRedemptionReleased = "coupon_redemption_released"
+
+	AggregateTypeCoupon = "coupon"
+)
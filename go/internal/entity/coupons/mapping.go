@@ -0,0 +1,46 @@
+package coupons
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (proj *CouponProjection) ToCouponDetails() *pb.CouponDetails {
+	details := &pb.CouponDetails{
+		Code:          proj.Code,
+		Kind:          MapStrToCouponKind(proj.Kind),
+		PercentOffBps: proj.PercentOffBps,
+		VendorId:      proj.VendorId,
+		UsageLimit:    proj.UsageLimit,
+		Redemptions:   int32(len(proj.Redemptions)),
+		CreatedAt:     timestamppb.New(proj.CreatedAt),
+	}
+
+	if proj.Kind == CouponKindFixed {
+		details.AmountOff = orders.MapMoneyToProto(proj.AmountOff)
+	}
+
+	return details
+}
+
+func MapStrToCouponKind(kind string) pb.CouponKind {
+	switch kind {
+	case CouponKindPercentage:
+		return pb.CouponKind_COUPON_KIND_PERCENTAGE
+	case CouponKindFixed:
+		return pb.CouponKind_COUPON_KIND_FIXED
+	}
+
+	return pb.CouponKind_COUPON_KIND_UNSPECIFIED
+}
+
+func MapCouponKindToStr(kind pb.CouponKind) string {
+	switch kind {
+	case pb.CouponKind_COUPON_KIND_PERCENTAGE:
+		return CouponKindPercentage
+	case pb.CouponKind_COUPON_KIND_FIXED:
+		return CouponKindFixed
+	}
+	return ""
+}
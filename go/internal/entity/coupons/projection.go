@@ -0,0 +1,121 @@
+package coupons
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// Coupon kind enum
+	CouponKindPercentage = "percentage"
+	CouponKindFixed      = "fixed"
+)
+
+type CouponProjection struct {
+	Code string
+	Kind string
+
+	// PercentOffBps is the discount of a percentage coupon, in basis points.
+	PercentOffBps int32
+	// AmountOff is the discount of a fixed coupon.
+	AmountOff money.Money
+
+	// VendorId restricts the coupon to the orders of a vendor. Empty for every vendor.
+	VendorId string
+	// UsageLimit is the maximum number of orders the coupon can be redeemed on. 0 for unlimited.
+	UsageLimit int32
+
+	// Redemptions records the orders the coupon is redeemed on.
+	Redemptions map[string]bool
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type SerializedEvent struct {
+	EventType string
+	EventData []byte
+}
+
+// IsExhausted returns true if the coupon cannot be redeemed on another order.
+func (p *CouponProjection) IsExhausted() bool {
+	return p.UsageLimit > 0 && int32(len(p.Redemptions)) >= p.UsageLimit
+}
+
+// ReduceToProjection will reduce the event list into a coupon projection
+func ReduceToProjection(events []SerializedEvent) (*CouponProjection, error) {
+	projection := &CouponProjection{Redemptions: make(map[string]bool)}
+	for _, event := range events {
+		err := applyEventToProjection(event, projection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return projection, nil
+}
+
+// applyEventToProjection will map the event type to the appropriate apply function
+func applyEventToProjection(event SerializedEvent, currentProjection *CouponProjection) error {
+	switch event.EventType {
+	case EventTypeCouponCreated:
+		return applyCouponCreatedToProjection(event.EventData, currentProjection)
+	case EventTypeCouponRedeemed:
+		return applyCouponRedeemedToProjection(event.EventData, currentProjection)
+	case EventTypeCouponRedemptionReleased:
+		return applyCouponRedemptionReleasedToProjection(event.EventData, currentProjection)
+	default:
+		return fmt.Errorf("unknown event type: %s", event.EventType)
+	}
+}
+
+func applyCouponCreatedToProjection(eventData []byte, currentProjection *CouponProjection) error {
+	var event pb.CouponCreated
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal coupon created event: %w", err)
+	}
+
+	currentProjection.Code = event.Code
+	currentProjection.Kind = MapCouponKindToStr(event.Kind)
+	currentProjection.PercentOffBps = event.PercentOffBps
+	if event.AmountOff != nil {
+		currentProjection.AmountOff = orders.MapProtoToMoney(event.AmountOff)
+	}
+	currentProjection.VendorId = event.VendorId
+	currentProjection.UsageLimit = event.UsageLimit
+	currentProjection.CreatedAt = event.Timestamp.AsTime()
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyCouponRedeemedToProjection(eventData []byte, currentProjection *CouponProjection) error {
+	var event pb.CouponRedeemed
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal coupon redeemed event: %w", err)
+	}
+
+	currentProjection.Redemptions[event.OrderId] = true
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyCouponRedemptionReleasedToProjection(eventData []byte, currentProjection *CouponProjection) error {
+	var event pb.CouponRedemptionReleased
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal coupon redemption released event: %w", err)
+	}
+
+	delete(currentProjection.Redemptions, event.OrderId)
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package fraud
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+)
+
+// MaxScore is the score of an order that is certainly fraudulent.
+const MaxScore = 100
+
+// Score is how suspicious an order is, from 0 (safe) to MaxScore, with the reasons why.
+type Score struct {
+	Value   int32
+	Reasons []string
+}
+
+// add raises the score by points, capped at MaxScore.
+func (s *Score) add(points int32, reason string) {
+	s.Value = min(s.Value+points, MaxScore)
+	s.Reasons = append(s.Reasons, reason)
+}
+
+// OrderCounter counts the recent orders of customers.
+type OrderCounter interface {
+	CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error)
+}
+
+// Rules configure the RuleScorer. A rule with a zero limit is disabled.
+type Rules struct {
+	// Orders placed by the same customer within VelocityWindow, beyond VelocityLimit, add VelocityScore.
+	VelocityWindow time.Duration
+	VelocityLimit  int
+	VelocityScore  int32
+
+	// Orders whose total reaches the threshold of their currency add AmountScore.
+	// Orders in a currency without a threshold are not scored on their amount.
+	AmountThresholds map[string]int64
+	AmountScore      int32
+}
+
+// RuleScorer scores orders with simple rules on the customer's order velocity and the order amount.
+type RuleScorer struct {
+	orders OrderCounter
+	rules  Rules
+}
+
+func NewRuleScorer(orders OrderCounter, rules Rules) *RuleScorer {
+	return &RuleScorer{orders: orders, rules: rules}
+}
+
+func (s *RuleScorer) Score(ctx context.Context, order *orders.OrderProjection) (Score, error) {
+	score := Score{}
+
+	if s.rules.VelocityLimit > 0 {
+		count, err := s.orders.CountByCustomerSince(ctx, order.CustomerId, order.CreatedAt.Add(-s.rules.VelocityWindow), order.OrderId)
+		if err != nil {
+			return Score{}, fmt.Errorf("failed to count customer orders: %w", err)
+		}
+
+		// The order itself counts towards the velocity
+		if count+1 > s.rules.VelocityLimit {
+			score.add(s.rules.VelocityScore, fmt.Sprintf("customer placed %d orders within %s", count+1, s.rules.VelocityWindow))
+		}
+	}
+
+	if threshold, ok := s.rules.AmountThresholds[order.TotalPrice.Currency]; ok && threshold > 0 {
+		if order.TotalPrice.Amount >= threshold {
+			limit := money.Money{Currency: order.TotalPrice.Currency, Amount: threshold}
+			score.add(s.rules.AmountScore, fmt.Sprintf("order total %s reaches %s", order.TotalPrice, limit))
+		}
+	}
+
+	return score, nil
+}
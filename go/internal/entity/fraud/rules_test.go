@@ -0,0 +1,107 @@
+package fraud
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCounter returns the same count of recent orders for every customer.
+type fakeCounter struct {
+	count int
+	err   error
+	since time.Time
+}
+
+func (f *fakeCounter) CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error) {
+	f.since = since
+	return f.count, f.err
+}
+
+func testRules() Rules {
+	return Rules{
+		VelocityWindow:   time.Hour,
+		VelocityLimit:    3,
+		VelocityScore:    50,
+		AmountThresholds: map[string]int64{"EUR": 100000},
+		AmountScore:      60,
+	}
+}
+
+func order(total money.Money) *orders.OrderProjection {
+	return &orders.OrderProjection{
+		OrderId:    "order-123",
+		CustomerId: "customer-456",
+		TotalPrice: total,
+		CreatedAt:  time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRuleScorer_Score(t *testing.T) {
+	tests := []struct {
+		name          string
+		recentOrders  int
+		total         money.Money
+		expectedScore int32
+		expectedCount int
+	}{
+		{name: "safe order", recentOrders: 0, total: money.Money{Currency: "EUR", Amount: 5000}, expectedScore: 0, expectedCount: 0},
+		{name: "velocity at the limit", recentOrders: 2, total: money.Money{Currency: "EUR", Amount: 5000}, expectedScore: 0, expectedCount: 0},
+		{name: "velocity above the limit", recentOrders: 3, total: money.Money{Currency: "EUR", Amount: 5000}, expectedScore: 50, expectedCount: 1},
+		{name: "amount at the threshold", recentOrders: 0, total: money.Money{Currency: "EUR", Amount: 100000}, expectedScore: 60, expectedCount: 1},
+		{name: "currency without a threshold", recentOrders: 0, total: money.Money{Currency: "JPY", Amount: 1000000}, expectedScore: 0, expectedCount: 0},
+		{name: "score is capped", recentOrders: 5, total: money.Money{Currency: "EUR", Amount: 200000}, expectedScore: MaxScore, expectedCount: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer := NewRuleScorer(&fakeCounter{count: tt.recentOrders}, testRules())
+
+			score, err := scorer.Score(context.Background(), order(tt.total))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedScore, score.Value)
+			assert.Len(t, score.Reasons, tt.expectedCount)
+		})
+	}
+}
+
+func TestRuleScorer_Score_Reasons(t *testing.T) {
+	counter := &fakeCounter{count: 3}
+	scorer := NewRuleScorer(counter, testRules())
+
+	score, err := scorer.Score(context.Background(), order(money.Money{Currency: "EUR", Amount: 150000}))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"customer placed 4 orders within 1h0m0s",
+		"order total 1500.00 EUR reaches 1000.00 EUR",
+	}, score.Reasons)
+	assert.Equal(t, time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), counter.since)
+}
+
+func TestRuleScorer_Score_DisabledVelocity(t *testing.T) {
+	rules := testRules()
+	rules.VelocityLimit = 0
+	scorer := NewRuleScorer(&fakeCounter{err: errors.New("should not be called")}, rules)
+
+	score, err := scorer.Score(context.Background(), order(money.Money{Currency: "EUR", Amount: 5000}))
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), score.Value)
+}
+
+func TestRuleScorer_Score_CounterError(t *testing.T) {
+	scorer := NewRuleScorer(&fakeCounter{err: errors.New("database unavailable")}, testRules())
+
+	_, err := scorer.Score(context.Background(), order(money.Money{Currency: "EUR", Amount: 5000}))
+
+	assert.Error(t, err)
+}
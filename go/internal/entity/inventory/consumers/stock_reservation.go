@@ -0,0 +1,183 @@
+package consumers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ConsumerNameStockReservation = "stock-reservation"
+
+	insufficientStockReason = "insufficient stock"
+)
+
+// StockReservationConsumer keeps the inventory in line with the order lifecycle. Stock is
+// handled per line item, each product being its own inventory aggregate.
+//
+//	OrderPlaced                      -> reserve stock, or back-order / reject the order
+//	OrderAmended                     -> release the stock of removed line items, reserve the new ones
+//	OrderPaid                        -> commit the reserved stock
+//	OrderCancelled                   -> release the reserved stock
+//	OrderStockStatusUpdated(reserved) -> commit the stock of a back-order that was already paid
+type StockReservationConsumer struct {
+	Controller      *controller.Controller
+	OrderController *orderctrl.Controller
+}
+
+func NewStockReservationConsumer(controller *controller.Controller, orderController *orderctrl.Controller) *StockReservationConsumer {
+	return &StockReservationConsumer{
+		Controller:      controller,
+		OrderController: orderController,
+	}
+}
+
+func (c *StockReservationConsumer) Name() string {
+	return ConsumerNameStockReservation
+}
+
+func (c *StockReservationConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+
+	if args.AggregateType != orders.AggregateTypeOrder {
+		return nil
+	}
+
+	switch args.EventType {
+	case orders.EventTypeOrderPlaced:
+		return c.reserve(ctx, args.AggregateID)
+
+	case orders.EventTypeOrderAmended:
+		var event pb.OrderAmended
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal order amended event: %w", err)
+		}
+		if len(event.LineItems) == 0 {
+			return nil
+		}
+
+		// Line items that change quantity are released, then reserved again with their new quantity
+		for _, item := range event.RemovedLineItems {
+			if err := c.Controller.ReleaseStock(ctx, item.ProductId, args.AggregateID); err != nil {
+				return fmt.Errorf("failed to release stock: %w", err)
+			}
+		}
+		return c.reserve(ctx, args.AggregateID)
+
+	case orders.EventTypeOrderPaid:
+		return c.commit(ctx, args.AggregateID, "")
+
+	case orders.EventTypeOrderCancelled:
+		orderProjection, err := c.getOrderProjection(ctx, args.AggregateID)
+		if err != nil {
+			return err
+		}
+		for _, item := range orderProjection.LineItems {
+			if err := c.Controller.ReleaseStock(ctx, item.ProductId, args.AggregateID); err != nil {
+				return fmt.Errorf("failed to release stock: %w", err)
+			}
+		}
+
+	case orders.EventTypeOrderStockStatusUpdated:
+		var event pb.OrderStockStatusUpdated
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal order stock status updated event: %w", err)
+		}
+		if event.Status != pb.StockStatus_STOCK_STATUS_RESERVED {
+			return nil
+		}
+		return c.commit(ctx, args.AggregateID, event.ProductId)
+	}
+
+	return nil
+}
+
+// reserve reserves the stock of every line item of an order. If a line item cannot be served, the
+// whole order is rejected and the stock already reserved is released when the order is cancelled.
+func (c *StockReservationConsumer) reserve(ctx context.Context, orderId string) error {
+	orderProjection, err := c.getOrderProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection.ShippingStatus == orders.ShippingStatusCancelled {
+		return nil
+	}
+
+	for _, item := range orderProjection.LineItems {
+		err := c.Controller.ReserveStock(ctx, controller.ReserveStockArgs{
+			ProductId: item.ProductId,
+			OrderId:   orderId,
+			Quantity:  item.Quantity,
+		})
+		if errors.Is(err, controller.ErrInsufficientStock) {
+			return c.reject(ctx, orderId, item.ProductId)
+		} else if err != nil {
+			return fmt.Errorf("failed to reserve stock: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// reject cancels an order with insufficient stock, when back-ordering is disabled.
+// An order that was already paid gets its refund requested by the cancellation.
+func (c *StockReservationConsumer) reject(ctx context.Context, orderId string, productId string) error {
+	logging.FromContext(ctx).Info("Rejecting order with insufficient stock", "orderId", orderId, "productId", productId)
+
+	_, err := c.OrderController.CancelOrder(ctx, &pb.CancelOrderRequest{
+		OrderId: orderId,
+		Reason:  insufficientStockReason,
+	})
+	if err != nil && !errors.Is(err, orderctrl.ErrOrderAlreadyCancelled) {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	return nil
+}
+
+// commit commits the stock of a paid order, for a single product or for every line item when
+// productId is empty. Orders that are not paid yet, or whose stock is not reserved yet, are
+// committed by a later event.
+func (c *StockReservationConsumer) commit(ctx context.Context, orderId string, productId string) error {
+	orderProjection, err := c.getOrderProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection.PaymentStatus != orders.PaymentStatusPaid || orderProjection.ShippingStatus == orders.ShippingStatusCancelled {
+		return nil
+	}
+
+	for _, item := range orderProjection.LineItems {
+		if productId != "" && item.ProductId != productId {
+			continue
+		}
+
+		err = c.Controller.CommitStock(ctx, item.ProductId, orderId)
+		if errors.Is(err, controller.ErrStockNotReserved) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to commit stock: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *StockReservationConsumer) getOrderProjection(ctx context.Context, orderId string) (*orders.OrderProjection, error) {
+	orderProjection, _, err := c.OrderController.GetProjection(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order projection: %w", err)
+	}
+	if orderProjection == nil {
+		return nil, orderctrl.ErrOrderNotFound
+	}
+	return orderProjection, nil
+}
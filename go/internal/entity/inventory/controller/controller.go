@@ -0,0 +1,18 @@
+package controller
+
+import (
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+)
+
+type Controller struct {
+	store    eventsrc.Store
+	producer eventsrc.Producer
+
+	// backorderEnabled decides what happens to an order that cannot be reserved.
+	// When enabled the order is back-ordered, otherwise the reservation is rejected.
+	backorderEnabled bool
+}
+
+func NewController(store eventsrc.Store, producer eventsrc.Producer, backorderEnabled bool) *Controller {
+	return &Controller{store: store, producer: producer, backorderEnabled: backorderEnabled}
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrProductNotFound = status.Errorf(codes.NotFound, "product not found")
+var ErrInsufficientStock = status.Errorf(codes.FailedPrecondition, "insufficient stock")
+var ErrStockNotReserved = status.Errorf(codes.FailedPrecondition, "stock is not reserved for the order")
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+)
+
+// GetProjection returns the stock projection for a product.
+// If no events are found, it returns nil, 0.
+func (c *Controller) GetProjection(ctx context.Context, productId string) (*inventory.StockProjection, int, error) {
+	events, err := c.store.ListByAggregateID(ctx, productId, inventory.AggregateTypeInventory)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list events for product %s: %w", productId, err)
+	}
+
+	if len(events) == 0 {
+		return nil, 0, nil
+	}
+
+	projEvents := []inventory.SerializedEvent{}
+	currentSequenceNumber := 0
+	for _, event := range events {
+		projEvents = append(projEvents, inventory.SerializedEvent{
+			EventType: event.EventType,
+			EventData: event.Data,
+		})
+		currentSequenceNumber = max(currentSequenceNumber, event.SequenceNumber)
+	}
+
+	projection, err := inventory.ReduceToProjection(projEvents)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reduce to projection: %w", err)
+	}
+
+	return projection, currentSequenceNumber, nil
+}
+
+// getProjectionForUpdate returns the stock projection for a product along with the sequence number of the next event.
+// A product without events gets an empty projection, so that it can be stocked or back-ordered.
+func (c *Controller) getProjectionForUpdate(ctx context.Context, productId string) (*inventory.StockProjection, int, error) {
+	projection, curSeqNum, err := c.GetProjection(ctx, productId)
+	if err != nil {
+		return nil, 0, err
+	}
+	if projection == nil {
+		projection, _ = inventory.ReduceToProjection(nil)
+		projection.ProductId = productId
+		return projection, 0, nil
+	}
+
+	return projection, curSeqNum + 1, nil
+}
+
+func (c *Controller) send(ctx context.Context, productId string, seqNum int, eventType string, value []byte) error {
+	err := c.producer.Send(ctx, newSendArgs(productId, seqNum, eventType, value))
+	if err != nil {
+		return fmt.Errorf("failed to send %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// sendAll persists the events of a single command together, so that a failure cannot leave only some of them stored.
+func (c *Controller) sendAll(ctx context.Context, events []*eventsrc.SendArgs) error {
+	err := c.producer.SendAll(ctx, events)
+	if err != nil {
+		return fmt.Errorf("failed to send %d events: %w", len(events), err)
+	}
+
+	return nil
+}
+
+func newSendArgs(productId string, seqNum int, eventType string, value []byte) *eventsrc.SendArgs {
+	return &eventsrc.SendArgs{
+		SequenceNumber: seqNum,
+		AggregateID:    productId,
+		AggregateType:  inventory.AggregateTypeInventory,
+		EventType:      eventType,
+		Value:          value,
+	}
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+)
+
+func (c *Controller) GetStockLevel(ctx context.Context, req *pb.GetStockLevelRequest) (*pb.GetStockLevelResponse, error) {
+	projection, _, err := c.GetProjection(ctx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+	if projection == nil {
+		return nil, ErrProductNotFound
+	}
+
+	return &pb.GetStockLevelResponse{StockLevel: projection.ToStockLevel()}, nil
+}
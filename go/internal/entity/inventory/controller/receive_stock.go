@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (c *Controller) ReceiveStock(ctx context.Context, req *pb.ReceiveStockRequest) (*pb.ReceiveStockResponse, error) {
+
+	projection, nextSeqNum, err := c.getProjectionForUpdate(ctx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	stockReceivedEvent := &pb.StockReceived{
+		ProductId: req.ProductId,
+		Timestamp: timestamppb.Now(),
+		Quantity:  req.Quantity,
+	}
+
+	stockReceivedEventBytes, err := proto.Marshal(stockReceivedEvent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stock received event: %w", err)
+	}
+
+	// The received stock goes to back-ordered orders first
+	reservedEvents, err := allocateBackorders(req.ProductId, projection.Available()+req.Quantity, projection.Backorders, nextSeqNum+1)
+	if err != nil {
+		return nil, err
+	}
+
+	events := append([]*eventsrc.SendArgs{newSendArgs(req.ProductId, nextSeqNum, inventory.EventTypeStockReceived, stockReceivedEventBytes)}, reservedEvents...)
+	err = c.sendAll(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ReceiveStockResponse{
+		ProductId: req.ProductId,
+	}, nil
+}
+
+// allocateBackorders builds the events reserving stock for back-ordered orders in the order they were received.
+// It stops at the first order that cannot be fully reserved, so that large orders are not starved by smaller ones.
+// The events are numbered from nextSeqNum and must be sent together with the event that freed the stock.
+func allocateBackorders(productId string, available int32, backorders []inventory.Backorder, nextSeqNum int) ([]*eventsrc.SendArgs, error) {
+	var events []*eventsrc.SendArgs
+	for _, backorder := range backorders {
+		if backorder.Quantity > available {
+			break
+		}
+
+		stockReservedEventBytes, err := marshalStockReserved(productId, backorder.OrderId, backorder.Quantity)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, newSendArgs(productId, nextSeqNum, inventory.EventTypeStockReserved, stockReservedEventBytes))
+
+		available -= backorder.Quantity
+		nextSeqNum++
+	}
+
+	return events, nil
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type ReserveStockArgs struct {
+	ProductId string
+	OrderId   string
+	Quantity  int32
+}
+
+// ReserveStock reserves stock for an order. If there is not enough stock available, the order is
+// back-ordered, or ErrInsufficientStock is returned when back-ordering is disabled.
+// Reserving stock for an order that was already handled is a no-op.
+func (c *Controller) ReserveStock(ctx context.Context, args ReserveStockArgs) error {
+
+	projection, nextSeqNum, err := c.getProjectionForUpdate(ctx, args.ProductId)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := projection.Reservations[args.OrderId]; ok || projection.IsCommitted(args.OrderId) || projection.IsBackordered(args.OrderId) {
+		return nil
+	}
+
+	// Orders already waiting for stock are served first
+	if len(projection.Backorders) == 0 && projection.Available() >= args.Quantity {
+		return c.sendStockReserved(ctx, args.ProductId, args.OrderId, args.Quantity, nextSeqNum)
+	}
+
+	if !c.backorderEnabled {
+		return ErrInsufficientStock
+	}
+
+	// Create new event
+	stockBackorderedEvent := &pb.StockBackordered{
+		ProductId: args.ProductId,
+		Timestamp: timestamppb.Now(),
+		OrderId:   args.OrderId,
+		Quantity:  args.Quantity,
+	}
+
+	stockBackorderedEventBytes, err := proto.Marshal(stockBackorderedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stock backordered event: %w", err)
+	}
+
+	return c.send(ctx, args.ProductId, nextSeqNum, inventory.EventTypeStockBackordered, stockBackorderedEventBytes)
+}
+
+// CommitStock removes the stock reserved for an order from the inventory.
+// Returns ErrStockNotReserved if the order has no reservation, e.g. while it is back-ordered.
+func (c *Controller) CommitStock(ctx context.Context, productId string, orderId string) error {
+
+	projection, nextSeqNum, err := c.getProjectionForUpdate(ctx, productId)
+	if err != nil {
+		return err
+	}
+
+	if projection.IsCommitted(orderId) {
+		return nil
+	}
+
+	quantity, ok := projection.Reservations[orderId]
+	if !ok {
+		return ErrStockNotReserved
+	}
+
+	// Create new event
+	stockCommittedEvent := &pb.StockCommitted{
+		ProductId: productId,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+		Quantity:  quantity,
+	}
+
+	stockCommittedEventBytes, err := proto.Marshal(stockCommittedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal stock committed event: %w", err)
+	}
+
+	return c.send(ctx, productId, nextSeqNum, inventory.EventTypeStockCommitted, stockCommittedEventBytes)
+}
+
+// ReleaseStock releases the stock reserved for an order, or removes it from the back-orders. The stock of
+// an order that was already committed, e.g. a paid order cancelled before delivery, is put back on hand.
+// The freed stock is allocated to back-ordered orders.
+//
+// Back-orders are allocated from the available stock even if the order was already released, so that
+// retrying a release whose reservations were not all sent allocates the stock it left behind.
+func (c *Controller) ReleaseStock(ctx context.Context, productId string, orderId string) error {
+
+	projection, nextSeqNum, err := c.getProjectionForUpdate(ctx, productId)
+	if err != nil {
+		return err
+	}
+
+	var events []*eventsrc.SendArgs
+	available := projection.Available()
+	backorders := projection.Backorders
+
+	if quantity, ok := projection.Reservations[orderId]; ok {
+		event, err := newStockReleased(productId, orderId, quantity, nextSeqNum)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		available += quantity
+	} else if backorder, ok := projection.GetBackorder(orderId); ok {
+		event, err := newStockReleased(productId, orderId, backorder.Quantity, nextSeqNum)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		backorders = slices.DeleteFunc(slices.Clone(backorders), func(b inventory.Backorder) bool { return b.OrderId == orderId })
+	} else if quantity, ok := projection.Committed[orderId]; ok && !projection.Restocked[orderId] {
+		event, err := newStockRestocked(productId, orderId, quantity, nextSeqNum)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		available += quantity
+	}
+
+	reservedEvents, err := allocateBackorders(productId, available, backorders, nextSeqNum+len(events))
+	if err != nil {
+		return err
+	}
+
+	events = append(events, reservedEvents...)
+	if len(events) == 0 {
+		return nil
+	}
+
+	return c.sendAll(ctx, events)
+}
+
+func newStockReleased(productId string, orderId string, quantity int32, seqNum int) (*eventsrc.SendArgs, error) {
+	stockReleasedEvent := &pb.StockReleased{
+		ProductId: productId,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+		Quantity:  quantity,
+	}
+
+	stockReleasedEventBytes, err := proto.Marshal(stockReleasedEvent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stock released event: %w", err)
+	}
+
+	return newSendArgs(productId, seqNum, inventory.EventTypeStockReleased, stockReleasedEventBytes), nil
+}
+
+func newStockRestocked(productId string, orderId string, quantity int32, seqNum int) (*eventsrc.SendArgs, error) {
+	stockRestockedEvent := &pb.StockRestocked{
+		ProductId: productId,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+		Quantity:  quantity,
+	}
+
+	stockRestockedEventBytes, err := proto.Marshal(stockRestockedEvent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stock restocked event: %w", err)
+	}
+
+	return newSendArgs(productId, seqNum, inventory.EventTypeStockRestocked, stockRestockedEventBytes), nil
+}
+
+func (c *Controller) sendStockReserved(ctx context.Context, productId string, orderId string, quantity int32, seqNum int) error {
+	stockReservedEventBytes, err := marshalStockReserved(productId, orderId, quantity)
+	if err != nil {
+		return err
+	}
+
+	return c.send(ctx, productId, seqNum, inventory.EventTypeStockReserved, stockReservedEventBytes)
+}
+
+func marshalStockReserved(productId string, orderId string, quantity int32) ([]byte, error) {
+	stockReservedEvent := &pb.StockReserved{
+		ProductId: productId,
+		Timestamp: timestamppb.Now(),
+		OrderId:   orderId,
+		Quantity:  quantity,
+	}
+
+	stockReservedEventBytes, err := proto.Marshal(stockReservedEvent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stock reserved event: %w", err)
+	}
+
+	return stockReservedEventBytes, nil
+}
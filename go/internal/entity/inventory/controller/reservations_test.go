@@ -0,0 +1,349 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// MockStore is a mock implementation of eventsrc.Store
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Persist(ctx context.Context, tx pg.Tx, args eventsrc.PersistEventArgs) (int, error) {
+	callArgs := m.Called(ctx, tx, args)
+	return callArgs.Int(0), callArgs.Error(1)
+}
+
+func (m *MockStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error {
+	callArgs := m.Called(ctx, tx, eventId)
+	return callArgs.Error(0)
+}
+
+func (m *MockStore) ListByAggregateID(ctx context.Context, aggregateID, aggregateType string) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, aggregateID, aggregateType)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+func (m *MockStore) ListEvents(ctx context.Context, args eventsrc.ListEventsArgs) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+// MockProducer is a mock implementation of eventsrc.Producer
+type MockProducer struct {
+	mock.Mock
+}
+
+func (m *MockProducer) Send(ctx context.Context, args *eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockProducer) SendAll(ctx context.Context, args []*eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+func stockEvent(seqNum int, eventType string, event proto.Message) eventsrc.Event {
+	data, _ := proto.Marshal(event)
+	return eventsrc.Event{EventType: eventType, Data: data, SequenceNumber: seqNum}
+}
+
+func stockReceived(seqNum int, quantity int32) eventsrc.Event {
+	return stockEvent(seqNum, inventory.EventTypeStockReceived, &pb.StockReceived{ProductId: "product-1", Quantity: quantity, Timestamp: timestamppb.Now()})
+}
+
+func stockReserved(seqNum int, orderId string, quantity int32) eventsrc.Event {
+	return stockEvent(seqNum, inventory.EventTypeStockReserved, &pb.StockReserved{ProductId: "product-1", OrderId: orderId, Quantity: quantity, Timestamp: timestamppb.Now()})
+}
+
+func stockBackordered(seqNum int, orderId string, quantity int32) eventsrc.Event {
+	return stockEvent(seqNum, inventory.EventTypeStockBackordered, &pb.StockBackordered{ProductId: "product-1", OrderId: orderId, Quantity: quantity, Timestamp: timestamppb.Now()})
+}
+
+func stockCommitted(seqNum int, orderId string, quantity int32) eventsrc.Event {
+	return stockEvent(seqNum, inventory.EventTypeStockCommitted, &pb.StockCommitted{ProductId: "product-1", OrderId: orderId, Quantity: quantity, Timestamp: timestamppb.Now()})
+}
+
+func stockRestocked(seqNum int, orderId string, quantity int32) eventsrc.Event {
+	return stockEvent(seqNum, inventory.EventTypeStockRestocked, &pb.StockRestocked{ProductId: "product-1", OrderId: orderId, Quantity: quantity, Timestamp: timestamppb.Now()})
+}
+
+func isEvent(args *eventsrc.SendArgs, eventType string, seqNum int, orderId string) bool {
+	if args.EventType != eventType || args.SequenceNumber != seqNum || args.AggregateType != inventory.AggregateTypeInventory || args.AggregateID != "product-1" {
+		return false
+	}
+	if orderId == "" {
+		return true
+	}
+	var event pb.StockReserved
+	return proto.Unmarshal(args.Value, &event) == nil && event.OrderId == orderId
+}
+
+func matchEvent(eventType string, seqNum int, orderId string) interface{} {
+	return mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+		return isEvent(args, eventType, seqNum, orderId)
+	})
+}
+
+type expectedEvent struct {
+	eventType string
+	seqNum    int
+	orderId   string
+}
+
+// matchEvents matches events sent together in a single batch.
+func matchEvents(expected ...expectedEvent) interface{} {
+	return mock.MatchedBy(func(args []*eventsrc.SendArgs) bool {
+		if len(args) != len(expected) {
+			return false
+		}
+		for i, e := range expected {
+			if !isEvent(args[i], e.eventType, e.seqNum, e.orderId) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func newTestController(events []eventsrc.Event, backorderEnabled bool) (*Controller, *MockProducer) {
+	mockStore := &MockStore{}
+	mockStore.On("ListByAggregateID", mock.Anything, "product-1", inventory.AggregateTypeInventory).Return(events, nil)
+	mockProducer := &MockProducer{}
+	return NewController(mockStore, mockProducer, backorderEnabled), mockProducer
+}
+
+func TestController_ReserveStock(t *testing.T) {
+	args := ReserveStockArgs{ProductId: "product-1", OrderId: "order-1", Quantity: 3}
+
+	t.Run("reserves available stock", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 5)}, true)
+		mockProducer.On("Send", mock.Anything, matchEvent(inventory.EventTypeStockReserved, 1, "order-1")).Return(nil)
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("back-orders when stock is insufficient", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 2)}, true)
+		mockProducer.On("Send", mock.Anything, matchEvent(inventory.EventTypeStockBackordered, 1, "order-1")).Return(nil)
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("back-orders a product without stock", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{}, true)
+		mockProducer.On("Send", mock.Anything, matchEvent(inventory.EventTypeStockBackordered, 0, "order-1")).Return(nil)
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("rejects when back-ordering is disabled", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 2)}, false)
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.Equal(t, ErrInsufficientStock, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("already reserved order is a no-op", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 5), stockReserved(1, "order-1", 3)}, true)
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("producer send error", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 5)}, true)
+		mockProducer.On("Send", mock.Anything, mock.Anything).Return(errors.New("database error"))
+
+		err := controller.ReserveStock(context.Background(), args)
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to send stock_reserved event")
+	})
+}
+
+func TestController_CommitStock(t *testing.T) {
+	t.Run("commits reserved stock", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 5), stockReserved(1, "order-1", 3)}, true)
+		mockProducer.On("Send", mock.Anything, matchEvent(inventory.EventTypeStockCommitted, 2, "order-1")).Return(nil)
+
+		err := controller.CommitStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("back-ordered stock cannot be committed", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockBackordered(0, "order-1", 3)}, true)
+
+		err := controller.CommitStock(context.Background(), "product-1", "order-1")
+
+		assert.Equal(t, ErrStockNotReserved, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_ReleaseStock(t *testing.T) {
+	t.Run("released stock is allocated to back-orders", func(t *testing.T) {
+		events := []eventsrc.Event{
+			stockReceived(0, 3),
+			stockReserved(1, "order-1", 3),
+			stockBackordered(2, "order-2", 2),
+			stockBackordered(3, "order-3", 1),
+		}
+		controller, mockProducer := newTestController(events, true)
+		mockProducer.On("SendAll", mock.Anything, matchEvents(
+			expectedEvent{inventory.EventTypeStockReleased, 4, "order-1"},
+			expectedEvent{inventory.EventTypeStockReserved, 5, "order-2"},
+			expectedEvent{inventory.EventTypeStockReserved, 6, "order-3"},
+		)).Return(nil)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("committed stock of a cancelled order is restocked and allocated to back-orders", func(t *testing.T) {
+		events := []eventsrc.Event{
+			stockReceived(0, 3),
+			stockReserved(1, "order-1", 3),
+			stockCommitted(2, "order-1", 3),
+			stockBackordered(3, "order-2", 2),
+		}
+		controller, mockProducer := newTestController(events, true)
+		mockProducer.On("SendAll", mock.Anything, matchEvents(
+			expectedEvent{inventory.EventTypeStockRestocked, 4, "order-1"},
+			expectedEvent{inventory.EventTypeStockReserved, 5, "order-2"},
+		)).Return(nil)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("restocked order is a no-op", func(t *testing.T) {
+		events := []eventsrc.Event{
+			stockReceived(0, 3),
+			stockReserved(1, "order-1", 3),
+			stockCommitted(2, "order-1", 3),
+			stockRestocked(3, "order-1", 3),
+		}
+		controller, mockProducer := newTestController(events, true)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "SendAll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("stock left unallocated by an earlier release is allocated on retry", func(t *testing.T) {
+		// order-1 was released, but publishing the reservation of order-2 failed
+		events := []eventsrc.Event{
+			stockReceived(0, 3),
+			stockReserved(1, "order-1", 3),
+			stockBackordered(2, "order-2", 2),
+			stockEvent(3, inventory.EventTypeStockReleased, &pb.StockReleased{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamppb.Now()}),
+		}
+		controller, mockProducer := newTestController(events, true)
+		mockProducer.On("SendAll", mock.Anything, matchEvents(
+			expectedEvent{inventory.EventTypeStockReserved, 4, "order-2"},
+		)).Return(nil)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("released back-order lets the next ones be allocated", func(t *testing.T) {
+		events := []eventsrc.Event{
+			stockReceived(0, 2),
+			stockBackordered(1, "order-1", 5),
+			stockBackordered(2, "order-2", 2),
+		}
+		controller, mockProducer := newTestController(events, true)
+		mockProducer.On("SendAll", mock.Anything, matchEvents(
+			expectedEvent{inventory.EventTypeStockReleased, 3, "order-1"},
+			expectedEvent{inventory.EventTypeStockReserved, 4, "order-2"},
+		)).Return(nil)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("unknown order is a no-op", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockReceived(0, 3)}, true)
+
+		err := controller.ReleaseStock(context.Background(), "product-1", "order-1")
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		mockProducer.AssertNotCalled(t, "SendAll", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_ReceiveStock(t *testing.T) {
+	t.Run("received stock is allocated to back-orders in order", func(t *testing.T) {
+		events := []eventsrc.Event{
+			stockBackordered(0, "order-1", 2),
+			stockBackordered(1, "order-2", 5),
+			stockBackordered(2, "order-3", 1),
+		}
+		controller, mockProducer := newTestController(events, true)
+		mockProducer.On("SendAll", mock.Anything, matchEvents(
+			expectedEvent{inventory.EventTypeStockReceived, 3, ""},
+			expectedEvent{inventory.EventTypeStockReserved, 4, "order-1"},
+		)).Return(nil)
+
+		// order-2 does not fit, so order-3 has to wait behind it
+		response, err := controller.ReceiveStock(context.Background(), &pb.ReceiveStockRequest{ProductId: "product-1", Quantity: 4})
+
+		assert.NoError(t, err)
+		require.NotNil(t, response)
+		assert.Equal(t, "product-1", response.ProductId)
+		mockProducer.AssertExpectations(t)
+		mockProducer.AssertNumberOfCalls(t, "SendAll", 1)
+	})
+
+	t.Run("nothing is stored when the batch fails", func(t *testing.T) {
+		controller, mockProducer := newTestController([]eventsrc.Event{stockBackordered(0, "order-1", 2)}, true)
+		mockProducer.On("SendAll", mock.Anything, mock.Anything).Return(errors.New("database error"))
+
+		response, err := controller.ReceiveStock(context.Background(), &pb.ReceiveStockRequest{ProductId: "product-1", Quantity: 4})
+
+		assert.Error(t, err)
+		assert.Nil(t, response)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,12 @@
+package inventory
+
+const (
+	EventTypeStockReceived    = "stock_received"
+	EventTypeStockReserved    = "stock_reserved"
+	EventTypeStockBackordered = "stock_backordered"
+	EventTypeStockReleased    = "stock_released"
+	EventTypeStockCommitted   = "stock_committed"
+	EventTypeStockRestocked   = "stock_restocked"
+
+	AggregateTypeInventory = "inventory"
+)
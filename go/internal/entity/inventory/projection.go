@@ -0,0 +1,231 @@
+package inventory
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Backorder struct {
+	OrderId  string
+	Quantity int32
+}
+
+type StockProjection struct {
+	ProductId string
+
+	// OnHand is the physical stock, including reserved units. Committed units leave the stock.
+	OnHand   int32
+	Reserved int32
+
+	// Reservations maps an order id to the quantity reserved for it.
+	Reservations map[string]int32
+	// Backorders are orders waiting for stock, in the order they were received.
+	Backorders []Backorder
+	// Committed maps an order id to the quantity committed for it. Orders are kept once committed, for idempotency.
+	Committed map[string]int32
+	// Restocked records the committed orders whose stock was put back on hand after they were cancelled.
+	Restocked map[string]bool
+
+	UpdatedAt time.Time
+}
+
+type SerializedEvent struct {
+	EventType string
+	EventData []byte
+}
+
+func newStockProjection() *StockProjection {
+	return &StockProjection{
+		Reservations: make(map[string]int32),
+		Committed:    make(map[string]int32),
+		Restocked:    make(map[string]bool),
+	}
+}
+
+// Available returns the number of units that can still be reserved.
+func (p *StockProjection) Available() int32 {
+	return p.OnHand - p.Reserved
+}
+
+// IsCommitted returns true if the stock of the order was committed.
+func (p *StockProjection) IsCommitted(orderId string) bool {
+	_, ok := p.Committed[orderId]
+	return ok
+}
+
+// BackorderedQuantity returns the number of units waiting for stock.
+func (p *StockProjection) BackorderedQuantity() int32 {
+	var quantity int32
+	for _, backorder := range p.Backorders {
+		quantity += backorder.Quantity
+	}
+	return quantity
+}
+
+// GetBackorder returns the back-order of an order, if it is waiting for stock.
+func (p *StockProjection) GetBackorder(orderId string) (Backorder, bool) {
+	for _, backorder := range p.Backorders {
+		if backorder.OrderId == orderId {
+			return backorder, true
+		}
+	}
+	return Backorder{}, false
+}
+
+// IsBackordered returns true if the order is waiting for stock.
+func (p *StockProjection) IsBackordered(orderId string) bool {
+	_, ok := p.GetBackorder(orderId)
+	return ok
+}
+
+func (p *StockProjection) removeBackorder(orderId string) {
+	for i, backorder := range p.Backorders {
+		if backorder.OrderId == orderId {
+			p.Backorders = append(p.Backorders[:i], p.Backorders[i+1:]...)
+			return
+		}
+	}
+}
+
+func (p *StockProjection) ToStockLevel() *pb.StockLevel {
+	return &pb.StockLevel{
+		ProductId:   p.ProductId,
+		OnHand:      p.OnHand,
+		Reserved:    p.Reserved,
+		Available:   p.Available(),
+		Backordered: p.BackorderedQuantity(),
+		UpdatedAt:   timestamppb.New(p.UpdatedAt),
+	}
+}
+
+// ReduceToProjection will reduce the event list into a stock projection
+func ReduceToProjection(events []SerializedEvent) (*StockProjection, error) {
+	projection := newStockProjection()
+	for _, event := range events {
+		err := applyEventToProjection(event, projection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return projection, nil
+}
+
+// applyEventToProjection will map the event type to the appropriate apply function
+func applyEventToProjection(event SerializedEvent, currentProjection *StockProjection) error {
+	switch event.EventType {
+	case EventTypeStockReceived:
+		return applyStockReceivedToProjection(event.EventData, currentProjection)
+	case EventTypeStockReserved:
+		return applyStockReservedToProjection(event.EventData, currentProjection)
+	case EventTypeStockBackordered:
+		return applyStockBackorderedToProjection(event.EventData, currentProjection)
+	case EventTypeStockReleased:
+		return applyStockReleasedToProjection(event.EventData, currentProjection)
+	case EventTypeStockCommitted:
+		return applyStockCommittedToProjection(event.EventData, currentProjection)
+	case EventTypeStockRestocked:
+		return applyStockRestockedToProjection(event.EventData, currentProjection)
+	default:
+		return fmt.Errorf("unknown event type: %s", event.EventType)
+	}
+}
+
+func applyStockReceivedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockReceived
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock received event: %w", err)
+	}
+
+	currentProjection.ProductId = event.ProductId
+	currentProjection.OnHand += event.Quantity
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyStockReservedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockReserved
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock reserved event: %w", err)
+	}
+
+	currentProjection.ProductId = event.ProductId
+	currentProjection.removeBackorder(event.OrderId)
+	currentProjection.Reservations[event.OrderId] = event.Quantity
+	currentProjection.Reserved += event.Quantity
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyStockBackorderedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockBackordered
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock backordered event: %w", err)
+	}
+
+	currentProjection.ProductId = event.ProductId
+	currentProjection.Backorders = append(currentProjection.Backorders, Backorder{
+		OrderId:  event.OrderId,
+		Quantity: event.Quantity,
+	})
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyStockReleasedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockReleased
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock released event: %w", err)
+	}
+
+	if reserved, ok := currentProjection.Reservations[event.OrderId]; ok {
+		currentProjection.Reserved -= reserved
+		delete(currentProjection.Reservations, event.OrderId)
+	}
+	currentProjection.removeBackorder(event.OrderId)
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyStockCommittedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockCommitted
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock committed event: %w", err)
+	}
+
+	if reserved, ok := currentProjection.Reservations[event.OrderId]; ok {
+		currentProjection.Reserved -= reserved
+		currentProjection.OnHand -= reserved
+		delete(currentProjection.Reservations, event.OrderId)
+	}
+	currentProjection.Committed[event.OrderId] = event.Quantity
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyStockRestockedToProjection(eventData []byte, currentProjection *StockProjection) error {
+	var event pb.StockRestocked
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal stock restocked event: %w", err)
+	}
+
+	currentProjection.OnHand += event.Quantity
+	currentProjection.Restocked[event.OrderId] = true
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package inventory
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func serialize(t *testing.T, eventType string, event proto.Message) SerializedEvent {
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+	return SerializedEvent{EventType: eventType, EventData: eventData}
+}
+
+func TestReduceToProjection_ReserveAndCommit(t *testing.T) {
+	timestamp := time.Now().UTC()
+	events := []SerializedEvent{
+		serialize(t, EventTypeStockReceived, &pb.StockReceived{ProductId: "product-1", Quantity: 10, Timestamp: timestamppb.New(timestamp)}),
+		serialize(t, EventTypeStockReserved, &pb.StockReserved{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamppb.New(timestamp)}),
+		serialize(t, EventTypeStockReserved, &pb.StockReserved{ProductId: "product-1", OrderId: "order-2", Quantity: 2, Timestamp: timestamppb.New(timestamp)}),
+		serialize(t, EventTypeStockCommitted, &pb.StockCommitted{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamppb.New(timestamp)}),
+		serialize(t, EventTypeStockReleased, &pb.StockReleased{ProductId: "product-1", OrderId: "order-2", Quantity: 2, Timestamp: timestamppb.New(timestamp)}),
+	}
+
+	projection, err := ReduceToProjection(events)
+	require.NoError(t, err)
+
+	assert.Equal(t, "product-1", projection.ProductId)
+	assert.Equal(t, int32(7), projection.OnHand)
+	assert.Equal(t, int32(0), projection.Reserved)
+	assert.Equal(t, int32(7), projection.Available())
+	assert.Empty(t, projection.Reservations)
+	assert.Equal(t, int32(3), projection.Committed["order-1"])
+	assert.Equal(t, timestamp, projection.UpdatedAt)
+}
+
+func TestReduceToProjection_Restock(t *testing.T) {
+	timestamp := timestamppb.Now()
+	events := []SerializedEvent{
+		serialize(t, EventTypeStockReceived, &pb.StockReceived{ProductId: "product-1", Quantity: 10, Timestamp: timestamp}),
+		serialize(t, EventTypeStockReserved, &pb.StockReserved{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamp}),
+		serialize(t, EventTypeStockCommitted, &pb.StockCommitted{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamp}),
+		serialize(t, EventTypeStockRestocked, &pb.StockRestocked{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamp}),
+	}
+
+	projection, err := ReduceToProjection(events)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(10), projection.OnHand)
+	assert.Equal(t, int32(10), projection.Available())
+	assert.True(t, projection.IsCommitted("order-1"))
+	assert.True(t, projection.Restocked["order-1"])
+}
+
+func TestReduceToProjection_Backorders(t *testing.T) {
+	timestamp := timestamppb.Now()
+	events := []SerializedEvent{
+		serialize(t, EventTypeStockBackordered, &pb.StockBackordered{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamp}),
+		serialize(t, EventTypeStockBackordered, &pb.StockBackordered{ProductId: "product-1", OrderId: "order-2", Quantity: 2, Timestamp: timestamp}),
+		serialize(t, EventTypeStockReceived, &pb.StockReceived{ProductId: "product-1", Quantity: 3, Timestamp: timestamp}),
+		serialize(t, EventTypeStockReserved, &pb.StockReserved{ProductId: "product-1", OrderId: "order-1", Quantity: 3, Timestamp: timestamp}),
+	}
+
+	projection, err := ReduceToProjection(events)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Backorder{{OrderId: "order-2", Quantity: 2}}, projection.Backorders)
+	assert.Equal(t, int32(2), projection.BackorderedQuantity())
+	assert.True(t, projection.IsBackordered("order-2"))
+	assert.False(t, projection.IsBackordered("order-1"))
+	assert.Equal(t, int32(0), projection.Available())
+
+	stockLevel := projection.ToStockLevel()
+	assert.Equal(t, int32(3), stockLevel.OnHand)
+	assert.Equal(t, int32(3), stockLevel.Reserved)
+	assert.Equal(t, int32(2), stockLevel.Backordered)
+}
+
+func TestReduceToProjection_UnknownEventType(t *testing.T) {
+	projection, err := ReduceToProjection([]SerializedEvent{{EventType: "unknown_event", EventData: []byte{}}})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown event type")
+	assert.Nil(t, projection)
+}
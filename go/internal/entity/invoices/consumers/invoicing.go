@@ -0,0 +1,95 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/invoices/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ConsumerNameInvoicing = "invoicing"
+)
+
+// InvoicingConsumer issues the invoices of paid orders and the credit notes of their refunds.
+type InvoicingConsumer struct {
+	Controller *controller.Controller
+}
+
+func NewInvoicingConsumer(controller *controller.Controller) *InvoicingConsumer {
+	return &InvoicingConsumer{
+		Controller: controller,
+	}
+}
+
+func (c *InvoicingConsumer) Name() string {
+	return ConsumerNameInvoicing
+}
+
+func (c *InvoicingConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	if args.AggregateType != orders.AggregateTypeOrder {
+		return nil
+	}
+
+	switch args.EventType {
+	case orders.EventTypeOrderPaid:
+		return c.handleOrderPaid(ctx, args)
+	case orders.EventTypeOrderPaymentRefunded:
+		return c.handleOrderPaymentRefunded(ctx, args)
+	case orders.EventTypeOrderReturnRefunded:
+		return c.handleOrderReturnRefunded(ctx, args)
+	default:
+		return nil
+	}
+}
+
+func (c *InvoicingConsumer) handleOrderPaid(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderPaid
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order paid event: %w", err)
+	}
+
+	invoice, err := c.Controller.IssueInvoice(ctx, event.OrderId, event.Timestamp.AsTime())
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Issued invoice", "orderId", event.OrderId, "invoiceId", invoice.InvoiceId, "number", invoice.Number())
+	return nil
+}
+
+func (c *InvoicingConsumer) handleOrderPaymentRefunded(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderPaymentRefunded
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order payment refunded event: %w", err)
+	}
+
+	creditNote, err := c.Controller.IssueOrderCreditNote(ctx, event.OrderId, event.Timestamp.AsTime())
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Issued credit note", "orderId", event.OrderId, "invoiceId", creditNote.InvoiceId, "number", creditNote.Number())
+	return nil
+}
+
+func (c *InvoicingConsumer) handleOrderReturnRefunded(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderReturnRefunded
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order return refunded event: %w", err)
+	}
+
+	creditNote, err := c.Controller.IssueReturnCreditNote(ctx, event.OrderId, event.ReturnId, event.Timestamp.AsTime())
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Issued credit note", "orderId", event.OrderId, "returnId", event.ReturnId, "invoiceId", creditNote.InvoiceId, "number", creditNote.Number())
+	return nil
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/invoices"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+)
+
+// OrderProvider returns the projection of an order, or nil if it does not exist.
+type OrderProvider interface {
+	GetProjection(ctx context.Context, orderId string) (*orders.OrderProjection, int, error)
+}
+
+type Controller struct {
+	repo       invoices.Repo
+	transactor pg.Transactor
+	orders     OrderProvider
+}
+
+func NewController(repo invoices.Repo, transactor pg.Transactor, orders OrderProvider) *Controller {
+	return &Controller{
+		repo:       repo,
+		transactor: transactor,
+		orders:     orders,
+	}
+}
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/invoices"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+
+	"github.com/google/uuid"
+)
+
+// Source ids are derived from the events invoices are issued for, so that replayed events issue
+// them once.
+func invoiceSourceId(orderId string) string {
+	return fmt.Sprintf("paid:%s", orderId)
+}
+
+func refundSourceId(orderId string) string {
+	return fmt.Sprintf("refund:%s", orderId)
+}
+
+func returnRefundSourceId(orderId string, returnId string) string {
+	return fmt.Sprintf("refund:%s:%s", orderId, returnId)
+}
+
+func (c *Controller) getOrder(ctx context.Context, orderId string) (*orders.OrderProjection, error) {
+	orderProjection, _, err := c.orders.GetProjection(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order projection: %w", err)
+	}
+	if orderProjection == nil {
+		return nil, orderctrl.ErrOrderNotFound
+	}
+	return orderProjection, nil
+}
+
+// issue numbers and stores an invoice, unless one was already issued for its source.
+func (c *Controller) issue(ctx context.Context, invoice invoices.Invoice) (invoices.Invoice, error) {
+	existing, err := c.repo.GetBySource(ctx, invoice.SourceId)
+	if err != nil {
+		return invoices.Invoice{}, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+
+	invoiceId, err := uuid.NewV7()
+	if err != nil {
+		return invoices.Invoice{}, fmt.Errorf("failed to generate invoice id: %w", err)
+	}
+	invoice.InvoiceId = invoiceId.String()
+
+	err = c.transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+		invoice, err = c.repo.Issue(ctx, tx, invoice)
+		if err != nil {
+			return fmt.Errorf("failed to issue invoice: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return invoices.Invoice{}, err
+	}
+
+	return invoice, nil
+}
+
+// IssueInvoice issues the invoice of a paid order.
+func (c *Controller) IssueInvoice(ctx context.Context, orderId string, issuedAt time.Time) (invoices.Invoice, error) {
+	orderProjection, err := c.getOrder(ctx, orderId)
+	if err != nil {
+		return invoices.Invoice{}, err
+	}
+
+	lineItems := make([]invoices.LineItem, len(orderProjection.LineItems))
+	for i, item := range orderProjection.LineItems {
+		lineItems[i] = invoices.LineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice,
+			Discount:   item.Discount,
+			TotalPrice: item.TotalPrice,
+		}
+	}
+
+	return c.issue(ctx, invoices.Invoice{
+		Kind:          invoices.KindInvoice,
+		VendorId:      orderProjection.VendorId,
+		OrderId:       orderId,
+		SourceId:      invoiceSourceId(orderId),
+		CustomerId:    orderProjection.CustomerId,
+		LineItems:     lineItems,
+		SubtotalPrice: orderProjection.SubtotalPrice,
+		Discount:      orderProjection.Discount,
+		TotalPrice:    orderProjection.TotalPrice,
+		IssuedAt:      issuedAt,
+	})
+}
+
+// IssueOrderCreditNote issues the credit note of an order whose payment was refunded in full.
+func (c *Controller) IssueOrderCreditNote(ctx context.Context, orderId string, issuedAt time.Time) (invoices.Invoice, error) {
+
+	// Orders paid before invoicing was enabled get their invoice first
+	invoice, err := c.IssueInvoice(ctx, orderId, issuedAt)
+	if err != nil {
+		return invoices.Invoice{}, err
+	}
+
+	return c.issue(ctx, invoices.Invoice{
+		Kind:              invoices.KindCreditNote,
+		VendorId:          invoice.VendorId,
+		OrderId:           orderId,
+		SourceId:          refundSourceId(orderId),
+		CustomerId:        invoice.CustomerId,
+		LineItems:         invoice.LineItems,
+		SubtotalPrice:     invoice.SubtotalPrice,
+		Discount:          invoice.Discount,
+		TotalPrice:        invoice.TotalPrice,
+		CreditedInvoiceId: invoice.InvoiceId,
+		CreditedNumber:    invoice.Number(),
+		IssuedAt:          issuedAt,
+	})
+}
+
+// IssueReturnCreditNote issues the credit note of a refunded return, for its line items.
+func (c *Controller) IssueReturnCreditNote(ctx context.Context, orderId string, returnId string, issuedAt time.Time) (invoices.Invoice, error) {
+	orderProjection, err := c.getOrder(ctx, orderId)
+	if err != nil {
+		return invoices.Invoice{}, err
+	}
+
+	var orderReturn *orders.Return
+	for i := range orderProjection.Returns {
+		if orderProjection.Returns[i].ReturnId == returnId {
+			orderReturn = &orderProjection.Returns[i]
+		}
+	}
+	if orderReturn == nil {
+		return invoices.Invoice{}, orderctrl.ErrReturnNotFound
+	}
+
+	invoice, err := c.IssueInvoice(ctx, orderId, issuedAt)
+	if err != nil {
+		return invoices.Invoice{}, err
+	}
+
+	// The refund of a line item is its share of the price paid, the rest of its price is discount
+	unitPrices := make(map[string]money.Money, len(orderProjection.LineItems))
+	for _, item := range orderProjection.LineItems {
+		unitPrices[item.ProductId] = item.UnitPrice
+	}
+
+	subtotal := money.Zero(orderReturn.RefundAmount.Currency)
+	lineItems := make([]invoices.LineItem, len(orderReturn.LineItems))
+	for i, item := range orderReturn.LineItems {
+		unitPrice := unitPrices[item.ProductId]
+		price := unitPrice.Mul(int64(item.Quantity))
+		discount, err := price.Sub(item.RefundAmount)
+		if err != nil {
+			return invoices.Invoice{}, fmt.Errorf("failed to compute line item discount: %w", err)
+		}
+
+		lineItems[i] = invoices.LineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  unitPrice,
+			Discount:   discount,
+			TotalPrice: item.RefundAmount,
+		}
+		subtotal.Amount += price.Amount
+	}
+
+	discount, err := subtotal.Sub(orderReturn.RefundAmount)
+	if err != nil {
+		return invoices.Invoice{}, fmt.Errorf("failed to compute discount: %w", err)
+	}
+
+	return c.issue(ctx, invoices.Invoice{
+		Kind:              invoices.KindCreditNote,
+		VendorId:          invoice.VendorId,
+		OrderId:           orderId,
+		SourceId:          returnRefundSourceId(orderId, returnId),
+		CustomerId:        invoice.CustomerId,
+		LineItems:         lineItems,
+		SubtotalPrice:     subtotal,
+		Discount:          discount,
+		TotalPrice:        orderReturn.RefundAmount,
+		CreditedInvoiceId: invoice.InvoiceId,
+		CreditedNumber:    invoice.Number(),
+		IssuedAt:          issuedAt,
+	})
+}
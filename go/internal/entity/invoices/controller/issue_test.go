@@ -0,0 +1,201 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/invoices"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOrders returns fixed order projections
+type fakeOrders map[string]*orders.OrderProjection
+
+func (f fakeOrders) GetProjection(ctx context.Context, orderId string) (*orders.OrderProjection, int, error) {
+	return f[orderId], 0, nil
+}
+
+func eur(amount int64) money.Money {
+	return money.Money{Currency: "EUR", Amount: amount}
+}
+
+func paidOrder(orderId string, vendorId string) *orders.OrderProjection {
+	return &orders.OrderProjection{
+		OrderId:    orderId,
+		CustomerId: "customer-1",
+		VendorId:   vendorId,
+		LineItems: []orders.LineItem{
+			{ProductId: "product-1", Quantity: 2, UnitPrice: eur(1000), Discount: eur(200), TotalPrice: eur(1800)},
+			{ProductId: "product-2", Quantity: 1, UnitPrice: eur(500), Discount: eur(50), TotalPrice: eur(450)},
+		},
+		SubtotalPrice: eur(2500),
+		Discount:      eur(250),
+		TotalPrice:    eur(2250),
+		Returns: []orders.Return{
+			{
+				ReturnId:     "return-1",
+				Status:       orders.ReturnStatusRefunded,
+				LineItems:    []orders.ReturnLineItem{{ProductId: "product-1", Quantity: 1, RefundAmount: eur(900)}},
+				RefundAmount: eur(900),
+			},
+		},
+	}
+}
+
+var testOrders = fakeOrders{
+	"order-1": paidOrder("order-1", "vendor-1"),
+	"order-2": paidOrder("order-2", "vendor-1"),
+	"order-3": paidOrder("order-3", "vendor-2"),
+}
+
+func newTestController() (*Controller, *invoices.InMemoryRepo) {
+	repo := invoices.NewInMemoryRepo()
+	return NewController(repo, &pg.TestTransactor{}, testOrders), repo
+}
+
+func TestController_IssueInvoice(t *testing.T) {
+	t.Run("numbers the invoices of every vendor without gaps", func(t *testing.T) {
+		controller, _ := newTestController()
+
+		first, err := controller.IssueInvoice(context.Background(), "order-1", time.Now())
+		assert.NoError(t, err)
+		second, err := controller.IssueInvoice(context.Background(), "order-2", time.Now())
+		assert.NoError(t, err)
+		other, err := controller.IssueInvoice(context.Background(), "order-3", time.Now())
+		assert.NoError(t, err)
+
+		assert.Equal(t, "INV-000001", first.Number())
+		assert.Equal(t, "INV-000002", second.Number())
+		assert.Equal(t, "INV-000001", other.Number())
+		assert.Equal(t, eur(2250), first.TotalPrice)
+		assert.Len(t, first.LineItems, 2)
+	})
+
+	t.Run("issues a replayed invoice once", func(t *testing.T) {
+		controller, repo := newTestController()
+
+		first, err := controller.IssueInvoice(context.Background(), "order-1", time.Now())
+		assert.NoError(t, err)
+		replayed, err := controller.IssueInvoice(context.Background(), "order-1", time.Now())
+		assert.NoError(t, err)
+
+		assert.Equal(t, first.InvoiceId, replayed.InvoiceId)
+		assert.Len(t, repo.Invoices, 1)
+	})
+
+	t.Run("fails for an unknown order", func(t *testing.T) {
+		controller, _ := newTestController()
+
+		_, err := controller.IssueInvoice(context.Background(), "order-unknown", time.Now())
+
+		assert.ErrorIs(t, err, orderctrl.ErrOrderNotFound)
+	})
+}
+
+func TestController_IssueCreditNote(t *testing.T) {
+	t.Run("credits the whole invoice of a refunded order", func(t *testing.T) {
+		controller, _ := newTestController()
+		invoice, err := controller.IssueInvoice(context.Background(), "order-1", time.Now())
+		assert.NoError(t, err)
+
+		creditNote, err := controller.IssueOrderCreditNote(context.Background(), "order-1", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, invoices.KindCreditNote, creditNote.Kind)
+		assert.Equal(t, "CN-000001", creditNote.Number())
+		assert.Equal(t, invoice.InvoiceId, creditNote.CreditedInvoiceId)
+		assert.Equal(t, "INV-000001", creditNote.CreditedNumber)
+		assert.Equal(t, invoice.TotalPrice, creditNote.TotalPrice)
+	})
+
+	t.Run("issues the missing invoice of an order first", func(t *testing.T) {
+		controller, repo := newTestController()
+
+		creditNote, err := controller.IssueOrderCreditNote(context.Background(), "order-1", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-000001", creditNote.CreditedNumber)
+		assert.Len(t, repo.Invoices, 2)
+	})
+
+	t.Run("credits the line items of a return", func(t *testing.T) {
+		controller, _ := newTestController()
+
+		creditNote, err := controller.IssueReturnCreditNote(context.Background(), "order-1", "return-1", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []invoices.LineItem{
+			{ProductId: "product-1", Quantity: 1, UnitPrice: eur(1000), Discount: eur(100), TotalPrice: eur(900)},
+		}, creditNote.LineItems)
+		assert.Equal(t, eur(1000), creditNote.SubtotalPrice)
+		assert.Equal(t, eur(100), creditNote.Discount)
+		assert.Equal(t, eur(900), creditNote.TotalPrice)
+	})
+
+	t.Run("fails for an unknown return", func(t *testing.T) {
+		controller, _ := newTestController()
+
+		_, err := controller.IssueReturnCreditNote(context.Background(), "order-1", "return-unknown", time.Now())
+
+		assert.ErrorIs(t, err, orderctrl.ErrReturnNotFound)
+	})
+}
+
+func TestController_GetInvoice(t *testing.T) {
+	controller, _ := newTestController()
+	invoice, err := controller.IssueInvoice(context.Background(), "order-1", time.Now())
+	assert.NoError(t, err)
+
+	t.Run("returns the invoice details", func(t *testing.T) {
+		resp, err := controller.GetInvoice(context.Background(), &pb.GetInvoiceRequest{InvoiceId: invoice.InvoiceId})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "INV-000001", resp.Invoice.Number)
+		assert.Equal(t, pb.InvoiceKind_INVOICE_KIND_INVOICE, resp.Invoice.Kind)
+		assert.Empty(t, resp.Document)
+	})
+
+	t.Run("renders the invoice as HTML", func(t *testing.T) {
+		resp, err := controller.GetInvoice(context.Background(), &pb.GetInvoiceRequest{InvoiceId: invoice.InvoiceId, Format: pb.InvoiceFormat_INVOICE_FORMAT_HTML})
+
+		assert.NoError(t, err)
+		assert.Equal(t, invoices.ContentTypeHTML, resp.ContentType)
+		assert.Contains(t, string(resp.Document), "Invoice INV-000001")
+		assert.Contains(t, string(resp.Document), "22.50 EUR")
+	})
+
+	t.Run("renders the invoice as PDF", func(t *testing.T) {
+		resp, err := controller.GetInvoice(context.Background(), &pb.GetInvoiceRequest{InvoiceId: invoice.InvoiceId, Format: pb.InvoiceFormat_INVOICE_FORMAT_PDF})
+
+		assert.NoError(t, err)
+		assert.Equal(t, invoices.ContentTypePDF, resp.ContentType)
+		assert.Contains(t, string(resp.Document), "%PDF-1.4")
+		assert.Contains(t, string(resp.Document), "(Invoice INV-000001) Tj")
+	})
+
+	t.Run("fails for an unknown invoice", func(t *testing.T) {
+		_, err := controller.GetInvoice(context.Background(), &pb.GetInvoiceRequest{InvoiceId: "invoice-unknown"})
+
+		assert.ErrorIs(t, err, ErrInvoiceNotFound)
+	})
+}
+
+func TestController_ListInvoices(t *testing.T) {
+	controller, _ := newTestController()
+	for _, orderId := range []string{"order-1", "order-2", "order-3"} {
+		_, err := controller.IssueInvoice(context.Background(), orderId, time.Now())
+		assert.NoError(t, err)
+	}
+
+	resp, err := controller.ListInvoices(context.Background(), &pb.ListInvoicesRequest{VendorId: "vendor-1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Invoices, 2)
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/invoices"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultLimit = 25
+const defaultOffset = 0
+
+var ErrInvoiceNotFound = status.Errorf(codes.NotFound, "invoice not found")
+
+// GetInvoice returns an invoice, rendered in the requested format.
+func (c *Controller) GetInvoice(ctx context.Context, req *pb.GetInvoiceRequest) (*pb.GetInvoiceResponse, error) {
+	invoice, err := c.repo.Get(ctx, req.InvoiceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+	if invoice == nil {
+		return nil, ErrInvoiceNotFound
+	}
+
+	resp := &pb.GetInvoiceResponse{Invoice: invoice.ToInvoiceDetails()}
+	switch req.Format {
+	case pb.InvoiceFormat_INVOICE_FORMAT_HTML:
+		resp.Document, err = invoices.RenderHTML(*invoice)
+		if err != nil {
+			return nil, err
+		}
+		resp.ContentType = invoices.ContentTypeHTML
+	case pb.InvoiceFormat_INVOICE_FORMAT_PDF:
+		resp.Document = invoices.RenderPDF(*invoice)
+		resp.ContentType = invoices.ContentTypePDF
+	}
+
+	return resp, nil
+}
+
+func (c *Controller) ListInvoices(ctx context.Context, req *pb.ListInvoicesRequest) (*pb.ListInvoicesResponse, error) {
+	var limit uint = defaultLimit
+	var offset uint = defaultOffset
+	if req.Limit != nil {
+		limit = uint(*req.Limit)
+	}
+	if req.Offset != nil {
+		offset = uint(*req.Offset)
+	}
+
+	list, err := c.repo.List(ctx, invoices.ListArgs{
+		Limit:    limit,
+		Offset:   offset,
+		VendorId: req.VendorId,
+		OrderId:  req.OrderId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+
+	details := make([]*pb.InvoiceDetails, len(list))
+	for i, invoice := range list {
+		details[i] = invoice.ToInvoiceDetails()
+	}
+
+	return &pb.ListInvoicesResponse{Invoices: details}, nil
+}
@@ -0,0 +1,60 @@
+package invoices
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+)
+
+const (
+	// Invoice kinds. Each kind is numbered in its own series per vendor.
+	KindInvoice    = "invoice"
+	KindCreditNote = "credit_note"
+)
+
+type LineItem struct {
+	ProductId string
+	Quantity  int32
+	UnitPrice money.Money
+	Discount  money.Money
+	// TotalPrice is the price of the line item after its discount.
+	TotalPrice money.Money
+}
+
+// Invoice is an invoice of a paid order, or a credit note of its refund. Amounts of credit notes
+// are positive, like the invoices they refund.
+type Invoice struct {
+	InvoiceId string
+	Kind      string
+	VendorId  string
+	// Sequence is the position of the invoice in the series of its vendor and kind, starting at 1.
+	Sequence int64
+	OrderId  string
+	// SourceId identifies what the invoice was issued for, so that it is issued once.
+	SourceId   string
+	CustomerId string
+
+	LineItems     []LineItem
+	SubtotalPrice money.Money
+	Discount      money.Money
+	TotalPrice    money.Money
+
+	// CreditedInvoiceId and CreditedNumber identify the invoice refunded by a credit note.
+	CreditedInvoiceId string
+	CreditedNumber    string
+	IssuedAt          time.Time
+}
+
+// Number returns the number printed on the invoice, e.g. INV-000042 or CN-000007.
+func (i Invoice) Number() string {
+	return FormatNumber(i.Kind, i.Sequence)
+}
+
+func FormatNumber(kind string, sequence int64) string {
+	prefix := "INV"
+	if kind == KindCreditNote {
+		prefix = "CN"
+	}
+	return fmt.Sprintf("%s-%06d", prefix, sequence)
+}
@@ -0,0 +1,48 @@
+package invoices
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (i Invoice) ToInvoiceDetails() *pb.InvoiceDetails {
+	lineItems := make([]*pb.InvoiceLineItem, len(i.LineItems))
+	for j, item := range i.LineItems {
+		lineItems[j] = &pb.InvoiceLineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  orders.MapMoneyToProto(item.UnitPrice),
+			Discount:   orders.MapMoneyToProto(item.Discount),
+			TotalPrice: orders.MapMoneyToProto(item.TotalPrice),
+		}
+	}
+
+	return &pb.InvoiceDetails{
+		InvoiceId:             i.InvoiceId,
+		Kind:                  MapStrToInvoiceKind(i.Kind),
+		Number:                i.Number(),
+		VendorId:              i.VendorId,
+		OrderId:               i.OrderId,
+		CustomerId:            i.CustomerId,
+		LineItems:             lineItems,
+		SubtotalPrice:         orders.MapMoneyToProto(i.SubtotalPrice),
+		Discount:              orders.MapMoneyToProto(i.Discount),
+		TotalPrice:            orders.MapMoneyToProto(i.TotalPrice),
+		CreditedInvoiceId:     i.CreditedInvoiceId,
+		CreditedInvoiceNumber: i.CreditedNumber,
+		IssuedAt:              timestamppb.New(i.IssuedAt),
+	}
+}
+
+func MapStrToInvoiceKind(kind string) pb.InvoiceKind {
+	switch kind {
+	case KindInvoice:
+		return pb.InvoiceKind_INVOICE_KIND_INVOICE
+	case KindCreditNote:
+		return pb.InvoiceKind_INVOICE_KIND_CREDIT_NOTE
+	}
+
+	return pb.InvoiceKind_INVOICE_KIND_UNSPECIFIED
+}
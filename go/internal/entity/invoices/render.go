@@ -0,0 +1,147 @@
+package invoices
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pdf"
+)
+
+const (
+	ContentTypeHTML = "text/html; charset=utf-8"
+	ContentTypePDF  = "application/pdf"
+)
+
+// document is what is printed on an invoice, shared by its HTML and PDF renderings.
+type document struct {
+	Title   string
+	Number  string
+	Invoice Invoice
+}
+
+func newDocument(invoice Invoice) document {
+	title := "Invoice"
+	if invoice.Kind == KindCreditNote {
+		title = "Credit Note"
+	}
+	return document{
+		Title:   title,
+		Number:  invoice.Number(),
+		Invoice: invoice,
+	}
+}
+
+var htmlTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.Number}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 14px; margin: 40px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 6px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.amount { text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}} {{.Number}}</h1>
+<p>
+Issued: {{.Invoice.IssuedAt.Format "2006-01-02"}}<br>
+Vendor: {{.Invoice.VendorId}}<br>
+Customer: {{.Invoice.CustomerId}}<br>
+Order: {{.Invoice.OrderId}}{{if .Invoice.CreditedNumber}}<br>
+Refunds invoice: {{.Invoice.CreditedNumber}}{{end}}
+</p>
+<table>
+<thead>
+<tr><th>Product</th><th class="amount">Quantity</th><th class="amount">Unit price</th><th class="amount">Discount</th><th class="amount">Total</th></tr>
+</thead>
+<tbody>
+{{- range .Invoice.LineItems}}
+<tr><td>{{.ProductId}}</td><td class="amount">{{.Quantity}}</td><td class="amount">{{.UnitPrice}}</td><td class="amount">{{.Discount}}</td><td class="amount">{{.TotalPrice}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td colspan="4" class="amount">Subtotal</td><td class="amount">{{.Invoice.SubtotalPrice}}</td></tr>
+<tr><td colspan="4" class="amount">Discount</td><td class="amount">{{.Invoice.Discount}}</td></tr>
+<tr><th colspan="4" class="amount">Total</th><th class="amount">{{.Invoice.TotalPrice}}</th></tr>
+</tfoot>
+</table>
+</body>
+</html>
+`))
+
+// RenderHTML renders an invoice as an HTML page.
+func RenderHTML(invoice Invoice) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, newDocument(invoice)); err != nil {
+		return nil, fmt.Errorf("failed to render invoice %s: %w", invoice.InvoiceId, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Layout of the PDF rendering, in points
+const (
+	pdfMargin     = 50
+	pdfLineHeight = 18
+	pdfFontSize   = 10
+)
+
+// Right edges of the amount columns
+var pdfColumns = [4]float64{330, 410, 480, pdf.PageWidth - pdfMargin}
+
+// RenderPDF renders an invoice as a PDF document.
+func RenderPDF(invoice Invoice) []byte {
+	doc := newDocument(invoice)
+	out := pdf.New()
+	page := out.AddPage()
+
+	y := float64(pdfMargin + 20)
+	page.Text(pdfMargin, y, 20, true, fmt.Sprintf("%s %s", doc.Title, doc.Number))
+	y += 2 * pdfLineHeight
+
+	details := []string{
+		fmt.Sprintf("Issued: %s", invoice.IssuedAt.Format("2006-01-02")),
+		fmt.Sprintf("Vendor: %s", invoice.VendorId),
+		fmt.Sprintf("Customer: %s", invoice.CustomerId),
+		fmt.Sprintf("Order: %s", invoice.OrderId),
+	}
+	if invoice.CreditedNumber != "" {
+		details = append(details, fmt.Sprintf("Refunds invoice: %s", invoice.CreditedNumber))
+	}
+	for _, line := range details {
+		page.Text(pdfMargin, y, pdfFontSize, false, line)
+		y += pdfLineHeight
+	}
+	y += pdfLineHeight
+
+	// Line items
+	row := func(bold bool, product string, amounts ...string) {
+		page.Text(pdfMargin, y, pdfFontSize, bold, product)
+		offset := len(pdfColumns) - len(amounts)
+		for i, amount := range amounts {
+			page.TextRight(pdfColumns[offset+i], y, pdfFontSize, bold, amount)
+		}
+		y += pdfLineHeight
+	}
+
+	row(true, "Product", "Quantity", "Unit price", "Discount", "Total")
+	page.Line(pdfMargin, y-pdfLineHeight+5, pdf.PageWidth-pdfMargin, y-pdfLineHeight+5, 0.5)
+	for _, item := range invoice.LineItems {
+		// Start a new page before running off the bottom of this one
+		if y > pdf.PageHeight-pdfMargin-4*pdfLineHeight {
+			page = out.AddPage()
+			y = pdfMargin + pdfLineHeight
+		}
+		row(false, item.ProductId, fmt.Sprintf("%d", item.Quantity), item.UnitPrice.String(), item.Discount.String(), item.TotalPrice.String())
+	}
+	page.Line(pdfMargin, y-pdfLineHeight+5, pdf.PageWidth-pdfMargin, y-pdfLineHeight+5, 0.5)
+
+	// Totals
+	row(false, "", "Subtotal", invoice.SubtotalPrice.String())
+	row(false, "", "Discount", invoice.Discount.String())
+	row(true, "", "Total", invoice.TotalPrice.String())
+
+	return out.Bytes()
+}
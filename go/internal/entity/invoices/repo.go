@@ -0,0 +1,345 @@
+package invoices
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	InvoiceTable  = "invoice"
+	SequenceTable = "invoice_sequence"
+)
+
+type ListArgs struct {
+	Limit  uint
+	Offset uint
+	// Filters, ignored when empty
+	VendorId string
+	OrderId  string
+}
+
+type Repo interface {
+	// Issue numbers the invoice with the next sequence of its vendor and kind, and stores it.
+	// The sequence is allocated in tx, so rolling it back leaves no gap.
+	Issue(ctx context.Context, tx pg.Tx, invoice Invoice) (Invoice, error)
+
+	// Get returns an invoice, or nil if it does not exist.
+	Get(ctx context.Context, invoiceId string) (*Invoice, error)
+
+	// GetBySource returns the invoice issued for a source, or nil if none was.
+	GetBySource(ctx context.Context, sourceId string) (*Invoice, error)
+
+	// List returns invoices, the latest first.
+	List(ctx context.Context, args ListArgs) ([]Invoice, error)
+}
+
+/** Postgres Repo */
+
+type DbLineItem struct {
+	ProductId  string `json:"product_id"`
+	Quantity   int32  `json:"quantity"`
+	UnitPrice  int64  `json:"unit_price_minor"`
+	Discount   int64  `json:"discount_minor"`
+	TotalPrice int64  `json:"total_price_minor"`
+}
+
+type DbInvoice struct {
+	InvoiceId         string         `db:"invoice_id"`
+	Kind              string         `db:"kind"`
+	VendorId          string         `db:"vendor_id"`
+	Sequence          int64          `db:"sequence"`
+	OrderId           string         `db:"order_id"`
+	SourceId          string         `db:"source_id"`
+	CustomerId        string         `db:"customer_id"`
+	CurrencyCode      string         `db:"currency_code"`
+	LineItems         []byte         `db:"line_items"`
+	SubtotalMinor     int64          `db:"subtotal_minor"`
+	DiscountMinor     int64          `db:"discount_minor"`
+	TotalMinor        int64          `db:"total_minor"`
+	CreditedInvoiceId sql.NullString `db:"credited_invoice_id"`
+	CreditedNumber    sql.NullString `db:"credited_invoice_number"`
+	IssuedAt          time.Time      `db:"issued_at"`
+}
+
+func (i DbInvoice) toInvoice() (Invoice, error) {
+	currency := func(amount int64) money.Money {
+		return money.Money{Currency: i.CurrencyCode, Amount: amount}
+	}
+
+	dbLineItems := []DbLineItem{}
+	if err := json.Unmarshal(i.LineItems, &dbLineItems); err != nil {
+		return Invoice{}, fmt.Errorf("failed to unmarshal line items of invoice %s: %w", i.InvoiceId, err)
+	}
+
+	lineItems := make([]LineItem, len(dbLineItems))
+	for j, item := range dbLineItems {
+		lineItems[j] = LineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  currency(item.UnitPrice),
+			Discount:   currency(item.Discount),
+			TotalPrice: currency(item.TotalPrice),
+		}
+	}
+
+	return Invoice{
+		InvoiceId:         i.InvoiceId,
+		Kind:              i.Kind,
+		VendorId:          i.VendorId,
+		Sequence:          i.Sequence,
+		OrderId:           i.OrderId,
+		SourceId:          i.SourceId,
+		CustomerId:        i.CustomerId,
+		LineItems:         lineItems,
+		SubtotalPrice:     currency(i.SubtotalMinor),
+		Discount:          currency(i.DiscountMinor),
+		TotalPrice:        currency(i.TotalMinor),
+		CreditedInvoiceId: i.CreditedInvoiceId.String,
+		CreditedNumber:    i.CreditedNumber.String,
+		IssuedAt:          i.IssuedAt,
+	}, nil
+}
+
+type PgRepo struct {
+	db *sqlx.DB
+}
+
+func NewPgRepo(db *sqlx.DB) *PgRepo {
+	return &PgRepo{db: db}
+}
+
+func (r *PgRepo) Issue(ctx context.Context, tx pg.Tx, invoice Invoice) (Invoice, error) {
+
+	// Allocate the next sequence. The row stays locked until tx ends, so concurrent invoices of a
+	// vendor are numbered one after the other.
+	ds := pg.Dialect.Insert(SequenceTable).Prepared(true).
+		Rows(goqu.Record{"vendor_id": invoice.VendorId, "kind": invoice.Kind, "last_sequence": 1}).
+		OnConflict(goqu.DoUpdate("vendor_id, kind", goqu.Record{
+			"last_sequence": goqu.L("? + 1", goqu.I(SequenceTable+".last_sequence")),
+		})).
+		Returning("last_sequence")
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return Invoice{}, pg.ErrorDsl(err)
+	}
+
+	rows, err := tx.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return Invoice{}, pg.ErrorDb(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return Invoice{}, pg.ErrorDb(fmt.Errorf("no sequence returned: %w", rows.Err()))
+	}
+	if err := rows.Scan(&invoice.Sequence); err != nil {
+		return Invoice{}, pg.ErrorUnmarshal(err)
+	}
+	if err := rows.Close(); err != nil {
+		return Invoice{}, pg.ErrorDb(err)
+	}
+
+	// Store the invoice
+	dbLineItems := make([]DbLineItem, len(invoice.LineItems))
+	for i, item := range invoice.LineItems {
+		dbLineItems[i] = DbLineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  item.UnitPrice.Amount,
+			Discount:   item.Discount.Amount,
+			TotalPrice: item.TotalPrice.Amount,
+		}
+	}
+	lineItems, err := json.Marshal(dbLineItems)
+	if err != nil {
+		return Invoice{}, fmt.Errorf("failed to marshal line items: %w", err)
+	}
+
+	ds = pg.Dialect.Insert(InvoiceTable).Prepared(true).
+		Rows(goqu.Record{
+			"invoice_id":              invoice.InvoiceId,
+			"kind":                    invoice.Kind,
+			"vendor_id":               invoice.VendorId,
+			"sequence":                invoice.Sequence,
+			"order_id":                invoice.OrderId,
+			"source_id":               invoice.SourceId,
+			"customer_id":             invoice.CustomerId,
+			"currency_code":           invoice.TotalPrice.Currency,
+			"line_items":              lineItems,
+			"subtotal_minor":          invoice.SubtotalPrice.Amount,
+			"discount_minor":          invoice.Discount.Amount,
+			"total_minor":             invoice.TotalPrice.Amount,
+			"credited_invoice_id":     sql.NullString{String: invoice.CreditedInvoiceId, Valid: invoice.CreditedInvoiceId != ""},
+			"credited_invoice_number": sql.NullString{String: invoice.CreditedNumber, Valid: invoice.CreditedNumber != ""},
+			"issued_at":               invoice.IssuedAt,
+		})
+
+	query, queryArgs, err = ds.ToSQL()
+	if err != nil {
+		return Invoice{}, pg.ErrorDsl(err)
+	}
+
+	_, err = tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return Invoice{}, pg.ErrorDb(err)
+	}
+
+	return invoice, nil
+}
+
+func (r *PgRepo) Get(ctx context.Context, invoiceId string) (*Invoice, error) {
+	return r.getOne(ctx, goqu.Ex{"invoice_id": invoiceId})
+}
+
+func (r *PgRepo) GetBySource(ctx context.Context, sourceId string) (*Invoice, error) {
+	return r.getOne(ctx, goqu.Ex{"source_id": sourceId})
+}
+
+func (r *PgRepo) getOne(ctx context.Context, where goqu.Ex) (*Invoice, error) {
+	ds := pg.Dialect.From(InvoiceTable).Prepared(true).
+		Select(&DbInvoice{}).
+		Where(where)
+
+	invoices, err := r.query(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+	if len(invoices) == 0 {
+		return nil, nil
+	}
+
+	return &invoices[0], nil
+}
+
+func (r *PgRepo) List(ctx context.Context, args ListArgs) ([]Invoice, error) {
+
+	where := goqu.Ex{}
+	if args.VendorId != "" {
+		where["vendor_id"] = args.VendorId
+	}
+	if args.OrderId != "" {
+		where["order_id"] = args.OrderId
+	}
+
+	ds := pg.Dialect.From(InvoiceTable).Prepared(true).
+		Select(&DbInvoice{}).
+		Where(where).
+		Order(goqu.I("issued_at").Desc(), goqu.I("invoice_id").Desc()).
+		Limit(args.Limit).
+		Offset(args.Offset)
+
+	return r.query(ctx, ds)
+}
+
+func (r *PgRepo) query(ctx context.Context, ds *goqu.SelectDataset) ([]Invoice, error) {
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	dbInvoices := []DbInvoice{}
+	err = sqlx.StructScan(rows, &dbInvoices)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	invoices := make([]Invoice, len(dbInvoices))
+	for i, dbInvoice := range dbInvoices {
+		invoices[i], err = dbInvoice.toInvoice()
+		if err != nil {
+			return nil, pg.ErrorUnmarshal(err)
+		}
+	}
+
+	return invoices, nil
+}
+
+/** In-Memory Repo */
+
+type InMemoryRepo struct {
+	mu        sync.Mutex
+	Invoices  []Invoice
+	sequences map[string]int64
+}
+
+func NewInMemoryRepo() *InMemoryRepo {
+	return &InMemoryRepo{sequences: map[string]int64{}}
+}
+
+func (r *InMemoryRepo) Issue(ctx context.Context, tx pg.Tx, invoice Invoice) (Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.Invoices {
+		if existing.SourceId == invoice.SourceId {
+			return Invoice{}, fmt.Errorf("invoice already issued for %s", invoice.SourceId)
+		}
+	}
+
+	key := invoice.VendorId + "/" + invoice.Kind
+	r.sequences[key]++
+	invoice.Sequence = r.sequences[key]
+	r.Invoices = append(r.Invoices, invoice)
+
+	return invoice, nil
+}
+
+func (r *InMemoryRepo) Get(ctx context.Context, invoiceId string) (*Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, invoice := range r.Invoices {
+		if invoice.InvoiceId == invoiceId {
+			return &invoice, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryRepo) GetBySource(ctx context.Context, sourceId string) (*Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, invoice := range r.Invoices {
+		if invoice.SourceId == sourceId {
+			return &invoice, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *InMemoryRepo) List(ctx context.Context, args ListArgs) ([]Invoice, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invoices := []Invoice{}
+	for _, invoice := range r.Invoices {
+		if (args.VendorId == "" || invoice.VendorId == args.VendorId) && (args.OrderId == "" || invoice.OrderId == args.OrderId) {
+			invoices = append(invoices, invoice)
+		}
+	}
+
+	sort.SliceStable(invoices, func(i, j int) bool {
+		return invoices[i].IssuedAt.After(invoices[j].IssuedAt)
+	})
+
+	start := min(int(args.Offset), len(invoices))
+	end := min(start+int(args.Limit), len(invoices))
+	return invoices[start:end], nil
+}
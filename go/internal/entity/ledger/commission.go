@@ -0,0 +1,23 @@
+package ledger
+
+import "github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+// CommissionRates are the marketplace fees taken on the sales of vendors, in basis points (1000 = 10%).
+type CommissionRates struct {
+	DefaultBps int32
+	// VendorBps overrides the default rate of some vendors.
+	VendorBps map[string]int32
+}
+
+// Rate returns the commission rate of a vendor, in basis points.
+func (r CommissionRates) Rate(vendorId string) int32 {
+	if bps, ok := r.VendorBps[vendorId]; ok {
+		return bps
+	}
+	return r.DefaultBps
+}
+
+// Commission returns the commission taken on a sale of a vendor, rounded half to even.
+func (r CommissionRates) Commission(vendorId string, amount money.Money) money.Money {
+	return amount.MulRatio(int64(r.Rate(vendorId)), 10000)
+}
@@ -0,0 +1,112 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ConsumerNameLedgerPosting = "vendor-ledger"
+)
+
+// LedgerPostingConsumer posts the sales, refunds and payouts of vendors to the ledger.
+// Postings are keyed by the event they come from, so replayed events are posted once.
+type LedgerPostingConsumer struct {
+	Controller *controller.Controller
+}
+
+func NewLedgerPostingConsumer(controller *controller.Controller) *LedgerPostingConsumer {
+	return &LedgerPostingConsumer{
+		Controller: controller,
+	}
+}
+
+func (c *LedgerPostingConsumer) Name() string {
+	return ConsumerNameLedgerPosting
+}
+
+func (c *LedgerPostingConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	switch {
+	case args.AggregateType == orders.AggregateTypeOrder && args.EventType == orders.EventTypeOrderPaid:
+		return c.handleOrderPaid(ctx, args)
+	case args.AggregateType == orders.AggregateTypeOrder && args.EventType == orders.EventTypeOrderPaymentRefunded:
+		return c.handleOrderPaymentRefunded(ctx, args)
+	case args.AggregateType == orders.AggregateTypeOrder && args.EventType == orders.EventTypeOrderReturnRefunded:
+		return c.handleOrderReturnRefunded(ctx, args)
+	case args.AggregateType == ledger.AggregateTypeVendor && args.EventType == ledger.EventTypePayoutIssued:
+		return c.handlePayoutIssued(ctx, args)
+	default:
+		return nil
+	}
+}
+
+func (c *LedgerPostingConsumer) handleOrderPaid(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderPaid
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order paid event: %w", err)
+	}
+
+	if err := c.Controller.PostSale(ctx, event.OrderId, event.Timestamp.AsTime()); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Posted sale to vendor ledger", "orderId", event.OrderId)
+	return nil
+}
+
+func (c *LedgerPostingConsumer) handleOrderPaymentRefunded(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderPaymentRefunded
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order payment refunded event: %w", err)
+	}
+
+	if err := c.Controller.PostOrderRefund(ctx, event.OrderId, event.Timestamp.AsTime()); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Posted refund to vendor ledger", "orderId", event.OrderId)
+	return nil
+}
+
+func (c *LedgerPostingConsumer) handleOrderReturnRefunded(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderReturnRefunded
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order return refunded event: %w", err)
+	}
+
+	amount := orders.MapProtoToMoney(event.RefundAmount)
+	if err := c.Controller.PostReturnRefund(ctx, event.OrderId, event.ReturnId, amount, event.Timestamp.AsTime()); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Posted return refund to vendor ledger", "orderId", event.OrderId, "returnId", event.ReturnId)
+	return nil
+}
+
+func (c *LedgerPostingConsumer) handlePayoutIssued(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	var event pb.PayoutIssued
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal payout issued event: %w", err)
+	}
+
+	payout := ledger.Payout{
+		PayoutId: event.PayoutId,
+		Amount:   orders.MapProtoToMoney(event.Amount),
+		IssuedAt: event.Timestamp.AsTime(),
+	}
+	if err := c.Controller.PostPayout(ctx, event.VendorId, payout); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).Info("Posted payout to vendor ledger", "vendorId", event.VendorId, "payoutId", event.PayoutId)
+	return nil
+}
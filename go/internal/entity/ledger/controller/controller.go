@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+)
+
+// OrderProvider returns the projection of an order, or nil if it does not exist.
+type OrderProvider interface {
+	GetProjection(ctx context.Context, orderId string) (*orders.OrderProjection, int, error)
+}
+
+type Controller struct {
+	store    eventsrc.Store
+	producer eventsrc.Producer
+
+	ledger     ledger.Ledger
+	orders     OrderProvider
+	commission ledger.CommissionRates
+}
+
+func NewController(store eventsrc.Store, producer eventsrc.Producer, vendorLedger ledger.Ledger, orders OrderProvider, commission ledger.CommissionRates) *Controller {
+	return &Controller{
+		store:      store,
+		producer:   producer,
+		ledger:     vendorLedger,
+		orders:     orders,
+		commission: commission,
+	}
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+)
+
+// GetProjection returns the payout projection of a vendor.
+// If no events are found, it returns nil, 0.
+func (c *Controller) GetProjection(ctx context.Context, vendorId string) (*ledger.VendorProjection, int, error) {
+	events, err := c.store.ListByAggregateID(ctx, vendorId, ledger.AggregateTypeVendor)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list events for vendor %s: %w", vendorId, err)
+	}
+
+	if len(events) == 0 {
+		return nil, 0, nil
+	}
+
+	projEvents := []ledger.SerializedEvent{}
+	currentSequenceNumber := 0
+	for _, event := range events {
+		projEvents = append(projEvents, ledger.SerializedEvent{
+			EventType: event.EventType,
+			EventData: event.Data,
+		})
+		currentSequenceNumber = max(currentSequenceNumber, event.SequenceNumber)
+	}
+
+	projection, err := ledger.ReduceToProjection(projEvents)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to reduce to projection: %w", err)
+	}
+
+	return projection, currentSequenceNumber, nil
+}
+
+// getProjectionForUpdate returns the payout projection of a vendor along with the sequence number of the next event.
+// A vendor without payouts gets an empty projection.
+func (c *Controller) getProjectionForUpdate(ctx context.Context, vendorId string) (*ledger.VendorProjection, int, error) {
+	projection, curSeqNum, err := c.GetProjection(ctx, vendorId)
+	if err != nil {
+		return nil, 0, err
+	}
+	if projection == nil {
+		return &ledger.VendorProjection{VendorId: vendorId}, 0, nil
+	}
+
+	return projection, curSeqNum + 1, nil
+}
+
+func (c *Controller) send(ctx context.Context, vendorId string, seqNum int, eventType string, value []byte) error {
+	err := c.producer.Send(ctx, &eventsrc.SendArgs{
+		SequenceNumber: seqNum,
+		AggregateID:    vendorId,
+		AggregateType:  ledger.AggregateTypeVendor,
+		EventType:      eventType,
+		Value:          value,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s event: %w", eventType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// IssuePayouts pays out the balance owed to vendors, one payout per vendor and currency.
+// Vendors without a positive balance are skipped.
+func (c *Controller) IssuePayouts(ctx context.Context, req *pb.IssuePayoutsRequest) (*pb.IssuePayoutsResponse, error) {
+	vendorIds := req.VendorIds
+	if len(vendorIds) == 0 {
+		var err error
+		vendorIds, err = c.ledger.ListVendors(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vendors: %w", err)
+		}
+	}
+
+	payouts := []*pb.PayoutDetails{}
+	for _, vendorId := range vendorIds {
+		vendorPayouts, err := c.issueVendorPayouts(ctx, vendorId)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, vendorPayouts...)
+	}
+
+	return &pb.IssuePayoutsResponse{Payouts: payouts}, nil
+}
+
+func (c *Controller) issueVendorPayouts(ctx context.Context, vendorId string) ([]*pb.PayoutDetails, error) {
+	projection, seqNum, err := c.getProjectionForUpdate(ctx, vendorId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Payouts are posted to the ledger asynchronously, so they are deducted from the vendor's
+	// earnings using the payouts of the event stream instead.
+	earnings, err := c.ledger.Sum(ctx, ledger.SumArgs{
+		VendorId: vendorId,
+		Account:  ledger.AccountVendorPayable,
+		Kinds:    []string{ledger.KindSale, ledger.KindRefund},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum vendor earnings: %w", err)
+	}
+
+	currencies := make([]string, 0, len(earnings))
+	for currency := range earnings {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	payouts := []*pb.PayoutDetails{}
+	for _, currency := range currencies {
+		due, err := negate(earnings[currency]).Sub(projection.PaidOut(currency))
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute payout: %w", err)
+		}
+		if due.IsZero() || due.IsNegative() {
+			continue
+		}
+
+		payoutId, err := uuid.NewV7()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate payout id: %w", err)
+		}
+
+		// Create new event
+		payoutIssuedEvent := &pb.PayoutIssued{
+			VendorId:  vendorId,
+			Timestamp: timestamppb.Now(),
+			PayoutId:  payoutId.String(),
+			Amount:    orders.MapMoneyToProto(due),
+		}
+
+		value, err := proto.Marshal(payoutIssuedEvent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal payout issued event: %w", err)
+		}
+
+		err = c.send(ctx, vendorId, seqNum, ledger.EventTypePayoutIssued, value)
+		if err != nil {
+			return nil, err
+		}
+		seqNum++
+
+		payouts = append(payouts, &pb.PayoutDetails{
+			PayoutId: payoutIssuedEvent.PayoutId,
+			VendorId: vendorId,
+			Amount:   payoutIssuedEvent.Amount,
+			IssuedAt: payoutIssuedEvent.Timestamp,
+		})
+	}
+
+	return payouts, nil
+}
@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func payoutIssued(seqNum int, amount int64) eventsrc.Event {
+	data, _ := proto.Marshal(&pb.PayoutIssued{
+		VendorId:  "vendor-1",
+		Timestamp: timestamppb.Now(),
+		PayoutId:  "payout-1",
+		Amount:    orders.MapMoneyToProto(eur(amount)),
+	})
+	return eventsrc.Event{EventType: ledger.EventTypePayoutIssued, Data: data, SequenceNumber: seqNum}
+}
+
+func matchPayout(seqNum int, amount int64) interface{} {
+	return mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+		var event pb.PayoutIssued
+		if err := proto.Unmarshal(args.Value, &event); err != nil {
+			return false
+		}
+		return args.EventType == ledger.EventTypePayoutIssued && args.SequenceNumber == seqNum && args.AggregateID == "vendor-1" &&
+			orders.MapProtoToMoney(event.Amount) == eur(amount)
+	})
+}
+
+func TestController_IssuePayouts(t *testing.T) {
+	t.Run("pays out the earnings of a vendor", func(t *testing.T) {
+		controller, _, mockProducer := newTestController([]eventsrc.Event{})
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+		mockProducer.On("Send", mock.Anything, matchPayout(0, 9000)).Return(nil)
+
+		resp, err := controller.IssuePayouts(context.Background(), &pb.IssuePayoutsRequest{})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Payouts, 1)
+		assert.Equal(t, "vendor-1", resp.Payouts[0].VendorId)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("deducts the payouts already issued", func(t *testing.T) {
+		controller, _, mockProducer := newTestController([]eventsrc.Event{payoutIssued(0, 9000)})
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+		assert.NoError(t, controller.PostSale(context.Background(), "order-2", time.Now()))
+		mockProducer.On("Send", mock.Anything, matchPayout(1, 4500)).Return(nil)
+
+		resp, err := controller.IssuePayouts(context.Background(), &pb.IssuePayoutsRequest{VendorIds: []string{"vendor-1"}})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Payouts, 1)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("skips vendors without a balance", func(t *testing.T) {
+		controller, _, mockProducer := newTestController([]eventsrc.Event{payoutIssued(0, 9000)})
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+
+		resp, err := controller.IssuePayouts(context.Background(), &pb.IssuePayoutsRequest{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, resp.Payouts)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_GetVendorStatement(t *testing.T) {
+	t.Run("lists the entries of the period with a running balance", func(t *testing.T) {
+		controller, _, _ := newTestController(nil)
+		from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", from.Add(-time.Hour)))
+		assert.NoError(t, controller.PostSale(context.Background(), "order-2", from.Add(time.Hour)))
+		assert.NoError(t, controller.PostReturnRefund(context.Background(), "order-1", "return-1", eur(2500), from.Add(2*time.Hour)))
+		assert.NoError(t, controller.PostPayout(context.Background(), "vendor-1", ledger.Payout{PayoutId: "payout-1", Amount: eur(4000), IssuedAt: from.Add(48 * time.Hour)}))
+
+		resp, err := controller.GetVendorStatement(context.Background(), &pb.GetVendorStatementRequest{
+			VendorId:     "vendor-1",
+			CurrencyCode: "eur",
+			From:         timestamppb.New(from),
+			To:           timestamppb.New(from.Add(24 * time.Hour)),
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, eur(9000), orders.MapProtoToMoney(resp.OpeningBalance))
+		assert.Len(t, resp.Entries, 2)
+		assert.Equal(t, ledger.KindSale, resp.Entries[0].Kind)
+		assert.Equal(t, eur(4500), orders.MapProtoToMoney(resp.Entries[0].Amount))
+		assert.Equal(t, eur(13500), orders.MapProtoToMoney(resp.Entries[0].Balance))
+		assert.Equal(t, ledger.KindRefund, resp.Entries[1].Kind)
+		assert.Equal(t, eur(-2250), orders.MapProtoToMoney(resp.Entries[1].Amount))
+		assert.Equal(t, eur(11250), orders.MapProtoToMoney(resp.ClosingBalance))
+	})
+
+	t.Run("rejects an unknown currency", func(t *testing.T) {
+		controller, _, _ := newTestController(nil)
+
+		_, err := controller.GetVendorStatement(context.Background(), &pb.GetVendorStatementRequest{
+			VendorId:     "vendor-1",
+			CurrencyCode: "XXX",
+			From:         timestamppb.Now(),
+			To:           timestamppb.New(time.Now().Add(time.Hour)),
+		})
+
+		assert.ErrorIs(t, err, ErrInvalidCurrency)
+	})
+}
+
+func TestController_GetVendorBalance(t *testing.T) {
+	controller, _, _ := newTestController(nil)
+	assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+	assert.NoError(t, controller.PostPayout(context.Background(), "vendor-1", ledger.Payout{PayoutId: "payout-1", Amount: eur(4000), IssuedAt: time.Now()}))
+
+	resp, err := controller.GetVendorBalance(context.Background(), &pb.GetVendorBalanceRequest{VendorId: "vendor-1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Balances, 1)
+	assert.Equal(t, eur(5000), orders.MapProtoToMoney(resp.Balances[0]))
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+)
+
+// Transaction ids are derived from the events they are posted from, so that replayed events are
+// posted once.
+func saleTransactionId(orderId string) string {
+	return fmt.Sprintf("sale:%s", orderId)
+}
+
+func refundTransactionId(orderId string) string {
+	return fmt.Sprintf("refund:%s", orderId)
+}
+
+func returnRefundTransactionId(orderId string, returnId string) string {
+	return fmt.Sprintf("refund:%s:%s", orderId, returnId)
+}
+
+func payoutTransactionId(payoutId string) string {
+	return fmt.Sprintf("payout:%s", payoutId)
+}
+
+func negate(amount money.Money) money.Money {
+	return money.Money{Currency: amount.Currency, Amount: -amount.Amount}
+}
+
+func (c *Controller) getOrder(ctx context.Context, orderId string) (*orders.OrderProjection, error) {
+	orderProjection, _, err := c.orders.GetProjection(ctx, orderId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order projection: %w", err)
+	}
+	if orderProjection == nil {
+		return nil, orderctrl.ErrOrderNotFound
+	}
+	return orderProjection, nil
+}
+
+// PostSale records the payment of an order: the total is collected, the commission is earned and
+// the rest is owed to the vendor.
+func (c *Controller) PostSale(ctx context.Context, orderId string, occurredAt time.Time) error {
+	orderProjection, err := c.getOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	total := orderProjection.TotalPrice
+	commission := c.commission.Commission(orderProjection.VendorId, total)
+	payable, err := total.Sub(commission)
+	if err != nil {
+		return fmt.Errorf("failed to compute vendor payable: %w", err)
+	}
+
+	_, err = c.ledger.Record(ctx, ledger.Transaction{
+		Id:          saleTransactionId(orderId),
+		Kind:        ledger.KindSale,
+		VendorId:    orderProjection.VendorId,
+		OrderId:     orderId,
+		Description: fmt.Sprintf("Sale of order %s", orderId),
+		OccurredAt:  occurredAt,
+		Entries: []ledger.Entry{
+			{Account: ledger.AccountCash, Amount: total},
+			{Account: ledger.AccountVendorPayable, Amount: negate(payable)},
+			{Account: ledger.AccountCommission, Amount: negate(commission)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record sale: %w", err)
+	}
+
+	return nil
+}
+
+// PostOrderRefund records the refund of the whole payment of an order. Payments captured after the
+// order was cancelled were never posted as a sale, so their refund leaves the ledger untouched.
+func (c *Controller) PostOrderRefund(ctx context.Context, orderId string, occurredAt time.Time) error {
+	orderProjection, err := c.getOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection.PaidAt == nil {
+		logging.FromContext(ctx).Info("Skipping refund of an order that was never paid", "orderId", orderId)
+		return nil
+	}
+
+	description := fmt.Sprintf("Refund of order %s", orderId)
+	return c.postRefund(ctx, refundTransactionId(orderId), orderProjection, orderProjection.TotalPrice, description, occurredAt)
+}
+
+// PostReturnRefund records the refund of a return.
+func (c *Controller) PostReturnRefund(ctx context.Context, orderId string, returnId string, amount money.Money, occurredAt time.Time) error {
+	orderProjection, err := c.getOrder(ctx, orderId)
+	if err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("Refund of return %s of order %s", returnId, orderId)
+	return c.postRefund(ctx, returnRefundTransactionId(orderId, returnId), orderProjection, amount, description, occurredAt)
+}
+
+// postRefund reverses a share of the sale of an order. The commission is given back in proportion
+// to the refund, at the rate it was taken.
+func (c *Controller) postRefund(ctx context.Context, transactionId string, orderProjection *orders.OrderProjection, amount money.Money, description string, occurredAt time.Time) error {
+	sale, err := c.ledger.GetPostings(ctx, saleTransactionId(orderProjection.OrderId))
+	if err != nil {
+		return fmt.Errorf("failed to get sale postings: %w", err)
+	}
+
+	commission := money.Zero(amount.Currency)
+	for _, posting := range sale {
+		if posting.Account != ledger.AccountCommission {
+			continue
+		}
+
+		saleCommission := negate(posting.Amount)
+		if !orderProjection.TotalPrice.IsZero() {
+			commission = saleCommission.MulRatio(amount.Amount, orderProjection.TotalPrice.Amount)
+		}
+	}
+
+	payable, err := amount.Sub(commission)
+	if err != nil {
+		return fmt.Errorf("failed to compute vendor payable: %w", err)
+	}
+
+	_, err = c.ledger.Record(ctx, ledger.Transaction{
+		Id:          transactionId,
+		Kind:        ledger.KindRefund,
+		VendorId:    orderProjection.VendorId,
+		OrderId:     orderProjection.OrderId,
+		Description: description,
+		OccurredAt:  occurredAt,
+		Entries: []ledger.Entry{
+			{Account: ledger.AccountCash, Amount: negate(amount)},
+			{Account: ledger.AccountVendorPayable, Amount: payable},
+			{Account: ledger.AccountCommission, Amount: commission},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	return nil
+}
+
+// PostPayout records a payout: the money owed to the vendor leaves the platform.
+func (c *Controller) PostPayout(ctx context.Context, vendorId string, payout ledger.Payout) error {
+	_, err := c.ledger.Record(ctx, ledger.Transaction{
+		Id:          payoutTransactionId(payout.PayoutId),
+		Kind:        ledger.KindPayout,
+		VendorId:    vendorId,
+		Description: fmt.Sprintf("Payout %s", payout.PayoutId),
+		OccurredAt:  payout.IssuedAt,
+		Entries: []ledger.Entry{
+			{Account: ledger.AccountVendorPayable, Amount: payout.Amount},
+			{Account: ledger.AccountCash, Amount: negate(payout.Amount)},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record payout: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockStore is a mock implementation of eventsrc.Store
+type MockStore struct {
+	mock.Mock
+}
+
+func (m *MockStore) Persist(ctx context.Context, tx pg.Tx, args eventsrc.PersistEventArgs) (int, error) {
+	callArgs := m.Called(ctx, tx, args)
+	return callArgs.Int(0), callArgs.Error(1)
+}
+
+func (m *MockStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error {
+	callArgs := m.Called(ctx, tx, eventId)
+	return callArgs.Error(0)
+}
+
+func (m *MockStore) ListByAggregateID(ctx context.Context, aggregateID, aggregateType string) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, aggregateID, aggregateType)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+func (m *MockStore) ListEvents(ctx context.Context, args eventsrc.ListEventsArgs) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
+// MockProducer is a mock implementation of eventsrc.Producer
+type MockProducer struct {
+	mock.Mock
+}
+
+func (m *MockProducer) Send(ctx context.Context, args *eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+func (m *MockProducer) SendAll(ctx context.Context, args []*eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+// fakeOrders returns fixed order projections
+type fakeOrders map[string]*orders.OrderProjection
+
+func (f fakeOrders) GetProjection(ctx context.Context, orderId string) (*orders.OrderProjection, int, error) {
+	return f[orderId], 0, nil
+}
+
+func eur(amount int64) money.Money {
+	return money.Money{Currency: "EUR", Amount: amount}
+}
+
+var testPaidAt = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+var testOrders = fakeOrders{
+	"order-1": {OrderId: "order-1", VendorId: "vendor-1", TotalPrice: eur(10000), PaidAt: &testPaidAt},
+	"order-2": {OrderId: "order-2", VendorId: "vendor-1", TotalPrice: eur(5000), PaidAt: &testPaidAt},
+	// Cancelled before its payment was confirmed
+	"order-3": {OrderId: "order-3", VendorId: "vendor-1", TotalPrice: eur(2000)},
+}
+
+func newTestController(vendorEvents []eventsrc.Event) (*Controller, *ledger.InMemoryLedger, *MockProducer) {
+	mockStore := &MockStore{}
+	mockStore.On("ListByAggregateID", mock.Anything, "vendor-1", ledger.AggregateTypeVendor).Return(vendorEvents, nil)
+	mockProducer := &MockProducer{}
+	vendorLedger := ledger.NewInMemoryLedger()
+
+	commission := ledger.CommissionRates{DefaultBps: 1000, VendorBps: map[string]int32{"vendor-2": 500}}
+	return NewController(mockStore, mockProducer, vendorLedger, testOrders, commission), vendorLedger, mockProducer
+}
+
+func accountBalance(t *testing.T, vendorLedger ledger.Ledger, account string) money.Money {
+	sums, err := vendorLedger.Sum(context.Background(), ledger.SumArgs{VendorId: "vendor-1", Account: account})
+	assert.NoError(t, err)
+	return sums["EUR"]
+}
+
+func TestController_PostSale(t *testing.T) {
+	t.Run("splits the sale between the vendor and the commission", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+
+		err := controller.PostSale(context.Background(), "order-1", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, eur(10000), accountBalance(t, vendorLedger, ledger.AccountCash))
+		assert.Equal(t, eur(-9000), accountBalance(t, vendorLedger, ledger.AccountVendorPayable))
+		assert.Equal(t, eur(-1000), accountBalance(t, vendorLedger, ledger.AccountCommission))
+	})
+
+	t.Run("posts a replayed sale once", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+
+		assert.Len(t, vendorLedger.Postings, 3)
+		assert.Equal(t, eur(-9000), accountBalance(t, vendorLedger, ledger.AccountVendorPayable))
+	})
+
+	t.Run("fails for an unknown order", func(t *testing.T) {
+		controller, _, _ := newTestController(nil)
+
+		err := controller.PostSale(context.Background(), "order-unknown", time.Now())
+
+		assert.ErrorIs(t, err, orderctrl.ErrOrderNotFound)
+	})
+}
+
+func TestController_PostRefund(t *testing.T) {
+	t.Run("reverses the whole sale", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+
+		err := controller.PostOrderRefund(context.Background(), "order-1", time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, eur(0), accountBalance(t, vendorLedger, ledger.AccountCash))
+		assert.Equal(t, eur(0), accountBalance(t, vendorLedger, ledger.AccountVendorPayable))
+		assert.Equal(t, eur(0), accountBalance(t, vendorLedger, ledger.AccountCommission))
+	})
+
+	t.Run("leaves the ledger untouched for an order that was never paid", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+
+		err := controller.PostOrderRefund(context.Background(), "order-3", time.Now())
+
+		assert.NoError(t, err)
+		assert.Empty(t, vendorLedger.Postings)
+	})
+
+	t.Run("gives back the commission in proportion to a return refund", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+
+		err := controller.PostReturnRefund(context.Background(), "order-1", "return-1", eur(2500), time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, eur(7500), accountBalance(t, vendorLedger, ledger.AccountCash))
+		assert.Equal(t, eur(-6750), accountBalance(t, vendorLedger, ledger.AccountVendorPayable))
+		assert.Equal(t, eur(-750), accountBalance(t, vendorLedger, ledger.AccountCommission))
+	})
+
+	t.Run("posts every return of an order once", func(t *testing.T) {
+		controller, vendorLedger, _ := newTestController(nil)
+		assert.NoError(t, controller.PostSale(context.Background(), "order-1", time.Now()))
+
+		assert.NoError(t, controller.PostReturnRefund(context.Background(), "order-1", "return-1", eur(2500), time.Now()))
+		assert.NoError(t, controller.PostReturnRefund(context.Background(), "order-1", "return-1", eur(2500), time.Now()))
+		assert.NoError(t, controller.PostReturnRefund(context.Background(), "order-1", "return-2", eur(2500), time.Now()))
+
+		assert.Equal(t, eur(-4500), accountBalance(t, vendorLedger, ledger.AccountVendorPayable))
+	})
+}
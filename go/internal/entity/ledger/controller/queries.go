@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/ledger"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrInvalidCurrency = status.Errorf(codes.InvalidArgument, "invalid currency code")
+
+// GetVendorBalance returns the amount owed to a vendor in every currency it sold in.
+func (c *Controller) GetVendorBalance(ctx context.Context, req *pb.GetVendorBalanceRequest) (*pb.GetVendorBalanceResponse, error) {
+	sums, err := c.ledger.Sum(ctx, ledger.SumArgs{
+		VendorId: req.VendorId,
+		Account:  ledger.AccountVendorPayable,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum vendor balance: %w", err)
+	}
+
+	currencies := make([]string, 0, len(sums))
+	for currency := range sums {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	balances := make([]*pb.Money, 0, len(currencies))
+	for _, currency := range currencies {
+		balances = append(balances, orders.MapMoneyToProto(owed(sums, currency)))
+	}
+
+	return &pb.GetVendorBalanceResponse{
+		VendorId: req.VendorId,
+		Balances: balances,
+	}, nil
+}
+
+// GetVendorStatement returns the changes of the amount owed to a vendor in a period and currency.
+func (c *Controller) GetVendorStatement(ctx context.Context, req *pb.GetVendorStatementRequest) (*pb.GetVendorStatementResponse, error) {
+	currency := strings.ToUpper(req.CurrencyCode)
+	if !money.IsCurrency(currency) {
+		return nil, ErrInvalidCurrency
+	}
+	from := req.From.AsTime()
+	to := req.To.AsTime()
+
+	opening, err := c.ledger.Sum(ctx, ledger.SumArgs{
+		VendorId: req.VendorId,
+		Account:  ledger.AccountVendorPayable,
+		Before:   from,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum opening balance: %w", err)
+	}
+
+	postings, err := c.ledger.ListPostings(ctx, ledger.ListPostingsArgs{
+		VendorId: req.VendorId,
+		Account:  ledger.AccountVendorPayable,
+		Currency: currency,
+		From:     from,
+		To:       to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list postings: %w", err)
+	}
+
+	openingBalance := owed(opening, currency)
+	balance := openingBalance
+	entries := make([]*pb.StatementEntry, 0, len(postings))
+	for _, posting := range postings {
+		amount := negate(posting.Amount)
+		balance, err = balance.Add(amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute balance: %w", err)
+		}
+
+		entries = append(entries, &pb.StatementEntry{
+			TransactionId: posting.TransactionId,
+			Kind:          posting.Kind,
+			OrderId:       posting.OrderId,
+			Description:   posting.Description,
+			Amount:        orders.MapMoneyToProto(amount),
+			Balance:       orders.MapMoneyToProto(balance),
+			OccurredAt:    timestamppb.New(posting.OccurredAt),
+		})
+	}
+
+	return &pb.GetVendorStatementResponse{
+		VendorId:       req.VendorId,
+		OpeningBalance: orders.MapMoneyToProto(openingBalance),
+		ClosingBalance: orders.MapMoneyToProto(balance),
+		Entries:        entries,
+	}, nil
+}
+
+// owed returns the amount owed to a vendor from the sum of its payable account.
+func owed(sums map[string]money.Money, currency string) money.Money {
+	sum, ok := sums[currency]
+	if !ok {
+		return money.Zero(currency)
+	}
+	return negate(sum)
+}
@@ -0,0 +1,7 @@
+package ledger
+
+const (
+	EventTypePayoutIssued = "payout_issued"
+
+	AggregateTypeVendor = "vendor"
+)
@@ -0,0 +1,423 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const PostingTable = "ledger_posting"
+
+const (
+	// Accounts. Amounts are signed: debits are positive and credits negative, so the entries of a
+	// transaction sum to zero.
+
+	// AccountCash is the money collected from customers and not paid out yet.
+	AccountCash = "cash"
+	// AccountVendorPayable is the money owed to a vendor. Its balance is negative (a credit).
+	AccountVendorPayable = "vendor_payable"
+	// AccountCommission is the marketplace fee earned on sales.
+	AccountCommission = "commission"
+
+	// Transaction kinds
+	KindSale   = "sale"
+	KindRefund = "refund"
+	KindPayout = "payout"
+)
+
+type Entry struct {
+	Account string
+	Amount  money.Money
+}
+
+// Transaction is a set of entries that sum to zero. Its id makes recording it idempotent.
+type Transaction struct {
+	Id          string
+	Kind        string
+	VendorId    string
+	OrderId     string
+	Description string
+	OccurredAt  time.Time
+	Entries     []Entry
+}
+
+// Validate checks that the transaction is balanced in every currency.
+func (t Transaction) Validate() error {
+	if len(t.Entries) < 2 {
+		return fmt.Errorf("transaction %s has less than two entries", t.Id)
+	}
+
+	sums := make(map[string]int64)
+	for _, entry := range t.Entries {
+		sums[entry.Amount.Currency] += entry.Amount.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("transaction %s is unbalanced by %d in %s", t.Id, sum, currency)
+		}
+	}
+
+	return nil
+}
+
+// Posting is an entry of a recorded transaction.
+type Posting struct {
+	PostingId     int64
+	TransactionId string
+	Kind          string
+	Account       string
+	VendorId      string
+	OrderId       string
+	Amount        money.Money
+	Description   string
+	OccurredAt    time.Time
+}
+
+type SumArgs struct {
+	VendorId string
+	Account  string
+	// Kinds restricts the sum to transactions of these kinds. Empty for every kind.
+	Kinds []string
+	// Before restricts the sum to postings that occurred before it. Zero for every posting.
+	Before time.Time
+}
+
+type ListPostingsArgs struct {
+	VendorId string
+	Account  string
+	Currency string
+	// From is inclusive and To exclusive.
+	From time.Time
+	To   time.Time
+}
+
+// Ledger records double-entry transactions of vendors.
+type Ledger interface {
+	// Record records a transaction. Returns false if a transaction with the same id was already recorded.
+	Record(ctx context.Context, txn Transaction) (bool, error)
+
+	// GetPostings returns the postings of a transaction, or none if it was not recorded.
+	GetPostings(ctx context.Context, transactionId string) ([]Posting, error)
+
+	// Sum returns the sum of the postings of a vendor account, per currency.
+	Sum(ctx context.Context, args SumArgs) (map[string]money.Money, error)
+
+	// ListPostings returns the postings of a vendor account in a period, in the order they occurred.
+	ListPostings(ctx context.Context, args ListPostingsArgs) ([]Posting, error)
+
+	// ListVendors returns the vendors that have postings.
+	ListVendors(ctx context.Context) ([]string, error)
+}
+
+/** Postgres Ledger */
+
+type DbPosting struct {
+	PostingId     int64          `db:"posting_id"`
+	TransactionId string         `db:"transaction_id"`
+	Kind          string         `db:"kind"`
+	Account       string         `db:"account"`
+	VendorId      string         `db:"vendor_id"`
+	OrderId       sql.NullString `db:"order_id"`
+	CurrencyCode  string         `db:"currency_code"`
+	AmountMinor   int64          `db:"amount_minor"`
+	Description   string         `db:"description"`
+	OccurredAt    time.Time      `db:"occurred_at"`
+}
+
+func (p DbPosting) toPosting() Posting {
+	return Posting{
+		PostingId:     p.PostingId,
+		TransactionId: p.TransactionId,
+		Kind:          p.Kind,
+		Account:       p.Account,
+		VendorId:      p.VendorId,
+		OrderId:       p.OrderId.String,
+		Amount:        money.Money{Currency: p.CurrencyCode, Amount: p.AmountMinor},
+		Description:   p.Description,
+		OccurredAt:    p.OccurredAt,
+	}
+}
+
+type PgLedger struct {
+	db *sqlx.DB
+}
+
+func NewPgLedger(db *sqlx.DB) *PgLedger {
+	return &PgLedger{db: db}
+}
+
+func (l *PgLedger) Record(ctx context.Context, txn Transaction) (bool, error) {
+	if err := txn.Validate(); err != nil {
+		return false, err
+	}
+
+	rows := make([]any, len(txn.Entries))
+	for i, entry := range txn.Entries {
+		rows[i] = goqu.Record{
+			"transaction_id": txn.Id,
+			"kind":           txn.Kind,
+			"account":        entry.Account,
+			"vendor_id":      txn.VendorId,
+			"order_id":       sql.NullString{String: txn.OrderId, Valid: txn.OrderId != ""},
+			"currency_code":  entry.Amount.Currency,
+			"amount_minor":   entry.Amount.Amount,
+			"description":    txn.Description,
+			"occurred_at":    txn.OccurredAt,
+		}
+	}
+
+	// All entries are inserted by one statement, so a transaction is either recorded whole or not at all
+	ds := pg.Dialect.Insert(PostingTable).Prepared(true).
+		Rows(rows...).
+		OnConflict(goqu.DoNothing())
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return false, pg.ErrorDsl(err)
+	}
+
+	result, err := l.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return false, pg.ErrorDb(err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, pg.ErrorDb(err)
+	}
+
+	return inserted > 0, nil
+}
+
+func (l *PgLedger) GetPostings(ctx context.Context, transactionId string) ([]Posting, error) {
+
+	ds := pg.Dialect.From(PostingTable).Prepared(true).
+		Select(&DbPosting{}).
+		Where(goqu.Ex{"transaction_id": transactionId}).
+		Order(goqu.I("posting_id").Asc())
+
+	return l.queryPostings(ctx, ds)
+}
+
+func (l *PgLedger) Sum(ctx context.Context, args SumArgs) (map[string]money.Money, error) {
+
+	where := goqu.Ex{"vendor_id": args.VendorId, "account": args.Account}
+	if len(args.Kinds) > 0 {
+		where["kind"] = args.Kinds
+	}
+
+	ds := pg.Dialect.From(PostingTable).Prepared(true).
+		Select(goqu.C("currency_code"), goqu.SUM("amount_minor").As("amount_minor")).
+		Where(where).
+		GroupBy("currency_code")
+	if !args.Before.IsZero() {
+		ds = ds.Where(goqu.C("occurred_at").Lt(args.Before))
+	}
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := l.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	sums := []struct {
+		CurrencyCode string `db:"currency_code"`
+		AmountMinor  int64  `db:"amount_minor"`
+	}{}
+	err = sqlx.StructScan(rows, &sums)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	totals := make(map[string]money.Money, len(sums))
+	for _, sum := range sums {
+		totals[sum.CurrencyCode] = money.Money{Currency: sum.CurrencyCode, Amount: sum.AmountMinor}
+	}
+
+	return totals, nil
+}
+
+func (l *PgLedger) ListPostings(ctx context.Context, args ListPostingsArgs) ([]Posting, error) {
+
+	ds := pg.Dialect.From(PostingTable).Prepared(true).
+		Select(&DbPosting{}).
+		Where(
+			goqu.Ex{"vendor_id": args.VendorId, "account": args.Account, "currency_code": args.Currency},
+			goqu.C("occurred_at").Gte(args.From),
+			goqu.C("occurred_at").Lt(args.To),
+		).
+		Order(goqu.I("occurred_at").Asc(), goqu.I("posting_id").Asc())
+
+	return l.queryPostings(ctx, ds)
+}
+
+func (l *PgLedger) ListVendors(ctx context.Context) ([]string, error) {
+
+	ds := pg.Dialect.From(PostingTable).Prepared(true).
+		Select("vendor_id").
+		Distinct().
+		Order(goqu.I("vendor_id").Asc())
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	vendorIds := []string{}
+	err = l.db.SelectContext(ctx, &vendorIds, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	return vendorIds, nil
+}
+
+func (l *PgLedger) queryPostings(ctx context.Context, ds *goqu.SelectDataset) ([]Posting, error) {
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := l.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	dbPostings := []DbPosting{}
+	err = sqlx.StructScan(rows, &dbPostings)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	postings := make([]Posting, len(dbPostings))
+	for i, posting := range dbPostings {
+		postings[i] = posting.toPosting()
+	}
+
+	return postings, nil
+}
+
+/** In-Memory Ledger */
+
+type InMemoryLedger struct {
+	mu       sync.Mutex
+	Postings []Posting
+}
+
+func NewInMemoryLedger() *InMemoryLedger {
+	return &InMemoryLedger{}
+}
+
+func (l *InMemoryLedger) Record(ctx context.Context, txn Transaction) (bool, error) {
+	if err := txn.Validate(); err != nil {
+		return false, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, posting := range l.Postings {
+		if posting.TransactionId == txn.Id {
+			return false, nil
+		}
+	}
+
+	for _, entry := range txn.Entries {
+		l.Postings = append(l.Postings, Posting{
+			PostingId:     int64(len(l.Postings) + 1),
+			TransactionId: txn.Id,
+			Kind:          txn.Kind,
+			Account:       entry.Account,
+			VendorId:      txn.VendorId,
+			OrderId:       txn.OrderId,
+			Amount:        entry.Amount,
+			Description:   txn.Description,
+			OccurredAt:    txn.OccurredAt,
+		})
+	}
+
+	return true, nil
+}
+
+func (l *InMemoryLedger) GetPostings(ctx context.Context, transactionId string) ([]Posting, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	postings := []Posting{}
+	for _, posting := range l.Postings {
+		if posting.TransactionId == transactionId {
+			postings = append(postings, posting)
+		}
+	}
+	return postings, nil
+}
+
+func (l *InMemoryLedger) Sum(ctx context.Context, args SumArgs) (map[string]money.Money, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	totals := make(map[string]money.Money)
+	for _, posting := range l.Postings {
+		if posting.VendorId != args.VendorId || posting.Account != args.Account {
+			continue
+		}
+		if len(args.Kinds) > 0 && !slices.Contains(args.Kinds, posting.Kind) {
+			continue
+		}
+		if !args.Before.IsZero() && !posting.OccurredAt.Before(args.Before) {
+			continue
+		}
+
+		total := totals[posting.Amount.Currency]
+		total.Currency = posting.Amount.Currency
+		total.Amount += posting.Amount.Amount
+		totals[posting.Amount.Currency] = total
+	}
+	return totals, nil
+}
+
+func (l *InMemoryLedger) ListPostings(ctx context.Context, args ListPostingsArgs) ([]Posting, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	postings := []Posting{}
+	for _, posting := range l.Postings {
+		if posting.VendorId == args.VendorId && posting.Account == args.Account && posting.Amount.Currency == args.Currency &&
+			!posting.OccurredAt.Before(args.From) && posting.OccurredAt.Before(args.To) {
+			postings = append(postings, posting)
+		}
+	}
+
+	sort.SliceStable(postings, func(i, j int) bool {
+		return postings[i].OccurredAt.Before(postings[j].OccurredAt)
+	})
+	return postings, nil
+}
+
+func (l *InMemoryLedger) ListVendors(ctx context.Context) ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	vendorIds := []string{}
+	for _, posting := range l.Postings {
+		if !slices.Contains(vendorIds, posting.VendorId) {
+			vendorIds = append(vendorIds, posting.VendorId)
+		}
+	}
+
+	sort.Strings(vendorIds)
+	return vendorIds, nil
+}
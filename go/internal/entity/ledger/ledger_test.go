@@ -0,0 +1,47 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_Validate(t *testing.T) {
+	eur := func(amount int64) money.Money { return money.Money{Currency: "EUR", Amount: amount} }
+
+	t.Run("accepts a balanced transaction", func(t *testing.T) {
+		txn := Transaction{Id: "sale:order-1", Entries: []Entry{
+			{Account: AccountCash, Amount: eur(1000)},
+			{Account: AccountVendorPayable, Amount: eur(-900)},
+			{Account: AccountCommission, Amount: eur(-100)},
+		}}
+		assert.NoError(t, txn.Validate())
+	})
+
+	t.Run("rejects an unbalanced transaction", func(t *testing.T) {
+		txn := Transaction{Id: "sale:order-1", Entries: []Entry{
+			{Account: AccountCash, Amount: eur(1000)},
+			{Account: AccountVendorPayable, Amount: eur(-900)},
+		}}
+		assert.Error(t, txn.Validate())
+	})
+
+	t.Run("rejects a transaction balanced across currencies", func(t *testing.T) {
+		txn := Transaction{Id: "sale:order-1", Entries: []Entry{
+			{Account: AccountCash, Amount: eur(1000)},
+			{Account: AccountVendorPayable, Amount: money.Money{Currency: "USD", Amount: -1000}},
+		}}
+		assert.Error(t, txn.Validate())
+	})
+}
+
+func TestCommissionRates_Commission(t *testing.T) {
+	rates := CommissionRates{DefaultBps: 1000, VendorBps: map[string]int32{"vendor-2": 250}}
+
+	assert.Equal(t, money.Money{Currency: "EUR", Amount: 1000}, rates.Commission("vendor-1", money.Money{Currency: "EUR", Amount: 10000}))
+	assert.Equal(t, money.Money{Currency: "EUR", Amount: 250}, rates.Commission("vendor-2", money.Money{Currency: "EUR", Amount: 10000}))
+	// 2.5% of 1.00 EUR is 2.5 cents, rounded half to even
+	assert.Equal(t, money.Money{Currency: "EUR", Amount: 2}, rates.Commission("vendor-2", money.Money{Currency: "EUR", Amount: 100}))
+}
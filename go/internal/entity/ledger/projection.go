@@ -0,0 +1,78 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"google.golang.org/protobuf/proto"
+)
+
+type Payout struct {
+	PayoutId string
+	Amount   money.Money
+	IssuedAt time.Time
+}
+
+// VendorProjection is the payout history of a vendor.
+type VendorProjection struct {
+	VendorId string
+	Payouts  []Payout
+}
+
+type SerializedEvent struct {
+	EventType string
+	EventData []byte
+}
+
+// PaidOut returns the total paid out to the vendor in a currency.
+func (p *VendorProjection) PaidOut(currency string) money.Money {
+	total := money.Zero(currency)
+	for _, payout := range p.Payouts {
+		if payout.Amount.Currency == currency {
+			total.Amount += payout.Amount.Amount
+		}
+	}
+	return total
+}
+
+// ReduceToProjection will reduce the event list into a vendor projection
+func ReduceToProjection(events []SerializedEvent) (*VendorProjection, error) {
+	projection := &VendorProjection{}
+	for _, event := range events {
+		err := applyEventToProjection(event, projection)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return projection, nil
+}
+
+// applyEventToProjection will map the event type to the appropriate apply function
+func applyEventToProjection(event SerializedEvent, currentProjection *VendorProjection) error {
+	switch event.EventType {
+	case EventTypePayoutIssued:
+		return applyPayoutIssuedToProjection(event.EventData, currentProjection)
+	default:
+		return fmt.Errorf("unknown event type: %s", event.EventType)
+	}
+}
+
+func applyPayoutIssuedToProjection(eventData []byte, currentProjection *VendorProjection) error {
+	var event pb.PayoutIssued
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal payout issued event: %w", err)
+	}
+
+	currentProjection.VendorId = event.VendorId
+	currentProjection.Payouts = append(currentProjection.Payouts, Payout{
+		PayoutId: event.PayoutId,
+		Amount:   orders.MapProtoToMoney(event.Amount),
+		IssuedAt: event.Timestamp.AsTime(),
+	})
+
+	return nil
+}
@@ -35,13 +35,13 @@ func (c *ProjectionIndexerConsumer) Consume(ctx context.Context, args eventsrc.C
 		return nil
 	}
 
-	logging.Logger.Info("Indexing projection for order", "orderId", args.AggregateID, "consumer", c.Name())
+	logging.FromContext(ctx).Info("Indexing projection for order", "orderId", args.AggregateID)
 
 	if err := c.Controller.IndexProjection(ctx, args.AggregateID); err != nil {
 		return fmt.Errorf("failed to index projection: %w", err)
 	}
 
-	logging.Logger.Info("Projection indexed for order", "orderId", args.AggregateID, "consumer", c.Name())
+	logging.FromContext(ctx).Info("Projection indexed for order", "orderId", args.AggregateID)
 
 	return nil
 }
@@ -0,0 +1,90 @@
+package consumers
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/inventory"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ConsumerNameStockStatus = "order-stock-status"
+)
+
+// StockStatusConsumer records the inventory events that concern an order on the order itself.
+type StockStatusConsumer struct {
+	Controller *controller.Controller
+}
+
+func NewStockStatusConsumer(controller *controller.Controller) *StockStatusConsumer {
+	return &StockStatusConsumer{
+		Controller: controller,
+	}
+}
+
+func (c *StockStatusConsumer) Name() string {
+	return ConsumerNameStockStatus
+}
+
+func (c *StockStatusConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+
+	if args.AggregateType != inventory.AggregateTypeInventory {
+		return nil
+	}
+
+	var orderId, productId string
+	var stockStatus pb.StockStatus
+	switch args.EventType {
+	case inventory.EventTypeStockReserved:
+		var event pb.StockReserved
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stock reserved event: %w", err)
+		}
+		orderId, productId, stockStatus = event.OrderId, event.ProductId, pb.StockStatus_STOCK_STATUS_RESERVED
+
+	case inventory.EventTypeStockBackordered:
+		var event pb.StockBackordered
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stock backordered event: %w", err)
+		}
+		orderId, productId, stockStatus = event.OrderId, event.ProductId, pb.StockStatus_STOCK_STATUS_BACKORDERED
+
+	case inventory.EventTypeStockCommitted:
+		var event pb.StockCommitted
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stock committed event: %w", err)
+		}
+		orderId, productId, stockStatus = event.OrderId, event.ProductId, pb.StockStatus_STOCK_STATUS_COMMITTED
+
+	case inventory.EventTypeStockReleased:
+		var event pb.StockReleased
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stock released event: %w", err)
+		}
+		orderId, productId, stockStatus = event.OrderId, event.ProductId, pb.StockStatus_STOCK_STATUS_RELEASED
+
+	case inventory.EventTypeStockRestocked:
+		var event pb.StockRestocked
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal stock restocked event: %w", err)
+		}
+		orderId, productId, stockStatus = event.OrderId, event.ProductId, pb.StockStatus_STOCK_STATUS_RELEASED
+
+	default:
+		return nil
+	}
+
+	if err := c.Controller.UpdateStockStatus(ctx, orderId, productId, stockStatus); err != nil {
+		return fmt.Errorf("failed to update stock status: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Updated stock status for order", "orderId", orderId, "productId", productId, "stockStatus", stockStatus.String())
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package consumers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	ConsumerNameTimerScheduler = "timer-scheduler"
+)
+
+// TimerSchedulerConsumer schedules and cancels the durable timers attached to an order.
+// A shipment deadline is scheduled once the order is paid, and cancelled when the order ships or is cancelled.
+// An order ships when a shipment is attached or its shipping status moves to in transit.
+// The payment timeout is owned by the payment saga.
+type TimerSchedulerConsumer struct {
+	Scheduler  timers.Scheduler
+	Transactor pg.Transactor
+
+	ShipmentOverdueAfter time.Duration
+}
+
+func NewTimerSchedulerConsumer(scheduler timers.Scheduler, transactor pg.Transactor, shipmentOverdueAfter time.Duration) *TimerSchedulerConsumer {
+	return &TimerSchedulerConsumer{
+		Scheduler:            scheduler,
+		Transactor:           transactor,
+		ShipmentOverdueAfter: shipmentOverdueAfter,
+	}
+}
+
+func (c *TimerSchedulerConsumer) Name() string {
+	return ConsumerNameTimerScheduler
+}
+
+func (c *TimerSchedulerConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+
+	if args.AggregateType != orders.AggregateTypeOrder {
+		return nil
+	}
+
+	switch args.EventType {
+	case orders.EventTypeOrderPaid:
+		var event pb.OrderPaid
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal order paid event: %w", err)
+		}
+		return c.schedule(ctx, orders.TimerTypeShipmentOverdue, orders.ShipmentOverdueTimerKey(args.AggregateID), args.AggregateID, event.Timestamp, c.ShipmentOverdueAfter)
+
+	case orders.EventTypeOrderCancelled, orders.EventTypeOrderShipmentCreated:
+		return c.cancel(ctx, orders.ShipmentOverdueTimerKey(args.AggregateID))
+
+	case orders.EventTypeOrderShippingStatusUpdated:
+		var event pb.OrderShippingStatusUpdated
+		if err := proto.Unmarshal(args.Data, &event); err != nil {
+			return fmt.Errorf("failed to unmarshal order shipping status updated event: %w", err)
+		}
+		if event.Status < pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT {
+			return nil
+		}
+		return c.cancel(ctx, orders.ShipmentOverdueTimerKey(args.AggregateID))
+	}
+
+	return nil
+}
+
+func (c *TimerSchedulerConsumer) schedule(ctx context.Context, timerType string, key string, orderId string, from *timestamppb.Timestamp, after time.Duration) error {
+	fireAt := from.AsTime().Add(after)
+
+	err := c.Transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+		return c.Scheduler.Schedule(ctx, tx, timers.ScheduleArgs{
+			Key:           key,
+			TimerType:     timerType,
+			AggregateID:   orderId,
+			AggregateType: orders.AggregateTypeOrder,
+			FireAt:        fireAt,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule timer %s: %w", key, err)
+	}
+
+	logging.FromContext(ctx).Info("Scheduled timer for order", "orderId", orderId, "timerKey", key, "fireAt", fireAt)
+
+	return nil
+}
+
+func (c *TimerSchedulerConsumer) cancel(ctx context.Context, key string) error {
+	err := c.Transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+		return c.Scheduler.Cancel(ctx, tx, key)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to cancel timer %s: %w", key, err)
+	}
+
+	return nil
+}
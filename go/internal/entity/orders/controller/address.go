@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func validateChangeAddressRequest(projection *orders.OrderProjection) error {
+
+	// The address can only change until the order is handed to a carrier
+	switch projection.ShippingStatus {
+	case orders.ShippingStatusWaitingForPayment, orders.ShippingStatusWaitingForShipment:
+		return nil
+	case orders.ShippingStatusCancelled:
+		return status.Errorf(codes.FailedPrecondition, "order has been cancelled")
+	default:
+		return status.Errorf(codes.FailedPrecondition, "order has already been shipped")
+	}
+}
+
+func (c *Controller) ChangeAddress(ctx context.Context, req *pb.ChangeOrderAddressRequest) (*pb.ChangeOrderAddressResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateChangeAddressRequest(orderProjection); err != nil {
+		return nil, err
+	}
+
+	shippingAddress, err := c.sealAddress(ctx, req.OrderId, req.ShippingAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	orderAddressChangedEvent := &pb.OrderAddressChanged{
+		OrderId:         req.OrderId,
+		Timestamp:       timestamppb.Now(),
+		ShippingAddress: shippingAddress,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderAddressChanged, orderAddressChangedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ChangeOrderAddressResponse{OrderId: req.OrderId}, nil
+}
+
+var ErrAddressStillNeeded = status.Errorf(codes.FailedPrecondition, "the address can only be redacted once the order is delivered or cancelled")
+
+func validateRedactAddressRequest(projection *orders.OrderProjection) error {
+
+	// The carrier needs the address until the order is delivered
+	if projection.ShippingStatus != orders.ShippingStatusDelivered && projection.ShippingStatus != orders.ShippingStatusCancelled {
+		return ErrAddressStillNeeded
+	}
+	if projection.ShippingAddress == nil {
+		return status.Errorf(codes.FailedPrecondition, "order has no shipping address")
+	}
+
+	return nil
+}
+
+// RedactAddress deletes the data key of an order, which redacts its shipping address from every event
+// at once, then records the redaction. Redacting an order that was already redacted is a no-op.
+func (c *Controller) RedactAddress(ctx context.Context, req *pb.RedactOrderAddressRequest) (*pb.RedactOrderAddressResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+	if orderProjection.AddressRedacted {
+		return &pb.RedactOrderAddressResponse{OrderId: req.OrderId}, nil
+	}
+
+	if err := validateRedactAddressRequest(orderProjection); err != nil {
+		return nil, err
+	}
+
+	// The key is deleted first, so that the event never records a redaction that did not happen
+	err = c.vault.Forget(ctx, orderProjection.ShippingAddress.KeyId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redact shipping address: %w", err)
+	}
+
+	// Create new event
+	orderAddressRedactedEvent := &pb.OrderAddressRedacted{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		Reason:    req.Reason,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderAddressRedacted, orderAddressRedactedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RedactOrderAddressResponse{OrderId: req.OrderId}, nil
+}
+
+// ShippingAddress opens the shipping address of an order. Returns nil for orders placed before
+// addresses, and pii.ErrRedacted if the address was redacted.
+func (c *Controller) ShippingAddress(ctx context.Context, projection *orders.OrderProjection) (*pb.Address, error) {
+	if projection.ShippingAddress == nil {
+		return nil, nil
+	}
+
+	addressBytes, err := c.vault.Open(ctx, projection.ShippingAddress.KeyId, projection.ShippingAddress.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shipping address: %w", err)
+	}
+
+	var address pb.Address
+	err = proto.Unmarshal(addressBytes, &address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal shipping address: %w", err)
+	}
+
+	return &address, nil
+}
+
+// sealAddress encrypts an address with the data key of its order, so that it can be recorded in
+// events and still be redacted later.
+func (c *Controller) sealAddress(ctx context.Context, orderId string, address *pb.Address) (*pb.SealedAddress, error) {
+	addressBytes, err := proto.Marshal(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal shipping address: %w", err)
+	}
+
+	ciphertext, err := c.vault.Seal(ctx, orderId, addressBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal shipping address: %w", err)
+	}
+
+	return &pb.SealedAddress{KeyId: orderId, Ciphertext: ciphertext}, nil
+}
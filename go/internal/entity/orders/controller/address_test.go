@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pii"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestController_ChangeAddress(t *testing.T) {
+	t.Run("successful address change", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+		vault := newVault()
+
+		var sent pb.OrderAddressChanged
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(paidOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.AggregateID == "order-123" &&
+				args.EventType == orders.EventTypeOrderAddressChanged &&
+				args.SequenceNumber == 2 &&
+				proto.Unmarshal(args.Value, &sent) == nil
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer, vault: vault}
+
+		response, err := controller.ChangeAddress(context.Background(), &pb.ChangeOrderAddressRequest{
+			OrderId:         "order-123",
+			ShippingAddress: shippingAddress(),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+
+		// The event only records the sealed address
+		assert.Equal(t, "order-123", sent.ShippingAddress.KeyId)
+		assert.NotContains(t, string(sent.ShippingAddress.Ciphertext), "Main Street")
+
+		address, err := controller.ShippingAddress(context.Background(), &orders.OrderProjection{
+			ShippingAddress: &orders.SealedAddress{KeyId: sent.ShippingAddress.KeyId, Ciphertext: sent.ShippingAddress.Ciphertext},
+		})
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(shippingAddress(), address))
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return([]eventsrc.Event{}, nil)
+
+		controller := &Controller{store: mockStore, producer: &MockProducer{}, vault: newVault()}
+
+		response, err := controller.ChangeAddress(context.Background(), &pb.ChangeOrderAddressRequest{
+			OrderId:         "order-123",
+			ShippingAddress: shippingAddress(),
+		})
+
+		assert.Equal(t, ErrOrderNotFound, err)
+		assert.Nil(t, response)
+	})
+}
+
+func TestValidateChangeAddressRequest(t *testing.T) {
+	tests := []struct {
+		shippingStatus string
+		message        string
+	}{
+		{orders.ShippingStatusWaitingForPayment, ""},
+		{orders.ShippingStatusWaitingForShipment, ""},
+		{orders.ShippingStatusInTransit, "order has already been shipped"},
+		{orders.ShippingStatusDelivered, "order has already been shipped"},
+		{orders.ShippingStatusCancelled, "order has been cancelled"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.shippingStatus, func(t *testing.T) {
+			err := validateChangeAddressRequest(&orders.OrderProjection{ShippingStatus: test.shippingStatus})
+
+			if test.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			st, ok := status.FromError(err)
+			assert.True(t, ok)
+			assert.Equal(t, codes.FailedPrecondition, st.Code())
+			assert.Equal(t, test.message, st.Message())
+		})
+	}
+}
+
+func TestController_ShippingAddress(t *testing.T) {
+	t.Run("order placed before addresses", func(t *testing.T) {
+		controller := &Controller{vault: newVault()}
+
+		address, err := controller.ShippingAddress(context.Background(), &orders.OrderProjection{})
+
+		assert.NoError(t, err)
+		assert.Nil(t, address)
+	})
+
+	t.Run("redacted address", func(t *testing.T) {
+		controller := &Controller{vault: newVault()}
+
+		sealed, err := controller.sealAddress(context.Background(), "order-123", shippingAddress())
+		require.NoError(t, err)
+		require.NoError(t, controller.vault.Forget(context.Background(), "order-123"))
+
+		address, err := controller.ShippingAddress(context.Background(), &orders.OrderProjection{
+			ShippingAddress: &orders.SealedAddress{KeyId: sealed.KeyId, Ciphertext: sealed.Ciphertext},
+		})
+
+		assert.ErrorIs(t, err, pii.ErrRedacted)
+		assert.Nil(t, address)
+	})
+}
+
+// addressedOrderEvents returns the events of an order placed with a shipping address sealed by the
+// controller, followed by the given events.
+func addressedOrderEvents(t *testing.T, controller *Controller, events ...eventsrc.Event) []eventsrc.Event {
+	sealed, err := controller.sealAddress(context.Background(), "order-123", shippingAddress())
+	require.NoError(t, err)
+
+	var orderPlacedEvent pb.OrderPlaced
+	require.NoError(t, proto.Unmarshal(createValidOrderPlacedEvent("order-123", "credit_card"), &orderPlacedEvent))
+	orderPlacedEvent.ShippingAddress = sealed
+	orderPlacedEventBytes, err := proto.Marshal(&orderPlacedEvent)
+	require.NoError(t, err)
+
+	return withSequenceNumbers(append([]eventsrc.Event{{EventType: orders.EventTypeOrderPlaced, Data: orderPlacedEventBytes}}, events...))
+}
+
+func TestController_RedactAddress(t *testing.T) {
+	orderDeliveredEvent, _ := proto.Marshal(&pb.OrderDelivered{OrderId: "order-123", Timestamp: timestamppb.Now()})
+	delivered := []eventsrc.Event{
+		{EventType: orders.EventTypeOrderPaid, Data: createValidOrderPaidEvent("order-123")},
+		{EventType: orders.EventTypeOrderDelivered, Data: orderDeliveredEvent},
+	}
+
+	t.Run("delivered order is redacted", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+		controller := &Controller{store: mockStore, producer: mockProducer, vault: newVault()}
+
+		events := addressedOrderEvents(t, controller, delivered...)
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.EventType == orders.EventTypeOrderAddressRedacted && args.SequenceNumber == 3
+		})).Return(nil)
+
+		response, err := controller.RedactAddress(context.Background(), &pb.RedactOrderAddressRequest{OrderId: "order-123", Reason: "privacy request"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+
+		// The address can no longer be read from the events
+		orderProjection, _, err := controller.GetProjection(context.Background(), "order-123")
+		require.NoError(t, err)
+		_, err = controller.ShippingAddress(context.Background(), orderProjection)
+		assert.ErrorIs(t, err, pii.ErrRedacted)
+	})
+
+	t.Run("redacted order is a no-op", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+		controller := &Controller{store: mockStore, producer: mockProducer, vault: newVault()}
+
+		orderAddressRedactedEvent, _ := proto.Marshal(&pb.OrderAddressRedacted{OrderId: "order-123", Timestamp: timestamppb.Now(), Reason: "privacy request"})
+		events := addressedOrderEvents(t, controller, append(delivered, eventsrc.Event{EventType: orders.EventTypeOrderAddressRedacted, Data: orderAddressRedactedEvent})...)
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		_, err := controller.RedactAddress(context.Background(), &pb.RedactOrderAddressRequest{OrderId: "order-123", Reason: "privacy request"})
+
+		require.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("address of an order in flight is kept", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+		controller := &Controller{store: mockStore, producer: mockProducer, vault: newVault()}
+
+		events := addressedOrderEvents(t, controller, delivered[0])
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		_, err := controller.RedactAddress(context.Background(), &pb.RedactOrderAddressRequest{OrderId: "order-123", Reason: "privacy request"})
+
+		assert.Equal(t, ErrAddressStillNeeded, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+
+		// The address can still be read
+		orderProjection, _, err := controller.GetProjection(context.Background(), "order-123")
+		require.NoError(t, err)
+		address, err := controller.ShippingAddress(context.Background(), orderProjection)
+		require.NoError(t, err)
+		assert.True(t, proto.Equal(shippingAddress(), address))
+	})
+}
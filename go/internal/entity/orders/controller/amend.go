@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrOrderNotAmendable = status.Errorf(codes.FailedPrecondition, "order can only be amended while its payment is pending or failed")
+
+func validateAmendOrderRequest(projection *orders.OrderProjection) error {
+	if projection.ShippingStatus == orders.ShippingStatusCancelled {
+		return ErrOrderAlreadyCancelled
+	}
+
+	// Once a payment is in flight or captured, its amount cannot change
+	if projection.PaymentStatus != orders.PaymentStatusPending && projection.PaymentStatus != orders.PaymentStatusFailed {
+		return ErrOrderNotAmendable
+	}
+
+	return nil
+}
+
+// AmendOrder changes the line items or the payment method of an order before it is paid. New line
+// items are priced again, keeping the coupon of the order, and the payment saga screens the order again.
+// Changing the payment method of a failed payment restarts the payment flow. Amending an order with its
+// current values is a no-op.
+func (c *Controller) AmendOrder(ctx context.Context, req *pb.AmendOrderRequest) (*pb.AmendOrderResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateAmendOrderRequest(orderProjection); err != nil {
+		return nil, err
+	}
+
+	// Create new event, with the changed fields only
+	orderAmendedEvent := &pb.OrderAmended{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+	}
+
+	total := orderProjection.TotalPrice
+	if removedLineItems, changed := diffLineItems(orderProjection.LineItems, req.LineItems); changed {
+		quote, err := c.pricing.Quote(ctx, pricing.QuoteArgs{
+			VendorId:   orderProjection.VendorId,
+			LineItems:  req.LineItems,
+			CouponCode: orderProjection.CouponCode,
+			OrderId:    req.OrderId,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		orderAmendedEvent.LineItems = quote.ToOrderLineItems()
+		orderAmendedEvent.SubtotalAmount = orders.MapMoneyToProto(quote.Subtotal)
+		orderAmendedEvent.DiscountAmount = orders.MapMoneyToProto(quote.Discount)
+		orderAmendedEvent.TotalAmount = orders.MapMoneyToProto(quote.Total)
+		for _, item := range removedLineItems {
+			orderAmendedEvent.RemovedLineItems = append(orderAmendedEvent.RemovedLineItems, item.ToOrderLineItem())
+		}
+		total = quote.Total
+	}
+
+	if err := checkExpectedTotal(req.TotalPrice, total); err != nil {
+		return nil, err
+	}
+
+	if req.PaymentMethod != "" && req.PaymentMethod != orderProjection.PaymentMethod {
+		orderAmendedEvent.PaymentMethod = req.PaymentMethod
+		orderAmendedEvent.PaymentRestarted = orderProjection.PaymentStatus == orders.PaymentStatusFailed
+	}
+
+	if len(orderAmendedEvent.LineItems) == 0 && orderAmendedEvent.PaymentMethod == "" {
+		return &pb.AmendOrderResponse{OrderId: req.OrderId}, nil
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderAmended, orderAmendedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AmendOrderResponse{OrderId: req.OrderId}, nil
+}
+
+// diffLineItems compares the line items of an order with new ones. It returns the current line items
+// that are removed or change quantity, and whether the line items changed at all. No new line items
+// means the line items are kept.
+func diffLineItems(current []orders.LineItem, next []*pb.PlaceOrderLineItem) ([]orders.LineItem, bool) {
+	if len(next) == 0 {
+		return nil, false
+	}
+
+	nextQuantities := make(map[string]int32, len(next))
+	for _, item := range next {
+		nextQuantities[item.ProductId] = item.Quantity
+	}
+
+	removed := []orders.LineItem{}
+	for _, item := range current {
+		if quantity, ok := nextQuantities[item.ProductId]; !ok || quantity != item.Quantity {
+			removed = append(removed, item)
+		}
+	}
+
+	return removed, len(removed) > 0 || len(next) != len(current)
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// amendableOrderEvents returns the events of an order of 2 x product-789 for 20.00 EUR, whose
+// payment failed if paymentFailed is set.
+func amendableOrderEvents(orderId string, paymentFailed bool) []eventsrc.Event {
+	orderPlacedEvent, _ := proto.Marshal(&pb.OrderPlaced{
+		OrderId:       orderId,
+		Timestamp:     timestamppb.Now(),
+		VendorId:      "vendor-123",
+		CustomerId:    "customer-456",
+		PaymentMethod: "credit_card",
+		LineItems: []*pb.OrderLineItem{
+			{ProductId: "product-789", Quantity: 2, UnitAmount: eur(1000), TotalAmount: eur(2000), DiscountAmount: eur(0)},
+		},
+		SubtotalAmount: eur(2000),
+		DiscountAmount: eur(0),
+		TotalAmount:    eur(2000),
+	})
+	events := []eventsrc.Event{{EventType: orders.EventTypeOrderPlaced, Data: orderPlacedEvent, SequenceNumber: 0}}
+
+	if paymentFailed {
+		orderPaymentFailedEvent, _ := proto.Marshal(&pb.OrderPaymentFailed{OrderId: orderId, Timestamp: timestamppb.Now()})
+		events = append(events, eventsrc.Event{EventType: orders.EventTypeOrderPaymentFailed, Data: orderPaymentFailedEvent, SequenceNumber: 1})
+	}
+
+	return events
+}
+
+func newAmendTestController(events []eventsrc.Event) (*Controller, *MockProducer, *pb.OrderAmended) {
+	mockStore := &MockStore{}
+	mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+	sent := &pb.OrderAmended{}
+	mockProducer := &MockProducer{}
+	mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+		return args.EventType == orders.EventTypeOrderAmended &&
+			args.SequenceNumber == len(events) &&
+			proto.Unmarshal(args.Value, sent) == nil
+	})).Return(nil)
+
+	return &Controller{store: mockStore, producer: mockProducer, pricing: newPricingEngine()}, mockProducer, sent
+}
+
+func TestController_AmendOrder(t *testing.T) {
+	t.Run("changing a quantity reprices the order", func(t *testing.T) {
+		controller, mockProducer, sent := newAmendTestController(amendableOrderEvents("order-123", false))
+
+		response, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId:    "order-123",
+			LineItems:  []*pb.PlaceOrderLineItem{{ProductId: "product-789", Quantity: 3}},
+			TotalPrice: eur(3000),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+
+		require.Len(t, sent.LineItems, 1)
+		assert.Equal(t, int32(3), sent.LineItems[0].Quantity)
+		assert.Equal(t, int64(3000), sent.TotalAmount.AmountMinor)
+		require.Len(t, sent.RemovedLineItems, 1)
+		assert.Equal(t, int32(2), sent.RemovedLineItems[0].Quantity)
+		assert.Empty(t, sent.PaymentMethod)
+		assert.False(t, sent.PaymentRestarted)
+	})
+
+	t.Run("adding a product keeps the other line items", func(t *testing.T) {
+		controller, mockProducer, sent := newAmendTestController(amendableOrderEvents("order-123", false))
+
+		_, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId: "order-123",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-789", Quantity: 2},
+				{ProductId: "product-790", Quantity: 1},
+			},
+			TotalPrice: eur(2250),
+		})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Len(t, sent.LineItems, 2)
+		assert.Empty(t, sent.RemovedLineItems)
+	})
+
+	t.Run("changing the payment method of a failed payment restarts the payment", func(t *testing.T) {
+		controller, mockProducer, sent := newAmendTestController(amendableOrderEvents("order-123", true))
+
+		_, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId:       "order-123",
+			PaymentMethod: "debit_card",
+			TotalPrice:    eur(2000),
+		})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, "debit_card", sent.PaymentMethod)
+		assert.True(t, sent.PaymentRestarted)
+		assert.Empty(t, sent.LineItems)
+	})
+
+	t.Run("changing the payment method of a pending payment", func(t *testing.T) {
+		controller, mockProducer, sent := newAmendTestController(amendableOrderEvents("order-123", false))
+
+		_, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId:       "order-123",
+			PaymentMethod: "debit_card",
+			TotalPrice:    eur(2000),
+		})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, "debit_card", sent.PaymentMethod)
+		assert.False(t, sent.PaymentRestarted)
+	})
+
+	t.Run("unchanged order is a no-op", func(t *testing.T) {
+		controller, mockProducer, _ := newAmendTestController(amendableOrderEvents("order-123", false))
+
+		_, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId:       "order-123",
+			LineItems:     []*pb.PlaceOrderLineItem{{ProductId: "product-789", Quantity: 2}},
+			PaymentMethod: "credit_card",
+			TotalPrice:    eur(2000),
+		})
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("mismatched client total", func(t *testing.T) {
+		controller, mockProducer, _ := newAmendTestController(amendableOrderEvents("order-123", false))
+
+		_, err := controller.AmendOrder(context.Background(), &pb.AmendOrderRequest{
+			OrderId:    "order-123",
+			LineItems:  []*pb.PlaceOrderLineItem{{ProductId: "product-789", Quantity: 3}},
+			TotalPrice: eur(2000),
+		})
+
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestValidateAmendOrderRequest(t *testing.T) {
+	tests := []struct {
+		name          string
+		paymentStatus string
+		expected      error
+	}{
+		{"pending payment", orders.PaymentStatusPending, nil},
+		{"failed payment", orders.PaymentStatusFailed, nil},
+		{"initiated payment", orders.PaymentStatusInitiated, ErrOrderNotAmendable},
+		{"payment awaiting confirmation", orders.PaymentStatusAwaitingConfirmation, ErrOrderNotAmendable},
+		{"paid order", orders.PaymentStatusPaid, ErrOrderNotAmendable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateAmendOrderRequest(&orders.OrderProjection{
+				PaymentStatus:  test.paymentStatus,
+				ShippingStatus: orders.ShippingStatusWaitingForPayment,
+			})
+			assert.Equal(t, test.expected, err)
+		})
+	}
+
+	t.Run("cancelled order", func(t *testing.T) {
+		err := validateAmendOrderRequest(&orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusFailed,
+			ShippingStatus: orders.ShippingStatusCancelled,
+		})
+		assert.Equal(t, ErrOrderAlreadyCancelled, err)
+	})
+}
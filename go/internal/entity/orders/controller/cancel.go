@@ -25,6 +25,7 @@ func validateCancelOrderRequest(projection *orders.OrderProjection) error {
 	return nil
 }
 
+// CancelOrder cancels an order that was not delivered yet. Cancelling a paid order also requests its refund.
 func (c *Controller) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
 	// Fetch the order projection
 	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
@@ -51,13 +52,37 @@ func (c *Controller) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest
 		return nil, fmt.Errorf("failed to marshal order cancelled event: %w", err)
 	}
 
-	err = c.producer.Send(ctx, &eventsrc.SendArgs{
+	events := []*eventsrc.SendArgs{{
 		SequenceNumber: curSeqNum + 1,
 		AggregateID:    req.OrderId,
 		AggregateType:  orders.AggregateTypeOrder,
 		EventType:      orders.EventTypeOrderCancelled,
 		Value:          orderCancelledEventBytes,
-	})
+	}}
+
+	// A paid order is refunded along with its cancellation, so that the refund cannot be lost
+	if orderProjection.PaymentStatus == orders.PaymentStatusPaid {
+		orderRefundRequestedEvent := &pb.OrderRefundRequested{
+			OrderId:   req.OrderId,
+			Timestamp: timestamppb.Now(),
+			Reason:    req.Reason,
+		}
+
+		orderRefundRequestedEventBytes, err := proto.Marshal(orderRefundRequestedEvent)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal order refund requested event: %w", err)
+		}
+
+		events = append(events, &eventsrc.SendArgs{
+			SequenceNumber: curSeqNum + 2,
+			AggregateID:    req.OrderId,
+			AggregateType:  orders.AggregateTypeOrder,
+			EventType:      orders.EventTypeOrderRefundRequested,
+			Value:          orderRefundRequestedEventBytes,
+		})
+	}
+
+	err = c.producer.SendAll(ctx, events)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send order cancelled event: %w", err)
 	}
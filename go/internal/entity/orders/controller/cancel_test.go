@@ -17,7 +17,7 @@ import (
 )
 
 func TestController_CancelOrder(t *testing.T) {
-	t.Run("successful order cancellation", func(t *testing.T) {
+	t.Run("paid order is cancelled and refunded", func(t *testing.T) {
 		mockStore := &MockStore{}
 		mockProducer := &MockProducer{}
 
@@ -39,12 +39,16 @@ func TestController_CancelOrder(t *testing.T) {
 		}
 
 		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(mockEvents, nil)
-		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
-			return args.AggregateID == "order-123" &&
-				args.AggregateType == orders.AggregateTypeOrder &&
-				args.EventType == orders.EventTypeOrderCancelled &&
-				args.SequenceNumber == 2 &&
-				len(args.Value) > 0
+		mockProducer.On("SendAll", mock.Anything, mock.MatchedBy(func(args []*eventsrc.SendArgs) bool {
+			return len(args) == 2 &&
+				args[0].AggregateID == "order-123" &&
+				args[0].AggregateType == orders.AggregateTypeOrder &&
+				args[0].EventType == orders.EventTypeOrderCancelled &&
+				args[0].SequenceNumber == 2 &&
+				len(args[0].Value) > 0 &&
+				args[1].AggregateID == "order-123" &&
+				args[1].EventType == orders.EventTypeOrderRefundRequested &&
+				args[1].SequenceNumber == 3
 		})).Return(nil)
 
 		controller := &Controller{
@@ -66,6 +70,37 @@ func TestController_CancelOrder(t *testing.T) {
 		mockProducer.AssertExpectations(t)
 	})
 
+	t.Run("unpaid order is cancelled without a refund", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockEvents := []eventsrc.Event{
+			{
+				EventType:      orders.EventTypeOrderPlaced,
+				Data:           createValidOrderPlacedEvent("order-123", "credit_card"),
+				SequenceNumber: 0,
+			},
+		}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(mockEvents, nil)
+		mockProducer.On("SendAll", mock.Anything, mock.MatchedBy(func(args []*eventsrc.SendArgs) bool {
+			return len(args) == 1 &&
+				args[0].EventType == orders.EventTypeOrderCancelled &&
+				args[0].SequenceNumber == 1
+		})).Return(nil)
+
+		controller := &Controller{
+			store:    mockStore,
+			producer: mockProducer,
+		}
+
+		response, err := controller.CancelOrder(context.Background(), &pb.CancelOrderRequest{OrderId: "order-123", Reason: "insufficient stock"})
+
+		assert.NoError(t, err)
+		assert.NotNil(t, response)
+		mockProducer.AssertExpectations(t)
+	})
+
 	t.Run("order not found", func(t *testing.T) {
 		mockStore := &MockStore{}
 		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return([]eventsrc.Event{}, nil)
@@ -207,7 +242,7 @@ func TestController_CancelOrder(t *testing.T) {
 		}
 
 		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(mockEvents, nil)
-		mockProducer.On("Send", mock.Anything, mock.Anything).Return(errors.New("kafka error"))
+		mockProducer.On("SendAll", mock.Anything, mock.Anything).Return(errors.New("kafka error"))
 
 		controller := &Controller{
 			store:    mockStore,
@@ -1,19 +1,84 @@
 package controller
 
 import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/fraud"
 	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pii"
+
+	"google.golang.org/protobuf/proto"
 )
 
+// CouponRedeemer redeems coupons on orders.
+type CouponRedeemer interface {
+	Redeem(ctx context.Context, code string, orderId string) error
+	Release(ctx context.Context, code string, orderId string) error
+}
+
+// FraudScorer scores how suspicious an order is.
+type FraudScorer interface {
+	Score(ctx context.Context, order *orders.OrderProjection) (fraud.Score, error)
+}
+
 type Controller struct {
 	store    eventsrc.Store
 	producer eventsrc.Producer
 
 	transactor     pg.Transactor
 	projectionRepo orders.ProjectionRepo
+
+	pricing *pricing.Engine
+	coupons CouponRedeemer
+
+	// vault seals the personal data recorded in order events
+	vault *pii.Vault
+
+	// returnWindow is how long after delivery an order can be returned
+	returnWindow time.Duration
+
+	// Orders scoring holdThreshold or more are held for review
+	fraud         FraudScorer
+	holdThreshold int32
 }
 
-func NewController(store eventsrc.Store, producer eventsrc.Producer, projectionRepo orders.ProjectionRepo, transactor pg.Transactor) *Controller {
-	return &Controller{store: store, producer: producer, projectionRepo: projectionRepo, transactor: transactor}
+func NewController(store eventsrc.Store, producer eventsrc.Producer, projectionRepo orders.ProjectionRepo, transactor pg.Transactor, pricingEngine *pricing.Engine, coupons CouponRedeemer, vault *pii.Vault, returnWindow time.Duration, fraudScorer FraudScorer, holdThreshold int32) *Controller {
+	return &Controller{
+		store:          store,
+		producer:       producer,
+		projectionRepo: projectionRepo,
+		transactor:     transactor,
+		pricing:        pricingEngine,
+		coupons:        coupons,
+		vault:          vault,
+		returnWindow:   returnWindow,
+		fraud:          fraudScorer,
+		holdThreshold:  holdThreshold,
+	}
+}
+
+// sendEvent marshals an order event and sends it with the given sequence number.
+func (c *Controller) sendEvent(ctx context.Context, orderId string, seqNum int, eventType string, event proto.Message) error {
+	eventBytes, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	err = c.producer.Send(ctx, &eventsrc.SendArgs{
+		SequenceNumber: seqNum,
+		AggregateID:    orderId,
+		AggregateType:  orders.AggregateTypeOrder,
+		EventType:      eventType,
+		Value:          eventBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send %s event: %w", eventType, err)
+	}
+
+	return nil
 }
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const paymentTimeoutReason = "payment was not received in time"
+
+var ErrOrderAlreadyPaid = status.Errorf(codes.FailedPrecondition, "order has already been paid")
+var ErrOrderAlreadyCancelled = status.Errorf(codes.FailedPrecondition, "order is already cancelled")
+var ErrPaymentAwaitingConfirmation = status.Errorf(codes.FailedPrecondition, "payment is awaiting the confirmation of the payment provider")
+
+func validateExpireUnpaidOrderRequest(projection *orders.OrderProjection) error {
+	if projection.ShippingStatus == orders.ShippingStatusCancelled {
+		return ErrOrderAlreadyCancelled
+	}
+	if projection.PaymentStatus == orders.PaymentStatusPaid {
+		return ErrOrderAlreadyPaid
+	}
+	// The payment provider may still capture a submitted payment
+	if projection.PaymentStatus == orders.PaymentStatusAwaitingConfirmation {
+		return ErrPaymentAwaitingConfirmation
+	}
+	return nil
+}
+
+// ExpireUnpaidOrder cancels an order whose payment was not received before the payment timeout.
+// Returns ErrPaymentAwaitingConfirmation while the payment provider has yet to confirm or decline the payment.
+func (c *Controller) ExpireUnpaidOrder(ctx context.Context, orderId string) error {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection == nil {
+		return ErrOrderNotFound
+	}
+
+	if err := validateExpireUnpaidOrderRequest(orderProjection); err != nil {
+		return err
+	}
+
+	// Create new event
+	orderCancelledEvent := &pb.OrderCancelled{
+		OrderId:   orderId,
+		Timestamp: timestamppb.Now(),
+		Reason:    paymentTimeoutReason,
+	}
+
+	orderCancelledEventBytes, err := proto.Marshal(orderCancelledEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order cancelled event: %w", err)
+	}
+
+	err = c.producer.Send(ctx, &eventsrc.SendArgs{
+		SequenceNumber: curSeqNum + 1,
+		AggregateID:    orderId,
+		AggregateType:  orders.AggregateTypeOrder,
+		EventType:      orders.EventTypeOrderCancelled,
+		Value:          orderCancelledEventBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send order cancelled event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestController_ExpireUnpaidOrder(t *testing.T) {
+	t.Run("successful expiry of an unpaid order", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockEvents := []eventsrc.Event{
+			{
+				EventType:      orders.EventTypeOrderPlaced,
+				Data:           createValidOrderPlacedEvent("order-123", "credit_card"),
+				SequenceNumber: 0,
+			},
+			{
+				EventType:      orders.EventTypeOrderPaymentInitiated,
+				Data:           createValidOrderPaymentInitiatedEvent("order-123"),
+				SequenceNumber: 1,
+			},
+		}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(mockEvents, nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.AggregateID == "order-123" &&
+				args.AggregateType == orders.AggregateTypeOrder &&
+				args.EventType == orders.EventTypeOrderCancelled &&
+				args.SequenceNumber == 2 &&
+				len(args.Value) > 0
+		})).Return(nil)
+
+		controller := &Controller{
+			store:    mockStore,
+			producer: mockProducer,
+		}
+
+		err := controller.ExpireUnpaidOrder(context.Background(), "order-123")
+
+		assert.NoError(t, err)
+		mockStore.AssertExpectations(t)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return([]eventsrc.Event{}, nil)
+
+		controller := &Controller{store: mockStore}
+
+		err := controller.ExpireUnpaidOrder(context.Background(), "order-123")
+
+		assert.Equal(t, ErrOrderNotFound, err)
+		mockStore.AssertExpectations(t)
+	})
+
+	t.Run("order already paid", func(t *testing.T) {
+		mockStore := &MockStore{}
+
+		mockEvents := []eventsrc.Event{
+			{
+				EventType:      orders.EventTypeOrderPlaced,
+				Data:           createValidOrderPlacedEvent("order-123", "credit_card"),
+				SequenceNumber: 0,
+			},
+			{
+				EventType:      orders.EventTypeOrderPaid,
+				Data:           createValidOrderPaidEvent("order-123"),
+				SequenceNumber: 1,
+			},
+		}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(mockEvents, nil)
+
+		controller := &Controller{store: mockStore}
+
+		err := controller.ExpireUnpaidOrder(context.Background(), "order-123")
+
+		assert.Equal(t, ErrOrderAlreadyPaid, err)
+		mockStore.AssertExpectations(t)
+	})
+}
+
+func TestValidateExpireUnpaidOrderRequest(t *testing.T) {
+	t.Run("pending payment", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusPending,
+			ShippingStatus: orders.ShippingStatusWaitingForPayment,
+		}
+
+		assert.NoError(t, validateExpireUnpaidOrderRequest(projection))
+	})
+
+	t.Run("failed payment", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusFailed,
+			ShippingStatus: orders.ShippingStatusWaitingForPayment,
+		}
+
+		assert.NoError(t, validateExpireUnpaidOrderRequest(projection))
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusPending,
+			ShippingStatus: orders.ShippingStatusCancelled,
+		}
+
+		assert.Equal(t, ErrOrderAlreadyCancelled, validateExpireUnpaidOrderRequest(projection))
+	})
+
+	t.Run("already paid", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusPaid,
+			ShippingStatus: orders.ShippingStatusWaitingForShipment,
+		}
+
+		assert.Equal(t, ErrOrderAlreadyPaid, validateExpireUnpaidOrderRequest(projection))
+	})
+	t.Run("payment awaiting confirmation", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusAwaitingConfirmation,
+			ShippingStatus: orders.ShippingStatusWaitingForPayment,
+		}
+
+		assert.Equal(t, ErrPaymentAwaitingConfirmation, validateExpireUnpaidOrderRequest(projection))
+	})
+}
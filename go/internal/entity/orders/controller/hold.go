@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrOrderNotOnHold = status.Errorf(codes.FailedPrecondition, "order is not on hold")
+
+// ScreenOrder scores an order that is placed, or whose line items were amended, and holds it for
+// review if its score reaches the hold threshold. Returns whether the order is on hold.
+func (c *Controller) ScreenOrder(ctx context.Context, orderId string) (bool, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, orderId)
+	if err != nil {
+		return false, err
+	}
+	if orderProjection == nil {
+		return false, ErrOrderNotFound
+	}
+
+	// Only orders waiting for their payment are screened, a failed payment being retried after an amendment
+	if orderProjection.OnHold {
+		return true, nil
+	}
+	if orderProjection.ShippingStatus == orders.ShippingStatusCancelled ||
+		(orderProjection.PaymentStatus != orders.PaymentStatusPending && orderProjection.PaymentStatus != orders.PaymentStatusFailed) {
+		return false, nil
+	}
+
+	score, err := c.fraud.Score(ctx, orderProjection)
+	if err != nil {
+		return false, fmt.Errorf("failed to score order: %w", err)
+	}
+	if score.Value < c.holdThreshold {
+		return false, nil
+	}
+
+	// Create new event
+	orderHeldEvent := &pb.OrderHeld{
+		OrderId:   orderId,
+		Timestamp: timestamppb.Now(),
+		Score:     score.Value,
+		Reasons:   score.Reasons,
+	}
+
+	err = c.sendEvent(ctx, orderId, curSeqNum+1, orders.EventTypeOrderHeld, orderHeldEvent)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ReleaseHeldOrder releases an order that passed review. The payment saga then initializes its payment.
+func (c *Controller) ReleaseHeldOrder(ctx context.Context, req *pb.ReleaseHeldOrderRequest) (*pb.ReleaseHeldOrderResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+	if !orderProjection.OnHold {
+		return nil, ErrOrderNotOnHold
+	}
+
+	// Create new event
+	orderReleasedEvent := &pb.OrderReleased{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		Note:      req.Note,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReleased, orderReleasedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ReleaseHeldOrderResponse{OrderId: req.OrderId}, nil
+}
+
+// RejectHeldOrder cancels an order that failed review.
+func (c *Controller) RejectHeldOrder(ctx context.Context, req *pb.RejectHeldOrderRequest) (*pb.RejectHeldOrderResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+	if !orderProjection.OnHold {
+		return nil, ErrOrderNotOnHold
+	}
+
+	// Create new event
+	orderCancelledEvent := &pb.OrderCancelled{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		Reason:    fmt.Sprintf("rejected after review: %s", req.Reason),
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderCancelled, orderCancelledEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RejectHeldOrderResponse{OrderId: req.OrderId}, nil
+}
+
+func (c *Controller) ListHeldOrders(ctx context.Context, req *pb.ListHeldOrdersRequest) (*pb.ListHeldOrdersResponse, error) {
+	var limit uint = defaultLimit
+	var offset uint = defaultOffset
+	if req.Limit != nil {
+		limit = uint(*req.Limit)
+	}
+	if req.Offset != nil {
+		offset = uint(*req.Offset)
+	}
+
+	heldOrders, err := c.projectionRepo.List(ctx, orders.ListArgs{
+		Limit:      limit,
+		Offset:     offset,
+		OnlyOnHold: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list held orders: %w", err)
+	}
+
+	protoOrders := make([]*pb.OrderDetails, 0, len(heldOrders))
+	for _, order := range heldOrders {
+		orderProjection, _, err := c.GetProjection(ctx, order.OrderId)
+		if err != nil {
+			return nil, err
+		}
+
+		// The index lags behind the event store, skip orders released in the meantime
+		if orderProjection == nil || !orderProjection.OnHold {
+			continue
+		}
+		protoOrders = append(protoOrders, orderProjection.ToOrderDetails())
+	}
+
+	return &pb.ListHeldOrdersResponse{Orders: protoOrders}, nil
+}
@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/fraud"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// fakeScorer gives every order the same score.
+type fakeScorer struct {
+	score fraud.Score
+	err   error
+}
+
+func (f fakeScorer) Score(ctx context.Context, order *orders.OrderProjection) (fraud.Score, error) {
+	return f.score, f.err
+}
+
+func heldOrderEvents(orderId string) []eventsrc.Event {
+	orderHeldEvent, _ := proto.Marshal(&pb.OrderHeld{OrderId: orderId, Timestamp: timestamppb.Now(), Score: 80, Reasons: []string{"suspicious"}})
+	return []eventsrc.Event{
+		{EventType: orders.EventTypeOrderPlaced, Data: createValidOrderPlacedEvent(orderId, "credit_card"), SequenceNumber: 0},
+		{EventType: orders.EventTypeOrderHeld, Data: orderHeldEvent, SequenceNumber: 1},
+	}
+}
+
+func placedOrderEvents(orderId string) []eventsrc.Event {
+	return []eventsrc.Event{
+		{EventType: orders.EventTypeOrderPlaced, Data: createValidOrderPlacedEvent(orderId, "credit_card"), SequenceNumber: 0},
+	}
+}
+
+func TestController_ScreenOrder(t *testing.T) {
+	t.Run("order scoring above the threshold is held", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(placedOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderHeld
+			return args.EventType == orders.EventTypeOrderHeld &&
+				args.SequenceNumber == 1 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Score == 60 &&
+				len(event.Reasons) == 1
+		})).Return(nil)
+
+		scorer := fakeScorer{score: fraud.Score{Value: 60, Reasons: []string{"large order"}}}
+		controller := &Controller{store: mockStore, producer: mockProducer, fraud: scorer, holdThreshold: 50}
+
+		held, err := controller.ScreenOrder(context.Background(), "order-123")
+
+		require.NoError(t, err)
+		assert.True(t, held)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("order scoring below the threshold is not held", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(placedOrderEvents("order-123"), nil)
+
+		scorer := fakeScorer{score: fraud.Score{Value: 49}}
+		controller := &Controller{store: mockStore, producer: mockProducer, fraud: scorer, holdThreshold: 50}
+
+		held, err := controller.ScreenOrder(context.Background(), "order-123")
+
+		require.NoError(t, err)
+		assert.False(t, held)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("amended order with a failed payment is screened again", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		orderPaymentFailedEvent, _ := proto.Marshal(&pb.OrderPaymentFailed{OrderId: "order-123", Timestamp: timestamppb.Now(), Reason: "card declined"})
+		events := append(placedOrderEvents("order-123"),
+			eventsrc.Event{EventType: orders.EventTypeOrderPaymentInitiated, Data: createValidOrderPaymentInitiatedEvent("order-123"), SequenceNumber: 1},
+			eventsrc.Event{EventType: orders.EventTypeOrderPaymentFailed, Data: orderPaymentFailedEvent, SequenceNumber: 2},
+		)
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.EventType == orders.EventTypeOrderHeld && args.SequenceNumber == 3
+		})).Return(nil)
+
+		scorer := fakeScorer{score: fraud.Score{Value: 60, Reasons: []string{"large order"}}}
+		controller := &Controller{store: mockStore, producer: mockProducer, fraud: scorer, holdThreshold: 50}
+
+		held, err := controller.ScreenOrder(context.Background(), "order-123")
+
+		require.NoError(t, err)
+		assert.True(t, held)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("held order is not scored again", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(heldOrderEvents("order-123"), nil)
+
+		scorer := fakeScorer{err: errors.New("should not be called")}
+		controller := &Controller{store: mockStore, producer: mockProducer, fraud: scorer, holdThreshold: 50}
+
+		held, err := controller.ScreenOrder(context.Background(), "order-123")
+
+		require.NoError(t, err)
+		assert.True(t, held)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("scorer error", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(placedOrderEvents("order-123"), nil)
+
+		scorer := fakeScorer{err: errors.New("database unavailable")}
+		controller := &Controller{store: mockStore, producer: mockProducer, fraud: scorer, holdThreshold: 50}
+
+		_, err := controller.ScreenOrder(context.Background(), "order-123")
+
+		assert.Error(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_ReleaseHeldOrder(t *testing.T) {
+	t.Run("successful release", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(heldOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderReleased
+			return args.EventType == orders.EventTypeOrderReleased &&
+				args.SequenceNumber == 2 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Note == "customer verified"
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		response, err := controller.ReleaseHeldOrder(context.Background(), &pb.ReleaseHeldOrderRequest{OrderId: "order-123", Note: "customer verified"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("order not on hold", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(placedOrderEvents("order-123"), nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		_, err := controller.ReleaseHeldOrder(context.Background(), &pb.ReleaseHeldOrderRequest{OrderId: "order-123"})
+
+		assert.Equal(t, ErrOrderNotOnHold, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_RejectHeldOrder(t *testing.T) {
+	t.Run("rejected order is cancelled", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(heldOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderCancelled
+			return args.EventType == orders.EventTypeOrderCancelled &&
+				args.SequenceNumber == 2 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Reason == "rejected after review: stolen card"
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		_, err := controller.RejectHeldOrder(context.Background(), &pb.RejectHeldOrderRequest{OrderId: "order-123", Reason: "stolen card"})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("order not found", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return([]eventsrc.Event{}, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		_, err := controller.RejectHeldOrder(context.Background(), &pb.RejectHeldOrderRequest{OrderId: "order-123", Reason: "stolen card"})
+
+		assert.Equal(t, ErrOrderNotFound, err)
+	})
+}
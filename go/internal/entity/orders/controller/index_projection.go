@@ -24,11 +24,14 @@ func (c *Controller) IndexProjection(ctx context.Context, orderId string) error
 	// Upsert the projection into the projection repo
 	return c.transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
 		err = c.projectionRepo.Upsert(ctx, tx, orders.UpsertArgs{
-			OrderId:        orderId,
-			PaymentStatus:  orderProjection.PaymentStatus,
-			ShippingStatus: orderProjection.ShippingStatus,
-			CreatedAt:      orderProjection.CreatedAt,
-			UpdatedAt:      orderProjection.UpdatedAt,
+			OrderId:          orderId,
+			PaymentStatus:    orderProjection.PaymentStatus,
+			ShippingStatus:   orderProjection.ShippingStatus,
+			PaymentReference: orderProjection.PaymentReference,
+			CustomerId:       orderProjection.CustomerId,
+			OnHold:           orderProjection.OnHold,
+			CreatedAt:        orderProjection.CreatedAt,
+			UpdatedAt:        orderProjection.UpdatedAt,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to upsert projection: %w", err)
@@ -8,6 +8,7 @@ import (
 	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
@@ -15,6 +16,7 @@ import (
 )
 
 var ErrPaymentStatusNotPending = status.Errorf(codes.FailedPrecondition, "order is not in pending payment status")
+var ErrOrderOnHold = status.Errorf(codes.FailedPrecondition, "order is on hold for review")
 
 func validateInitPendingPaymentRequest(projection *orders.OrderProjection) error {
 
@@ -27,6 +29,11 @@ func validateInitPendingPaymentRequest(projection *orders.OrderProjection) error
 		return ErrPaymentStatusNotPending
 	}
 
+	// Held orders are paid once released
+	if projection.OnHold {
+		return ErrOrderOnHold
+	}
+
 	return nil
 }
 
@@ -45,10 +52,16 @@ func (c *Controller) InitializePendingPayment(ctx context.Context, orderId strin
 		return err
 	}
 
+	paymentReference, err := uuid.NewV7()
+	if err != nil {
+		return fmt.Errorf("failed to generate payment reference: %w", err)
+	}
+
 	// Create new event
 	orderPaymentInitiatedEvent := &pb.OrderPaymentInitiated{
-		OrderId:   orderId,
-		Timestamp: timestamppb.Now(),
+		OrderId:          orderId,
+		Timestamp:        timestamppb.Now(),
+		PaymentReference: paymentReference.String(),
 	}
 
 	orderPaymentInitiatedEventBytes, err := proto.Marshal(orderPaymentInitiatedEvent)
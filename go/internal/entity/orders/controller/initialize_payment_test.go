@@ -37,6 +37,11 @@ func (m *MockStore) ListByAggregateID(ctx context.Context, aggregateID, aggregat
 	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
 }
 
+func (m *MockStore) ListEvents(ctx context.Context, args eventsrc.ListEventsArgs) ([]eventsrc.Event, error) {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Get(0).([]eventsrc.Event), callArgs.Error(1)
+}
+
 func createValidOrderPlacedEvent(orderId string, paymentMethod string) []byte {
 	event := &pb.OrderPlaced{
 		OrderId:       orderId,
@@ -218,4 +223,14 @@ func TestValidatePaymentRequest(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, codes.FailedPrecondition, st.Code())
 	})
+	t.Run("order on hold", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentMethod: "credit_card",
+			PaymentStatus: orders.PaymentStatusPending,
+			OnHold:        true,
+		}
+
+		err := validateInitPendingPaymentRequest(projection)
+		assert.Equal(t, ErrOrderOnHold, err)
+	})
 }
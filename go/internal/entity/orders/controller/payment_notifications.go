@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const lateConfirmationRefundReason = "payment was confirmed after the order was cancelled"
+
+var ErrStalePaymentNotification = status.Errorf(codes.FailedPrecondition, "a more recent payment notification was already applied")
+var ErrPaymentNotPaid = status.Errorf(codes.FailedPrecondition, "order has not been paid")
+
+type PaymentNotificationArgs struct {
+	OrderId string
+	Reason  string
+	// NotifiedAt is when the payment provider emitted the notification. Used to discard notifications received out of order.
+	NotifiedAt time.Time
+}
+
+// FindOrderByPaymentReference returns the id of the order with the payment reference.
+func (c *Controller) FindOrderByPaymentReference(ctx context.Context, paymentReference string) (string, error) {
+	orderId, err := c.projectionRepo.GetOrderIdByPaymentReference(ctx, paymentReference)
+	if err != nil {
+		return "", fmt.Errorf("failed to get order by payment reference: %w", err)
+	}
+	if orderId == "" {
+		return "", ErrOrderNotFound
+	}
+
+	return orderId, nil
+}
+
+// SubmitPayment hands an initiated payment to the payment provider, which confirms it asynchronously.
+func (c *Controller) SubmitPayment(ctx context.Context, orderId string) error {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection == nil {
+		return ErrOrderNotFound
+	}
+
+	if err := validateProcessPaymentRequest(orderProjection); err != nil {
+		return err
+	}
+
+	// Create new event
+	orderPaymentSubmittedEvent := &pb.OrderPaymentSubmitted{
+		OrderId:          orderId,
+		Timestamp:        timestamppb.Now(),
+		PaymentReference: orderProjection.PaymentReference,
+	}
+
+	return c.sendEvent(ctx, orderId, curSeqNum+1, orders.EventTypeOrderPaymentSubmitted, orderPaymentSubmittedEvent)
+}
+
+// getProjectionForNotification fetches the order projection and discards notifications
+// older than the latest one applied to the order.
+func (c *Controller) getProjectionForNotification(ctx context.Context, args PaymentNotificationArgs) (*orders.OrderProjection, int, error) {
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, args.OrderId)
+	if err != nil {
+		return nil, 0, err
+	}
+	if orderProjection == nil {
+		return nil, 0, ErrOrderNotFound
+	}
+
+	if args.NotifiedAt.Before(orderProjection.PaymentNotifiedAt) {
+		return nil, 0, ErrStalePaymentNotification
+	}
+
+	return orderProjection, curSeqNum, nil
+}
+
+// ConfirmPayment marks the order as paid after the payment provider confirmed the payment.
+// Confirming an order that is already paid is a no-op. A payment confirmed after the order was
+// cancelled was still captured, so its refund is requested instead.
+func (c *Controller) ConfirmPayment(ctx context.Context, args PaymentNotificationArgs) error {
+
+	orderProjection, curSeqNum, err := c.getProjectionForNotification(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	switch orderProjection.PaymentStatus {
+	case orders.PaymentStatusPaid, orders.PaymentStatusRefundPending, orders.PaymentStatusRefunded:
+		return nil
+	case orders.PaymentStatusPending:
+		return ErrPaymentStatusNotInitiated
+	}
+	if orderProjection.ShippingStatus == orders.ShippingStatusCancelled {
+		orderRefundRequestedEvent := &pb.OrderRefundRequested{
+			OrderId:    args.OrderId,
+			Timestamp:  timestamppb.Now(),
+			Reason:     lateConfirmationRefundReason,
+			NotifiedAt: timestamppb.New(args.NotifiedAt),
+		}
+		return c.sendEvent(ctx, args.OrderId, curSeqNum+1, orders.EventTypeOrderRefundRequested, orderRefundRequestedEvent)
+	}
+
+	// Create new event
+	orderPaidEvent := &pb.OrderPaid{
+		OrderId:    args.OrderId,
+		Timestamp:  timestamppb.Now(),
+		NotifiedAt: timestamppb.New(args.NotifiedAt),
+	}
+
+	return c.sendEvent(ctx, args.OrderId, curSeqNum+1, orders.EventTypeOrderPaid, orderPaidEvent)
+}
+
+// FailPayment marks the payment of the order as failed after the payment provider declined it.
+// Payments that are not in flight are left untouched.
+func (c *Controller) FailPayment(ctx context.Context, args PaymentNotificationArgs) error {
+
+	orderProjection, curSeqNum, err := c.getProjectionForNotification(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	if orderProjection.PaymentStatus != orders.PaymentStatusInitiated && orderProjection.PaymentStatus != orders.PaymentStatusAwaitingConfirmation {
+		return nil
+	}
+
+	// Create new event
+	orderPaymentFailedEvent := &pb.OrderPaymentFailed{
+		OrderId:    args.OrderId,
+		Timestamp:  timestamppb.Now(),
+		Reason:     args.Reason,
+		NotifiedAt: timestamppb.New(args.NotifiedAt),
+	}
+
+	return c.sendEvent(ctx, args.OrderId, curSeqNum+1, orders.EventTypeOrderPaymentFailed, orderPaymentFailedEvent)
+}
+
+// RefundPayment marks the payment of the order as refunded by the payment provider.
+// Returns ErrPaymentNotPaid if the payment has not been confirmed yet, so that the refund can be retried.
+func (c *Controller) RefundPayment(ctx context.Context, args PaymentNotificationArgs) error {
+
+	orderProjection, curSeqNum, err := c.getProjectionForNotification(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	switch orderProjection.PaymentStatus {
+	case orders.PaymentStatusRefunded:
+		return nil
+	case orders.PaymentStatusPaid, orders.PaymentStatusRefundPending:
+	default:
+		return ErrPaymentNotPaid
+	}
+
+	// Create new event
+	orderPaymentRefundedEvent := &pb.OrderPaymentRefunded{
+		OrderId:    args.OrderId,
+		Timestamp:  timestamppb.Now(),
+		Reason:     args.Reason,
+		NotifiedAt: timestamppb.New(args.NotifiedAt),
+	}
+
+	return c.sendEvent(ctx, args.OrderId, curSeqNum+1, orders.EventTypeOrderPaymentRefunded, orderPaymentRefundedEvent)
+}
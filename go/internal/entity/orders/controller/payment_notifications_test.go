@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var testNotifiedAt = time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+func createValidOrderPaymentSubmittedEvent(orderId string) []byte {
+	event := &pb.OrderPaymentSubmitted{
+		OrderId:          orderId,
+		Timestamp:        timestamppb.Now(),
+		PaymentReference: "pay-123",
+	}
+
+	eventBytes, _ := proto.Marshal(event)
+	return eventBytes
+}
+
+func createValidNotifiedOrderPaidEvent(orderId string, notifiedAt time.Time) []byte {
+	event := &pb.OrderPaid{
+		OrderId:    orderId,
+		Timestamp:  timestamppb.Now(),
+		NotifiedAt: timestamppb.New(notifiedAt),
+	}
+
+	eventBytes, _ := proto.Marshal(event)
+	return eventBytes
+}
+
+func awaitingConfirmationEvents(orderId string) []eventsrc.Event {
+	return []eventsrc.Event{
+		{
+			EventType:      orders.EventTypeOrderPlaced,
+			Data:           createValidOrderPlacedEvent(orderId, "credit_card"),
+			SequenceNumber: 0,
+		},
+		{
+			EventType:      orders.EventTypeOrderPaymentInitiated,
+			Data:           createValidOrderPaymentInitiatedEvent(orderId),
+			SequenceNumber: 1,
+		},
+		{
+			EventType:      orders.EventTypeOrderPaymentSubmitted,
+			Data:           createValidOrderPaymentSubmittedEvent(orderId),
+			SequenceNumber: 2,
+		},
+	}
+}
+
+func cancelledAwaitingConfirmationEvents(orderId string) []eventsrc.Event {
+	orderCancelledEvent, _ := proto.Marshal(&pb.OrderCancelled{OrderId: orderId, Timestamp: timestamppb.Now(), Reason: "changed my mind"})
+	return append(awaitingConfirmationEvents(orderId), eventsrc.Event{
+		EventType:      orders.EventTypeOrderCancelled,
+		Data:           orderCancelledEvent,
+		SequenceNumber: 3,
+	})
+}
+
+func TestController_ConfirmPayment(t *testing.T) {
+	t.Run("confirms a payment awaiting confirmation", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(awaitingConfirmationEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.EventType == orders.EventTypeOrderPaid && args.SequenceNumber == 3
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.ConfirmPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", NotifiedAt: testNotifiedAt})
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("requests the refund of a payment confirmed after the order was cancelled", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(cancelledAwaitingConfirmationEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderRefundRequested
+			return args.EventType == orders.EventTypeOrderRefundRequested &&
+				args.SequenceNumber == 4 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.NotifiedAt.AsTime().Equal(testNotifiedAt)
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.ConfirmPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", NotifiedAt: testNotifiedAt})
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("duplicate confirmation is a no-op", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		events := append(awaitingConfirmationEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderPaid,
+			Data:           createValidNotifiedOrderPaidEvent("order-123", testNotifiedAt),
+			SequenceNumber: 3,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.ConfirmPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", NotifiedAt: testNotifiedAt})
+
+		assert.NoError(t, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_FailPayment(t *testing.T) {
+	t.Run("failure received after the confirmation is stale", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		events := append(awaitingConfirmationEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderPaid,
+			Data:           createValidNotifiedOrderPaidEvent("order-123", testNotifiedAt),
+			SequenceNumber: 3,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.FailPayment(context.Background(), PaymentNotificationArgs{
+			OrderId:    "order-123",
+			Reason:     "card declined",
+			NotifiedAt: testNotifiedAt.Add(-time.Minute),
+		})
+
+		assert.Equal(t, ErrStalePaymentNotification, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fails a payment awaiting confirmation", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(awaitingConfirmationEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderPaymentFailed
+			return args.EventType == orders.EventTypeOrderPaymentFailed &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Reason == "card declined"
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.FailPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", Reason: "card declined", NotifiedAt: testNotifiedAt})
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+}
+
+func TestController_RefundPayment(t *testing.T) {
+	t.Run("refund before the confirmation is retried", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(awaitingConfirmationEvents("order-123"), nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.RefundPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", NotifiedAt: testNotifiedAt})
+
+		assert.Equal(t, ErrPaymentNotPaid, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+	t.Run("refunds a payment whose refund was requested", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		orderRefundRequestedEvent, _ := proto.Marshal(&pb.OrderRefundRequested{OrderId: "order-123", Timestamp: timestamppb.Now(), NotifiedAt: timestamppb.New(testNotifiedAt)})
+		events := append(cancelledAwaitingConfirmationEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderRefundRequested,
+			Data:           orderRefundRequestedEvent,
+			SequenceNumber: 4,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			return args.EventType == orders.EventTypeOrderPaymentRefunded && args.SequenceNumber == 5
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		err := controller.RefundPayment(context.Background(), PaymentNotificationArgs{OrderId: "order-123", NotifiedAt: testNotifiedAt.Add(time.Hour)})
+
+		assert.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+}
@@ -6,29 +6,64 @@ import (
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
 	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
 
 	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (c *Controller) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
+
+	// Price the order on the server, the client total is only used to detect price changes
+	quote, err := c.pricing.Quote(ctx, pricing.QuoteArgs{
+		VendorId:   req.VendorId,
+		LineItems:  req.LineItems,
+		CouponCode: req.CouponCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkExpectedTotal(req.TotalPrice, quote.Total); err != nil {
+		return nil, err
+	}
+
 	orderId, err := uuid.NewV7()
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate order id: %w", err)
 	}
 
+	shippingAddress, err := c.sealAddress(ctx, orderId.String(), req.ShippingAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.CouponCode != "" {
+		err = c.coupons.Redeem(ctx, quote.CouponCode, orderId.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create new event
 	orderPlacedEvent := &pb.OrderPlaced{
-		OrderId:       orderId.String(),
-		Timestamp:     timestamppb.Now(),
-		VendorId:      req.VendorId,
-		CustomerId:    req.CustomerId,
-		ProductId:     req.ProductId,
-		Quantity:      req.Quantity,
-		TotalPrice:    req.TotalPrice,
-		PaymentMethod: req.PaymentMethod,
+		OrderId:         orderId.String(),
+		Timestamp:       timestamppb.Now(),
+		VendorId:        req.VendorId,
+		CustomerId:      req.CustomerId,
+		LineItems:       quote.ToOrderLineItems(),
+		SubtotalAmount:  orders.MapMoneyToProto(quote.Subtotal),
+		DiscountAmount:  orders.MapMoneyToProto(quote.Discount),
+		TotalAmount:     orders.MapMoneyToProto(quote.Total),
+		CouponCode:      quote.CouponCode,
+		PaymentMethod:   req.PaymentMethod,
+		ShippingAddress: shippingAddress,
 	}
 
 	orderPlacedEventBytes, err := proto.Marshal(orderPlacedEvent)
@@ -44,8 +79,32 @@ func (c *Controller) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest)
 		Value:          orderPlacedEventBytes,
 	})
 	if err != nil {
+		c.releaseCoupon(ctx, quote.CouponCode, orderPlacedEvent.OrderId)
 		return nil, fmt.Errorf("failed to send order placed event: %w", err)
 	}
 
 	return &pb.PlaceOrderResponse{OrderId: orderPlacedEvent.OrderId}, nil
 }
+
+// checkExpectedTotal makes sure the client expects to pay the total computed by the server.
+func checkExpectedTotal(expected *pb.Money, total money.Money) error {
+	expectedTotal, err := orders.ParseProtoMoney(expected)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid total price: %v", err)
+	}
+	if !expectedTotal.Equal(total) {
+		return status.Errorf(codes.InvalidArgument, "total price %s does not match the order total %s", expectedTotal, total)
+	}
+	return nil
+}
+
+// releaseCoupon gives back the coupon redeemed for an order that could not be placed.
+func (c *Controller) releaseCoupon(ctx context.Context, couponCode string, orderId string) {
+	if couponCode == "" {
+		return
+	}
+
+	if err := c.coupons.Release(ctx, couponCode, orderId); err != nil {
+		logging.FromContext(ctx).Error("failed to release coupon of an order that could not be placed", "couponCode", couponCode, "orderId", orderId, "error", err)
+	}
+}
@@ -6,12 +6,19 @@ import (
 	"testing"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
 	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pii"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // MockProducer is a mock implementation of eventsrc.Producer
@@ -24,26 +31,89 @@ func (m *MockProducer) Send(ctx context.Context, args *eventsrc.SendArgs) error
 	return callArgs.Error(0)
 }
 
+func (m *MockProducer) SendAll(ctx context.Context, args []*eventsrc.SendArgs) error {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Error(0)
+}
+
+// MockCouponRedeemer is a mock implementation of CouponRedeemer
+type MockCouponRedeemer struct {
+	mock.Mock
+}
+
+func (m *MockCouponRedeemer) Redeem(ctx context.Context, code string, orderId string) error {
+	callArgs := m.Called(ctx, code, orderId)
+	return callArgs.Error(0)
+}
+
+func (m *MockCouponRedeemer) Release(ctx context.Context, code string, orderId string) error {
+	callArgs := m.Called(ctx, code, orderId)
+	return callArgs.Error(0)
+}
+
+// fakeCoupons is an in-memory pricing.CouponProvider
+type fakeCoupons map[string]*coupons.CouponProjection
+
+func (f fakeCoupons) GetProjection(ctx context.Context, code string) (*coupons.CouponProjection, int, error) {
+	return f[code], 0, nil
+}
+
+func newPricingEngine() *pricing.Engine {
+	catalog := pricing.NewInMemoryPriceCatalog()
+	catalog.Prices["product-789"] = money.Money{Currency: "EUR", Amount: 1000}
+	catalog.Prices["product-790"] = money.Money{Currency: "EUR", Amount: 250}
+
+	return pricing.NewEngine(catalog, fakeCoupons{
+		"SAVE10": {Code: "SAVE10", Kind: coupons.CouponKindPercentage, PercentOffBps: 1000, Redemptions: map[string]bool{}},
+	})
+}
+
+func newVault() *pii.Vault {
+	return pii.NewVault(pii.NewInMemoryKeyStore())
+}
+
+func shippingAddress() *pb.Address {
+	return &pb.Address{RecipientName: "Jane Doe", Line1: "1 Main Street", City: "Springfield", PostalCode: "12345", CountryCode: "US"}
+}
+
+func eur(amount int64) *pb.Money {
+	return &pb.Money{CurrencyCode: "EUR", AmountMinor: amount}
+}
+
 func TestController_PlaceOrder(t *testing.T) {
 	t.Run("successful order placement", func(t *testing.T) {
 		mockProducer := &MockProducer{}
 		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderPlaced
+			if proto.Unmarshal(args.Value, &event) != nil {
+				return false
+			}
 			return args.AggregateType == orders.AggregateTypeOrder &&
 				args.EventType == orders.EventTypeOrderPlaced &&
 				args.AggregateID != "" &&
 				args.SequenceNumber == 0 &&
-				len(args.Value) > 0
+				len(event.LineItems) == 2 &&
+				event.LineItems[0].UnitAmount.AmountMinor == 1000 &&
+				event.LineItems[0].TotalAmount.AmountMinor == 2000 &&
+				event.LineItems[1].TotalAmount.AmountMinor == 750 &&
+				event.TotalAmount.AmountMinor == 2750 &&
+				event.TotalAmount.CurrencyCode == "EUR" &&
+				event.DiscountAmount.AmountMinor == 0 &&
+				event.ShippingAddress.KeyId == args.AggregateID
 		})).Return(nil)
 
-		controller := &Controller{producer: mockProducer}
+		controller := &Controller{producer: mockProducer, pricing: newPricingEngine(), vault: newVault()}
 
 		request := &pb.PlaceOrderRequest{
-			VendorId:      "vendor-123",
-			CustomerId:    "customer-456",
-			ProductId:     "product-789",
-			Quantity:      2,
-			TotalPrice:    99.99,
-			PaymentMethod: "credit_card",
+			VendorId:   "vendor-123",
+			CustomerId: "customer-456",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-789", Quantity: 2},
+				{ProductId: "product-790", Quantity: 3},
+			},
+			TotalPrice:      eur(2750),
+			PaymentMethod:   "credit_card",
+			ShippingAddress: shippingAddress(),
 		}
 
 		response, err := controller.PlaceOrder(context.Background(), request)
@@ -54,19 +124,113 @@ func TestController_PlaceOrder(t *testing.T) {
 		mockProducer.AssertExpectations(t)
 	})
 
-	t.Run("producer send error", func(t *testing.T) {
+	t.Run("order placement with a coupon", func(t *testing.T) {
 		mockProducer := &MockProducer{}
+		mockCoupons := &MockCouponRedeemer{}
+
+		mockCoupons.On("Redeem", mock.Anything, "SAVE10", mock.Anything).Return(nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderPlaced
+			if proto.Unmarshal(args.Value, &event) != nil {
+				return false
+			}
+			return event.CouponCode == "SAVE10" &&
+				event.SubtotalAmount.AmountMinor == 2750 &&
+				event.DiscountAmount.AmountMinor == 275 &&
+				event.TotalAmount.AmountMinor == 2475 &&
+				event.LineItems[0].DiscountAmount.AmountMinor == 200 &&
+				event.LineItems[1].DiscountAmount.AmountMinor == 75
+		})).Return(nil)
+
+		controller := &Controller{producer: mockProducer, pricing: newPricingEngine(), vault: newVault(), coupons: mockCoupons}
+
+		request := &pb.PlaceOrderRequest{
+			VendorId:   "vendor-123",
+			CustomerId: "customer-456",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-789", Quantity: 2},
+				{ProductId: "product-790", Quantity: 3},
+			},
+			TotalPrice:      eur(2475),
+			CouponCode:      "save10",
+			PaymentMethod:   "credit_card",
+			ShippingAddress: shippingAddress(),
+		}
+
+		response, err := controller.PlaceOrder(context.Background(), request)
+
+		assert.NoError(t, err)
+		require.NotNil(t, response)
+		mockCoupons.AssertCalled(t, "Redeem", mock.Anything, "SAVE10", response.OrderId)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("mismatched client total", func(t *testing.T) {
+		mockProducer := &MockProducer{}
+		controller := &Controller{producer: mockProducer, pricing: newPricingEngine(), vault: newVault()}
+
+		request := &pb.PlaceOrderRequest{
+			VendorId:   "vendor-123",
+			CustomerId: "customer-456",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-789", Quantity: 1},
+			},
+			TotalPrice:      eur(999),
+			PaymentMethod:   "credit_card",
+			ShippingAddress: shippingAddress(),
+		}
+
+		response, err := controller.PlaceOrder(context.Background(), request)
+
+		assert.Error(t, err)
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Contains(t, st.Message(), "does not match the order total 10.00 EUR")
+		assert.Nil(t, response)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("product without a price", func(t *testing.T) {
+		controller := &Controller{producer: &MockProducer{}, pricing: newPricingEngine(), vault: newVault()}
+
+		request := &pb.PlaceOrderRequest{
+			VendorId:   "vendor-123",
+			CustomerId: "customer-456",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-000", Quantity: 1},
+			},
+			TotalPrice:      eur(1000),
+			PaymentMethod:   "credit_card",
+			ShippingAddress: shippingAddress(),
+		}
+
+		response, err := controller.PlaceOrder(context.Background(), request)
+
+		assert.Equal(t, pricing.ErrProductNotPriced, err)
+		assert.Nil(t, response)
+	})
+
+	t.Run("producer send error releases the coupon", func(t *testing.T) {
+		mockProducer := &MockProducer{}
+		mockCoupons := &MockCouponRedeemer{}
+
+		mockCoupons.On("Redeem", mock.Anything, "SAVE10", mock.Anything).Return(nil)
+		mockCoupons.On("Release", mock.Anything, "SAVE10", mock.Anything).Return(nil)
 		mockProducer.On("Send", mock.Anything, mock.Anything).Return(errors.New("database error"))
 
-		controller := &Controller{producer: mockProducer}
+		controller := &Controller{producer: mockProducer, pricing: newPricingEngine(), vault: newVault(), coupons: mockCoupons}
 
 		request := &pb.PlaceOrderRequest{
-			VendorId:      "vendor-123",
-			CustomerId:    "customer-456",
-			ProductId:     "product-789",
-			Quantity:      1,
-			TotalPrice:    50.00,
-			PaymentMethod: "debit_card",
+			VendorId:   "vendor-123",
+			CustomerId: "customer-456",
+			LineItems: []*pb.PlaceOrderLineItem{
+				{ProductId: "product-789", Quantity: 1},
+			},
+			TotalPrice:      eur(900),
+			CouponCode:      "SAVE10",
+			PaymentMethod:   "debit_card",
+			ShippingAddress: shippingAddress(),
 		}
 
 		response, err := controller.PlaceOrder(context.Background(), request)
@@ -75,5 +239,6 @@ func TestController_PlaceOrder(t *testing.T) {
 		assert.Contains(t, err.Error(), "failed to send order placed event")
 		assert.Nil(t, response)
 		mockProducer.AssertExpectations(t)
+		mockCoupons.AssertExpectations(t)
 	})
 }
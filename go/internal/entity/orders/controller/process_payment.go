@@ -18,6 +18,11 @@ var ErrPaymentStatusNotInitiated = status.Errorf(codes.FailedPrecondition, "orde
 
 func validateProcessPaymentRequest(projection *orders.OrderProjection) error {
 
+	// A cancelled order must not be charged
+	if projection.ShippingStatus == orders.ShippingStatusCancelled {
+		return ErrOrderAlreadyCancelled
+	}
+
 	// Check if the payment method is set
 	if projection.PaymentMethod == "" {
 		return status.Errorf(codes.InvalidArgument, "payment method is required")
@@ -225,4 +225,14 @@ func TestValidateProcessPaymentRequest(t *testing.T) {
 		assert.Error(t, err)
 		assert.Equal(t, ErrPaymentStatusNotInitiated, err)
 	})
+	t.Run("cancelled order", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentMethod:  "credit_card",
+			PaymentStatus:  orders.PaymentStatusInitiated,
+			ShippingStatus: orders.ShippingStatusCancelled,
+		}
+
+		err := validateProcessPaymentRequest(projection)
+		assert.Equal(t, ErrOrderAlreadyCancelled, err)
+	})
 }
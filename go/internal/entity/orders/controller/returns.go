@@ -0,0 +1,255 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var ErrReturnNotFound = status.Errorf(codes.NotFound, "return not found")
+var ErrOrderNotDelivered = status.Errorf(codes.FailedPrecondition, "only delivered orders can be returned")
+var ErrReturnWindowClosed = status.Errorf(codes.FailedPrecondition, "return window has closed")
+
+func (c *Controller) validateRequestReturnRequest(projection *orders.OrderProjection, req *pb.RequestReturnRequest, now time.Time) error {
+	if projection.DeliveredAt == nil {
+		return ErrOrderNotDelivered
+	}
+	if now.After(projection.DeliveredAt.Add(c.returnWindow)) {
+		return ErrReturnWindowClosed
+	}
+
+	// Line items can be returned across several returns, up to the quantity ordered
+	for _, item := range req.LineItems {
+		lineItem, ok := projection.GetLineItem(item.ProductId)
+		if !ok {
+			return status.Errorf(codes.InvalidArgument, "product %s is not part of the order", item.ProductId)
+		}
+
+		returnable := lineItem.Quantity - projection.ReturnedQuantity(item.ProductId)
+		if item.Quantity > returnable {
+			return status.Errorf(codes.InvalidArgument, "only %d of product %s can be returned", returnable, item.ProductId)
+		}
+	}
+
+	return nil
+}
+
+// validateReturnStatus checks that a return exists and is in the status a transition starts from.
+func validateReturnStatus(projection *orders.OrderProjection, returnId string, expected string) error {
+	orderReturn := projection.GetReturn(returnId)
+	if orderReturn == nil {
+		return ErrReturnNotFound
+	}
+	if orderReturn.Status != expected {
+		return status.Errorf(codes.FailedPrecondition, "return is %s, expected %s", orderReturn.Status, expected)
+	}
+
+	return nil
+}
+
+// RequestReturn opens a return for line items of a delivered order, within the return window. The
+// refund of each line item is its share of the price paid, so discounts are refunded pro rata, and the
+// refunds of a line item add up to its price once all of it is returned.
+func (c *Controller) RequestReturn(ctx context.Context, req *pb.RequestReturnRequest) (*pb.RequestReturnResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	now := time.Now()
+	if err := c.validateRequestReturnRequest(orderProjection, req, now); err != nil {
+		return nil, err
+	}
+
+	returnId, err := uuid.NewV7()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate return id: %w", err)
+	}
+
+	refundAmount := money.Zero(orderProjection.TotalPrice.Currency)
+	lineItems := make([]*pb.OrderReturnLineItem, len(req.LineItems))
+	for i, item := range req.LineItems {
+		lineItem, _ := orderProjection.GetLineItem(item.ProductId)
+
+		// The refund is a share of what is left to refund for the line item, so that rounding never
+		// refunds more than was paid and the last unit returned gets the exact remainder
+		returnedAmount, err := orderProjection.ReturnedAmount(item.ProductId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute returned amount: %w", err)
+		}
+		remainingAmount, err := lineItem.TotalPrice.Sub(returnedAmount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute refund amount: %w", err)
+		}
+		returnable := lineItem.Quantity - orderProjection.ReturnedQuantity(item.ProductId)
+		lineRefund := remainingAmount.MulRatio(int64(item.Quantity), int64(returnable))
+
+		refundAmount, err = refundAmount.Add(lineRefund)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute refund amount: %w", err)
+		}
+
+		lineItems[i] = &pb.OrderReturnLineItem{
+			ProductId:    item.ProductId,
+			Quantity:     item.Quantity,
+			RefundAmount: orders.MapMoneyToProto(lineRefund),
+		}
+	}
+
+	// Create new event
+	orderReturnRequestedEvent := &pb.OrderReturnRequested{
+		OrderId:      req.OrderId,
+		Timestamp:    timestamppb.New(now),
+		ReturnId:     returnId.String(),
+		Reason:       req.Reason,
+		LineItems:    lineItems,
+		RefundAmount: orders.MapMoneyToProto(refundAmount),
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReturnRequested, orderReturnRequestedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RequestReturnResponse{OrderId: req.OrderId, ReturnId: returnId.String()}, nil
+}
+
+func (c *Controller) ApproveReturn(ctx context.Context, req *pb.ApproveReturnRequest) (*pb.ApproveReturnResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateReturnStatus(orderProjection, req.ReturnId, orders.ReturnStatusRequested); err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	orderReturnApprovedEvent := &pb.OrderReturnApproved{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		ReturnId:  req.ReturnId,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReturnApproved, orderReturnApprovedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ApproveReturnResponse{OrderId: req.OrderId, ReturnId: req.ReturnId}, nil
+}
+
+// RejectReturn declines a requested return. Its line items can be requested again in a new return.
+func (c *Controller) RejectReturn(ctx context.Context, req *pb.RejectReturnRequest) (*pb.RejectReturnResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateReturnStatus(orderProjection, req.ReturnId, orders.ReturnStatusRequested); err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	orderReturnRejectedEvent := &pb.OrderReturnRejected{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		ReturnId:  req.ReturnId,
+		Reason:    req.Reason,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReturnRejected, orderReturnRejectedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RejectReturnResponse{OrderId: req.OrderId, ReturnId: req.ReturnId}, nil
+}
+
+// ReceiveReturn records that the items of an approved return arrived back at the vendor.
+func (c *Controller) ReceiveReturn(ctx context.Context, req *pb.ReceiveReturnRequest) (*pb.ReceiveReturnResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateReturnStatus(orderProjection, req.ReturnId, orders.ReturnStatusApproved); err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	orderReturnReceivedEvent := &pb.OrderReturnReceived{
+		OrderId:   req.OrderId,
+		Timestamp: timestamppb.Now(),
+		ReturnId:  req.ReturnId,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReturnReceived, orderReturnReceivedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ReceiveReturnResponse{OrderId: req.OrderId, ReturnId: req.ReturnId}, nil
+}
+
+// RefundReturn refunds a received return, for the amount computed when it was requested.
+func (c *Controller) RefundReturn(ctx context.Context, req *pb.RefundReturnRequest) (*pb.RefundReturnResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateReturnStatus(orderProjection, req.ReturnId, orders.ReturnStatusReceived); err != nil {
+		return nil, err
+	}
+	refundAmount := orders.MapMoneyToProto(orderProjection.GetReturn(req.ReturnId).RefundAmount)
+
+	// Create new event
+	orderReturnRefundedEvent := &pb.OrderReturnRefunded{
+		OrderId:      req.OrderId,
+		Timestamp:    timestamppb.Now(),
+		ReturnId:     req.ReturnId,
+		RefundAmount: refundAmount,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderReturnRefunded, orderReturnRefundedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.RefundReturnResponse{OrderId: req.OrderId, ReturnId: req.ReturnId, RefundAmount: refundAmount}, nil
+}
@@ -0,0 +1,314 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// deliveredOrderEvents returns the events of an order of 3 x product-789 for 27.00 EUR after a 10%
+// discount, delivered at deliveredAt.
+func deliveredOrderEvents(orderId string, deliveredAt time.Time) []eventsrc.Event {
+	orderPlacedEvent, _ := proto.Marshal(&pb.OrderPlaced{
+		OrderId:       orderId,
+		Timestamp:     timestamppb.New(deliveredAt.Add(-48 * time.Hour)),
+		VendorId:      "vendor-123",
+		CustomerId:    "customer-456",
+		PaymentMethod: "credit_card",
+		LineItems: []*pb.OrderLineItem{
+			{ProductId: "product-789", Quantity: 3, UnitAmount: eur(1000), TotalAmount: eur(2700), DiscountAmount: eur(300)},
+		},
+		SubtotalAmount: eur(3000),
+		DiscountAmount: eur(300),
+		TotalAmount:    eur(2700),
+		CouponCode:     "SAVE10",
+	})
+	orderDeliveredEvent, _ := proto.Marshal(&pb.OrderDelivered{OrderId: orderId, Timestamp: timestamppb.New(deliveredAt)})
+
+	return []eventsrc.Event{
+		{EventType: orders.EventTypeOrderPlaced, Data: orderPlacedEvent, SequenceNumber: 0},
+		{EventType: orders.EventTypeOrderPaid, Data: createValidOrderPaidEvent(orderId), SequenceNumber: 1},
+		{EventType: orders.EventTypeOrderDelivered, Data: orderDeliveredEvent, SequenceNumber: 2},
+	}
+}
+
+// returnEvents returns the events of a return of product-789 that went through the given statuses.
+func returnEvents(orderId string, returnId string, quantity int32, statuses ...string) []eventsrc.Event {
+	orderReturnRequestedEvent, _ := proto.Marshal(&pb.OrderReturnRequested{
+		OrderId:      orderId,
+		Timestamp:    timestamppb.Now(),
+		ReturnId:     returnId,
+		Reason:       "damaged",
+		LineItems:    []*pb.OrderReturnLineItem{{ProductId: "product-789", Quantity: quantity, RefundAmount: eur(900 * int64(quantity))}},
+		RefundAmount: eur(900 * int64(quantity)),
+	})
+	events := []eventsrc.Event{{EventType: orders.EventTypeOrderReturnRequested, Data: orderReturnRequestedEvent}}
+
+	for _, returnStatus := range statuses {
+		var event eventsrc.Event
+		switch returnStatus {
+		case orders.ReturnStatusApproved:
+			data, _ := proto.Marshal(&pb.OrderReturnApproved{OrderId: orderId, Timestamp: timestamppb.Now(), ReturnId: returnId})
+			event = eventsrc.Event{EventType: orders.EventTypeOrderReturnApproved, Data: data}
+		case orders.ReturnStatusRejected:
+			data, _ := proto.Marshal(&pb.OrderReturnRejected{OrderId: orderId, Timestamp: timestamppb.Now(), ReturnId: returnId, Reason: "worn"})
+			event = eventsrc.Event{EventType: orders.EventTypeOrderReturnRejected, Data: data}
+		case orders.ReturnStatusReceived:
+			data, _ := proto.Marshal(&pb.OrderReturnReceived{OrderId: orderId, Timestamp: timestamppb.Now(), ReturnId: returnId})
+			event = eventsrc.Event{EventType: orders.EventTypeOrderReturnReceived, Data: data}
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func withSequenceNumbers(events []eventsrc.Event) []eventsrc.Event {
+	for i := range events {
+		events[i].SequenceNumber = i
+	}
+	return events
+}
+
+func newReturnsTestController(events []eventsrc.Event, eventType string, sent proto.Message) (*Controller, *MockProducer) {
+	mockStore := &MockStore{}
+	mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+	mockProducer := &MockProducer{}
+	mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+		return args.EventType == eventType &&
+			args.SequenceNumber == len(events) &&
+			proto.Unmarshal(args.Value, sent) == nil
+	})).Return(nil)
+
+	return &Controller{store: mockStore, producer: mockProducer, returnWindow: 30 * 24 * time.Hour}, mockProducer
+}
+
+func TestController_RequestReturn(t *testing.T) {
+	t.Run("refund is the share of the price paid", func(t *testing.T) {
+		sent := &pb.OrderReturnRequested{}
+		events := deliveredOrderEvents("order-123", time.Now().Add(-24*time.Hour))
+		controller, mockProducer := newReturnsTestController(events, orders.EventTypeOrderReturnRequested, sent)
+
+		response, err := controller.RequestReturn(context.Background(), &pb.RequestReturnRequest{
+			OrderId:   "order-123",
+			Reason:    "damaged",
+			LineItems: []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 2}},
+		})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, "order-123", response.OrderId)
+		assert.Equal(t, sent.ReturnId, response.ReturnId)
+		assert.NotEmpty(t, response.ReturnId)
+		assert.Equal(t, int64(1800), sent.RefundAmount.AmountMinor)
+		require.Len(t, sent.LineItems, 1)
+		assert.Equal(t, int64(1800), sent.LineItems[0].RefundAmount.AmountMinor)
+	})
+
+	t.Run("rejected returns do not count towards the returned quantity", func(t *testing.T) {
+		sent := &pb.OrderReturnRequested{}
+		events := deliveredOrderEvents("order-123", time.Now().Add(-24*time.Hour))
+		events = withSequenceNumbers(append(events, returnEvents("order-123", "return-1", 3, orders.ReturnStatusRejected)...))
+		controller, mockProducer := newReturnsTestController(events, orders.EventTypeOrderReturnRequested, sent)
+
+		_, err := controller.RequestReturn(context.Background(), &pb.RequestReturnRequest{
+			OrderId:   "order-123",
+			Reason:    "damaged",
+			LineItems: []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 3}},
+		})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("refunds of a line item add up to the price paid", func(t *testing.T) {
+		deliveredAt := time.Now().Add(-24 * time.Hour)
+		orderPlacedEvent, _ := proto.Marshal(&pb.OrderPlaced{
+			OrderId:        "order-123",
+			Timestamp:      timestamppb.New(deliveredAt.Add(-48 * time.Hour)),
+			VendorId:       "vendor-123",
+			CustomerId:     "customer-456",
+			PaymentMethod:  "credit_card",
+			LineItems:      []*pb.OrderLineItem{{ProductId: "product-789", Quantity: 3, UnitAmount: eur(100), TotalAmount: eur(200), DiscountAmount: eur(100)}},
+			SubtotalAmount: eur(300),
+			DiscountAmount: eur(100),
+			TotalAmount:    eur(200),
+		})
+		orderDeliveredEvent, _ := proto.Marshal(&pb.OrderDelivered{OrderId: "order-123", Timestamp: timestamppb.New(deliveredAt)})
+		events := []eventsrc.Event{
+			{EventType: orders.EventTypeOrderPlaced, Data: orderPlacedEvent},
+			{EventType: orders.EventTypeOrderPaid, Data: createValidOrderPaidEvent("order-123")},
+			{EventType: orders.EventTypeOrderDelivered, Data: orderDeliveredEvent},
+		}
+
+		// Each unit is returned separately, the last one getting the remainder
+		var refunds []int64
+		for range 3 {
+			sent := &pb.OrderReturnRequested{}
+			events = withSequenceNumbers(events)
+			controller, _ := newReturnsTestController(events, orders.EventTypeOrderReturnRequested, sent)
+
+			_, err := controller.RequestReturn(context.Background(), &pb.RequestReturnRequest{
+				OrderId:   "order-123",
+				Reason:    "damaged",
+				LineItems: []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 1}},
+			})
+			require.NoError(t, err)
+
+			refunds = append(refunds, sent.RefundAmount.AmountMinor)
+			data, _ := proto.Marshal(sent)
+			events = append(events, eventsrc.Event{EventType: orders.EventTypeOrderReturnRequested, Data: data})
+		}
+
+		assert.Equal(t, []int64{67, 66, 67}, refunds)
+	})
+
+	tests := []struct {
+		name        string
+		events      []eventsrc.Event
+		lineItems   []*pb.ReturnLineItem
+		expectedErr error
+	}{
+		{
+			name:        "order not found",
+			events:      []eventsrc.Event{},
+			lineItems:   []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 1}},
+			expectedErr: ErrOrderNotFound,
+		},
+		{
+			name:        "order not delivered",
+			events:      paidOrderEvents("order-123"),
+			lineItems:   []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 1}},
+			expectedErr: ErrOrderNotDelivered,
+		},
+		{
+			name:        "return window closed",
+			events:      deliveredOrderEvents("order-123", time.Now().Add(-31*24*time.Hour)),
+			lineItems:   []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 1}},
+			expectedErr: ErrReturnWindowClosed,
+		},
+		{
+			name:        "product not in the order",
+			events:      deliveredOrderEvents("order-123", time.Now()),
+			lineItems:   []*pb.ReturnLineItem{{ProductId: "product-790", Quantity: 1}},
+			expectedErr: status.Errorf(codes.InvalidArgument, "product product-790 is not part of the order"),
+		},
+		{
+			name: "quantity already returned",
+			events: withSequenceNumbers(append(
+				deliveredOrderEvents("order-123", time.Now()),
+				returnEvents("order-123", "return-1", 2, orders.ReturnStatusApproved)...,
+			)),
+			lineItems:   []*pb.ReturnLineItem{{ProductId: "product-789", Quantity: 2}},
+			expectedErr: status.Errorf(codes.InvalidArgument, "only 1 of product product-789 can be returned"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, mockProducer := newReturnsTestController(tt.events, orders.EventTypeOrderReturnRequested, &pb.OrderReturnRequested{})
+
+			_, err := controller.RequestReturn(context.Background(), &pb.RequestReturnRequest{
+				OrderId:   "order-123",
+				Reason:    "damaged",
+				LineItems: tt.lineItems,
+			})
+
+			assert.Equal(t, tt.expectedErr, err)
+			mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestController_ReturnTransitions(t *testing.T) {
+	delivered := deliveredOrderEvents("order-123", time.Now())
+	withReturn := func(statuses ...string) []eventsrc.Event {
+		events := append([]eventsrc.Event{}, delivered...)
+		return withSequenceNumbers(append(events, returnEvents("order-123", "return-1", 1, statuses...)...))
+	}
+
+	t.Run("approve a requested return", func(t *testing.T) {
+		sent := &pb.OrderReturnApproved{}
+		controller, mockProducer := newReturnsTestController(withReturn(), orders.EventTypeOrderReturnApproved, sent)
+
+		_, err := controller.ApproveReturn(context.Background(), &pb.ApproveReturnRequest{OrderId: "order-123", ReturnId: "return-1"})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, "return-1", sent.ReturnId)
+	})
+
+	t.Run("reject a requested return", func(t *testing.T) {
+		sent := &pb.OrderReturnRejected{}
+		controller, mockProducer := newReturnsTestController(withReturn(), orders.EventTypeOrderReturnRejected, sent)
+
+		_, err := controller.RejectReturn(context.Background(), &pb.RejectReturnRequest{OrderId: "order-123", ReturnId: "return-1", Reason: "worn"})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, "worn", sent.Reason)
+	})
+
+	t.Run("receive an approved return", func(t *testing.T) {
+		sent := &pb.OrderReturnReceived{}
+		controller, mockProducer := newReturnsTestController(withReturn(orders.ReturnStatusApproved), orders.EventTypeOrderReturnReceived, sent)
+
+		_, err := controller.ReceiveReturn(context.Background(), &pb.ReceiveReturnRequest{OrderId: "order-123", ReturnId: "return-1"})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("refund a received return", func(t *testing.T) {
+		sent := &pb.OrderReturnRefunded{}
+		events := withReturn(orders.ReturnStatusApproved, orders.ReturnStatusReceived)
+		controller, mockProducer := newReturnsTestController(events, orders.EventTypeOrderReturnRefunded, sent)
+
+		response, err := controller.RefundReturn(context.Background(), &pb.RefundReturnRequest{OrderId: "order-123", ReturnId: "return-1"})
+
+		require.NoError(t, err)
+		mockProducer.AssertExpectations(t)
+		assert.Equal(t, int64(900), sent.RefundAmount.AmountMinor)
+		assert.Equal(t, int64(900), response.RefundAmount.AmountMinor)
+	})
+
+	t.Run("refund a return that was not received", func(t *testing.T) {
+		controller, mockProducer := newReturnsTestController(withReturn(orders.ReturnStatusApproved), orders.EventTypeOrderReturnRefunded, &pb.OrderReturnRefunded{})
+
+		_, err := controller.RefundReturn(context.Background(), &pb.RefundReturnRequest{OrderId: "order-123", ReturnId: "return-1"})
+
+		assert.Equal(t, status.Errorf(codes.FailedPrecondition, "return is approved, expected received"), err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("approve a rejected return", func(t *testing.T) {
+		controller, mockProducer := newReturnsTestController(withReturn(orders.ReturnStatusRejected), orders.EventTypeOrderReturnApproved, &pb.OrderReturnApproved{})
+
+		_, err := controller.ApproveReturn(context.Background(), &pb.ApproveReturnRequest{OrderId: "order-123", ReturnId: "return-1"})
+
+		assert.Equal(t, status.Errorf(codes.FailedPrecondition, "return is rejected, expected requested"), err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("return not found", func(t *testing.T) {
+		controller, mockProducer := newReturnsTestController(withReturn(), orders.EventTypeOrderReturnApproved, &pb.OrderReturnApproved{})
+
+		_, err := controller.ApproveReturn(context.Background(), &pb.ApproveReturnRequest{OrderId: "order-123", ReturnId: "return-2"})
+
+		assert.Equal(t, ErrReturnNotFound, err)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func validateAttachShipmentRequest(req *pb.AttachShipmentRequest, projection *orders.OrderProjection) error {
+
+	// Make sure that the order does not already have a shipment
+	if projection.Shipment != nil {
+		return status.Errorf(codes.FailedPrecondition, "order already has a shipment")
+	}
+
+	// Attaching a shipment puts the order in transit, so the shipping status rules apply
+	return validateUpdateShippingStatusRequest(&pb.UpdateOrderShippingStatusRequest{
+		OrderId: req.OrderId,
+		Status:  pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT,
+	}, projection)
+}
+
+func validateUpdateShipmentTrackingRequest(projection *orders.OrderProjection) error {
+
+	// Make sure that the order has been shipped
+	if projection.Shipment == nil {
+		return status.Errorf(codes.FailedPrecondition, "order has no shipment")
+	}
+
+	// Make sure that the shipment is still in progress
+	if projection.ShippingStatus == orders.ShippingStatusCancelled {
+		return status.Errorf(codes.FailedPrecondition, "order is cancelled")
+	}
+	if projection.ShippingStatus == orders.ShippingStatusDelivered {
+		return status.Errorf(codes.FailedPrecondition, "order has already been delivered")
+	}
+
+	return nil
+}
+
+// AttachShipment records the carrier and tracking number of an order, which puts it in transit.
+func (c *Controller) AttachShipment(ctx context.Context, req *pb.AttachShipmentRequest) (*pb.AttachShipmentResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateAttachShipmentRequest(req, orderProjection); err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	orderShipmentCreatedEvent := &pb.OrderShipmentCreated{
+		OrderId:             req.OrderId,
+		Timestamp:           timestamppb.Now(),
+		Carrier:             req.Carrier,
+		TrackingNumber:      req.TrackingNumber,
+		EstimatedDeliveryAt: req.EstimatedDeliveryAt,
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, orders.EventTypeOrderShipmentCreated, orderShipmentCreatedEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AttachShipmentResponse{
+		OrderId: req.OrderId,
+	}, nil
+}
+
+// UpdateShipmentTracking records a tracking update from the carrier of an order.
+func (c *Controller) UpdateShipmentTracking(ctx context.Context, req *pb.UpdateShipmentTrackingRequest) (*pb.UpdateShipmentTrackingResponse, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, req.OrderId)
+	if err != nil {
+		return nil, err
+	}
+	if orderProjection == nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if err := validateUpdateShipmentTrackingRequest(orderProjection); err != nil {
+		return nil, err
+	}
+
+	// Create new event
+	var eventType string
+	var event proto.Message
+	switch update := req.Update.(type) {
+	case *pb.UpdateShipmentTrackingRequest_EtaUpdated:
+		eventType = orders.EventTypeOrderShipmentEtaUpdated
+		event = &pb.OrderShipmentEtaUpdated{
+			OrderId:             req.OrderId,
+			Timestamp:           timestamppb.Now(),
+			EstimatedDeliveryAt: update.EtaUpdated.EstimatedDeliveryAt,
+		}
+	case *pb.UpdateShipmentTrackingRequest_DeliveryAttempted:
+		eventType = orders.EventTypeOrderDeliveryAttempted
+		event = &pb.OrderDeliveryAttempted{
+			OrderId:   req.OrderId,
+			Timestamp: timestamppb.Now(),
+			Reason:    update.DeliveryAttempted.Reason,
+		}
+	case *pb.UpdateShipmentTrackingRequest_Delivered:
+		eventType = orders.EventTypeOrderDelivered
+		event = &pb.OrderDelivered{
+			OrderId:   req.OrderId,
+			Timestamp: timestamppb.Now(),
+			Proof:     update.Delivered,
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown shipment tracking update")
+	}
+
+	err = c.sendEvent(ctx, req.OrderId, curSeqNum+1, eventType, event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UpdateShipmentTrackingResponse{
+		OrderId: req.OrderId,
+	}, nil
+}
+
+// FlagShipmentOverdue flags an order that is still waiting for shipment at its shipment deadline.
+// Returns whether the order was flagged. Orders that were shipped, cancelled or already flagged are left untouched.
+func (c *Controller) FlagShipmentOverdue(ctx context.Context, orderId string, dueAt time.Time) (bool, error) {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, orderId)
+	if err != nil {
+		return false, err
+	}
+	if orderProjection == nil {
+		return false, ErrOrderNotFound
+	}
+
+	if orderProjection.ShipmentOverdue || orderProjection.ShippingStatus != orders.ShippingStatusWaitingForShipment {
+		return false, nil
+	}
+
+	// Create new event
+	orderShipmentOverdueEvent := &pb.OrderShipmentOverdue{
+		OrderId:   orderId,
+		Timestamp: timestamppb.Now(),
+		DueAt:     timestamppb.New(dueAt),
+	}
+
+	err = c.sendEvent(ctx, orderId, curSeqNum+1, orders.EventTypeOrderShipmentOverdue, orderShipmentOverdueEvent)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
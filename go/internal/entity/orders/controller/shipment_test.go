@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func createValidOrderShipmentCreatedEvent(orderId string) []byte {
+	event := &pb.OrderShipmentCreated{
+		OrderId:        orderId,
+		Timestamp:      timestamppb.Now(),
+		Carrier:        "ups",
+		TrackingNumber: "1Z999AA10123456784",
+	}
+
+	eventBytes, _ := proto.Marshal(event)
+	return eventBytes
+}
+
+func paidOrderEvents(orderId string) []eventsrc.Event {
+	return []eventsrc.Event{
+		{
+			EventType:      orders.EventTypeOrderPlaced,
+			Data:           createValidOrderPlacedEvent(orderId, "credit_card"),
+			SequenceNumber: 0,
+		},
+		{
+			EventType:      orders.EventTypeOrderPaid,
+			Data:           createValidOrderPaidEvent(orderId),
+			SequenceNumber: 1,
+		},
+	}
+}
+
+func TestController_AttachShipment(t *testing.T) {
+	t.Run("successful shipment attachment", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(paidOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderShipmentCreated
+			return args.AggregateID == "order-123" &&
+				args.EventType == orders.EventTypeOrderShipmentCreated &&
+				args.SequenceNumber == 2 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Carrier == "ups" &&
+				event.TrackingNumber == "1Z999AA10123456784"
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		response, err := controller.AttachShipment(context.Background(), &pb.AttachShipmentRequest{
+			OrderId:        "order-123",
+			Carrier:        "ups",
+			TrackingNumber: "1Z999AA10123456784",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("shipment already attached", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		events := append(paidOrderEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderShipmentCreated,
+			Data:           createValidOrderShipmentCreatedEvent("order-123"),
+			SequenceNumber: 2,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		response, err := controller.AttachShipment(context.Background(), &pb.AttachShipmentRequest{
+			OrderId:        "order-123",
+			Carrier:        "fedex",
+			TrackingNumber: "123456789012",
+		})
+
+		assert.Nil(t, response)
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Contains(t, st.Message(), "order already has a shipment")
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("order not paid", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(paidOrderEvents("order-123")[:1], nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		_, err := controller.AttachShipment(context.Background(), &pb.AttachShipmentRequest{
+			OrderId:        "order-123",
+			Carrier:        "ups",
+			TrackingNumber: "1Z999AA10123456784",
+		})
+
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Contains(t, st.Message(), "order has not been paid")
+	})
+}
+
+func TestController_UpdateShipmentTracking(t *testing.T) {
+	t.Run("delivery with proof", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		events := append(paidOrderEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderShipmentCreated,
+			Data:           createValidOrderShipmentCreatedEvent("order-123"),
+			SequenceNumber: 2,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderDelivered
+			return args.EventType == orders.EventTypeOrderDelivered &&
+				args.SequenceNumber == 3 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.Proof.GetSignedBy() == "J. Doe"
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		response, err := controller.UpdateShipmentTracking(context.Background(), &pb.UpdateShipmentTrackingRequest{
+			OrderId: "order-123",
+			Update: &pb.UpdateShipmentTrackingRequest_Delivered{
+				Delivered: &pb.DeliveryProof{SignedBy: "J. Doe"},
+			},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "order-123", response.OrderId)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("order without shipment", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(paidOrderEvents("order-123"), nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		_, err := controller.UpdateShipmentTracking(context.Background(), &pb.UpdateShipmentTrackingRequest{
+			OrderId: "order-123",
+			Update: &pb.UpdateShipmentTrackingRequest_DeliveryAttempted{
+				DeliveryAttempted: &pb.DeliveryAttempt{Reason: "nobody home"},
+			},
+		})
+
+		st, _ := status.FromError(err)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Contains(t, st.Message(), "order has no shipment")
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
+
+func TestController_FlagShipmentOverdue(t *testing.T) {
+	dueAt := time.Date(2025, 1, 4, 12, 0, 0, 0, time.UTC)
+
+	t.Run("flags a paid order waiting for shipment", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(paidOrderEvents("order-123"), nil)
+		mockProducer.On("Send", mock.Anything, mock.MatchedBy(func(args *eventsrc.SendArgs) bool {
+			var event pb.OrderShipmentOverdue
+			return args.AggregateID == "order-123" &&
+				args.EventType == orders.EventTypeOrderShipmentOverdue &&
+				args.SequenceNumber == 2 &&
+				proto.Unmarshal(args.Value, &event) == nil &&
+				event.DueAt.AsTime().Equal(dueAt)
+		})).Return(nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		flagged, err := controller.FlagShipmentOverdue(context.Background(), "order-123", dueAt)
+
+		assert.NoError(t, err)
+		assert.True(t, flagged)
+		mockProducer.AssertExpectations(t)
+	})
+
+	t.Run("shipped order is left untouched", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		events := append(paidOrderEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderShipmentCreated,
+			Data:           createValidOrderShipmentCreatedEvent("order-123"),
+			SequenceNumber: 2,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		flagged, err := controller.FlagShipmentOverdue(context.Background(), "order-123", dueAt)
+
+		assert.NoError(t, err)
+		assert.False(t, flagged)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+
+	t.Run("order is flagged once", func(t *testing.T) {
+		mockStore := &MockStore{}
+		mockProducer := &MockProducer{}
+
+		overdueEventBytes, _ := proto.Marshal(&pb.OrderShipmentOverdue{OrderId: "order-123", Timestamp: timestamppb.Now(), DueAt: timestamppb.New(dueAt)})
+		events := append(paidOrderEvents("order-123"), eventsrc.Event{
+			EventType:      orders.EventTypeOrderShipmentOverdue,
+			Data:           overdueEventBytes,
+			SequenceNumber: 2,
+		})
+		mockStore.On("ListByAggregateID", mock.Anything, "order-123", orders.AggregateTypeOrder).Return(events, nil)
+
+		controller := &Controller{store: mockStore, producer: mockProducer}
+
+		flagged, err := controller.FlagShipmentOverdue(context.Background(), "order-123", dueAt)
+
+		assert.NoError(t, err)
+		assert.False(t, flagged)
+		mockProducer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+	})
+}
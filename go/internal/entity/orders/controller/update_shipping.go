@@ -21,6 +21,11 @@ func validateUpdateShippingStatusRequest(req *pb.UpdateOrderShippingStatusReques
 		return status.Errorf(codes.FailedPrecondition, "order has not been paid")
 	}
 
+	// Make sure that the order is not waiting for stock
+	if projection.StockStatus == orders.StockStatusBackordered {
+		return status.Errorf(codes.FailedPrecondition, "order is back-ordered")
+	}
+
 	// Make sure they aren't cancelling the order
 	if req.Status == pb.ShippingStatus_SHIPPING_STATUS_CANCELLED {
 		return status.Errorf(codes.FailedPrecondition, "cannot cancel the order when updating shipping status. Please cancel the order instead.")
@@ -255,6 +255,26 @@ func TestValidateUpdateShippingStatusRequest(t *testing.T) {
 		assert.Contains(t, st.Message(), "order has not been paid")
 	})
 
+	t.Run("order back-ordered", func(t *testing.T) {
+		projection := &orders.OrderProjection{
+			PaymentStatus:  orders.PaymentStatusPaid,
+			ShippingStatus: orders.ShippingStatusWaitingForShipment,
+			StockStatus:    orders.StockStatusBackordered,
+		}
+
+		request := &pb.UpdateOrderShippingStatusRequest{
+			OrderId: "order-123",
+			Status:  pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT,
+		}
+
+		err := validateUpdateShippingStatusRequest(request, projection)
+		assert.Error(t, err)
+		st, ok := status.FromError(err)
+		assert.True(t, ok)
+		assert.Equal(t, codes.FailedPrecondition, st.Code())
+		assert.Contains(t, st.Message(), "order is back-ordered")
+	})
+
 	t.Run("cancelling the order", func(t *testing.T) {
 		projection := &orders.OrderProjection{
 			PaymentStatus:  orders.PaymentStatusPaid,
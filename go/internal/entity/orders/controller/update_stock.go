@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// UpdateStockStatus records the state of the stock reserved by the inventory for a line item of an order.
+// Updating a line item to its current stock status, or a line item the order no longer has, is a no-op.
+func (c *Controller) UpdateStockStatus(ctx context.Context, orderId string, productId string, stockStatus pb.StockStatus) error {
+
+	// Fetch the order projection
+	orderProjection, curSeqNum, err := c.GetProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if orderProjection == nil {
+		return ErrOrderNotFound
+	}
+
+	// The line item may have been removed by an amendment, its stock being released
+	lineItem, ok := orderProjection.GetLineItem(productId)
+	if !ok {
+		return nil
+	}
+	if lineItem.StockStatus == orders.MapStockStatusToStr(stockStatus) {
+		return nil
+	}
+
+	// Create new event
+	orderStockStatusUpdatedEvent := &pb.OrderStockStatusUpdated{
+		OrderId:   orderId,
+		Timestamp: timestamppb.Now(),
+		Status:    stockStatus,
+		ProductId: productId,
+	}
+
+	orderStockStatusUpdatedEventBytes, err := proto.Marshal(orderStockStatusUpdatedEvent)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order stock status updated event: %w", err)
+	}
+
+	err = c.producer.Send(ctx, &eventsrc.SendArgs{
+		SequenceNumber: curSeqNum + 1,
+		AggregateID:    orderId,
+		AggregateType:  orders.AggregateTypeOrder,
+		EventType:      orders.EventTypeOrderStockStatusUpdated,
+		Value:          orderStockStatusUpdatedEventBytes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send order stock status updated event: %w", err)
+	}
+
+	return nil
+}
@@ -5,8 +5,27 @@ const (
 	EventTypeOrderPaid                  = "order_paid"
 	EventTypeOrderPaymentInitiated      = "order_payment_initiated"
 	EventTypeOrderPaymentFailed         = "order_payment_failed"
+	EventTypeOrderPaymentSubmitted      = "order_payment_submitted"
+	EventTypeOrderPaymentRefunded       = "order_payment_refunded"
+	EventTypeOrderRefundRequested       = "order_refund_requested"
 	EventTypeOrderCancelled             = "order_cancelled"
 	EventTypeOrderShippingStatusUpdated = "order_shipping_status_updated"
+	EventTypeOrderAddressChanged        = "order_address_changed"
+	EventTypeOrderAddressRedacted       = "order_address_redacted"
+	EventTypeOrderAmended               = "order_amended"
+	EventTypeOrderHeld                  = "order_held"
+	EventTypeOrderReleased              = "order_released"
+	EventTypeOrderStockStatusUpdated    = "order_stock_status_updated"
+	EventTypeOrderShipmentCreated       = "order_shipment_created"
+	EventTypeOrderShipmentEtaUpdated    = "order_shipment_eta_updated"
+	EventTypeOrderDeliveryAttempted     = "order_delivery_attempted"
+	EventTypeOrderDelivered             = "order_delivered"
+	EventTypeOrderShipmentOverdue       = "order_shipment_overdue"
+	EventTypeOrderReturnRequested       = "order_return_requested"
+	EventTypeOrderReturnApproved        = "order_return_approved"
+	EventTypeOrderReturnRejected        = "order_return_rejected"
+	EventTypeOrderReturnReceived        = "order_return_received"
+	EventTypeOrderReturnRefunded        = "order_return_refunded"
 
 	AggregateTypeOrder = "order"
 )
@@ -2,23 +2,134 @@ package orders
 
 import (
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (proj *OrderProjection) ToOrderDetails() *pb.OrderDetails {
-	return &pb.OrderDetails{
-		OrderId:        proj.OrderId,
-		CustomerId:     proj.CustomerId,
-		VendorId:       proj.VendorId,
-		ProductId:      proj.ProductId,
-		Quantity:       int32(proj.Quantity),
-		TotalPrice:     proj.TotalPrice,
-		PaymentMethod:  proj.PaymentMethod,
-		ShippingStatus: MapStrToShippingStatus(proj.ShippingStatus),
-		PaymentStatus:  MapStrToPaymentStatus(proj.PaymentStatus),
-		CreatedAt:      timestamppb.New(proj.CreatedAt),
-		UpdatedAt:      timestamppb.New(proj.UpdatedAt),
+	details := &pb.OrderDetails{
+		OrderId:          proj.OrderId,
+		CustomerId:       proj.CustomerId,
+		VendorId:         proj.VendorId,
+		TotalPrice:       MapMoneyToProto(proj.TotalPrice),
+		SubtotalPrice:    MapMoneyToProto(proj.SubtotalPrice),
+		Discount:         MapMoneyToProto(proj.Discount),
+		CouponCode:       proj.CouponCode,
+		PaymentMethod:    proj.PaymentMethod,
+		ShippingStatus:   MapStrToShippingStatus(proj.ShippingStatus),
+		PaymentStatus:    MapStrToPaymentStatus(proj.PaymentStatus),
+		StockStatus:      MapStrToStockStatus(proj.StockStatus),
+		OnHold:           proj.OnHold,
+		FraudScore:       proj.FraudScore,
+		HoldReasons:      proj.HoldReasons,
+		ShipmentOverdue:  proj.ShipmentOverdue,
+		PaymentReference: proj.PaymentReference,
+		CreatedAt:        timestamppb.New(proj.CreatedAt),
+		UpdatedAt:        timestamppb.New(proj.UpdatedAt),
 	}
+
+	details.LineItems = make([]*pb.LineItemDetails, len(proj.LineItems))
+	for i, item := range proj.LineItems {
+		details.LineItems[i] = item.ToLineItemDetails()
+	}
+
+	if proj.Shipment != nil {
+		details.Shipment = proj.Shipment.ToShipmentDetails()
+	}
+	if proj.DeliveredAt != nil {
+		details.DeliveredAt = timestamppb.New(*proj.DeliveredAt)
+	}
+
+	details.Returns = make([]*pb.ReturnDetails, len(proj.Returns))
+	for i, orderReturn := range proj.Returns {
+		details.Returns[i] = orderReturn.ToReturnDetails()
+	}
+
+	return details
+}
+
+func (item LineItem) ToLineItemDetails() *pb.LineItemDetails {
+	return &pb.LineItemDetails{
+		ProductId:   item.ProductId,
+		Quantity:    item.Quantity,
+		UnitPrice:   MapMoneyToProto(item.UnitPrice),
+		TotalPrice:  MapMoneyToProto(item.TotalPrice),
+		Discount:    MapMoneyToProto(item.Discount),
+		StockStatus: MapStrToStockStatus(item.StockStatus),
+	}
+}
+
+func (item LineItem) ToOrderLineItem() *pb.OrderLineItem {
+	return &pb.OrderLineItem{
+		ProductId:      item.ProductId,
+		Quantity:       item.Quantity,
+		UnitAmount:     MapMoneyToProto(item.UnitPrice),
+		TotalAmount:    MapMoneyToProto(item.TotalPrice),
+		DiscountAmount: MapMoneyToProto(item.Discount),
+	}
+}
+
+func (orderReturn Return) ToReturnDetails() *pb.ReturnDetails {
+	details := &pb.ReturnDetails{
+		ReturnId:        orderReturn.ReturnId,
+		Status:          MapStrToReturnStatus(orderReturn.Status),
+		Reason:          orderReturn.Reason,
+		RefundAmount:    MapMoneyToProto(orderReturn.RefundAmount),
+		RejectionReason: orderReturn.RejectionReason,
+		RequestedAt:     timestamppb.New(orderReturn.RequestedAt),
+		UpdatedAt:       timestamppb.New(orderReturn.UpdatedAt),
+	}
+
+	details.LineItems = make([]*pb.ReturnLineItemDetails, len(orderReturn.LineItems))
+	for i, item := range orderReturn.LineItems {
+		details.LineItems[i] = &pb.ReturnLineItemDetails{
+			ProductId:    item.ProductId,
+			Quantity:     item.Quantity,
+			RefundAmount: MapMoneyToProto(item.RefundAmount),
+		}
+	}
+
+	return details
+}
+
+func (shipment *Shipment) ToShipmentDetails() *pb.ShipmentDetails {
+	details := &pb.ShipmentDetails{
+		Carrier:          shipment.Carrier,
+		TrackingNumber:   shipment.TrackingNumber,
+		DeliveryAttempts: shipment.DeliveryAttempts,
+	}
+
+	if shipment.EstimatedDeliveryAt != nil {
+		details.EstimatedDeliveryAt = timestamppb.New(*shipment.EstimatedDeliveryAt)
+	}
+	if shipment.DeliveredAt != nil {
+		details.DeliveredAt = timestamppb.New(*shipment.DeliveredAt)
+		details.Proof = &pb.DeliveryProof{
+			SignedBy: shipment.ProofSignedBy,
+			PhotoUrl: shipment.ProofPhotoUrl,
+		}
+	}
+
+	return details
+}
+
+func MapMoneyToProto(amount money.Money) *pb.Money {
+	return &pb.Money{
+		CurrencyCode: amount.Currency,
+		AmountMinor:  amount.Amount,
+	}
+}
+
+func MapProtoToMoney(amount *pb.Money) money.Money {
+	return money.Money{
+		Currency: amount.GetCurrencyCode(),
+		Amount:   amount.GetAmountMinor(),
+	}
+}
+
+// ParseProtoMoney converts an amount received in a request, rejecting unknown currencies.
+func ParseProtoMoney(amount *pb.Money) (money.Money, error) {
+	return money.New(amount.GetCurrencyCode(), amount.GetAmountMinor())
 }
 
 func MapStrToShippingStatus(status string) pb.ShippingStatus {
@@ -66,7 +177,68 @@ func MapStrToPaymentStatus(status string) pb.PaymentStatus {
 		return pb.PaymentStatus_PAYMENT_STATUS_PAID
 	case PaymentStatusFailed:
 		return pb.PaymentStatus_PAYMENT_STATUS_FAILED
+	case PaymentStatusAwaitingConfirmation:
+		return pb.PaymentStatus_PAYMENT_STATUS_AWAITING_CONFIRMATION
+	case PaymentStatusRefunded:
+		return pb.PaymentStatus_PAYMENT_STATUS_REFUNDED
+	case PaymentStatusRefundPending:
+		return pb.PaymentStatus_PAYMENT_STATUS_REFUND_PENDING
 	}
 
 	return pb.PaymentStatus_PAYMENT_STATUS_UNSPECIFIED
 }
+
+func MapStrToStockStatus(status string) pb.StockStatus {
+	switch status {
+	case StockStatusReserved:
+		return pb.StockStatus_STOCK_STATUS_RESERVED
+	case StockStatusBackordered:
+		return pb.StockStatus_STOCK_STATUS_BACKORDERED
+	case StockStatusCommitted:
+		return pb.StockStatus_STOCK_STATUS_COMMITTED
+	case StockStatusReleased:
+		return pb.StockStatus_STOCK_STATUS_RELEASED
+	}
+
+	return pb.StockStatus_STOCK_STATUS_UNSPECIFIED
+}
+
+func MapStockStatusToStr(status pb.StockStatus) string {
+	switch status {
+	case pb.StockStatus_STOCK_STATUS_RESERVED:
+		return StockStatusReserved
+	case pb.StockStatus_STOCK_STATUS_BACKORDERED:
+		return StockStatusBackordered
+	case pb.StockStatus_STOCK_STATUS_COMMITTED:
+		return StockStatusCommitted
+	case pb.StockStatus_STOCK_STATUS_RELEASED:
+		return StockStatusReleased
+	case pb.StockStatus_STOCK_STATUS_UNSPECIFIED:
+		return StockStatusUnspecified
+	}
+	return ""
+}
+
+func MapStrToReturnStatus(status string) pb.ReturnStatus {
+	switch status {
+	case ReturnStatusRequested:
+		return pb.ReturnStatus_RETURN_STATUS_REQUESTED
+	case ReturnStatusApproved:
+		return pb.ReturnStatus_RETURN_STATUS_APPROVED
+	case ReturnStatusRejected:
+		return pb.ReturnStatus_RETURN_STATUS_REJECTED
+	case ReturnStatusReceived:
+		return pb.ReturnStatus_RETURN_STATUS_RECEIVED
+	case ReturnStatusRefunded:
+		return pb.ReturnStatus_RETURN_STATUS_REFUNDED
+	}
+
+	return pb.ReturnStatus_RETURN_STATUS_UNSPECIFIED
+}
+
+func mapSealedAddress(address *pb.SealedAddress) *SealedAddress {
+	if address == nil {
+		return nil
+	}
+	return &SealedAddress{KeyId: address.KeyId, Ciphertext: address.Ciphertext}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -14,12 +15,13 @@ func TestOrderProjection_ToOrderDetails(t *testing.T) {
 	updatedAt := time.Date(2023, 1, 16, 14, 45, 0, 0, time.UTC)
 
 	projection := &OrderProjection{
-		OrderId:        "order-123",
-		CustomerId:     "customer-456",
-		VendorId:       "vendor-789",
-		ProductId:      "product-101",
-		Quantity:       3,
-		TotalPrice:     99.99,
+		OrderId:    "order-123",
+		CustomerId: "customer-456",
+		VendorId:   "vendor-789",
+		LineItems: []LineItem{
+			{ProductId: "product-101", Quantity: 3, UnitPrice: usd(3333), TotalPrice: usd(9999), StockStatus: StockStatusReserved},
+		},
+		TotalPrice:     usd(9999),
 		PaymentMethod:  "credit_card",
 		PaymentStatus:  PaymentStatusPaid,
 		ShippingStatus: ShippingStatusInTransit,
@@ -33,9 +35,13 @@ func TestOrderProjection_ToOrderDetails(t *testing.T) {
 	assert.Equal(t, "order-123", orderDetails.OrderId)
 	assert.Equal(t, "customer-456", orderDetails.CustomerId)
 	assert.Equal(t, "vendor-789", orderDetails.VendorId)
-	assert.Equal(t, "product-101", orderDetails.ProductId)
-	assert.Equal(t, int32(3), orderDetails.Quantity)
-	assert.Equal(t, 99.99, orderDetails.TotalPrice)
+	require.Len(t, orderDetails.LineItems, 1)
+	assert.Equal(t, "product-101", orderDetails.LineItems[0].ProductId)
+	assert.Equal(t, int32(3), orderDetails.LineItems[0].Quantity)
+	assert.Equal(t, &pb.Money{CurrencyCode: "USD", AmountMinor: 3333}, orderDetails.LineItems[0].UnitPrice)
+	assert.Equal(t, &pb.Money{CurrencyCode: "USD", AmountMinor: 9999}, orderDetails.LineItems[0].TotalPrice)
+	assert.Equal(t, pb.StockStatus_STOCK_STATUS_RESERVED, orderDetails.LineItems[0].StockStatus)
+	assert.Equal(t, &pb.Money{CurrencyCode: "USD", AmountMinor: 9999}, orderDetails.TotalPrice)
 	assert.Equal(t, "credit_card", orderDetails.PaymentMethod)
 	assert.Equal(t, pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT, orderDetails.ShippingStatus)
 	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_PAID, orderDetails.PaymentStatus)
@@ -115,3 +121,12 @@ func TestMapStrToPaymentStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestParseProtoMoney(t *testing.T) {
+	amount, err := ParseProtoMoney(&pb.Money{CurrencyCode: "USD", AmountMinor: 1500})
+	require.NoError(t, err)
+	assert.Equal(t, money.Money{Currency: "USD", Amount: 1500}, amount)
+
+	_, err = ParseProtoMoney(&pb.Money{CurrencyCode: "XYZ", AmountMinor: 1500})
+	assert.ErrorIs(t, err, money.ErrUnknownCurrency)
+}
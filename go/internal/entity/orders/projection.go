@@ -5,16 +5,27 @@ import (
 	"time"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
 	"google.golang.org/protobuf/proto"
 )
 
 const (
+	// LegacyCurrency is the currency of the orders placed before amounts carried a currency.
+	LegacyCurrency = "USD"
+
 	// Payment status enum
 	PaymentStatusPending   = "pending"
 	PaymentStatusInitiated = "initiated"
 	PaymentStatusPaid      = "paid"
 	PaymentStatusFailed    = "failed"
 
+	// PaymentStatusAwaitingConfirmation is the in-flight state of an asynchronous payment,
+	// between submission to the payment provider and its confirmation.
+	PaymentStatusAwaitingConfirmation = "awaiting_confirmation"
+	PaymentStatusRefunded             = "refunded"
+	// PaymentStatusRefundPending is the state of a captured payment that the payment provider has yet to refund.
+	PaymentStatusRefundPending = "refund_pending"
+
 	// Shipping status enum
 	ShippingStatusUnspecified        = "unspecified"
 	ShippingStatusWaitingForPayment  = "waiting_for_payment"
@@ -22,20 +33,108 @@ const (
 	ShippingStatusInTransit          = "in_transit"
 	ShippingStatusDelivered          = "delivered"
 	ShippingStatusCancelled          = "cancelled"
+
+	// Stock status enum
+	StockStatusUnspecified = "unspecified"
+	StockStatusReserved    = "reserved"
+	StockStatusBackordered = "backordered"
+	StockStatusCommitted   = "committed"
+	StockStatusReleased    = "released"
+
+	// Return status enum
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
 )
 
+type Shipment struct {
+	Carrier             string
+	TrackingNumber      string
+	EstimatedDeliveryAt *time.Time
+	DeliveryAttempts    int32
+	DeliveredAt         *time.Time
+	ProofSignedBy       string
+	ProofPhotoUrl       string
+}
+
+type LineItem struct {
+	ProductId string
+	Quantity  int32
+	UnitPrice money.Money
+	// TotalPrice is the price of the line item after its share of the discount.
+	TotalPrice  money.Money
+	Discount    money.Money
+	StockStatus string
+}
+
+type ReturnLineItem struct {
+	ProductId string
+	Quantity  int32
+	// RefundAmount is the share of the price paid for the line item that is refunded.
+	RefundAmount money.Money
+}
+
+// Return is a request of the customer to send back line items of a delivered order.
+type Return struct {
+	ReturnId        string
+	Status          string
+	Reason          string
+	RejectionReason string
+	LineItems       []ReturnLineItem
+	RefundAmount    money.Money
+	RequestedAt     time.Time
+	UpdatedAt       time.Time
+}
+
+// SealedAddress is a shipping address encrypted with the data key of its order. It can only be
+// opened while the key exists, see pii.Vault.
+type SealedAddress struct {
+	KeyId      string
+	Ciphertext []byte
+}
+
 type OrderProjection struct {
 	OrderId        string
 	CustomerId     string
 	VendorId       string
-	ProductId      string
-	Quantity       int32
-	TotalPrice     float64
+	LineItems      []LineItem
+	TotalPrice     money.Money
+	SubtotalPrice  money.Money
+	Discount       money.Money
+	CouponCode     string
 	PaymentMethod  string
 	PaymentStatus  string
 	ShippingStatus string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// ShippingAddress is nil for orders placed before addresses.
+	ShippingAddress *SealedAddress
+	// AddressRedacted is set once the data key of the order was deleted.
+	AddressRedacted bool
+	// StockStatus summarizes the stock status of the line items, see aggregateStockStatus.
+	StockStatus string
+
+	// OnHold is set while the order is held for fraud review. Its score and reasons are kept once released.
+	OnHold      bool
+	FraudScore  int32
+	HoldReasons []string
+
+	PaymentReference string
+	// PaymentNotifiedAt is the time of the latest payment provider notification that was applied.
+	PaymentNotifiedAt time.Time
+
+	// PaidAt is the time the order was paid, nil if it never was.
+	PaidAt *time.Time
+
+	Shipment *Shipment
+	// ShipmentOverdue is set once the order missed its shipment deadline, and kept after it is shipped.
+	ShipmentOverdue bool
+	// DeliveredAt is the time the order was delivered, from which its return window is measured.
+	DeliveredAt *time.Time
+	Returns     []Return
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type SerializedEvent struct {
@@ -66,10 +165,48 @@ func applyEventToProjection(event SerializedEvent, currentProjection *OrderProje
 		return applyOrderPaidToProjection(event.EventData, currentProjection)
 	case EventTypeOrderPaymentFailed:
 		return applyOrderPaymentFailedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderPaymentSubmitted:
+		return applyOrderPaymentSubmittedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderPaymentRefunded:
+		return applyOrderPaymentRefundedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderRefundRequested:
+		return applyOrderRefundRequestedToProjection(event.EventData, currentProjection)
 	case EventTypeOrderCancelled:
 		return applyOrderCancelledToProjection(event.EventData, currentProjection)
 	case EventTypeOrderShippingStatusUpdated:
 		return applyOrderShippingStatusUpdatedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderAmended:
+		return applyOrderAmendedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderAddressChanged:
+		return applyOrderAddressChangedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderAddressRedacted:
+		return applyOrderAddressRedactedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderHeld:
+		return applyOrderHeldToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReleased:
+		return applyOrderReleasedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderStockStatusUpdated:
+		return applyOrderStockStatusUpdatedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderShipmentCreated:
+		return applyOrderShipmentCreatedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderShipmentEtaUpdated:
+		return applyOrderShipmentEtaUpdatedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderDeliveryAttempted:
+		return applyOrderDeliveryAttemptedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderDelivered:
+		return applyOrderDeliveredToProjection(event.EventData, currentProjection)
+	case EventTypeOrderShipmentOverdue:
+		return applyOrderShipmentOverdueToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReturnRequested:
+		return applyOrderReturnRequestedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReturnApproved:
+		return applyOrderReturnApprovedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReturnRejected:
+		return applyOrderReturnRejectedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReturnReceived:
+		return applyOrderReturnReceivedToProjection(event.EventData, currentProjection)
+	case EventTypeOrderReturnRefunded:
+		return applyOrderReturnRefundedToProjection(event.EventData, currentProjection)
 	default:
 		return fmt.Errorf("unknown event type: %s", event.EventType)
 	}
@@ -85,18 +222,216 @@ func applyOrderPlacedToProjection(eventData []byte, currentProjection *OrderProj
 	currentProjection.OrderId = event.OrderId
 	currentProjection.CustomerId = event.CustomerId
 	currentProjection.VendorId = event.VendorId
-	currentProjection.ProductId = event.ProductId
-	currentProjection.Quantity = event.Quantity
-	currentProjection.TotalPrice = event.TotalPrice
+	currentProjection.LineItems = upcastLineItems(&event)
+	currentProjection.TotalPrice = upcastMoney(event.TotalAmount, event.TotalPrice)
+	currentProjection.SubtotalPrice, currentProjection.Discount = upcastBreakdown(event.SubtotalAmount, event.DiscountAmount, currentProjection.TotalPrice)
+	currentProjection.CouponCode = event.CouponCode
 	currentProjection.PaymentMethod = event.PaymentMethod
 	currentProjection.PaymentStatus = PaymentStatusPending
 	currentProjection.ShippingStatus = ShippingStatusWaitingForPayment
+	currentProjection.ShippingAddress = mapSealedAddress(event.ShippingAddress)
 	currentProjection.CreatedAt = event.Timestamp.AsTime()
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 
 	return nil
 }
 
+func applyOrderAmendedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderAmended
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order amended event: %w", err)
+	}
+
+	if len(event.LineItems) > 0 {
+		previousLineItems := make(map[string]LineItem, len(currentProjection.LineItems))
+		for _, item := range currentProjection.LineItems {
+			previousLineItems[item.ProductId] = item
+		}
+
+		// Line items that did not change keep their stock status
+		currentProjection.LineItems = mapLineItems(event.LineItems)
+		for i, item := range currentProjection.LineItems {
+			if previous, ok := previousLineItems[item.ProductId]; ok && previous.Quantity == item.Quantity {
+				currentProjection.LineItems[i].StockStatus = previous.StockStatus
+			}
+		}
+		currentProjection.StockStatus = aggregateStockStatus(currentProjection.LineItems)
+
+		currentProjection.TotalPrice = MapProtoToMoney(event.TotalAmount)
+		currentProjection.SubtotalPrice, currentProjection.Discount = upcastBreakdown(event.SubtotalAmount, event.DiscountAmount, currentProjection.TotalPrice)
+	}
+
+	if event.PaymentMethod != "" {
+		currentProjection.PaymentMethod = event.PaymentMethod
+	}
+	if event.PaymentRestarted {
+		currentProjection.PaymentStatus = PaymentStatusPending
+	}
+
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderHeldToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderHeld
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order held event: %w", err)
+	}
+
+	currentProjection.OnHold = true
+	currentProjection.FraudScore = event.Score
+	currentProjection.HoldReasons = event.Reasons
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderReleasedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReleased
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order released event: %w", err)
+	}
+
+	currentProjection.OnHold = false
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderAddressChangedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderAddressChanged
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order address changed event: %w", err)
+	}
+
+	currentProjection.ShippingAddress = mapSealedAddress(event.ShippingAddress)
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderAddressRedactedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderAddressRedacted
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order address redacted event: %w", err)
+	}
+
+	currentProjection.AddressRedacted = true
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+// upcastLineItems returns the line items of an order placed event. Orders placed before line
+// items carry a single product, which is upcast into one line item.
+func upcastLineItems(event *pb.OrderPlaced) []LineItem {
+	if len(event.LineItems) == 0 {
+		if event.ProductId == "" {
+			return nil
+		}
+
+		totalPrice := upcastMoney(event.TotalAmount, event.TotalPrice)
+		unitPrice := money.Zero(totalPrice.Currency)
+		if event.Quantity > 0 {
+			unitPrice = totalPrice.MulRatio(1, int64(event.Quantity))
+		}
+		return []LineItem{{
+			ProductId:  event.ProductId,
+			Quantity:   event.Quantity,
+			UnitPrice:  unitPrice,
+			TotalPrice: totalPrice,
+			Discount:   money.Zero(totalPrice.Currency),
+		}}
+	}
+
+	return mapLineItems(event.LineItems)
+}
+
+func mapLineItems(items []*pb.OrderLineItem) []LineItem {
+	lineItems := make([]LineItem, len(items))
+	for i, item := range items {
+		totalPrice := upcastMoney(item.TotalAmount, item.TotalPrice)
+		_, discount := upcastBreakdown(nil, item.DiscountAmount, totalPrice)
+		lineItems[i] = LineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  upcastMoney(item.UnitAmount, item.UnitPrice),
+			TotalPrice: totalPrice,
+			Discount:   discount,
+		}
+	}
+	return lineItems
+}
+
+// upcastMoney returns an amount of money, or the legacy double amount recorded before exact money
+// converted into the legacy currency.
+func upcastMoney(amount *pb.Money, legacyAmount float64) money.Money {
+	if amount != nil {
+		return MapProtoToMoney(amount)
+	}
+
+	// The legacy currency is always a known currency
+	legacyMoney, _ := money.FromFloat(LegacyCurrency, legacyAmount)
+	return legacyMoney
+}
+
+// upcastBreakdown returns the subtotal and discount of a price. Prices recorded before pricing have
+// no breakdown, and are not discounted.
+func upcastBreakdown(subtotal *pb.Money, discount *pb.Money, total money.Money) (money.Money, money.Money) {
+	subtotalMoney, discountMoney := total, money.Zero(total.Currency)
+	if subtotal != nil {
+		subtotalMoney = MapProtoToMoney(subtotal)
+	}
+	if discount != nil {
+		discountMoney = MapProtoToMoney(discount)
+	}
+	return subtotalMoney, discountMoney
+}
+
+// aggregateStockStatus summarizes the stock status of an order from its line items. An order is
+// back-ordered as soon as one line item is, and reserved or committed only once every line item is.
+func aggregateStockStatus(lineItems []LineItem) string {
+	var reserved, committed int
+	for _, item := range lineItems {
+		switch item.StockStatus {
+		case StockStatusBackordered:
+			return StockStatusBackordered
+		case StockStatusReleased:
+			return StockStatusReleased
+		case StockStatusReserved:
+			reserved++
+		case StockStatusCommitted:
+			committed++
+		}
+	}
+
+	switch {
+	case len(lineItems) == 0:
+		return ""
+	case committed == len(lineItems):
+		return StockStatusCommitted
+	case reserved+committed == len(lineItems):
+		return StockStatusReserved
+	}
+	return ""
+}
+
+// GetLineItem returns the line item of a product, if the order contains it.
+func (p *OrderProjection) GetLineItem(productId string) (LineItem, bool) {
+	for _, item := range p.LineItems {
+		if item.ProductId == productId {
+			return item, true
+		}
+	}
+	return LineItem{}, false
+}
+
 func applyOrderPaymentInitiatedToProjection(eventData []byte, currentProjection *OrderProjection) error {
 	var event pb.OrderPaymentInitiated
 	err := proto.Unmarshal(eventData, &event)
@@ -105,6 +440,7 @@ func applyOrderPaymentInitiatedToProjection(eventData []byte, currentProjection
 	}
 
 	currentProjection.PaymentStatus = PaymentStatusInitiated
+	currentProjection.PaymentReference = event.PaymentReference
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 
 	return nil
@@ -117,8 +453,13 @@ func applyOrderPaidToProjection(eventData []byte, currentProjection *OrderProjec
 		return fmt.Errorf("failed to unmarshal order paid event: %w", err)
 	}
 
+	paidAt := event.Timestamp.AsTime()
+	currentProjection.PaidAt = &paidAt
 	currentProjection.PaymentStatus = PaymentStatusPaid
 	currentProjection.ShippingStatus = ShippingStatusWaitingForShipment
+	if event.NotifiedAt != nil {
+		currentProjection.PaymentNotifiedAt = event.NotifiedAt.AsTime()
+	}
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 
 	return nil
@@ -132,6 +473,9 @@ func applyOrderPaymentFailedToProjection(eventData []byte, currentProjection *Or
 	}
 
 	currentProjection.PaymentStatus = PaymentStatusFailed
+	if event.NotifiedAt != nil {
+		currentProjection.PaymentNotifiedAt = event.NotifiedAt.AsTime()
+	}
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 	return nil
 }
@@ -144,6 +488,7 @@ func applyOrderCancelledToProjection(eventData []byte, currentProjection *OrderP
 	}
 
 	currentProjection.ShippingStatus = ShippingStatusCancelled
+	currentProjection.OnHold = false
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 
 	return nil
@@ -159,8 +504,303 @@ func applyOrderShippingStatusUpdatedToProjection(eventData []byte, currentProjec
 	if event.Status != pb.ShippingStatus_SHIPPING_STATUS_UNSPECIFIED {
 		currentProjection.ShippingStatus = MapShippingStatusToStr(event.Status)
 	}
+	if event.Status == pb.ShippingStatus_SHIPPING_STATUS_DELIVERED && currentProjection.DeliveredAt == nil {
+		deliveredAt := event.Timestamp.AsTime()
+		currentProjection.DeliveredAt = &deliveredAt
+	}
+
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderStockStatusUpdatedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderStockStatusUpdated
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order stock status updated event: %w", err)
+	}
+
+	if event.Status != pb.StockStatus_STOCK_STATUS_UNSPECIFIED {
+		for i := range currentProjection.LineItems {
+			// Events recorded before line items apply to the whole order
+			if event.ProductId == "" || currentProjection.LineItems[i].ProductId == event.ProductId {
+				currentProjection.LineItems[i].StockStatus = MapStockStatusToStr(event.Status)
+			}
+		}
+		currentProjection.StockStatus = aggregateStockStatus(currentProjection.LineItems)
+	}
+
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderShipmentCreatedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderShipmentCreated
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order shipment created event: %w", err)
+	}
 
+	currentProjection.Shipment = &Shipment{
+		Carrier:        event.Carrier,
+		TrackingNumber: event.TrackingNumber,
+	}
+	if event.EstimatedDeliveryAt != nil {
+		estimatedDeliveryAt := event.EstimatedDeliveryAt.AsTime()
+		currentProjection.Shipment.EstimatedDeliveryAt = &estimatedDeliveryAt
+	}
+	currentProjection.ShippingStatus = ShippingStatusInTransit
 	currentProjection.UpdatedAt = event.Timestamp.AsTime()
 
 	return nil
 }
+
+func applyOrderShipmentEtaUpdatedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderShipmentEtaUpdated
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order shipment eta updated event: %w", err)
+	}
+
+	if currentProjection.Shipment != nil {
+		estimatedDeliveryAt := event.EstimatedDeliveryAt.AsTime()
+		currentProjection.Shipment.EstimatedDeliveryAt = &estimatedDeliveryAt
+	}
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderDeliveryAttemptedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderDeliveryAttempted
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order delivery attempted event: %w", err)
+	}
+
+	if currentProjection.Shipment != nil {
+		currentProjection.Shipment.DeliveryAttempts++
+	}
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderDeliveredToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderDelivered
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order delivered event: %w", err)
+	}
+
+	deliveredAt := event.Timestamp.AsTime()
+	if currentProjection.Shipment != nil {
+		currentProjection.Shipment.DeliveredAt = &deliveredAt
+		currentProjection.Shipment.ProofSignedBy = event.Proof.GetSignedBy()
+		currentProjection.Shipment.ProofPhotoUrl = event.Proof.GetPhotoUrl()
+	}
+	currentProjection.DeliveredAt = &deliveredAt
+	currentProjection.ShippingStatus = ShippingStatusDelivered
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderShipmentOverdueToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderShipmentOverdue
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order shipment overdue event: %w", err)
+	}
+
+	currentProjection.ShipmentOverdue = true
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderPaymentSubmittedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderPaymentSubmitted
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order payment submitted event: %w", err)
+	}
+
+	currentProjection.PaymentStatus = PaymentStatusAwaitingConfirmation
+	currentProjection.PaymentReference = event.PaymentReference
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderPaymentRefundedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderPaymentRefunded
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order payment refunded event: %w", err)
+	}
+
+	currentProjection.PaymentStatus = PaymentStatusRefunded
+	if event.NotifiedAt != nil {
+		currentProjection.PaymentNotifiedAt = event.NotifiedAt.AsTime()
+	}
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderRefundRequestedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderRefundRequested
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order refund requested event: %w", err)
+	}
+
+	currentProjection.PaymentStatus = PaymentStatusRefundPending
+	if event.NotifiedAt != nil {
+		currentProjection.PaymentNotifiedAt = event.NotifiedAt.AsTime()
+	}
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+// GetReturn returns a return of the order, or nil if it does not exist.
+func (p *OrderProjection) GetReturn(returnId string) *Return {
+	for i := range p.Returns {
+		if p.Returns[i].ReturnId == returnId {
+			return &p.Returns[i]
+		}
+	}
+	return nil
+}
+
+// ReturnedQuantity returns the quantity of a product that is returned, or being returned, by the
+// returns of the order that were not rejected.
+func (p *OrderProjection) ReturnedQuantity(productId string) int32 {
+	var quantity int32
+	for _, orderReturn := range p.Returns {
+		if orderReturn.Status == ReturnStatusRejected {
+			continue
+		}
+		for _, item := range orderReturn.LineItems {
+			if item.ProductId == productId {
+				quantity += item.Quantity
+			}
+		}
+	}
+	return quantity
+}
+
+// ReturnedAmount returns the refund amount of a product that is refunded, or being refunded, by the
+// returns of the order that were not rejected.
+func (p *OrderProjection) ReturnedAmount(productId string) (money.Money, error) {
+	amount := money.Zero(p.TotalPrice.Currency)
+	for _, orderReturn := range p.Returns {
+		if orderReturn.Status == ReturnStatusRejected {
+			continue
+		}
+		for _, item := range orderReturn.LineItems {
+			if item.ProductId != productId {
+				continue
+			}
+			var err error
+			amount, err = amount.Add(item.RefundAmount)
+			if err != nil {
+				return money.Money{}, err
+			}
+		}
+	}
+	return amount, nil
+}
+
+func applyOrderReturnRequestedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReturnRequested
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order return requested event: %w", err)
+	}
+
+	lineItems := make([]ReturnLineItem, len(event.LineItems))
+	for i, item := range event.LineItems {
+		lineItems[i] = ReturnLineItem{
+			ProductId:    item.ProductId,
+			Quantity:     item.Quantity,
+			RefundAmount: MapProtoToMoney(item.RefundAmount),
+		}
+	}
+
+	currentProjection.Returns = append(currentProjection.Returns, Return{
+		ReturnId:     event.ReturnId,
+		Status:       ReturnStatusRequested,
+		Reason:       event.Reason,
+		LineItems:    lineItems,
+		RefundAmount: MapProtoToMoney(event.RefundAmount),
+		RequestedAt:  event.Timestamp.AsTime(),
+		UpdatedAt:    event.Timestamp.AsTime(),
+	})
+	currentProjection.UpdatedAt = event.Timestamp.AsTime()
+
+	return nil
+}
+
+func applyOrderReturnApprovedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReturnApproved
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order return approved event: %w", err)
+	}
+
+	return updateReturnStatus(currentProjection, event.ReturnId, ReturnStatusApproved, event.Timestamp.AsTime())
+}
+
+func applyOrderReturnRejectedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReturnRejected
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order return rejected event: %w", err)
+	}
+
+	if orderReturn := currentProjection.GetReturn(event.ReturnId); orderReturn != nil {
+		orderReturn.RejectionReason = event.Reason
+	}
+	return updateReturnStatus(currentProjection, event.ReturnId, ReturnStatusRejected, event.Timestamp.AsTime())
+}
+
+func applyOrderReturnReceivedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReturnReceived
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order return received event: %w", err)
+	}
+
+	return updateReturnStatus(currentProjection, event.ReturnId, ReturnStatusReceived, event.Timestamp.AsTime())
+}
+
+func applyOrderReturnRefundedToProjection(eventData []byte, currentProjection *OrderProjection) error {
+	var event pb.OrderReturnRefunded
+	err := proto.Unmarshal(eventData, &event)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal order return refunded event: %w", err)
+	}
+
+	if orderReturn := currentProjection.GetReturn(event.ReturnId); orderReturn != nil {
+		orderReturn.RefundAmount = MapProtoToMoney(event.RefundAmount)
+	}
+	return updateReturnStatus(currentProjection, event.ReturnId, ReturnStatusRefunded, event.Timestamp.AsTime())
+}
+
+func updateReturnStatus(currentProjection *OrderProjection, returnId string, status string, timestamp time.Time) error {
+	orderReturn := currentProjection.GetReturn(returnId)
+	if orderReturn == nil {
+		return fmt.Errorf("unknown return: %s", returnId)
+	}
+
+	orderReturn.Status = status
+	orderReturn.UpdatedAt = timestamp
+	currentProjection.UpdatedAt = timestamp
+
+	return nil
+}
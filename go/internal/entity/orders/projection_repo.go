@@ -2,42 +2,70 @@ package orders
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/metrics"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	ProjectionTable = "order_projection"
 )
 
+// ProjectionTableSchema is what the Postgres repo expects of the projection table, which it upserts by order id.
+var ProjectionTableSchema = pg.TableSchema{
+	Name:    ProjectionTable,
+	Columns: []string{"order_id", "payment_status", "shipping_status", "payment_reference", "customer_id", "on_hold", "created_at", "updated_at"},
+	Unique:  [][]string{{"order_id"}},
+}
+
 type DbProjection struct {
-	OrderId        string    `db:"order_id"`
-	PaymentStatus  string    `db:"payment_status"`
-	ShippingStatus string    `db:"shipping_status"`
-	CreatedAt      time.Time `db:"created_at"`
-	UpdatedAt      time.Time `db:"updated_at"`
+	OrderId          string         `db:"order_id"`
+	PaymentStatus    string         `db:"payment_status"`
+	ShippingStatus   string         `db:"shipping_status"`
+	PaymentReference sql.NullString `db:"payment_reference"`
+	CustomerId       sql.NullString `db:"customer_id"`
+	OnHold           bool           `db:"on_hold"`
+	CreatedAt        time.Time      `db:"created_at"`
+	UpdatedAt        time.Time      `db:"updated_at"`
 }
 
 type UpsertArgs struct {
-	OrderId        string
-	PaymentStatus  string
-	ShippingStatus string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	OrderId          string
+	PaymentStatus    string
+	ShippingStatus   string
+	PaymentReference string
+	CustomerId       string
+	OnHold           bool
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
 }
 
 type ListArgs struct {
 	Limit  uint
 	Offset uint
+
+	// OnlyOnHold lists only the orders held for fraud review.
+	OnlyOnHold bool
 }
 
 type ProjectionRepo interface {
 	Upsert(ctx context.Context, tx pg.Tx, args UpsertArgs) error
 
 	List(ctx context.Context, args ListArgs) ([]DbProjection, error)
+
+	// GetOrderIdByPaymentReference returns the id of the order with the payment reference, or "" if there is none.
+	GetOrderIdByPaymentReference(ctx context.Context, paymentReference string) (string, error)
+
+	// CountByCustomerSince counts the orders of a customer created at or after since, excluding excludeOrderId.
+	CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error)
 }
 
 // Postgres implementation
@@ -52,21 +80,27 @@ func NewPgProjectionRepo(db *sqlx.DB) ProjectionRepo {
 func (r *PgProjectionRepo) Upsert(ctx context.Context, tx pg.Tx, args UpsertArgs) error {
 	// Compile query
 	ds := pg.Dialect.Insert(ProjectionTable).Prepared(true).
-		Cols("order_id", "payment_status", "shipping_status").
+		Cols("order_id", "payment_status", "shipping_status", "payment_reference", "customer_id", "on_hold").
 		Rows([]goqu.Record{
 			{
-				"order_id":        args.OrderId,
-				"payment_status":  args.PaymentStatus,
-				"shipping_status": args.ShippingStatus,
-				"created_at":      args.CreatedAt,
-				"updated_at":      args.UpdatedAt,
+				"order_id":          args.OrderId,
+				"payment_status":    args.PaymentStatus,
+				"shipping_status":   args.ShippingStatus,
+				"payment_reference": sql.NullString{String: args.PaymentReference, Valid: args.PaymentReference != ""},
+				"customer_id":       sql.NullString{String: args.CustomerId, Valid: args.CustomerId != ""},
+				"on_hold":           args.OnHold,
+				"created_at":        args.CreatedAt,
+				"updated_at":        args.UpdatedAt,
 			},
 		}).
 		OnConflict(goqu.DoUpdate("order_id", goqu.Record{
-			"payment_status":  goqu.I("excluded.payment_status"),
-			"shipping_status": goqu.I("excluded.shipping_status"),
-			"created_at":      goqu.I("excluded.created_at"),
-			"updated_at":      goqu.I("excluded.updated_at"),
+			"payment_status":    goqu.I("excluded.payment_status"),
+			"shipping_status":   goqu.I("excluded.shipping_status"),
+			"payment_reference": goqu.I("excluded.payment_reference"),
+			"customer_id":       goqu.I("excluded.customer_id"),
+			"on_hold":           goqu.I("excluded.on_hold"),
+			"created_at":        goqu.I("excluded.created_at"),
+			"updated_at":        goqu.I("excluded.updated_at"),
 		}))
 
 	query, queryArgs, err := ds.ToSQL()
@@ -89,6 +123,9 @@ func (r *PgProjectionRepo) List(ctx context.Context, args ListArgs) ([]DbProject
 		Order(goqu.I("created_at").Desc()).
 		Limit(args.Limit).
 		Offset(args.Offset)
+	if args.OnlyOnHold {
+		ds = ds.Where(goqu.Ex{"on_hold": true})
+	}
 
 	query, queryArgs, err := ds.ToSQL()
 	if err != nil {
@@ -108,3 +145,123 @@ func (r *PgProjectionRepo) List(ctx context.Context, args ListArgs) ([]DbProject
 
 	return projections, nil
 }
+
+func (r *PgProjectionRepo) GetOrderIdByPaymentReference(ctx context.Context, paymentReference string) (string, error) {
+
+	ds := pg.Dialect.From(ProjectionTable).Prepared(true).
+		Select("order_id").
+		Where(goqu.Ex{"payment_reference": paymentReference})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return "", pg.ErrorDsl(err)
+	}
+
+	var orderId string
+	err = r.db.QueryRowxContext(ctx, query, queryArgs...).Scan(&orderId)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", pg.ErrorDb(err)
+	}
+
+	return orderId, nil
+}
+
+func (r *PgProjectionRepo) CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error) {
+
+	ds := pg.Dialect.From(ProjectionTable).Prepared(true).
+		Select(goqu.COUNT("*")).
+		Where(
+			goqu.Ex{"customer_id": customerId},
+			goqu.C("created_at").Gte(since),
+			goqu.C("order_id").Neq(excludeOrderId),
+		)
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return 0, pg.ErrorDsl(err)
+	}
+
+	var count int
+	err = r.db.QueryRowxContext(ctx, query, queryArgs...).Scan(&count)
+	if err != nil {
+		return 0, pg.ErrorDb(err)
+	}
+
+	return count, nil
+}
+
+// Traced implementation, which records a span for every query of a repo
+type TracedProjectionRepo struct {
+	repo ProjectionRepo
+}
+
+func NewTracedProjectionRepo(repo ProjectionRepo) ProjectionRepo {
+	return &TracedProjectionRepo{repo: repo}
+}
+
+func (r *TracedProjectionRepo) Upsert(ctx context.Context, tx pg.Tx, args UpsertArgs) error {
+	ctx, span := tracing.Start(ctx, "orders.ProjectionRepo/Upsert", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("order.id", args.OrderId),
+	))
+	err := r.repo.Upsert(ctx, tx, args)
+	tracing.End(span, err)
+	return err
+}
+
+func (r *TracedProjectionRepo) List(ctx context.Context, args ListArgs) ([]DbProjection, error) {
+	ctx, span := tracing.Start(ctx, "orders.ProjectionRepo/List", trace.WithSpanKind(trace.SpanKindClient))
+	projections, err := r.repo.List(ctx, args)
+	tracing.End(span, err)
+	return projections, err
+}
+
+func (r *TracedProjectionRepo) GetOrderIdByPaymentReference(ctx context.Context, paymentReference string) (string, error) {
+	ctx, span := tracing.Start(ctx, "orders.ProjectionRepo/GetOrderIdByPaymentReference", trace.WithSpanKind(trace.SpanKindClient))
+	orderId, err := r.repo.GetOrderIdByPaymentReference(ctx, paymentReference)
+	tracing.End(span, err)
+	return orderId, err
+}
+
+func (r *TracedProjectionRepo) CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error) {
+	ctx, span := tracing.Start(ctx, "orders.ProjectionRepo/CountByCustomerSince", trace.WithSpanKind(trace.SpanKindClient))
+	count, err := r.repo.CountByCustomerSince(ctx, customerId, since, excludeOrderId)
+	tracing.End(span, err)
+	return count, err
+}
+
+var projectionUpsertDuration = metrics.Factory.NewHistogram(prometheus.HistogramOpts{
+	Namespace: metrics.Namespace,
+	Name:      "projection_upsert_duration_seconds",
+	Help:      "Time taken to upsert order projections.",
+	Buckets:   prometheus.DefBuckets,
+})
+
+// Metered implementation, which records the latency of the upserts of a repo
+type MeteredProjectionRepo struct {
+	repo ProjectionRepo
+}
+
+func NewMeteredProjectionRepo(repo ProjectionRepo) ProjectionRepo {
+	return &MeteredProjectionRepo{repo: repo}
+}
+
+func (r *MeteredProjectionRepo) Upsert(ctx context.Context, tx pg.Tx, args UpsertArgs) error {
+	start := time.Now()
+	err := r.repo.Upsert(ctx, tx, args)
+	projectionUpsertDuration.Observe(metrics.Since(start))
+	return err
+}
+
+func (r *MeteredProjectionRepo) List(ctx context.Context, args ListArgs) ([]DbProjection, error) {
+	return r.repo.List(ctx, args)
+}
+
+func (r *MeteredProjectionRepo) GetOrderIdByPaymentReference(ctx context.Context, paymentReference string) (string, error) {
+	return r.repo.GetOrderIdByPaymentReference(ctx, paymentReference)
+}
+
+func (r *MeteredProjectionRepo) CountByCustomerSince(ctx context.Context, customerId string, since time.Time, excludeOrderId string) (int, error) {
+	return r.repo.CountByCustomerSince(ctx, customerId, since, excludeOrderId)
+}
@@ -5,12 +5,21 @@ import (
 	"time"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+func usd(amount int64) money.Money {
+	return money.Money{Currency: "USD", Amount: amount}
+}
+
+func eur(amount int64) money.Money {
+	return money.Money{Currency: "EUR", Amount: amount}
+}
+
 func TestApplyOrderPlacedToProjection(t *testing.T) {
 	// Create test event data
 	timestamp := time.Now().UTC()
@@ -18,11 +27,23 @@ func TestApplyOrderPlacedToProjection(t *testing.T) {
 		OrderId:       "order-123",
 		CustomerId:    "customer-456",
 		VendorId:      "vendor-789",
-		ProductId:     "product-101",
-		Quantity:      5,
-		TotalPrice:    99.99,
+		TotalAmount:   &pb.Money{CurrencyCode: "EUR", AmountMinor: 9999},
 		PaymentMethod: "credit_card",
 		Timestamp:     timestamppb.New(timestamp),
+		LineItems: []*pb.OrderLineItem{
+			{
+				ProductId:   "product-101",
+				Quantity:    5,
+				UnitAmount:  &pb.Money{CurrencyCode: "EUR", AmountMinor: 1500},
+				TotalAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 7500},
+			},
+			{
+				ProductId:   "product-102",
+				Quantity:    1,
+				UnitAmount:  &pb.Money{CurrencyCode: "EUR", AmountMinor: 2499},
+				TotalAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 2499},
+			},
+		},
 	}
 
 	eventData, err := proto.Marshal(event)
@@ -37,9 +58,11 @@ func TestApplyOrderPlacedToProjection(t *testing.T) {
 	assert.Equal(t, "order-123", projection.OrderId)
 	assert.Equal(t, "customer-456", projection.CustomerId)
 	assert.Equal(t, "vendor-789", projection.VendorId)
-	assert.Equal(t, "product-101", projection.ProductId)
-	assert.Equal(t, int32(5), projection.Quantity)
-	assert.Equal(t, 99.99, projection.TotalPrice)
+	assert.Equal(t, []LineItem{
+		{ProductId: "product-101", Quantity: 5, UnitPrice: eur(1500), TotalPrice: eur(7500), Discount: eur(0)},
+		{ProductId: "product-102", Quantity: 1, UnitPrice: eur(2499), TotalPrice: eur(2499), Discount: eur(0)},
+	}, projection.LineItems)
+	assert.Equal(t, eur(9999), projection.TotalPrice)
 	assert.Equal(t, "credit_card", projection.PaymentMethod)
 	assert.Equal(t, PaymentStatusPending, projection.PaymentStatus)
 	assert.Equal(t, ShippingStatusWaitingForPayment, projection.ShippingStatus)
@@ -218,6 +241,110 @@ func TestApplyOrderShippingStatusUpdatedToProjection_Delivered(t *testing.T) {
 	assert.Equal(t, originalCreatedAt, projection.CreatedAt)
 }
 
+func TestApplyOrderAmendedToProjection(t *testing.T) {
+	// Create test event data
+	timestamp := time.Now().UTC()
+	event := &pb.OrderAmended{
+		OrderId:   "order-123",
+		Timestamp: timestamppb.New(timestamp),
+		LineItems: []*pb.OrderLineItem{
+			{ProductId: "product-101", Quantity: 1, UnitAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 1000}, TotalAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 1000}},
+			{ProductId: "product-102", Quantity: 3, UnitAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 500}, TotalAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 1500}},
+		},
+		SubtotalAmount:   &pb.Money{CurrencyCode: "EUR", AmountMinor: 2500},
+		DiscountAmount:   &pb.Money{CurrencyCode: "EUR", AmountMinor: 0},
+		TotalAmount:      &pb.Money{CurrencyCode: "EUR", AmountMinor: 2500},
+		PaymentMethod:    "debit_card",
+		PaymentRestarted: true,
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	// Test projection
+	projection := &OrderProjection{
+		OrderId: "order-123",
+		LineItems: []LineItem{
+			{ProductId: "product-101", Quantity: 1, StockStatus: StockStatusReserved},
+			{ProductId: "product-102", Quantity: 2, StockStatus: StockStatusReserved},
+		},
+		StockStatus:   StockStatusReserved,
+		PaymentMethod: "credit_card",
+		PaymentStatus: PaymentStatusFailed,
+	}
+
+	err = applyOrderAmendedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify only the unchanged line item keeps its stock status
+	assert.Equal(t, StockStatusReserved, projection.LineItems[0].StockStatus)
+	assert.Equal(t, "", projection.LineItems[1].StockStatus)
+	assert.Equal(t, int32(3), projection.LineItems[1].Quantity)
+	assert.Equal(t, "", projection.StockStatus)
+	assert.Equal(t, eur(2500), projection.TotalPrice)
+	assert.Equal(t, eur(2500), projection.SubtotalPrice)
+
+	// Verify the payment starts over with the new method
+	assert.Equal(t, "debit_card", projection.PaymentMethod)
+	assert.Equal(t, PaymentStatusPending, projection.PaymentStatus)
+	assert.Equal(t, timestamp, projection.UpdatedAt)
+}
+
+func TestApplyOrderAmendedToProjection_PaymentMethodOnly(t *testing.T) {
+	event := &pb.OrderAmended{
+		OrderId:       "order-123",
+		Timestamp:     timestamppb.Now(),
+		PaymentMethod: "debit_card",
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	lineItems := []LineItem{{ProductId: "product-101", Quantity: 1, TotalPrice: eur(1000)}}
+	projection := &OrderProjection{
+		OrderId:       "order-123",
+		LineItems:     lineItems,
+		TotalPrice:    eur(1000),
+		PaymentMethod: "credit_card",
+		PaymentStatus: PaymentStatusPending,
+	}
+
+	err = applyOrderAmendedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify the fields that did not change are kept
+	assert.Equal(t, lineItems, projection.LineItems)
+	assert.Equal(t, eur(1000), projection.TotalPrice)
+	assert.Equal(t, "debit_card", projection.PaymentMethod)
+	assert.Equal(t, PaymentStatusPending, projection.PaymentStatus)
+}
+
+func TestApplyOrderAddressChangedToProjection(t *testing.T) {
+	// Create test event data
+	timestamp := time.Now().UTC()
+	event := &pb.OrderAddressChanged{
+		OrderId:         "order-123",
+		Timestamp:       timestamppb.New(timestamp),
+		ShippingAddress: &pb.SealedAddress{KeyId: "order-123", Ciphertext: []byte("new")},
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	// Test projection
+	projection := &OrderProjection{
+		OrderId:         "order-123",
+		ShippingAddress: &SealedAddress{KeyId: "order-123", Ciphertext: []byte("old")},
+	}
+
+	err = applyOrderAddressChangedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify the address is replaced
+	assert.Equal(t, &SealedAddress{KeyId: "order-123", Ciphertext: []byte("new")}, projection.ShippingAddress)
+	assert.Equal(t, timestamp, projection.UpdatedAt)
+}
+
 func TestApplyOrderShippingStatusUpdatedToProjection_InTransit(t *testing.T) {
 	// Create test event data
 	timestamp := time.Now().UTC()
@@ -288,6 +415,363 @@ func TestApplyOrderShippingStatusUpdatedToProjection_UnknownStatus(t *testing.T)
 	assert.Equal(t, originalCreatedAt, projection.CreatedAt)
 }
 
+func TestApplyOrderStockStatusUpdatedToProjection_Backordered(t *testing.T) {
+	// Create test event data
+	timestamp := time.Now().UTC()
+	event := &pb.OrderStockStatusUpdated{
+		OrderId:   "order-123",
+		Status:    pb.StockStatus_STOCK_STATUS_BACKORDERED,
+		Timestamp: timestamppb.New(timestamp),
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	// Test projection
+	originalCreatedAt := time.Now().Add(-1 * time.Hour).UTC()
+	projection := &OrderProjection{
+		OrderId:        "order-123",
+		LineItems:      []LineItem{{ProductId: "product-101", Quantity: 1}},
+		PaymentStatus:  PaymentStatusPending,
+		ShippingStatus: ShippingStatusWaitingForPayment,
+		CreatedAt:      originalCreatedAt,
+		UpdatedAt:      originalCreatedAt,
+	}
+
+	err = applyOrderStockStatusUpdatedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify stock status is updated to backordered
+	assert.Equal(t, StockStatusBackordered, projection.StockStatus)
+	// Verify UpdatedAt is updated
+	assert.Equal(t, timestamp, projection.UpdatedAt)
+	// Verify other fields remain unchanged
+	assert.Equal(t, ShippingStatusWaitingForPayment, projection.ShippingStatus)
+	assert.Equal(t, originalCreatedAt, projection.CreatedAt)
+}
+
+func TestApplyShipmentEventsToProjection(t *testing.T) {
+	timestamp := time.Now().UTC()
+	estimatedDeliveryAt := timestamp.Add(48 * time.Hour)
+
+	events := []proto.Message{
+		&pb.OrderShipmentCreated{OrderId: "order-123", Timestamp: timestamppb.New(timestamp), Carrier: "ups", TrackingNumber: "1Z999"},
+		&pb.OrderShipmentEtaUpdated{OrderId: "order-123", Timestamp: timestamppb.New(timestamp), EstimatedDeliveryAt: timestamppb.New(estimatedDeliveryAt)},
+		&pb.OrderDeliveryAttempted{OrderId: "order-123", Timestamp: timestamppb.New(timestamp), Reason: "nobody home"},
+		&pb.OrderDelivered{OrderId: "order-123", Timestamp: timestamppb.New(timestamp), Proof: &pb.DeliveryProof{SignedBy: "J. Doe"}},
+	}
+	eventTypes := []string{EventTypeOrderShipmentCreated, EventTypeOrderShipmentEtaUpdated, EventTypeOrderDeliveryAttempted, EventTypeOrderDelivered}
+
+	projection := &OrderProjection{
+		OrderId:        "order-123",
+		PaymentStatus:  PaymentStatusPaid,
+		ShippingStatus: ShippingStatusWaitingForShipment,
+	}
+
+	for i, event := range events {
+		eventData, err := proto.Marshal(event)
+		require.NoError(t, err)
+		require.NoError(t, applyEventToProjection(SerializedEvent{EventType: eventTypes[i], EventData: eventData}, projection))
+
+		// Creating the shipment puts the order in transit
+		if i == 0 {
+			assert.Equal(t, ShippingStatusInTransit, projection.ShippingStatus)
+		}
+	}
+
+	require.NotNil(t, projection.Shipment)
+	assert.Equal(t, "ups", projection.Shipment.Carrier)
+	assert.Equal(t, "1Z999", projection.Shipment.TrackingNumber)
+	assert.Equal(t, estimatedDeliveryAt, *projection.Shipment.EstimatedDeliveryAt)
+	assert.Equal(t, int32(1), projection.Shipment.DeliveryAttempts)
+	assert.Equal(t, timestamp, *projection.Shipment.DeliveredAt)
+	assert.Equal(t, "J. Doe", projection.Shipment.ProofSignedBy)
+	assert.Equal(t, ShippingStatusDelivered, projection.ShippingStatus)
+
+	details := projection.ToOrderDetails()
+	assert.Equal(t, "ups", details.Shipment.Carrier)
+	assert.Equal(t, "J. Doe", details.Shipment.Proof.SignedBy)
+}
+
+func TestApplyOrderPlacedToProjection_LegacySingleProduct(t *testing.T) {
+	// Orders placed before line items carry a single product
+	event := &pb.OrderPlaced{
+		OrderId:       "order-123",
+		CustomerId:    "customer-456",
+		VendorId:      "vendor-789",
+		ProductId:     "product-101",
+		Quantity:      4,
+		TotalPrice:    100.00,
+		PaymentMethod: "credit_card",
+		Timestamp:     timestamppb.Now(),
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	projection := &OrderProjection{}
+	err = applyOrderPlacedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify the product is upcast into one line item, in the legacy currency
+	assert.Equal(t, []LineItem{{ProductId: "product-101", Quantity: 4, UnitPrice: usd(2500), TotalPrice: usd(10000), Discount: usd(0)}}, projection.LineItems)
+	assert.Equal(t, usd(10000), projection.TotalPrice)
+}
+
+func TestApplyOrderPlacedToProjection_LegacyPrices(t *testing.T) {
+	// Line items placed before exact money carry double prices
+	event := &pb.OrderPlaced{
+		OrderId:    "order-123",
+		TotalPrice: 0.3,
+		Timestamp:  timestamppb.Now(),
+		LineItems: []*pb.OrderLineItem{
+			{ProductId: "product-101", Quantity: 1, UnitPrice: 0.1, TotalPrice: 0.1},
+			{ProductId: "product-102", Quantity: 2, UnitPrice: 0.1, TotalPrice: 0.2},
+		},
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	projection := &OrderProjection{}
+	err = applyOrderPlacedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify the prices are rounded to exact minor units
+	assert.Equal(t, []LineItem{
+		{ProductId: "product-101", Quantity: 1, UnitPrice: usd(10), TotalPrice: usd(10), Discount: usd(0)},
+		{ProductId: "product-102", Quantity: 2, UnitPrice: usd(10), TotalPrice: usd(20), Discount: usd(0)},
+	}, projection.LineItems)
+	assert.Equal(t, usd(30), projection.TotalPrice)
+	assert.Equal(t, usd(30), projection.SubtotalPrice)
+	assert.Equal(t, usd(0), projection.Discount)
+}
+
+func TestApplyOrderPlacedToProjection_Discount(t *testing.T) {
+	event := &pb.OrderPlaced{
+		OrderId:        "order-123",
+		SubtotalAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 3000},
+		DiscountAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 300},
+		TotalAmount:    &pb.Money{CurrencyCode: "EUR", AmountMinor: 2700},
+		CouponCode:     "SAVE10",
+		Timestamp:      timestamppb.Now(),
+		LineItems: []*pb.OrderLineItem{
+			{
+				ProductId:      "product-101",
+				Quantity:       3,
+				UnitAmount:     &pb.Money{CurrencyCode: "EUR", AmountMinor: 1000},
+				DiscountAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 300},
+				TotalAmount:    &pb.Money{CurrencyCode: "EUR", AmountMinor: 2700},
+			},
+		},
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	projection := &OrderProjection{}
+	err = applyOrderPlacedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	assert.Equal(t, []LineItem{{ProductId: "product-101", Quantity: 3, UnitPrice: eur(1000), TotalPrice: eur(2700), Discount: eur(300)}}, projection.LineItems)
+	assert.Equal(t, eur(3000), projection.SubtotalPrice)
+	assert.Equal(t, eur(300), projection.Discount)
+	assert.Equal(t, eur(2700), projection.TotalPrice)
+	assert.Equal(t, "SAVE10", projection.CouponCode)
+}
+
+func TestApplyOrderStockStatusUpdatedToProjection_LineItems(t *testing.T) {
+	projection := &OrderProjection{
+		OrderId: "order-123",
+		LineItems: []LineItem{
+			{ProductId: "product-101", Quantity: 1},
+			{ProductId: "product-102", Quantity: 2},
+		},
+	}
+
+	apply := func(productId string, status pb.StockStatus) {
+		eventData, err := proto.Marshal(&pb.OrderStockStatusUpdated{
+			OrderId:   "order-123",
+			Timestamp: timestamppb.Now(),
+			Status:    status,
+			ProductId: productId,
+		})
+		require.NoError(t, err)
+		require.NoError(t, applyOrderStockStatusUpdatedToProjection(eventData, projection))
+	}
+
+	// The order is only reserved once every line item is
+	apply("product-101", pb.StockStatus_STOCK_STATUS_RESERVED)
+	assert.Equal(t, StockStatusReserved, projection.LineItems[0].StockStatus)
+	assert.Equal(t, "", projection.StockStatus)
+
+	apply("product-102", pb.StockStatus_STOCK_STATUS_BACKORDERED)
+	assert.Equal(t, StockStatusBackordered, projection.StockStatus)
+
+	apply("product-102", pb.StockStatus_STOCK_STATUS_RESERVED)
+	assert.Equal(t, StockStatusReserved, projection.StockStatus)
+
+	apply("product-101", pb.StockStatus_STOCK_STATUS_COMMITTED)
+	assert.Equal(t, StockStatusReserved, projection.StockStatus)
+
+	// Events recorded before line items apply to every line item
+	apply("", pb.StockStatus_STOCK_STATUS_COMMITTED)
+	assert.Equal(t, StockStatusCommitted, projection.LineItems[1].StockStatus)
+	assert.Equal(t, StockStatusCommitted, projection.StockStatus)
+}
+
+func TestApplyOrderPaymentSubmittedToProjection(t *testing.T) {
+	// Create test event data
+	timestamp := time.Now().UTC()
+	event := &pb.OrderPaymentSubmitted{
+		OrderId:          "order-123",
+		Timestamp:        timestamppb.New(timestamp),
+		PaymentReference: "pay-123",
+	}
+
+	eventData, err := proto.Marshal(event)
+	require.NoError(t, err)
+
+	// Test projection
+	projection := &OrderProjection{
+		OrderId:        "order-123",
+		PaymentStatus:  PaymentStatusInitiated,
+		ShippingStatus: ShippingStatusWaitingForPayment,
+	}
+
+	err = applyOrderPaymentSubmittedToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	// Verify the payment is in flight
+	assert.Equal(t, PaymentStatusAwaitingConfirmation, projection.PaymentStatus)
+	assert.Equal(t, "pay-123", projection.PaymentReference)
+	assert.Equal(t, timestamp, projection.UpdatedAt)
+	assert.Equal(t, pb.PaymentStatus_PAYMENT_STATUS_AWAITING_CONFIRMATION, projection.ToOrderDetails().PaymentStatus)
+}
+
+func TestApplyOrderReturnEventsToProjection(t *testing.T) {
+	// Create test event data
+	requestedAt := time.Now().UTC()
+	requestedData, err := proto.Marshal(&pb.OrderReturnRequested{
+		OrderId:   "order-123",
+		Timestamp: timestamppb.New(requestedAt),
+		ReturnId:  "return-1",
+		Reason:    "damaged",
+		LineItems: []*pb.OrderReturnLineItem{
+			{ProductId: "product-101", Quantity: 1, RefundAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 900}},
+		},
+		RefundAmount: &pb.Money{CurrencyCode: "EUR", AmountMinor: 900},
+	})
+	require.NoError(t, err)
+
+	rejectedAt := requestedAt.Add(time.Hour)
+	rejectedData, err := proto.Marshal(&pb.OrderReturnRejected{
+		OrderId:   "order-123",
+		Timestamp: timestamppb.New(rejectedAt),
+		ReturnId:  "return-1",
+		Reason:    "worn",
+	})
+	require.NoError(t, err)
+
+	// Test projection
+	projection := &OrderProjection{
+		OrderId:   "order-123",
+		LineItems: []LineItem{{ProductId: "product-101", Quantity: 2, TotalPrice: eur(1800)}},
+	}
+
+	err = applyOrderReturnRequestedToProjection(requestedData, projection)
+	require.NoError(t, err)
+
+	// Verify the return is open and counts towards the returned quantity
+	require.Len(t, projection.Returns, 1)
+	assert.Equal(t, ReturnStatusRequested, projection.Returns[0].Status)
+	assert.Equal(t, eur(900), projection.Returns[0].RefundAmount)
+	assert.Equal(t, requestedAt, projection.Returns[0].RequestedAt)
+	assert.Equal(t, int32(1), projection.ReturnedQuantity("product-101"))
+
+	err = applyOrderReturnRejectedToProjection(rejectedData, projection)
+	require.NoError(t, err)
+
+	// Verify a rejected return gives its quantity back
+	assert.Equal(t, ReturnStatusRejected, projection.Returns[0].Status)
+	assert.Equal(t, "worn", projection.Returns[0].RejectionReason)
+	assert.Equal(t, rejectedAt, projection.UpdatedAt)
+	assert.Equal(t, int32(0), projection.ReturnedQuantity("product-101"))
+	assert.Equal(t, pb.ReturnStatus_RETURN_STATUS_REJECTED, projection.ToOrderDetails().Returns[0].Status)
+}
+
+func TestApplyOrderReturnApprovedToProjection_UnknownReturn(t *testing.T) {
+	eventData, err := proto.Marshal(&pb.OrderReturnApproved{OrderId: "order-123", Timestamp: timestamppb.Now(), ReturnId: "return-1"})
+	require.NoError(t, err)
+
+	err = applyOrderReturnApprovedToProjection(eventData, &OrderProjection{OrderId: "order-123"})
+	assert.Error(t, err)
+}
+
+func TestApplyOrderDeliveredToProjection_DeliveredAt(t *testing.T) {
+	timestamp := time.Now().UTC()
+	eventData, err := proto.Marshal(&pb.OrderDelivered{OrderId: "order-123", Timestamp: timestamppb.New(timestamp)})
+	require.NoError(t, err)
+
+	projection := &OrderProjection{OrderId: "order-123", Shipment: &Shipment{}}
+	err = applyOrderDeliveredToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	require.NotNil(t, projection.DeliveredAt)
+	assert.Equal(t, timestamp, *projection.DeliveredAt)
+}
+
+func TestApplyOrderShipmentOverdueToProjection(t *testing.T) {
+	timestamp := time.Now().UTC()
+	eventData, err := proto.Marshal(&pb.OrderShipmentOverdue{OrderId: "order-123", Timestamp: timestamppb.New(timestamp), DueAt: timestamppb.New(timestamp)})
+	require.NoError(t, err)
+
+	projection := &OrderProjection{OrderId: "order-123", ShippingStatus: ShippingStatusWaitingForShipment}
+	err = applyOrderShipmentOverdueToProjection(eventData, projection)
+	require.NoError(t, err)
+
+	assert.True(t, projection.ShipmentOverdue)
+	assert.True(t, projection.ToOrderDetails().ShipmentOverdue)
+	assert.Equal(t, ShippingStatusWaitingForShipment, projection.ShippingStatus)
+}
+
+func TestApplyOrderHeldAndReleasedToProjection(t *testing.T) {
+	// Create test event data
+	heldAt := time.Now().UTC()
+	heldData, err := proto.Marshal(&pb.OrderHeld{
+		OrderId:   "order-123",
+		Timestamp: timestamppb.New(heldAt),
+		Score:     80,
+		Reasons:   []string{"customer placed 5 orders within 1h0m0s"},
+	})
+	require.NoError(t, err)
+
+	releasedAt := heldAt.Add(time.Hour)
+	releasedData, err := proto.Marshal(&pb.OrderReleased{OrderId: "order-123", Timestamp: timestamppb.New(releasedAt)})
+	require.NoError(t, err)
+
+	// Test projection
+	projection := &OrderProjection{OrderId: "order-123", PaymentStatus: PaymentStatusPending}
+
+	err = applyOrderHeldToProjection(heldData, projection)
+	require.NoError(t, err)
+
+	// Verify the order is on hold
+	assert.True(t, projection.OnHold)
+	assert.Equal(t, int32(80), projection.FraudScore)
+	assert.Equal(t, []string{"customer placed 5 orders within 1h0m0s"}, projection.HoldReasons)
+	assert.Equal(t, heldAt, projection.UpdatedAt)
+
+	err = applyOrderReleasedToProjection(releasedData, projection)
+	require.NoError(t, err)
+
+	// Verify the order is released, keeping its score
+	assert.False(t, projection.OnHold)
+	assert.Equal(t, int32(80), projection.FraudScore)
+	assert.Equal(t, releasedAt, projection.UpdatedAt)
+	assert.Equal(t, int32(80), projection.ToOrderDetails().FraudScore)
+}
+
 func TestReduceToProjection_SingleEvent(t *testing.T) {
 	// Create a single OrderPlaced event
 	timestamp := time.Now().UTC()
@@ -320,9 +804,8 @@ func TestReduceToProjection_SingleEvent(t *testing.T) {
 	assert.Equal(t, "order-123", projection.OrderId)
 	assert.Equal(t, "customer-456", projection.CustomerId)
 	assert.Equal(t, "vendor-789", projection.VendorId)
-	assert.Equal(t, "product-101", projection.ProductId)
-	assert.Equal(t, int32(3), projection.Quantity)
-	assert.Equal(t, 150.00, projection.TotalPrice)
+	assert.Equal(t, []LineItem{{ProductId: "product-101", Quantity: 3, UnitPrice: usd(5000), TotalPrice: usd(15000), Discount: usd(0)}}, projection.LineItems)
+	assert.Equal(t, usd(15000), projection.TotalPrice)
 	assert.Equal(t, "paypal", projection.PaymentMethod)
 	assert.Equal(t, PaymentStatusPending, projection.PaymentStatus)
 	assert.Equal(t, ShippingStatusWaitingForPayment, projection.ShippingStatus)
@@ -398,9 +881,8 @@ func TestReduceToProjection_MultipleEvents(t *testing.T) {
 	assert.Equal(t, "order-123", projection.OrderId)
 	assert.Equal(t, "customer-456", projection.CustomerId)
 	assert.Equal(t, "vendor-789", projection.VendorId)
-	assert.Equal(t, "product-101", projection.ProductId)
-	assert.Equal(t, int32(2), projection.Quantity)
-	assert.Equal(t, 100.00, projection.TotalPrice)
+	assert.Equal(t, []LineItem{{ProductId: "product-101", Quantity: 2, UnitPrice: usd(5000), TotalPrice: usd(10000), Discount: usd(0)}}, projection.LineItems)
+	assert.Equal(t, usd(10000), projection.TotalPrice)
 	assert.Equal(t, "credit_card", projection.PaymentMethod)
 	assert.Equal(t, PaymentStatusPaid, projection.PaymentStatus)
 	assert.Equal(t, ShippingStatusInTransit, projection.ShippingStatus)
@@ -418,9 +900,8 @@ func TestReduceToProjection_EmptyEvents(t *testing.T) {
 	assert.Equal(t, "", projection.OrderId)
 	assert.Equal(t, "", projection.CustomerId)
 	assert.Equal(t, "", projection.VendorId)
-	assert.Equal(t, "", projection.ProductId)
-	assert.Equal(t, int32(0), projection.Quantity)
-	assert.Equal(t, 0.0, projection.TotalPrice)
+	assert.Empty(t, projection.LineItems)
+	assert.True(t, projection.TotalPrice.IsZero())
 	assert.Equal(t, "", projection.PaymentMethod)
 	assert.Equal(t, "", projection.PaymentStatus)
 	assert.Equal(t, "", projection.ShippingStatus)
@@ -0,0 +1,229 @@
+package sagas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/saga"
+
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SagaTypePayment = "order-payment"
+
+	// Payment saga steps
+	StepOnHold               = "on_hold"
+	StepInitiatingPayment    = "initiating_payment"
+	StepProcessingPayment    = "processing_payment"
+	StepAwaitingConfirmation = "awaiting_confirmation"
+	StepPaymentFailed        = "payment_failed"
+	StepPaid                 = "paid"
+	StepCancelled            = "cancelled"
+	StepExpired              = "expired"
+
+	TimeoutPayment = "payment"
+)
+
+// PaymentSaga drives an order from placement to payment.
+//
+//	OrderPlaced           -> screen the order for fraud, then initialize the payment and start the payment timeout
+//	                         or wait for the review of the held order
+//	OrderReleased         -> initialize the payment, start the payment timeout
+//	OrderPaymentInitiated -> process the payment, or submit it to the payment provider
+//	OrderPaymentSubmitted -> wait for the payment provider to confirm the payment
+//	OrderPaid             -> done
+//	OrderPaymentFailed    -> wait for the customer until the payment timeout
+//	OrderAmended          -> screen the order again if its line items changed, then initialize the payment
+//	                         again if the payment method of a failed payment changed
+//	OrderCancelled        -> done
+//	payment timeout       -> compensate by cancelling the unpaid order, or extend the timeout while the
+//	                         payment provider has yet to confirm or decline a submitted payment
+type PaymentSaga struct {
+	Controller     *controller.Controller
+	PaymentTimeout time.Duration
+
+	// AsyncConfirmation submits payments to the payment provider, which confirms them through webhooks,
+	// instead of processing them synchronously.
+	AsyncConfirmation bool
+}
+
+func NewPaymentSaga(controller *controller.Controller, paymentTimeout time.Duration, asyncConfirmation bool) *PaymentSaga {
+	return &PaymentSaga{
+		Controller:        controller,
+		PaymentTimeout:    paymentTimeout,
+		AsyncConfirmation: asyncConfirmation,
+	}
+}
+
+func (s *PaymentSaga) Name() string {
+	return SagaTypePayment
+}
+
+func (s *PaymentSaga) Correlate(args eventsrc.ConsumeArgs) (string, bool) {
+	if args.AggregateType != orders.AggregateTypeOrder {
+		return "", false
+	}
+	return args.AggregateID, true
+}
+
+func (s *PaymentSaga) Handle(ctx context.Context, state *saga.State, args eventsrc.ConsumeArgs) error {
+	// Only an OrderPlaced event can start the saga
+	if state.IsNew() && args.EventType != orders.EventTypeOrderPlaced {
+		return nil
+	}
+
+	switch args.EventType {
+	case orders.EventTypeOrderPlaced:
+		return s.handleOrderPlaced(ctx, state, args)
+
+	case orders.EventTypeOrderReleased:
+		return s.handleOrderReleased(ctx, state, args)
+
+	case orders.EventTypeOrderPaymentInitiated:
+		if s.AsyncConfirmation {
+			err := s.Controller.SubmitPayment(ctx, state.CorrelationID)
+			if err != nil && !errors.Is(err, controller.ErrPaymentStatusNotInitiated) && !errors.Is(err, controller.ErrOrderAlreadyCancelled) {
+				return fmt.Errorf("failed to submit payment: %w", err)
+			}
+		} else {
+			err := s.Controller.ProcessPayment(ctx, state.CorrelationID)
+			if err != nil && !errors.Is(err, controller.ErrPaymentStatusNotInitiated) && !errors.Is(err, controller.ErrOrderAlreadyCancelled) {
+				return fmt.Errorf("failed to process payment: %w", err)
+			}
+		}
+		state.TransitionTo(StepProcessingPayment)
+
+	case orders.EventTypeOrderPaymentSubmitted:
+		state.TransitionTo(StepAwaitingConfirmation)
+
+	case orders.EventTypeOrderPaid:
+		state.CancelTimeout(TimeoutPayment)
+		state.TransitionTo(StepPaid)
+		state.Complete()
+
+	case orders.EventTypeOrderPaymentFailed:
+		state.TransitionTo(StepPaymentFailed)
+
+	case orders.EventTypeOrderAmended:
+		return s.handleOrderAmended(ctx, state, args)
+
+	case orders.EventTypeOrderCancelled:
+		state.CancelTimeout(TimeoutPayment)
+		state.TransitionTo(StepCancelled)
+		state.Complete()
+	}
+
+	return nil
+}
+
+func (s *PaymentSaga) handleOrderPlaced(ctx context.Context, state *saga.State, args eventsrc.ConsumeArgs) error {
+	if !state.IsNew() {
+		return nil
+	}
+
+	var event pb.OrderPlaced
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order placed event: %w", err)
+	}
+
+	// Held orders wait for their review without a payment timeout
+	held, err := s.Controller.ScreenOrder(ctx, state.CorrelationID)
+	if err != nil {
+		return fmt.Errorf("failed to screen order: %w", err)
+	}
+	if held {
+		state.TransitionTo(StepOnHold)
+		return nil
+	}
+
+	return s.initializePayment(ctx, state, event.Timestamp.AsTime())
+}
+
+func (s *PaymentSaga) handleOrderReleased(ctx context.Context, state *saga.State, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderReleased
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order released event: %w", err)
+	}
+
+	// The customer gets the full payment timeout from the release
+	return s.initializePayment(ctx, state, event.Timestamp.AsTime())
+}
+
+func (s *PaymentSaga) initializePayment(ctx context.Context, state *saga.State, startedAt time.Time) error {
+	err := s.Controller.InitializePendingPayment(ctx, state.CorrelationID)
+	if err != nil && !errors.Is(err, controller.ErrPaymentStatusNotPending) {
+		return fmt.Errorf("failed to initialize payment: %w", err)
+	}
+
+	state.ScheduleTimeout(TimeoutPayment, startedAt.Add(s.PaymentTimeout))
+	state.TransitionTo(StepInitiatingPayment)
+
+	return nil
+}
+
+func (s *PaymentSaga) handleOrderAmended(ctx context.Context, state *saga.State, args eventsrc.ConsumeArgs) error {
+	var event pb.OrderAmended
+	if err := proto.Unmarshal(args.Data, &event); err != nil {
+		return fmt.Errorf("failed to unmarshal order amended event: %w", err)
+	}
+	// New line items change what is paid for, so the order is screened again before its payment.
+	// Held orders wait for their review without a payment timeout.
+	if len(event.LineItems) > 0 {
+		held, err := s.Controller.ScreenOrder(ctx, state.CorrelationID)
+		if err != nil {
+			return fmt.Errorf("failed to screen order: %w", err)
+		}
+		if held {
+			state.CancelTimeout(TimeoutPayment)
+			state.TransitionTo(StepOnHold)
+			return nil
+		}
+	}
+
+	if !event.PaymentRestarted {
+		return nil
+	}
+
+	// The payment timeout of the order still applies. A held order is initialized once released.
+	err := s.Controller.InitializePendingPayment(ctx, state.CorrelationID)
+	if errors.Is(err, controller.ErrOrderOnHold) {
+		return nil
+	} else if err != nil && !errors.Is(err, controller.ErrPaymentStatusNotPending) {
+		return fmt.Errorf("failed to initialize payment: %w", err)
+	}
+
+	state.TransitionTo(StepInitiatingPayment)
+
+	return nil
+}
+
+func (s *PaymentSaga) HandleTimeout(ctx context.Context, state *saga.State, name string) error {
+	if name != TimeoutPayment {
+		return fmt.Errorf("unknown timeout: %s", name)
+	}
+
+	// Compensate by cancelling the order. If it was paid or cancelled in the meantime,
+	// the saga will be finished by the corresponding event.
+	err := s.Controller.ExpireUnpaidOrder(ctx, state.CorrelationID)
+	if errors.Is(err, controller.ErrOrderAlreadyPaid) || errors.Is(err, controller.ErrOrderAlreadyCancelled) {
+		return nil
+	} else if errors.Is(err, controller.ErrPaymentAwaitingConfirmation) {
+		// A submitted payment may still be captured, so wait for the payment provider to confirm or decline it
+		state.ScheduleTimeout(TimeoutPayment, time.Now().Add(s.PaymentTimeout))
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to expire unpaid order: %w", err)
+	}
+
+	state.TransitionTo(StepExpired)
+	state.Compensate()
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package sagas
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/fraud"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/saga"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	testOrderId        = "order-123"
+	testPaymentTimeout = 30 * time.Minute
+	testTimeoutKey     = "saga:" + SagaTypePayment + ":" + testOrderId + ":" + TimeoutPayment
+)
+
+// fixedScorer gives every order the same score, which tests change to hold orders.
+type fixedScorer struct {
+	value int32
+}
+
+func (s *fixedScorer) Score(ctx context.Context, order *orders.OrderProjection) (fraud.Score, error) {
+	return fraud.Score{Value: s.value, Reasons: []string{"suspicious"}}, nil
+}
+
+// paymentSagaTest runs the payment saga against a controller backed by in-memory stores.
+type paymentSagaTest struct {
+	t          *testing.T
+	events     *eventsrc.InMemoryStore
+	timers     *timers.InMemoryStore
+	sagas      *saga.InMemoryStore
+	scorer     *fixedScorer
+	controller *controller.Controller
+	runner     *saga.Runner
+}
+
+func newPaymentSagaTest(t *testing.T) *paymentSagaTest {
+	events := eventsrc.NewInMemoryStore()
+	producer := eventsrc.NewTransactionProducer(events, eventsrc.NewInMemoryBus(), &pg.TestTransactor{})
+	scorer := &fixedScorer{}
+	ctrl := controller.NewController(events, producer, nil, &pg.TestTransactor{}, nil, nil, nil, 0, scorer, 50)
+
+	timerStore := timers.NewInMemoryStore()
+	sagaStore := saga.NewInMemoryStore()
+	runner := saga.NewRunner(NewPaymentSaga(ctrl, testPaymentTimeout, true), sagaStore, timerStore, &pg.TestTransactor{})
+
+	return &paymentSagaTest{t: t, events: events, timers: timerStore, sagas: sagaStore, scorer: scorer, controller: ctrl, runner: runner}
+}
+
+// publish records an order event, as a command of another service would, and passes it to the saga.
+func (s *paymentSagaTest) publish(eventType string, event proto.Message) {
+	data, err := proto.Marshal(event)
+	require.NoError(s.t, err)
+
+	recorded, err := s.events.ListByAggregateID(context.Background(), testOrderId, orders.AggregateTypeOrder)
+	require.NoError(s.t, err)
+
+	_, err = s.events.Persist(context.Background(), nil, eventsrc.PersistEventArgs{
+		SequenceNumber: len(recorded),
+		AggregateId:    testOrderId,
+		AggregateType:  orders.AggregateTypeOrder,
+		EventType:      eventType,
+		Data:           data,
+	})
+	require.NoError(s.t, err)
+
+	s.consume(eventType)
+}
+
+// consume passes the last recorded event of a type to the saga.
+func (s *paymentSagaTest) consume(eventType string) {
+	recorded, err := s.events.ListByAggregateID(context.Background(), testOrderId, orders.AggregateTypeOrder)
+	require.NoError(s.t, err)
+
+	for i := len(recorded) - 1; i >= 0; i-- {
+		if recorded[i].EventType != eventType {
+			continue
+		}
+		err := s.runner.Consume(context.Background(), eventsrc.ConsumeArgs{
+			AggregateID:   testOrderId,
+			AggregateType: orders.AggregateTypeOrder,
+			EventType:     eventType,
+			Data:          recorded[i].Data,
+		})
+		require.NoError(s.t, err)
+		return
+	}
+
+	s.t.Fatalf("no %s event was recorded", eventType)
+}
+
+// fireTimeout fires the payment timeout as the timer poller would.
+func (s *paymentSagaTest) fireTimeout() timers.Timer {
+	now := time.Now().UTC().Add(2 * testPaymentTimeout)
+	claimed, err := s.timers.ClaimDue(context.Background(), now, 10, time.Minute)
+	require.NoError(s.t, err)
+	require.Len(s.t, claimed, 1)
+
+	require.NoError(s.t, s.runner.Handle(context.Background(), claimed[0]))
+	require.NoError(s.t, s.timers.MarkFired(context.Background(), claimed[0]))
+
+	return claimed[0]
+}
+
+func (s *paymentSagaTest) step() string {
+	state, err := s.sagas.Load(context.Background(), SagaTypePayment, testOrderId)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, state)
+	return state.Step
+}
+
+func eur(amount int64) *pb.Money {
+	return &pb.Money{CurrencyCode: "EUR", AmountMinor: amount}
+}
+
+func orderPlacedEvent() *pb.OrderPlaced {
+	return &pb.OrderPlaced{
+		OrderId:       testOrderId,
+		Timestamp:     timestamppb.Now(),
+		VendorId:      "vendor-123",
+		CustomerId:    "customer-456",
+		PaymentMethod: "credit_card",
+		LineItems: []*pb.OrderLineItem{
+			{ProductId: "product-789", Quantity: 2, UnitAmount: eur(1000), TotalAmount: eur(2000), DiscountAmount: eur(0)},
+		},
+		SubtotalAmount: eur(2000),
+		DiscountAmount: eur(0),
+		TotalAmount:    eur(2000),
+	}
+}
+
+func TestPaymentSaga_PaymentTimeout(t *testing.T) {
+	t.Run("timeout is extended while the payment awaits confirmation", func(t *testing.T) {
+		s := newPaymentSagaTest(t)
+
+		s.publish(orders.EventTypeOrderPlaced, orderPlacedEvent())
+		s.consume(orders.EventTypeOrderPaymentInitiated)
+		s.consume(orders.EventTypeOrderPaymentSubmitted)
+		require.Equal(t, StepAwaitingConfirmation, s.step())
+
+		claimed := s.fireTimeout()
+
+		assert.Equal(t, StepAwaitingConfirmation, s.step())
+		timer := s.timers.Timers[testTimeoutKey]
+		require.NotNil(t, timer)
+		assert.Equal(t, timers.StatusScheduled, timer.Status)
+		assert.NotEqual(t, claimed.FireAt, timer.FireAt)
+		assert.True(t, timer.FireAt.After(time.Now()))
+	})
+
+	t.Run("timeout is started again when an order held after an amendment is released", func(t *testing.T) {
+		s := newPaymentSagaTest(t)
+
+		s.publish(orders.EventTypeOrderPlaced, orderPlacedEvent())
+		require.Equal(t, StepInitiatingPayment, s.step())
+		require.Equal(t, timers.StatusScheduled, s.timers.Timers[testTimeoutKey].Status)
+
+		s.scorer.value = 80
+		s.publish(orders.EventTypeOrderAmended, &pb.OrderAmended{
+			OrderId:   testOrderId,
+			Timestamp: timestamppb.Now(),
+			LineItems: []*pb.OrderLineItem{
+				{ProductId: "product-789", Quantity: 5, UnitAmount: eur(1000), TotalAmount: eur(5000), DiscountAmount: eur(0)},
+			},
+			SubtotalAmount:   eur(5000),
+			DiscountAmount:   eur(0),
+			TotalAmount:      eur(5000),
+			PaymentRestarted: true,
+		})
+		require.Equal(t, StepOnHold, s.step())
+		require.Equal(t, timers.StatusCancelled, s.timers.Timers[testTimeoutKey].Status)
+
+		releasedAt := time.Now()
+		_, err := s.controller.ReleaseHeldOrder(context.Background(), &pb.ReleaseHeldOrderRequest{OrderId: testOrderId})
+		require.NoError(t, err)
+		s.consume(orders.EventTypeOrderReleased)
+
+		assert.Equal(t, StepInitiatingPayment, s.step())
+		timer := s.timers.Timers[testTimeoutKey]
+		require.NotNil(t, timer)
+		assert.Equal(t, timers.StatusScheduled, timer.Status)
+		assert.False(t, timer.FireAt.Before(releasedAt.Add(testPaymentTimeout)))
+	})
+}
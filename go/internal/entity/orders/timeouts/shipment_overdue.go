@@ -0,0 +1,43 @@
+package timeouts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+)
+
+// ShipmentOverdueHandler flags orders that are still waiting for shipment when their shipment deadline fires,
+// by emitting OrderShipmentOverdue.
+type ShipmentOverdueHandler struct {
+	Controller *controller.Controller
+}
+
+func NewShipmentOverdueHandler(controller *controller.Controller) *ShipmentOverdueHandler {
+	return &ShipmentOverdueHandler{
+		Controller: controller,
+	}
+}
+
+func (h *ShipmentOverdueHandler) TimerType() string {
+	return orders.TimerTypeShipmentOverdue
+}
+
+func (h *ShipmentOverdueHandler) Handle(ctx context.Context, timer timers.Timer) error {
+	flagged, err := h.Controller.FlagShipmentOverdue(ctx, timer.AggregateID, timer.FireAt)
+	if errors.Is(err, controller.ErrOrderNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to flag overdue shipment: %w", err)
+	}
+
+	if flagged {
+		logging.FromContext(ctx).Warn("Order is overdue for shipment", "orderId", timer.AggregateID, "dueAt", timer.FireAt, "timerType", h.TimerType())
+	}
+
+	return nil
+}
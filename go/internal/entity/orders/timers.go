@@ -0,0 +1,12 @@
+package orders
+
+import "fmt"
+
+const (
+	TimerTypeShipmentOverdue = "order_shipment_overdue"
+)
+
+// ShipmentOverdueTimerKey returns the key of the timer that flags a paid order that was never shipped.
+func ShipmentOverdueTimerKey(orderId string) string {
+	return fmt.Sprintf("%s:%s", TimerTypeShipmentOverdue, orderId)
+}
@@ -0,0 +1,129 @@
+package pricing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const ProductPriceTable = "product_price"
+
+type DbProductPrice struct {
+	ProductId    string    `db:"product_id"`
+	CurrencyCode string    `db:"currency_code"`
+	AmountMinor  int64     `db:"amount_minor"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// PriceCatalog stores the unit price of products.
+type PriceCatalog interface {
+	SetPrice(ctx context.Context, productId string, price money.Money) error
+
+	// GetPrices returns the price of the given products. Products without a price are left out.
+	GetPrices(ctx context.Context, productIds []string) (map[string]money.Money, error)
+}
+
+/** Postgres Catalog */
+
+type PgPriceCatalog struct {
+	db *sqlx.DB
+}
+
+func NewPgPriceCatalog(db *sqlx.DB) *PgPriceCatalog {
+	return &PgPriceCatalog{db: db}
+}
+
+func (c *PgPriceCatalog) SetPrice(ctx context.Context, productId string, price money.Money) error {
+	// Compile query
+	ds := pg.Dialect.Insert(ProductPriceTable).Prepared(true).
+		Rows(goqu.Record{
+			"product_id":    productId,
+			"currency_code": price.Currency,
+			"amount_minor":  price.Amount,
+			"updated_at":    time.Now(),
+		}).
+		OnConflict(goqu.DoUpdate("product_id", goqu.Record{
+			"currency_code": goqu.I("excluded.currency_code"),
+			"amount_minor":  goqu.I("excluded.amount_minor"),
+			"updated_at":    goqu.I("excluded.updated_at"),
+		}))
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = c.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+func (c *PgPriceCatalog) GetPrices(ctx context.Context, productIds []string) (map[string]money.Money, error) {
+
+	ds := pg.Dialect.From(ProductPriceTable).Prepared(true).
+		Select(&DbProductPrice{}).
+		Where(goqu.Ex{"product_id": productIds})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := c.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	dbPrices := []DbProductPrice{}
+	err = sqlx.StructScan(rows, &dbPrices)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	prices := make(map[string]money.Money, len(dbPrices))
+	for _, price := range dbPrices {
+		prices[price.ProductId] = money.Money{Currency: price.CurrencyCode, Amount: price.AmountMinor}
+	}
+
+	return prices, nil
+}
+
+/** In-Memory Catalog */
+
+type InMemoryPriceCatalog struct {
+	mu     sync.Mutex
+	Prices map[string]money.Money
+}
+
+func NewInMemoryPriceCatalog() *InMemoryPriceCatalog {
+	return &InMemoryPriceCatalog{Prices: make(map[string]money.Money)}
+}
+
+func (c *InMemoryPriceCatalog) SetPrice(ctx context.Context, productId string, price money.Money) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Prices[productId] = price
+	return nil
+}
+
+func (c *InMemoryPriceCatalog) GetPrices(ctx context.Context, productIds []string) (map[string]money.Money, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prices := make(map[string]money.Money, len(productIds))
+	for _, productId := range productIds {
+		if price, ok := c.Prices[productId]; ok {
+			prices[productId] = price
+		}
+	}
+	return prices, nil
+}
@@ -0,0 +1,159 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+	couponctrl "github.com/cgund98/go-eventsrc-example/internal/entity/coupons/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+)
+
+// CouponProvider returns the projection of a coupon, or nil if it does not exist.
+type CouponProvider interface {
+	GetProjection(ctx context.Context, code string) (*coupons.CouponProjection, int, error)
+}
+
+// Engine prices orders from the price catalog and applies coupons.
+type Engine struct {
+	catalog PriceCatalog
+	coupons CouponProvider
+}
+
+func NewEngine(catalog PriceCatalog, coupons CouponProvider) *Engine {
+	return &Engine{catalog: catalog, coupons: coupons}
+}
+
+type QuoteArgs struct {
+	VendorId   string
+	LineItems  []*pb.PlaceOrderLineItem
+	CouponCode string
+
+	// OrderId is set when repricing an existing order. The coupon the order already redeemed
+	// stays applicable even if its usage limit is reached.
+	OrderId string
+}
+
+type QuotedLineItem struct {
+	ProductId string
+	Quantity  int32
+	UnitPrice money.Money
+	// Discount is the share of the order discount given to the line item.
+	Discount money.Money
+	// TotalPrice is the price of the line item after its discount.
+	TotalPrice money.Money
+}
+
+// Quote is the price breakdown of an order: Total = Subtotal - Discount.
+type Quote struct {
+	LineItems  []QuotedLineItem
+	Subtotal   money.Money
+	Discount   money.Money
+	Total      money.Money
+	CouponCode string
+}
+
+// NormalizeCouponCode returns the canonical form of a coupon code. Codes are case-insensitive.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Quote computes the price of an order. Every product must be priced in the catalog, in the
+// same currency. The discount of the coupon, if any, is spread over the line items in
+// proportion to their price.
+func (e *Engine) Quote(ctx context.Context, args QuoteArgs) (*Quote, error) {
+
+	productIds := make([]string, len(args.LineItems))
+	for i, item := range args.LineItems {
+		productIds[i] = item.ProductId
+	}
+
+	prices, err := e.catalog.GetPrices(ctx, productIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product prices: %w", err)
+	}
+
+	quote := &Quote{LineItems: make([]QuotedLineItem, len(args.LineItems))}
+	weights := make([]int64, len(args.LineItems))
+	for i, item := range args.LineItems {
+		unitPrice, ok := prices[item.ProductId]
+		if !ok {
+			return nil, ErrProductNotPriced
+		}
+		if i == 0 {
+			quote.Subtotal = money.Zero(unitPrice.Currency)
+		}
+
+		lineSubtotal := unitPrice.Mul(int64(item.Quantity))
+		quote.Subtotal, err = quote.Subtotal.Add(lineSubtotal)
+		if errors.Is(err, money.ErrCurrencyMismatch) {
+			return nil, ErrCurrencyMismatch
+		}
+
+		quote.LineItems[i] = QuotedLineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  unitPrice,
+			TotalPrice: lineSubtotal,
+		}
+		weights[i] = lineSubtotal.Amount
+	}
+
+	quote.Discount = money.Zero(quote.Subtotal.Currency)
+	if args.CouponCode != "" {
+		quote.CouponCode = NormalizeCouponCode(args.CouponCode)
+		quote.Discount, err = e.couponDiscount(ctx, quote.CouponCode, args, quote.Subtotal)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Spread the discount over the line items
+	for i, discount := range quote.Discount.Allocate(weights...) {
+		quote.LineItems[i].Discount = discount
+		quote.LineItems[i].TotalPrice, _ = quote.LineItems[i].TotalPrice.Sub(discount)
+	}
+
+	quote.Total, _ = quote.Subtotal.Sub(quote.Discount)
+
+	return quote, nil
+}
+
+// couponDiscount returns the discount of a coupon on an order subtotal. The discount never
+// exceeds the subtotal.
+func (e *Engine) couponDiscount(ctx context.Context, code string, args QuoteArgs, subtotal money.Money) (money.Money, error) {
+
+	coupon, _, err := e.coupons.GetProjection(ctx, code)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to get coupon: %w", err)
+	}
+	if coupon == nil {
+		return money.Money{}, ErrUnknownCoupon
+	}
+
+	if coupon.VendorId != "" && coupon.VendorId != args.VendorId {
+		return money.Money{}, ErrCouponNotApplicable
+	}
+	if coupon.IsExhausted() && !coupon.Redemptions[args.OrderId] {
+		return money.Money{}, couponctrl.ErrCouponExhausted
+	}
+
+	switch coupon.Kind {
+	case coupons.CouponKindPercentage:
+		return subtotal.MulRatio(int64(coupon.PercentOffBps), 10000), nil
+
+	case coupons.CouponKindFixed:
+		if coupon.AmountOff.Currency != subtotal.Currency {
+			return money.Money{}, ErrCouponCurrencyMismatch
+		}
+		if coupon.AmountOff.Amount > subtotal.Amount {
+			return subtotal, nil
+		}
+		return coupon.AmountOff, nil
+	}
+
+	return money.Money{}, fmt.Errorf("unknown coupon kind: %s", coupon.Kind)
+}
@@ -0,0 +1,129 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/coupons"
+	couponctrl "github.com/cgund98/go-eventsrc-example/internal/entity/coupons/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCoupons is an in-memory CouponProvider
+type fakeCoupons map[string]*coupons.CouponProjection
+
+func (f fakeCoupons) GetProjection(ctx context.Context, code string) (*coupons.CouponProjection, int, error) {
+	return f[code], 0, nil
+}
+
+func usd(amount int64) money.Money {
+	return money.Money{Currency: "USD", Amount: amount}
+}
+
+func newTestEngine() *Engine {
+	catalog := NewInMemoryPriceCatalog()
+	catalog.Prices["product-1"] = usd(999)
+	catalog.Prices["product-2"] = usd(333)
+	catalog.Prices["product-3"] = money.Money{Currency: "EUR", Amount: 500}
+
+	return NewEngine(catalog, fakeCoupons{
+		"PCT15": {Code: "PCT15", Kind: coupons.CouponKindPercentage, PercentOffBps: 1500, Redemptions: map[string]bool{}},
+		"FIVE":  {Code: "FIVE", Kind: coupons.CouponKindFixed, AmountOff: usd(500), Redemptions: map[string]bool{}},
+		"HUGE":  {Code: "HUGE", Kind: coupons.CouponKindFixed, AmountOff: usd(100000), Redemptions: map[string]bool{}},
+		"VENDOR": {Code: "VENDOR", Kind: coupons.CouponKindPercentage, PercentOffBps: 1000, VendorId: "vendor-2",
+			Redemptions: map[string]bool{}},
+		"ONCE": {Code: "ONCE", Kind: coupons.CouponKindPercentage, PercentOffBps: 1000, UsageLimit: 1,
+			Redemptions: map[string]bool{"order-1": true}},
+	})
+}
+
+func quoteArgs(couponCode string, productIds ...string) QuoteArgs {
+	args := QuoteArgs{VendorId: "vendor-1", CouponCode: couponCode}
+	for _, productId := range productIds {
+		args.LineItems = append(args.LineItems, &pb.PlaceOrderLineItem{ProductId: productId, Quantity: 1})
+	}
+	return args
+}
+
+func TestEngine_Quote(t *testing.T) {
+	t.Run("prices line items from the catalog", func(t *testing.T) {
+		args := quoteArgs("", "product-1", "product-2")
+		args.LineItems[1].Quantity = 3
+
+		quote, err := newTestEngine().Quote(context.Background(), args)
+
+		require.NoError(t, err)
+		assert.Equal(t, usd(1998), quote.Subtotal)
+		assert.Equal(t, usd(0), quote.Discount)
+		assert.Equal(t, usd(1998), quote.Total)
+		assert.Equal(t, usd(999), quote.LineItems[1].TotalPrice)
+	})
+
+	t.Run("percentage coupon is rounded and spread over the line items", func(t *testing.T) {
+		quote, err := newTestEngine().Quote(context.Background(), quoteArgs("pct15", "product-1", "product-2"))
+
+		require.NoError(t, err)
+		// 15% of 13.32 is 1.998, rounded to 2.00
+		assert.Equal(t, "PCT15", quote.CouponCode)
+		assert.Equal(t, usd(200), quote.Discount)
+		assert.Equal(t, usd(1132), quote.Total)
+		assert.Equal(t, usd(150), quote.LineItems[0].Discount)
+		assert.Equal(t, usd(50), quote.LineItems[1].Discount)
+		assert.Equal(t, usd(849), quote.LineItems[0].TotalPrice)
+		assert.Equal(t, usd(283), quote.LineItems[1].TotalPrice)
+	})
+
+	t.Run("fixed coupon", func(t *testing.T) {
+		quote, err := newTestEngine().Quote(context.Background(), quoteArgs("FIVE", "product-1"))
+
+		require.NoError(t, err)
+		assert.Equal(t, usd(500), quote.Discount)
+		assert.Equal(t, usd(499), quote.Total)
+	})
+
+	t.Run("fixed coupon never exceeds the subtotal", func(t *testing.T) {
+		quote, err := newTestEngine().Quote(context.Background(), quoteArgs("HUGE", "product-1"))
+
+		require.NoError(t, err)
+		assert.Equal(t, usd(999), quote.Discount)
+		assert.Equal(t, usd(0), quote.Total)
+	})
+
+	t.Run("exhausted coupon stays applicable to the order that redeemed it", func(t *testing.T) {
+		args := quoteArgs("ONCE", "product-1")
+		args.OrderId = "order-1"
+
+		quote, err := newTestEngine().Quote(context.Background(), args)
+
+		require.NoError(t, err)
+		assert.Equal(t, usd(100), quote.Discount)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			args     QuoteArgs
+			expected error
+		}{
+			{"unpriced product", quoteArgs("", "product-1", "product-0"), ErrProductNotPriced},
+			{"mixed currencies", quoteArgs("", "product-1", "product-3"), ErrCurrencyMismatch},
+			{"unknown coupon", quoteArgs("NOPE", "product-1"), ErrUnknownCoupon},
+			{"coupon of another vendor", quoteArgs("VENDOR", "product-1"), ErrCouponNotApplicable},
+			{"exhausted coupon", quoteArgs("ONCE", "product-1"), couponctrl.ErrCouponExhausted},
+			{"fixed coupon in another currency", quoteArgs("FIVE", "product-3"), ErrCouponCurrencyMismatch},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				quote, err := newTestEngine().Quote(context.Background(), test.args)
+
+				assert.Equal(t, test.expected, err)
+				assert.Nil(t, quote)
+			})
+		}
+	})
+}
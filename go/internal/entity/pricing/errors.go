@@ -0,0 +1,13 @@
+package pricing
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrProductNotFound = status.Errorf(codes.NotFound, "product has no price")
+var ErrProductNotPriced = status.Errorf(codes.InvalidArgument, "product has no price")
+var ErrCurrencyMismatch = status.Errorf(codes.InvalidArgument, "all products must be priced in the same currency")
+var ErrUnknownCoupon = status.Errorf(codes.InvalidArgument, "unknown coupon code")
+var ErrCouponNotApplicable = status.Errorf(codes.FailedPrecondition, "coupon is not valid for this vendor")
+var ErrCouponCurrencyMismatch = status.Errorf(codes.FailedPrecondition, "coupon currency does not match the order currency")
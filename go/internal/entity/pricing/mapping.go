@@ -0,0 +1,42 @@
+package pricing
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+)
+
+func (quote *Quote) ToQuoteOrderResponse() *pb.QuoteOrderResponse {
+	lineItems := make([]*pb.QuoteLineItem, len(quote.LineItems))
+	for i, item := range quote.LineItems {
+		lineItems[i] = &pb.QuoteLineItem{
+			ProductId:  item.ProductId,
+			Quantity:   item.Quantity,
+			UnitPrice:  orders.MapMoneyToProto(item.UnitPrice),
+			TotalPrice: orders.MapMoneyToProto(item.TotalPrice),
+			Discount:   orders.MapMoneyToProto(item.Discount),
+		}
+	}
+
+	return &pb.QuoteOrderResponse{
+		LineItems:     lineItems,
+		SubtotalPrice: orders.MapMoneyToProto(quote.Subtotal),
+		Discount:      orders.MapMoneyToProto(quote.Discount),
+		TotalPrice:    orders.MapMoneyToProto(quote.Total),
+		CouponCode:    quote.CouponCode,
+	}
+}
+
+// ToOrderLineItems returns the line items of the quote as recorded on an order placed event.
+func (quote *Quote) ToOrderLineItems() []*pb.OrderLineItem {
+	lineItems := make([]*pb.OrderLineItem, len(quote.LineItems))
+	for i, item := range quote.LineItems {
+		lineItems[i] = &pb.OrderLineItem{
+			ProductId:      item.ProductId,
+			Quantity:       item.Quantity,
+			UnitAmount:     orders.MapMoneyToProto(item.UnitPrice),
+			TotalAmount:    orders.MapMoneyToProto(item.TotalPrice),
+			DiscountAmount: orders.MapMoneyToProto(item.Discount),
+		}
+	}
+	return lineItems
+}
@@ -0,0 +1,53 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (e *Engine) QuoteOrder(ctx context.Context, req *pb.QuoteOrderRequest) (*pb.QuoteOrderResponse, error) {
+	quote, err := e.Quote(ctx, QuoteArgs{
+		VendorId:   req.VendorId,
+		LineItems:  req.LineItems,
+		CouponCode: req.CouponCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return quote.ToQuoteOrderResponse(), nil
+}
+
+func (e *Engine) GetProductPrice(ctx context.Context, req *pb.GetProductPriceRequest) (*pb.GetProductPriceResponse, error) {
+	prices, err := e.catalog.GetPrices(ctx, []string{req.ProductId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product price: %w", err)
+	}
+
+	price, ok := prices[req.ProductId]
+	if !ok {
+		return nil, ErrProductNotFound
+	}
+
+	return &pb.GetProductPriceResponse{ProductId: req.ProductId, Price: orders.MapMoneyToProto(price)}, nil
+}
+
+func (e *Engine) SetProductPrice(ctx context.Context, req *pb.SetProductPriceRequest) (*pb.SetProductPriceResponse, error) {
+	price, err := orders.ParseProtoMoney(req.Price)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid price: %v", err)
+	}
+
+	err = e.catalog.SetPrice(ctx, req.ProductId, price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product price: %w", err)
+	}
+
+	return &pb.SetProductPriceResponse{ProductId: req.ProductId}, nil
+}
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type VerifierOptions struct {
+	// Issuer and Audience are checked against the iss and aud claims when set.
+	Issuer   string
+	Audience string
+	// Leeway is the clock skew tolerated on the exp and nbf claims.
+	Leeway time.Duration
+}
+
+// Verifier verifies JSON Web Tokens signed with an HMAC secret (HS256, HS384, HS512) or with
+// the keys of a JWKS (RS256, RS384, RS512, ES256, ES384, ES512).
+type Verifier struct {
+	secret []byte
+	keys   map[string]crypto.PublicKey
+	opts   VerifierOptions
+}
+
+func NewHMACVerifier(secret []byte, opts VerifierOptions) *Verifier {
+	return &Verifier{secret: secret, opts: opts}
+}
+
+// NewJWKSVerifier verifies tokens with public keys by key id, see LoadJWKS.
+func NewJWKSVerifier(keys map[string]crypto.PublicKey, opts VerifierOptions) *Verifier {
+	return &Verifier{keys: keys, opts: opts}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *int64   `json:"exp"`
+	NotBefore *int64   `json:"nbf"`
+	Role      string   `json:"role"`
+	VendorId  string   `json:"vendor_id"`
+}
+
+// audience is the aud claim, which is either a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// Verify checks the signature and the claims of a token and returns its principal. Tokens must
+// expire and carry a sub and a role claim, plus a vendor_id claim for vendors.
+func (v *Verifier) Verify(token string, now time.Time) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: malformed header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature: %v", ErrInvalidToken, err)
+	}
+	if err := v.verifySignature(h, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var c claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed claims: %v", ErrInvalidToken, err)
+	}
+	if err := v.verifyClaims(c, now); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	principal := &Principal{Subject: c.Subject, Role: c.Role, VendorId: c.VendorId}
+	if err := principal.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	return principal, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (v *Verifier) verifySignature(h header, signed string, signature []byte) error {
+	hashFunc, err := hashOf(h.Alg)
+	if err != nil {
+		return err
+	}
+
+	// The algorithm must match the kind of key, so that a public key is never used as an HMAC secret
+	if strings.HasPrefix(h.Alg, "HS") {
+		if len(v.secret) == 0 {
+			return fmt.Errorf("algorithm %s is not accepted", h.Alg)
+		}
+		mac := hmac.New(hashFunc.New, v.secret)
+		mac.Write([]byte(signed))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+
+	key, err := v.key(h.Kid)
+	if err != nil {
+		return err
+	}
+	digest := hashFunc.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(h.Alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match an RSA key", h.Alg)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hashFunc, sum, signature); err != nil {
+			return errors.New("signature mismatch")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(h.Alg, "ES") {
+			return fmt.Errorf("algorithm %s does not match an EC key", h.Alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("signature mismatch")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, sum, r, s) {
+			return errors.New("signature mismatch")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+
+	return nil
+}
+
+// key returns the key of a key id. Tokens without a key id are accepted when there is one key.
+func (v *Verifier) key(kid string) (crypto.PublicKey, error) {
+	if key, ok := v.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key id %q", kid)
+}
+
+// hashOf returns the hash of an algorithm, e.g. SHA-256 for RS256.
+func hashOf(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "HS256", "RS256", "ES256":
+		return crypto.SHA256, nil
+	case "HS384", "RS384", "ES384":
+		return crypto.SHA384, nil
+	case "HS512", "RS512", "ES512":
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("unsupported algorithm %q", alg)
+}
+
+func (v *Verifier) verifyClaims(c claims, now time.Time) error {
+	if c.ExpiresAt == nil {
+		return errors.New("token does not expire")
+	}
+	if now.After(time.Unix(*c.ExpiresAt, 0).Add(v.opts.Leeway)) {
+		return errors.New("token expired")
+	}
+	if c.NotBefore != nil && now.Before(time.Unix(*c.NotBefore, 0).Add(-v.opts.Leeway)) {
+		return errors.New("token not valid yet")
+	}
+	if v.opts.Issuer != "" && c.Issuer != v.opts.Issuer {
+		return fmt.Errorf("unexpected issuer %q", c.Issuer)
+	}
+	if v.opts.Audience != "" {
+		found := false
+		for _, aud := range c.Audience {
+			found = found || aud == v.opts.Audience
+		}
+		if !found {
+			return fmt.Errorf("token is not intended for %q", v.opts.Audience)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":  "customer-1",
+		"role": RoleCustomer,
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+}
+
+func encodeSegment(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	signed := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	sum := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func signES256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]any) string {
+	signed := encodeSegment(t, map[string]string{"alg": "ES256", "kid": kid}) + "." + encodeSegment(t, claims)
+	sum := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum[:])
+	require.NoError(t, err)
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifier_HMAC(t *testing.T) {
+	secret := []byte("secret")
+	verifier := NewHMACVerifier(secret, VerifierOptions{Issuer: "issuer", Audience: "orders", Leeway: time.Minute})
+
+	withClaims := func(update map[string]any) map[string]any {
+		claims := validClaims()
+		claims["iss"] = "issuer"
+		claims["aud"] = []string{"orders", "other"}
+		for k, v := range update {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	t.Run("returns the principal of a valid token", func(t *testing.T) {
+		principal, err := verifier.Verify(signHS256(t, secret, withClaims(nil)), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &Principal{Subject: "customer-1", Role: RoleCustomer}, principal)
+	})
+
+	t.Run("returns the vendor of a vendor principal", func(t *testing.T) {
+		token := signHS256(t, secret, withClaims(map[string]any{"role": RoleVendor, "vendor_id": "vendor-1"}))
+
+		principal, err := verifier.Verify(token, now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "vendor-1", principal.VendorId)
+	})
+
+	t.Run("tolerates the leeway past expiry", func(t *testing.T) {
+		token := signHS256(t, secret, withClaims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}))
+
+		_, err := verifier.Verify(token, now)
+
+		assert.NoError(t, err)
+	})
+
+	invalid := map[string]string{
+		"an expired token":                signHS256(t, secret, withClaims(map[string]any{"exp": now.Add(-time.Hour).Unix()})),
+		"a token without expiry":          signHS256(t, secret, withClaims(map[string]any{"exp": nil})),
+		"a token not valid yet":           signHS256(t, secret, withClaims(map[string]any{"nbf": now.Add(time.Hour).Unix()})),
+		"a token of another issuer":       signHS256(t, secret, withClaims(map[string]any{"iss": "other"})),
+		"a token for another audience":    signHS256(t, secret, withClaims(map[string]any{"aud": "other"})),
+		"a token of an unknown role":      signHS256(t, secret, withClaims(map[string]any{"role": "root"})),
+		"a vendor token without a vendor": signHS256(t, secret, withClaims(map[string]any{"role": RoleVendor})),
+		"a token signed with another key": signHS256(t, []byte("other"), withClaims(nil)),
+		"a malformed token":               "not-a-token",
+		"an unsigned token":               encodeSegment(t, map[string]string{"alg": "none"}) + "." + encodeSegment(t, withClaims(nil)) + ".",
+	}
+	for name, token := range invalid {
+		t.Run(fmt.Sprintf("rejects %s", name), func(t *testing.T) {
+			_, err := verifier.Verify(token, now)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestVerifier_JWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	verifier := NewJWKSVerifier(map[string]crypto.PublicKey{
+		"rsa": &rsaKey.PublicKey,
+		"ec":  &ecKey.PublicKey,
+	}, VerifierOptions{})
+
+	t.Run("verifies RS256 tokens", func(t *testing.T) {
+		principal, err := verifier.Verify(signRS256(t, rsaKey, "rsa", validClaims()), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "customer-1", principal.Subject)
+	})
+
+	t.Run("verifies ES256 tokens", func(t *testing.T) {
+		principal, err := verifier.Verify(signES256(t, ecKey, "ec", validClaims()), now)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "customer-1", principal.Subject)
+	})
+
+	t.Run("rejects tokens of an unknown key", func(t *testing.T) {
+		_, err := verifier.Verify(signRS256(t, rsaKey, "unknown", validClaims()), now)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects an algorithm that does not match the key", func(t *testing.T) {
+		_, err := verifier.Verify(signRS256(t, rsaKey, "ec", validClaims()), now)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("rejects HMAC tokens", func(t *testing.T) {
+		_, err := verifier.Verify(signHS256(t, []byte("secret"), validClaims()), now)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(data []byte) string {
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	t.Run("parses RSA and EC keys", func(t *testing.T) {
+		document := fmt.Sprintf(`{"keys": [
+			{"kty": "RSA", "kid": "rsa", "n": %q, "e": "AQAB"},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q}
+		]}`, encode(rsaKey.N.Bytes()), encode(ecKey.X.Bytes()), encode(ecKey.Y.Bytes()))
+
+		keys, err := ParseJWKS([]byte(document))
+
+		assert.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(keys["rsa"]))
+		assert.True(t, ecKey.PublicKey.Equal(keys["ec"]))
+	})
+
+	t.Run("rejects EC points off their curve", func(t *testing.T) {
+		document := fmt.Sprintf(`{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q}]}`,
+			encode(ecKey.X.Bytes()), encode(ecKey.X.Bytes()))
+
+		_, err := ParseJWKS([]byte(document))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects documents without keys", func(t *testing.T) {
+		_, err := ParseJWKS([]byte(`{"keys": []}`))
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Roles
+	RoleCustomer = "customer"
+	RoleVendor   = "vendor"
+	RoleAdmin    = "admin"
+
+	// SystemActor is the actor of the events emitted without a principal, e.g. by consumers and sagas.
+	SystemActor = "system"
+)
+
+var ErrPermissionDenied = status.Errorf(codes.PermissionDenied, "permission denied")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Role    string
+	// VendorId is the vendor a vendor principal acts for.
+	VendorId string
+}
+
+// Actor identifies the principal on the events it causes, e.g. customer:1234.
+func (p *Principal) Actor() string {
+	return fmt.Sprintf("%s:%s", p.Role, p.Subject)
+}
+
+func (p *Principal) validate() error {
+	switch p.Role {
+	case RoleCustomer, RoleAdmin:
+	case RoleVendor:
+		if p.VendorId == "" {
+			return fmt.Errorf("vendor principal %s has no vendor id", p.Subject)
+		}
+	default:
+		return fmt.Errorf("unknown role %q", p.Role)
+	}
+	if p.Subject == "" {
+		return fmt.Errorf("principal has no subject")
+	}
+	return nil
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of a request, if it was authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// ActorFromContext returns the actor of the principal of ctx, or SystemActor without one.
+func ActorFromContext(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.Actor()
+	}
+	return SystemActor
+}
+
+// CheckOwner checks that the principal of ctx may act on a resource of a customer and a vendor:
+// admins act on every resource, customers and vendors on their own. Either id can be empty if the
+// resource has no such owner.
+//
+// Contexts without a principal are allowed, as they come from the service itself or from requests
+// served while authentication is disabled.
+func CheckOwner(ctx context.Context, customerId string, vendorId string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	switch principal.Role {
+	case RoleAdmin:
+		return nil
+	case RoleCustomer:
+		if customerId != "" && principal.Subject == customerId {
+			return nil
+		}
+	case RoleVendor:
+		if vendorId != "" && principal.VendorId == vendorId {
+			return nil
+		}
+	}
+
+	return ErrPermissionDenied
+}
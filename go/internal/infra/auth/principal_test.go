@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOwner(t *testing.T) {
+	customer := &Principal{Subject: "customer-1", Role: RoleCustomer}
+	vendor := &Principal{Subject: "user-1", Role: RoleVendor, VendorId: "vendor-1"}
+	admin := &Principal{Subject: "user-2", Role: RoleAdmin}
+
+	tests := []struct {
+		name       string
+		principal  *Principal
+		customerId string
+		vendorId   string
+		allowed    bool
+	}{
+		{"allows customers on their resources", customer, "customer-1", "vendor-1", true},
+		{"denies customers on other resources", customer, "customer-2", "vendor-1", false},
+		{"allows vendors on their resources", vendor, "customer-1", "vendor-1", true},
+		{"denies vendors on other resources", vendor, "customer-1", "vendor-2", false},
+		{"denies vendors on resources without a vendor", vendor, "customer-1", "", false},
+		{"allows admins on every resource", admin, "customer-1", "vendor-1", true},
+		{"allows requests without a principal", nil, "customer-1", "vendor-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = WithPrincipal(ctx, tt.principal)
+			}
+
+			err := CheckOwner(ctx, tt.customerId, tt.vendorId)
+
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrPermissionDenied)
+			}
+		})
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	ctx := WithPrincipal(context.Background(), &Principal{Subject: "user-1", Role: RoleVendor, VendorId: "vendor-1"})
+
+	assert.Equal(t, "vendor:user-1", ActorFromContext(ctx))
+	assert.Equal(t, SystemActor, ActorFromContext(context.Background()))
+}
@@ -1,12 +1,19 @@
 package config
 
 import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
 type Config struct {
 	GrpcPort int `default:"8081"`
 	HttpPort int `default:"8080"`
+	// Port of the admin gRPC API, only served when authentication is enabled
+	AdminGrpcPort int `default:"8082"`
 
 	PostgresHost     string `default:"localhost"`
 	PostgresPort     int    `default:"5432"`
@@ -19,12 +26,114 @@ type Config struct {
 
 	KafkaHost string `default:"localhost"`
 	KafkaPort int    `default:"9092"`
+
+	TimerPollInterval time.Duration `default:"5s"`
+	// How often consumers read whether they were paused or resumed through the admin API
+	ConsumerPausePollInterval time.Duration `default:"5s"`
+
+	OrderPaymentTimeout       time.Duration `default:"30m"`
+	OrderShipmentOverdueAfter time.Duration `default:"72h"`
+	// How long after delivery an order can be returned
+	OrderReturnWindow time.Duration `default:"720h"`
+
+	// When enabled, payments are confirmed asynchronously by payment provider webhooks
+	PaymentAsyncConfirmation bool `default:"false"`
+	// Secret used to verify payment provider webhook signatures. The webhook endpoint is disabled when empty.
+	PaymentWebhookSecret string
+
+	// Orders with a fraud score of at least FraudHoldThreshold (0-100) are held for review
+	FraudHoldThreshold int32 `default:"50"`
+	// Orders of a customer beyond FraudVelocityLimit within FraudVelocityWindow add FraudVelocityScore
+	FraudVelocityWindow time.Duration `default:"1h"`
+	FraudVelocityLimit  int           `default:"3"`
+	FraudVelocityScore  int32         `default:"50"`
+	// Order totals reaching the threshold of their currency, in minor units, add FraudAmountScore
+	FraudAmountThresholds map[string]int64 `default:"USD:100000,EUR:100000,GBP:100000"`
+	FraudAmountScore      int32            `default:"50"`
+
+	// Marketplace commission taken on the sales of vendors, in basis points (1000 = 10%)
+	LedgerDefaultCommissionBps int32 `default:"1000"`
+	// Commission overrides of some vendors, e.g. "vendor-1:500,vendor-2:1500"
+	LedgerCommissionBps map[string]int32
+
+	// When disabled, orders that cannot be reserved are cancelled instead of back-ordered
+	InventoryBackorderEnabled bool `default:"true"`
+
+	// Secret used to verify carrier webhook signatures. The webhook endpoint is disabled when empty.
+	CarrierWebhookSecret string
+
+	// Requests are authenticated with JWTs signed by the keys of AuthJwksFile, or with AuthHmacSecret.
+	// Authentication is disabled when both are empty.
+	AuthJwksFile   string
+	AuthHmacSecret string
+	// Expected iss and aud claims of the tokens, not checked when empty
+	AuthIssuer   string
+	AuthAudience string
+	// Clock skew tolerated on the expiry of the tokens
+	AuthLeeway time.Duration `default:"1m"`
+
+	// Requests per second that every client (principal, or IP address if unauthenticated) can make
+	// to every RPC, with bursts of up to RateLimitBurst requests. Rate limiting is disabled when 0.
+	RateLimitRate  float64 `default:"20"`
+	RateLimitBurst int     `default:"40"`
+	// Overrides of some RPCs, by method name, e.g. "PlaceOrder:1,QuoteOrder:5"
+	RateLimitMethodRates  map[string]float64 `default:"PlaceOrder:1"`
+	RateLimitMethodBursts map[string]int     `default:"PlaceOrder:5"`
+
+	// Exporter of the traces: none, stdout, file (to TracingFile) or otlp (to TracingOtlpEndpoint)
+	TracingExporter     string `default:"none"`
+	TracingFile         string `default:"traces.json"`
+	TracingOtlpEndpoint string
+	TracingOtlpInsecure bool `default:"false"`
+	// Share of the traces started by the service that are sampled, between 0 and 1
+	TracingSampleRatio float64 `default:"1"`
+	TracingServiceName string  `default:"order-service"`
+
+	// Path of the Prometheus metrics on the HTTP port, not served when empty
+	MetricsPath string `default:"/metrics"`
+
+	// Readiness is checked every HealthCheckInterval, each check timing out after HealthCheckTimeout.
+	// Consumers are not ready once they fail HealthConsumerMaxFailures events in a row.
+	HealthCheckInterval       time.Duration `default:"10s"`
+	HealthCheckTimeout        time.Duration `default:"3s"`
+	HealthConsumerMaxFailures int           `default:"3"`
+
+	// Time given to the servers and consumers to finish the requests and events in flight on SIGTERM
+	ShutdownTimeout time.Duration `default:"20s"`
 }
 
+// Prefix prefixes the environment variables of the config, e.g. ORDER_SVC_HTTPPORT
+const Prefix = "ORDER_SVC"
+
 func LoadConfig() (*Config, error) {
 	var cfg Config
-	if err := envconfig.Process("ORDER_SVC", &cfg); err != nil {
+	if err := envconfig.Process(Prefix, &cfg); err != nil {
 		return nil, err
 	}
 	return &cfg, nil
 }
+
+// LoadRoleConfig loads the config of a role of the service, e.g. "consume". Variables prefixed with the
+// role, e.g. ORDER_SVC_CONSUME_HTTPPORT, override the shared ones, e.g. ORDER_SVC_HTTPPORT, so that
+// processes running different roles can share an environment.
+func LoadRoleConfig(role string) (*Config, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	rolePrefix := Prefix + "_" + strings.ToUpper(strings.ReplaceAll(role, "-", "_"))
+	var overrides Config
+	if err := envconfig.Process(rolePrefix, &overrides); err != nil {
+		return nil, err
+	}
+
+	target, source := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(&overrides).Elem()
+	for i := range target.NumField() {
+		if _, ok := os.LookupEnv(rolePrefix + "_" + strings.ToUpper(target.Type().Field(i).Name)); ok {
+			target.Field(i).Set(source.Field(i))
+		}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRoleConfig(t *testing.T) {
+	t.Setenv("ORDER_SVC_HTTPPORT", "9000")
+	t.Setenv("ORDER_SVC_GRPCPORT", "9001")
+	t.Setenv("ORDER_SVC_CONSUME_HTTPPORT", "9100")
+	t.Setenv("ORDER_SVC_POLL_TIMERS_HTTPPORT", "9200")
+
+	cfg, err := LoadRoleConfig("consume")
+	require.NoError(t, err)
+	assert.Equal(t, 9100, cfg.HttpPort)
+	assert.Equal(t, 9001, cfg.GrpcPort)
+	assert.Equal(t, "events", cfg.EventsTopic)
+
+	cfg, err = LoadRoleConfig("poll-timers")
+	require.NoError(t, err)
+	assert.Equal(t, 9200, cfg.HttpPort)
+
+	cfg, err = LoadRoleConfig("serve-api")
+	require.NoError(t, err)
+	assert.Equal(t, 9000, cfg.HttpPort)
+}
@@ -5,13 +5,18 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const KafkaWriteTimeout = 10 * time.Second
 const KafkaHeaderAggregateID = "aggregate-id"
 const KafkaHeaderAggregateType = "aggregate-type"
 const KafkaHeaderEventType = "event-type"
+const KafkaHeaderRequestID = "request-id"
 
 type Bus interface {
 	Publish(ctx context.Context, args *PublishArgs) error
@@ -22,6 +27,8 @@ type PublishArgs struct {
 	AggregateType string
 	EventType     string
 	Value         []byte
+	// RequestID is the id of the request that caused the event, if any
+	RequestID string
 }
 
 /** Kafka Bus */
@@ -34,7 +41,16 @@ func NewKafkaBus(writer *kafka.Writer) *KafkaBus {
 	return &KafkaBus{writer: writer}
 }
 
-func (b *KafkaBus) Publish(ctx context.Context, args *PublishArgs) error {
+func (b *KafkaBus) Publish(ctx context.Context, args *PublishArgs) (err error) {
+	ctx, span := tracing.Start(ctx, b.writer.Topic+" publish", trace.WithSpanKind(trace.SpanKindProducer), trace.WithAttributes(
+		semconv.MessagingSystemKafka,
+		semconv.MessagingDestinationName(b.writer.Topic),
+		AttributeAggregateID.String(args.AggregateID),
+		AttributeAggregateType.String(args.AggregateType),
+		AttributeEventType.String(args.EventType),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	msg := kafka.Message{
 		Headers: []kafka.Header{
 			{
@@ -52,16 +68,16 @@ func (b *KafkaBus) Publish(ctx context.Context, args *PublishArgs) error {
 		},
 		Value: args.Value,
 	}
+	if args.RequestID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: KafkaHeaderRequestID, Value: []byte(args.RequestID)})
+	}
+	// Consumers continue the trace of the event
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &msg.Headers})
 
 	wCtx, cancel := context.WithTimeout(ctx, KafkaWriteTimeout)
 	defer cancel()
 
-	err := b.writer.WriteMessages(wCtx, msg)
-	if err != nil {
-		return err
-	}
-
-	return err
+	return b.writer.WriteMessages(wCtx, msg)
 }
 
 func GetAggregateIDFromMessage(msg *kafka.Message) (string, error) {
@@ -91,6 +107,16 @@ func GetEventTypeFromMessage(msg *kafka.Message) (string, error) {
 	return "", fmt.Errorf("event type not found in message")
 }
 
+// GetRequestIDFromMessage returns the id of the request that caused an event, or an empty string.
+func GetRequestIDFromMessage(msg *kafka.Message) string {
+	for _, header := range msg.Headers {
+		if header.Key == KafkaHeaderRequestID {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
 /** In Memory Bus */
 type BusEvent struct {
 	EventType string
@@ -2,11 +2,18 @@ package eventsrc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const ConsumerRetryDelay = 5 * time.Second
@@ -40,14 +47,17 @@ func parseEventType(msg kafka.Message) string {
 }
 
 // runKafkaConsumerOnce reads a single event from the reader and passes it to the consumer.
-func runKafkaConsumerOnce(ctx context.Context, reader Reader, consumer Consumer) error {
+func runKafkaConsumerOnce(ctx context.Context, reader Reader, consumer Consumer) (err error) {
 	event, err := reader.FetchMessage(ctx)
 	if err != nil {
-		logging.Logger.Error("error reading event", "error", err)
+		// Fetching is interrupted when the consumer stops
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("error reading event", "error", err)
+		}
 		return err
 	}
 
-	logging.Logger.Debug("Received event", "eventType", parseEventType(event), "consumer", consumer.Name())
+	logging.FromContext(ctx).Debug("Received event", "eventType", parseEventType(event), "consumer", consumer.Name())
 
 	eventType, err := GetEventTypeFromMessage(&event)
 	if err != nil {
@@ -64,6 +74,29 @@ func runKafkaConsumerOnce(ctx context.Context, reader Reader, consumer Consumer)
 		return err
 	}
 
+	// Finish processing the fetched event and commit it even if the consumer is stopping
+	ctx = context.WithoutCancel(ctx)
+
+	// Continue the trace of the event in a span of its own
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &event.Headers})
+	ctx, span := tracing.Start(ctx, consumer.Name()+" process", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		semconv.MessagingSystemKafka,
+		semconv.MessagingKafkaConsumerGroup(consumer.Name()),
+		AttributeAggregateID.String(aggregateID),
+		AttributeAggregateType.String(aggregateType),
+		AttributeEventType.String(eventType),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Log the event with the request that caused it, which is passed on to the events it causes
+	if requestId := GetRequestIDFromMessage(&event); requestId != "" {
+		ctx = logging.WithRequestId(ctx, requestId)
+	}
+	ctx = logging.With(ctx, "consumer", consumer.Name(), "eventType", eventType, "aggregateId", aggregateID)
+	if span.SpanContext().IsValid() {
+		ctx = logging.With(ctx, "traceId", span.SpanContext().TraceID().String())
+	}
+
 	err = consumer.Consume(ctx, ConsumeArgs{
 		AggregateID:   aggregateID,
 		AggregateType: aggregateType,
@@ -71,13 +104,13 @@ func runKafkaConsumerOnce(ctx context.Context, reader Reader, consumer Consumer)
 		Data:          event.Value,
 	})
 	if err != nil {
-		logging.Logger.Error("error consuming event", "error", err)
+		logging.FromContext(ctx).Error("error consuming event", "error", err)
 		return err
 	}
 
 	err = reader.CommitMessages(ctx, event)
 	if err != nil {
-		logging.Logger.Error("error committing event", "error", err)
+		logging.FromContext(ctx).Error("error committing event", "error", err)
 		return err
 	}
 
@@ -86,10 +119,79 @@ func runKafkaConsumerOnce(ctx context.Context, reader Reader, consumer Consumer)
 
 type RunKafkaConsumerOptions struct {
 	RetryDelay *time.Duration
+	// Status records whether the consumer is running and processing events, for readiness checks
+	Status *ConsumerStatus
+}
+
+// ConsumerStatus tracks a consumer run by RunKafkaConsumer: whether it is running or paused, and how
+// many times in a row it failed to process an event.
+type ConsumerStatus struct {
+	maxFailures int
+
+	mu       sync.Mutex
+	running  bool
+	paused   bool
+	stopErr  error
+	failures int
+	lastErr  error
+}
+
+// NewConsumerStatus creates a status whose check fails once the consumer failed maxFailures times in a row.
+func NewConsumerStatus(maxFailures int) *ConsumerStatus {
+	return &ConsumerStatus{maxFailures: max(maxFailures, 1)}
+}
+
+func (s *ConsumerStatus) start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running, s.paused, s.stopErr, s.failures, s.lastErr = true, false, nil, 0, nil
+}
+
+func (s *ConsumerStatus) pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running, s.paused, s.stopErr = false, true, nil
+}
+
+func (s *ConsumerStatus) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running, s.stopErr = false, err
+}
+
+func (s *ConsumerStatus) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		s.failures, s.lastErr = 0, nil
+		return
+	}
+	s.failures++
+	s.lastErr = err
+}
+
+// Check returns an error if the consumer is not running, or is stuck retrying after failing
+// maxFailures times in a row. Paused consumers are healthy.
+func (s *ConsumerStatus) Check(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.paused:
+		return nil
+	case !s.running && s.stopErr != nil:
+		return fmt.Errorf("consumer stopped: %w", s.stopErr)
+	case !s.running:
+		return errors.New("consumer is not running")
+	case s.failures >= s.maxFailures:
+		return fmt.Errorf("consumer failed %d times in a row: %w", s.failures, s.lastErr)
+	}
+	return nil
 }
 
 // RunConsumer runs a kafka consumer in a loop.
-// It will read events from the reader and pass them to the consumer.
+// It will read events from the reader and pass them to the consumer. Once the context is cancelled,
+// the event being processed is committed and the loop stops.
 func RunKafkaConsumer(ctx context.Context, reader Reader, consumer Consumer, opts RunKafkaConsumerOptions) error {
 
 	// Parse options
@@ -98,17 +200,28 @@ func RunKafkaConsumer(ctx context.Context, reader Reader, consumer Consumer, opt
 		retryDelay = *opts.RetryDelay
 	}
 
-	logging.Logger.Info("Starting kafka consumer", "consumer", consumer.Name())
+	status := opts.Status
+	if status == nil {
+		status = NewConsumerStatus(1)
+	}
+
+	logging.FromContext(ctx).Info("Starting kafka consumer", "consumer", consumer.Name())
 
+	status.start()
 	for {
 		select {
 		case <-ctx.Done():
+			status.stop(ctx.Err())
 			return ctx.Err()
 		default:
 			err := runKafkaConsumerOnce(ctx, reader, consumer)
-			if err != nil {
-				logging.Logger.Error("error running kafka consumer", "error", err)
-				time.Sleep(retryDelay)
+			status.record(err)
+			if err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("error running kafka consumer", "consumer", consumer.Name(), "error", err)
+				select {
+				case <-ctx.Done():
+				case <-time.After(retryDelay):
+				}
 			}
 		}
 	}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -69,6 +71,32 @@ func TestRunKafkaConsumerOnce(t *testing.T) {
 		mockConsumer.AssertExpectations(t)
 	})
 
+	t.Run("passes on the request id of the event", func(t *testing.T) {
+		mockReader := &MockReader{}
+		mockConsumer := &MockConsumer{}
+
+		msg := kafka.Message{
+			Value: []byte("test event data"),
+			Headers: []kafka.Header{
+				{Key: KafkaHeaderEventType, Value: []byte("test_event")},
+				{Key: KafkaHeaderAggregateID, Value: []byte("agg_id")},
+				{Key: KafkaHeaderAggregateType, Value: []byte("agg_type")},
+				{Key: KafkaHeaderRequestID, Value: []byte("request-1")},
+			},
+		}
+
+		mockReader.On("FetchMessage", mock.Anything).Return(msg, nil)
+		mockConsumer.On("Consume", mock.MatchedBy(func(ctx context.Context) bool {
+			return logging.RequestIdFromContext(ctx) == "request-1"
+		}), mock.Anything).Return(nil)
+		mockReader.On("CommitMessages", mock.Anything, mock.Anything).Return(nil)
+
+		err := runKafkaConsumerOnce(context.Background(), mockReader, mockConsumer)
+
+		assert.NoError(t, err)
+		mockConsumer.AssertExpectations(t)
+	})
+
 	t.Run("fetch message error", func(t *testing.T) {
 		mockReader := &MockReader{}
 		mockConsumer := &MockConsumer{}
@@ -225,3 +253,76 @@ func TestRunKafkaConsumer(t *testing.T) {
 		mockReader.AssertNotCalled(t, "FetchMessage")
 	})
 }
+
+func TestConsumerStatus(t *testing.T) {
+	t.Run("is not ready before the consumer runs", func(t *testing.T) {
+		status := NewConsumerStatus(2)
+
+		assert.EqualError(t, status.Check(context.Background()), "consumer is not running")
+	})
+
+	t.Run("is not ready once the consumer fails too many times in a row", func(t *testing.T) {
+		status := NewConsumerStatus(2)
+		status.start()
+
+		status.record(errors.New("projection unavailable"))
+		assert.NoError(t, status.Check(context.Background()))
+
+		status.record(errors.New("projection unavailable"))
+		assert.EqualError(t, status.Check(context.Background()), "consumer failed 2 times in a row: projection unavailable")
+
+		status.record(nil)
+		assert.NoError(t, status.Check(context.Background()))
+	})
+
+	t.Run("is not ready once the consumer stops", func(t *testing.T) {
+		status := NewConsumerStatus(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_ = RunKafkaConsumer(ctx, &MockReader{}, &MockConsumer{}, RunKafkaConsumerOptions{Status: status})
+
+		assert.ErrorIs(t, status.Check(context.Background()), context.Canceled)
+	})
+}
+
+func TestRunKafkaConsumer_Shutdown(t *testing.T) {
+	t.Run("stops waiting to retry when cancelled", func(t *testing.T) {
+		mockReader := &MockReader{}
+		mockReader.On("FetchMessage", mock.Anything).Return(kafka.Message{}, errors.New("broker unavailable"))
+		retryDelay := time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- RunKafkaConsumer(ctx, mockReader, &MockConsumer{}, RunKafkaConsumerOptions{RetryDelay: &retryDelay})
+		}()
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.Equal(t, context.Canceled, err)
+		case <-time.After(time.Second):
+			t.Fatal("consumer did not stop")
+		}
+	})
+
+	t.Run("commits the event being processed when cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		mockReader := &MockReader{}
+		mockConsumer := &MockConsumer{}
+		mockReader.On("FetchMessage", mock.Anything).Return(kafka.Message{Headers: []kafka.Header{
+			{Key: KafkaHeaderEventType, Value: []byte("test_event")},
+			{Key: KafkaHeaderAggregateID, Value: []byte("agg_id")},
+			{Key: KafkaHeaderAggregateType, Value: []byte("agg_type")},
+		}}, nil).Once()
+		mockConsumer.On("Consume", mock.Anything, mock.Anything).Run(func(args mock.Arguments) { cancel() }).Return(nil)
+		mockReader.On("CommitMessages", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), mock.Anything).Return(nil)
+
+		err := RunKafkaConsumer(ctx, mockReader, mockConsumer, RunKafkaConsumerOptions{})
+
+		assert.Equal(t, context.Canceled, err)
+		mockReader.AssertNumberOfCalls(t, "FetchMessage", 1)
+		mockReader.AssertCalled(t, "CommitMessages", mock.Anything, mock.Anything)
+	})
+}
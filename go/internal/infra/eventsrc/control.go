@@ -0,0 +1,238 @@
+package eventsrc
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	ConsumerStateTable = "consumer_state"
+
+	ConsumerPausePollInterval = 5 * time.Second
+)
+
+// ConsumerStateStore records the consumers paused by operators, for every instance of the consumers.
+type ConsumerStateStore interface {
+	SetPaused(ctx context.Context, consumer string, paused bool) error
+	Paused(ctx context.Context, consumer string) (bool, error)
+	// ListPaused returns the names of the paused consumers.
+	ListPaused(ctx context.Context) ([]string, error)
+}
+
+/** Postgres Store */
+
+// ConsumerStateTableSchema is what the Postgres store expects of its table, which it upserts by consumer.
+var ConsumerStateTableSchema = pg.TableSchema{
+	Name:    ConsumerStateTable,
+	Columns: []string{"consumer", "paused", "updated_by", "updated_at"},
+	Unique:  [][]string{{"consumer"}},
+}
+
+type PostgresConsumerStateStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresConsumerStateStore(db *sqlx.DB) *PostgresConsumerStateStore {
+	return &PostgresConsumerStateStore{db: db}
+}
+
+// SetPaused records the actor of ctx as the last one to pause or resume the consumer.
+func (s *PostgresConsumerStateStore) SetPaused(ctx context.Context, consumer string, paused bool) error {
+	ds := pg.Dialect.Insert(ConsumerStateTable).Prepared(true).
+		Rows(goqu.Record{
+			"consumer":   consumer,
+			"paused":     paused,
+			"updated_by": auth.ActorFromContext(ctx),
+			"updated_at": time.Now().UTC(),
+		}).
+		OnConflict(goqu.DoUpdate("consumer", goqu.Record{
+			"paused":     goqu.I("excluded.paused"),
+			"updated_by": goqu.I("excluded.updated_by"),
+			"updated_at": goqu.I("excluded.updated_at"),
+		}))
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, query, queryArgs...); err != nil {
+		return pg.ErrorDb(err)
+	}
+	return nil
+}
+
+func (s *PostgresConsumerStateStore) Paused(ctx context.Context, consumer string) (bool, error) {
+	ds := pg.Dialect.From(ConsumerStateTable).Prepared(true).
+		Select("paused").
+		Where(goqu.Ex{"consumer": consumer})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return false, pg.ErrorDsl(err)
+	}
+
+	paused := []bool{}
+	if err := s.db.SelectContext(ctx, &paused, query, queryArgs...); err != nil {
+		return false, pg.ErrorDb(err)
+	}
+	return len(paused) > 0 && paused[0], nil
+}
+
+func (s *PostgresConsumerStateStore) ListPaused(ctx context.Context) ([]string, error) {
+	ds := pg.Dialect.From(ConsumerStateTable).Prepared(true).
+		Select("consumer").
+		Where(goqu.Ex{"paused": true}).
+		Order(goqu.C("consumer").Asc())
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	consumers := []string{}
+	if err := s.db.SelectContext(ctx, &consumers, query, queryArgs...); err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+	return consumers, nil
+}
+
+/** In-memory Store */
+
+type InMemoryConsumerStateStore struct {
+	Consumers map[string]bool
+	mu        sync.RWMutex
+}
+
+func NewInMemoryConsumerStateStore() *InMemoryConsumerStateStore {
+	return &InMemoryConsumerStateStore{Consumers: make(map[string]bool)}
+}
+
+func (s *InMemoryConsumerStateStore) SetPaused(ctx context.Context, consumer string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Consumers[consumer] = paused
+	return nil
+}
+
+func (s *InMemoryConsumerStateStore) Paused(ctx context.Context, consumer string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Consumers[consumer], nil
+}
+
+func (s *InMemoryConsumerStateStore) ListPaused(ctx context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	consumers := []string{}
+	for consumer, paused := range s.Consumers {
+		if paused {
+			consumers = append(consumers, consumer)
+		}
+	}
+	slices.Sort(consumers)
+	return consumers, nil
+}
+
+/** Pausable consumer */
+
+// ReadCloser is a Reader that leaves its consumer group once closed, e.g. a kafka.Reader.
+type ReadCloser interface {
+	Reader
+	Close() error
+}
+
+type RunPausableKafkaConsumerOptions struct {
+	RunKafkaConsumerOptions
+	// PollInterval is how often the consumer reads whether it is paused
+	PollInterval *time.Duration
+}
+
+// RunPausableKafkaConsumer runs a consumer with RunKafkaConsumer while it is not paused. Pausing the
+// consumer finishes the event being processed and closes the reader, so that the instance leaves the
+// consumer group and the offsets of the group can be reset. Resuming it opens a new reader.
+func RunPausableKafkaConsumer(ctx context.Context, states ConsumerStateStore, newReader func() ReadCloser, consumer Consumer, opts RunPausableKafkaConsumerOptions) error {
+
+	// Parse options
+	pollInterval := ConsumerPausePollInterval
+	if opts.PollInterval != nil {
+		pollInterval = *opts.PollInterval
+	}
+	if opts.Status == nil {
+		opts.Status = NewConsumerStatus(1)
+	}
+
+	ctx = logging.With(ctx, "consumer", consumer.Name())
+	paused := false
+	for {
+		wasPaused := paused
+		paused = readPaused(ctx, states, consumer.Name(), paused)
+		if paused {
+			if !wasPaused {
+				logging.FromContext(ctx).Info("Kafka consumer is paused")
+			}
+			opts.Status.pause()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(pollInterval):
+			}
+			continue
+		}
+
+		// Stop consuming once the consumer is paused
+		runCtx, cancel := context.WithCancel(ctx)
+		watched := make(chan struct{})
+		go func() {
+			defer close(watched)
+			for {
+				select {
+				case <-runCtx.Done():
+					return
+				case <-time.After(pollInterval):
+				}
+				if readPaused(runCtx, states, consumer.Name(), false) {
+					cancel()
+					return
+				}
+			}
+		}()
+
+		reader := newReader()
+		err := RunKafkaConsumer(runCtx, reader, consumer, opts.RunKafkaConsumerOptions)
+		cancel()
+		<-watched
+		if closeErr := reader.Close(); closeErr != nil {
+			logging.FromContext(ctx).Error("error closing kafka reader", "error", closeErr)
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+}
+
+// readPaused reads whether a consumer is paused, keeping its current state if the store cannot be read.
+func readPaused(ctx context.Context, states ConsumerStateStore, consumer string, current bool) bool {
+	paused, err := states.Paused(ctx, consumer)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.FromContext(ctx).Error("error reading consumer state", "error", err)
+		}
+		return current
+	}
+	return paused
+}
@@ -0,0 +1,99 @@
+package eventsrc
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingReader is a reader without events, which blocks until the consumer stops.
+type blockingReader struct {
+	closed *atomic.Int32
+}
+
+func (r *blockingReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *blockingReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return nil
+}
+
+func (r *blockingReader) Close() error {
+	r.closed.Add(1)
+	return nil
+}
+
+func TestInMemoryConsumerStateStore(t *testing.T) {
+	states := NewInMemoryConsumerStateStore()
+	ctx := context.Background()
+
+	require.NoError(t, states.SetPaused(ctx, "invoicing", true))
+	require.NoError(t, states.SetPaused(ctx, "coupon-redemption", true))
+	require.NoError(t, states.SetPaused(ctx, "invoicing", false))
+
+	paused, err := states.Paused(ctx, "coupon-redemption")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	paused, err = states.Paused(ctx, "invoicing")
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	consumers, err := states.ListPaused(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"coupon-redemption"}, consumers)
+}
+
+func TestRunPausableKafkaConsumer(t *testing.T) {
+	states := NewInMemoryConsumerStateStore()
+	consumer := &MockConsumer{}
+	status := NewConsumerStatus(1)
+	pollInterval := 5 * time.Millisecond
+
+	var opened, closed atomic.Int32
+	newReader := func() ReadCloser {
+		opened.Add(1)
+		return &blockingReader{closed: &closed}
+	}
+
+	require.NoError(t, states.SetPaused(context.Background(), consumer.Name(), true))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- RunPausableKafkaConsumer(ctx, states, newReader, consumer, RunPausableKafkaConsumerOptions{
+			RunKafkaConsumerOptions: RunKafkaConsumerOptions{Status: status},
+			PollInterval:            &pollInterval,
+		})
+	}()
+
+	// A paused consumer does not join its group, and is healthy
+	time.Sleep(20 * pollInterval)
+	assert.Equal(t, int32(0), opened.Load())
+	assert.NoError(t, status.Check(context.Background()))
+
+	// Resuming opens a reader
+	require.NoError(t, states.SetPaused(context.Background(), consumer.Name(), false))
+	assert.Eventually(t, func() bool { return opened.Load() == 1 }, time.Second, pollInterval)
+	assert.NoError(t, status.Check(context.Background()))
+
+	// Pausing closes it, so that the instance leaves the group
+	require.NoError(t, states.SetPaused(context.Background(), consumer.Name(), true))
+	assert.Eventually(t, func() bool { return closed.Load() == 1 }, time.Second, pollInterval)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not stop")
+	}
+	assert.Equal(t, int32(1), opened.Load())
+}
@@ -0,0 +1,279 @@
+package eventsrc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka states of consumer groups without members, whose offsets can be moved
+const (
+	GroupStateEmpty = "Empty"
+	GroupStateDead  = "Dead"
+)
+
+var ErrConsumerGroupActive = errors.New("consumer group has active members")
+
+// KafkaAdmin is the part of the Kafka client used to inspect and move consumer groups, e.g. a kafka.Client.
+type KafkaAdmin interface {
+	Metadata(ctx context.Context, req *kafka.MetadataRequest) (*kafka.MetadataResponse, error)
+	ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error)
+	DescribeGroups(ctx context.Context, req *kafka.DescribeGroupsRequest) (*kafka.DescribeGroupsResponse, error)
+	OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error)
+	OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error)
+}
+
+// PartitionPosition is the position of a consumer group in a partition of the topic.
+type PartitionPosition struct {
+	Partition int
+	// CommittedOffset is the offset of the next event the group consumes, -1 if it never committed one
+	CommittedOffset int64
+	// HighWaterMark is the offset of the next event published to the partition
+	HighWaterMark int64
+	// Lag is the number of events the group has left to consume
+	Lag int64
+}
+
+// ConsumerGroup is the consumer group of a consumer, named after it.
+type ConsumerGroup struct {
+	Name string
+	// State is the Kafka state of the group, e.g. Stable, or Empty once its members left
+	State      string
+	Members    int
+	Lag        int64
+	Partitions []PartitionPosition
+}
+
+type ResetPosition int
+
+const (
+	// ResetEarliest moves a consumer group to the oldest events retained by the topic
+	ResetEarliest ResetPosition = iota
+	// ResetLatest moves a consumer group past the last events, skipping them
+	ResetLatest
+	// ResetTime moves a consumer group to the first events published at or after a time
+	ResetTime
+)
+
+type ResetArgs struct {
+	Position ResetPosition
+	// Time is the time to reset to with ResetTime
+	Time time.Time
+}
+
+// ConsumerGroups inspects and moves the consumer groups reading a topic.
+type ConsumerGroups struct {
+	admin KafkaAdmin
+	topic string
+}
+
+func NewConsumerGroups(admin KafkaAdmin, topic string) *ConsumerGroups {
+	return &ConsumerGroups{admin: admin, topic: topic}
+}
+
+// Describe returns the state, the positions and the lag of consumer groups.
+func (g *ConsumerGroups) Describe(ctx context.Context, names []string) ([]ConsumerGroup, error) {
+	partitions, err := g.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := g.offsets(ctx, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	described, err := g.admin.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: names})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
+	}
+
+	groups := make([]ConsumerGroup, len(names))
+	for i, name := range names {
+		groups[i] = ConsumerGroup{Name: name, State: GroupStateDead}
+		for _, group := range described.Groups {
+			if group.GroupID == name && group.Error == nil {
+				groups[i].State, groups[i].Members = group.GroupState, len(group.Members)
+			}
+		}
+
+		fetched, err := g.admin.OffsetFetch(ctx, &kafka.OffsetFetchRequest{GroupID: name, Topics: map[string][]int{g.topic: partitions}})
+		if err == nil {
+			err = fetched.Error
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch offsets of consumer group %s: %w", name, err)
+		}
+
+		committed := map[int]int64{}
+		for _, partition := range partitions {
+			committed[partition] = -1
+		}
+		for _, partition := range fetched.Topics[g.topic] {
+			committed[partition.Partition] = partition.CommittedOffset
+		}
+		for _, partition := range partitions {
+			position := newPartitionPosition(partition, committed[partition], offsets[partition])
+			groups[i].Partitions = append(groups[i].Partitions, position)
+			groups[i].Lag += position.Lag
+		}
+	}
+
+	return groups, nil
+}
+
+// Reset moves a consumer group in every partition of the topic and returns its new positions. Kafka
+// only lets the offsets of groups without members be moved, so the consumer must be stopped first.
+func (g *ConsumerGroups) Reset(ctx context.Context, name string, args ResetArgs) ([]PartitionPosition, error) {
+	described, err := g.admin.DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: []string{name}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer group: %w", err)
+	}
+	for _, group := range described.Groups {
+		if group.GroupID == name && group.Error == nil && group.GroupState != GroupStateEmpty && group.GroupState != GroupStateDead {
+			return nil, fmt.Errorf("%w: %s is %s with %d members", ErrConsumerGroupActive, name, group.GroupState, len(group.Members))
+		}
+	}
+
+	partitions, err := g.partitions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	offsets, err := g.offsets(ctx, partitions)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := map[int]int64{}
+	for _, partition := range partitions {
+		switch args.Position {
+		case ResetEarliest:
+			targets[partition] = offsets[partition].FirstOffset
+		default:
+			targets[partition] = offsets[partition].LastOffset
+		}
+	}
+	if args.Position == ResetTime {
+		if err := g.offsetsAt(ctx, partitions, args.Time, targets); err != nil {
+			return nil, err
+		}
+	}
+
+	commits := make([]kafka.OffsetCommit, len(partitions))
+	for i, partition := range partitions {
+		commits[i] = kafka.OffsetCommit{Partition: partition, Offset: targets[partition]}
+	}
+	// Groups without members commit outside of any generation
+	committed, err := g.admin.OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      name,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{g.topic: commits},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit offsets of consumer group %s: %w", name, err)
+	}
+	for _, partition := range committed.Topics[g.topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("failed to commit offset of consumer group %s in partition %d: %w", name, partition.Partition, partition.Error)
+		}
+	}
+
+	positions := make([]PartitionPosition, len(partitions))
+	for i, partition := range partitions {
+		positions[i] = newPartitionPosition(partition, targets[partition], offsets[partition])
+	}
+	return positions, nil
+}
+
+// partitions returns the partitions of the topic in order.
+func (g *ConsumerGroups) partitions(ctx context.Context) ([]int, error) {
+	metadata, err := g.admin.Metadata(ctx, &kafka.MetadataRequest{Topics: []string{g.topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata of topic %s: %w", g.topic, err)
+	}
+
+	partitions := []int{}
+	for _, topic := range metadata.Topics {
+		if topic.Name != g.topic {
+			continue
+		}
+		if topic.Error != nil {
+			return nil, fmt.Errorf("failed to read metadata of topic %s: %w", g.topic, topic.Error)
+		}
+		for _, partition := range topic.Partitions {
+			partitions = append(partitions, partition.ID)
+		}
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("topic %s has no partitions", g.topic)
+	}
+
+	slices.Sort(partitions)
+	return partitions, nil
+}
+
+// offsets returns the first and last offsets of the partitions of the topic.
+func (g *ConsumerGroups) offsets(ctx context.Context, partitions []int) (map[int]kafka.PartitionOffsets, error) {
+	requests := []kafka.OffsetRequest{}
+	for _, partition := range partitions {
+		requests = append(requests, kafka.FirstOffsetOf(partition), kafka.LastOffsetOf(partition))
+	}
+
+	listed, err := g.admin.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{g.topic: requests}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic %s: %w", g.topic, err)
+	}
+
+	offsets := map[int]kafka.PartitionOffsets{}
+	for _, partition := range listed.Topics[g.topic] {
+		if partition.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of partition %d: %w", partition.Partition, partition.Error)
+		}
+		offsets[partition.Partition] = partition
+	}
+	return offsets, nil
+}
+
+// offsetsAt sets the targets to the offsets of the first events published at or after a time. Partitions
+// without such events keep their target, the last offset.
+func (g *ConsumerGroups) offsetsAt(ctx context.Context, partitions []int, at time.Time, targets map[int]int64) error {
+	requests := make([]kafka.OffsetRequest, len(partitions))
+	for i, partition := range partitions {
+		requests[i] = kafka.TimeOffsetOf(partition, at)
+	}
+
+	listed, err := g.admin.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: map[string][]kafka.OffsetRequest{g.topic: requests}})
+	if err != nil {
+		return fmt.Errorf("failed to list offsets of topic %s at %s: %w", g.topic, at, err)
+	}
+
+	for _, partition := range listed.Topics[g.topic] {
+		if partition.Error != nil {
+			return fmt.Errorf("failed to list offsets of partition %d: %w", partition.Partition, partition.Error)
+		}
+		for offset := range partition.Offsets {
+			if offset >= 0 {
+				targets[partition.Partition] = offset
+			}
+		}
+	}
+	return nil
+}
+
+// newPartitionPosition computes the lag of a group in a partition. Groups that never committed an offset
+// start from the first event retained.
+func newPartitionPosition(partition int, committed int64, offsets kafka.PartitionOffsets) PartitionPosition {
+	next := committed
+	if next < 0 {
+		next = offsets.FirstOffset
+	}
+	return PartitionPosition{
+		Partition:       partition,
+		CommittedOffset: committed,
+		HighWaterMark:   offsets.LastOffset,
+		Lag:             max(offsets.LastOffset-next, 0),
+	}
+}
@@ -0,0 +1,114 @@
+package eventsrc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockKafkaAdmin is a mock implementation of KafkaAdmin
+type MockKafkaAdmin struct {
+	mock.Mock
+}
+
+func (m *MockKafkaAdmin) Metadata(ctx context.Context, req *kafka.MetadataRequest) (*kafka.MetadataResponse, error) {
+	callArgs := m.Called(ctx, req)
+	return callArgs.Get(0).(*kafka.MetadataResponse), callArgs.Error(1)
+}
+
+func (m *MockKafkaAdmin) ListOffsets(ctx context.Context, req *kafka.ListOffsetsRequest) (*kafka.ListOffsetsResponse, error) {
+	callArgs := m.Called(ctx, req)
+	return callArgs.Get(0).(*kafka.ListOffsetsResponse), callArgs.Error(1)
+}
+
+func (m *MockKafkaAdmin) DescribeGroups(ctx context.Context, req *kafka.DescribeGroupsRequest) (*kafka.DescribeGroupsResponse, error) {
+	callArgs := m.Called(ctx, req)
+	return callArgs.Get(0).(*kafka.DescribeGroupsResponse), callArgs.Error(1)
+}
+
+func (m *MockKafkaAdmin) OffsetFetch(ctx context.Context, req *kafka.OffsetFetchRequest) (*kafka.OffsetFetchResponse, error) {
+	callArgs := m.Called(ctx, req)
+	return callArgs.Get(0).(*kafka.OffsetFetchResponse), callArgs.Error(1)
+}
+
+func (m *MockKafkaAdmin) OffsetCommit(ctx context.Context, req *kafka.OffsetCommitRequest) (*kafka.OffsetCommitResponse, error) {
+	callArgs := m.Called(ctx, req)
+	return callArgs.Get(0).(*kafka.OffsetCommitResponse), callArgs.Error(1)
+}
+
+// newTestKafkaAdmin mocks a topic of two partitions, retaining offsets 10 to 100 and 0 to 50.
+func newTestKafkaAdmin(groupState string) *MockKafkaAdmin {
+	admin := &MockKafkaAdmin{}
+	admin.On("Metadata", mock.Anything, mock.Anything).Return(&kafka.MetadataResponse{
+		Topics: []kafka.Topic{{Name: "events", Partitions: []kafka.Partition{{ID: 1}, {ID: 0}}}},
+	}, nil)
+	admin.On("ListOffsets", mock.Anything, mock.Anything).Return(&kafka.ListOffsetsResponse{
+		Topics: map[string][]kafka.PartitionOffsets{"events": {
+			{Partition: 0, FirstOffset: 10, LastOffset: 100},
+			{Partition: 1, FirstOffset: 0, LastOffset: 50},
+		}},
+	}, nil)
+	admin.On("DescribeGroups", mock.Anything, mock.Anything).Return(&kafka.DescribeGroupsResponse{
+		Groups: []kafka.DescribeGroupsResponseGroup{{GroupID: "invoicing", GroupState: groupState, Members: []kafka.DescribeGroupsResponseMember{{}}}},
+	}, nil)
+	return admin
+}
+
+func TestConsumerGroups_Describe(t *testing.T) {
+	admin := newTestKafkaAdmin("Stable")
+	admin.On("OffsetFetch", mock.Anything, mock.Anything).Return(&kafka.OffsetFetchResponse{
+		Topics: map[string][]kafka.OffsetFetchPartition{"events": {
+			{Partition: 0, CommittedOffset: 90},
+			{Partition: 1, CommittedOffset: -1},
+		}},
+	}, nil)
+
+	groups, err := NewConsumerGroups(admin, "events").Describe(context.Background(), []string{"invoicing"})
+
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, ConsumerGroup{
+		Name:    "invoicing",
+		State:   "Stable",
+		Members: 1,
+		Lag:     60,
+		Partitions: []PartitionPosition{
+			{Partition: 0, CommittedOffset: 90, HighWaterMark: 100, Lag: 10},
+			// Groups without offsets start from the first event retained
+			{Partition: 1, CommittedOffset: -1, HighWaterMark: 50, Lag: 50},
+		},
+	}, groups[0])
+}
+
+func TestConsumerGroups_Reset(t *testing.T) {
+	t.Run("commits the earliest offsets of an empty group", func(t *testing.T) {
+		admin := newTestKafkaAdmin(GroupStateEmpty)
+		admin.On("OffsetCommit", mock.Anything, mock.Anything).Return(&kafka.OffsetCommitResponse{}, nil)
+
+		positions, err := NewConsumerGroups(admin, "events").Reset(context.Background(), "invoicing", ResetArgs{Position: ResetEarliest})
+
+		require.NoError(t, err)
+		assert.Equal(t, []PartitionPosition{
+			{Partition: 0, CommittedOffset: 10, HighWaterMark: 100, Lag: 90},
+			{Partition: 1, CommittedOffset: 0, HighWaterMark: 50, Lag: 50},
+		}, positions)
+		admin.AssertCalled(t, "OffsetCommit", mock.Anything, &kafka.OffsetCommitRequest{
+			GroupID:      "invoicing",
+			GenerationID: -1,
+			Topics:       map[string][]kafka.OffsetCommit{"events": {{Partition: 0, Offset: 10}, {Partition: 1, Offset: 0}}},
+		})
+	})
+
+	t.Run("rejects groups with members", func(t *testing.T) {
+		admin := newTestKafkaAdmin("Stable")
+
+		_, err := NewConsumerGroups(admin, "events").Reset(context.Background(), "invoicing", ResetArgs{Position: ResetLatest})
+
+		assert.ErrorIs(t, err, ErrConsumerGroupActive)
+		admin.AssertNotCalled(t, "OffsetCommit", mock.Anything, mock.Anything)
+	})
+}
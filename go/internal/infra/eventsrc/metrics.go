@@ -0,0 +1,176 @@
+package eventsrc
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/metrics"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	eventsPersisted = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "events_persisted_total",
+		Help:      "Events persisted to the store, by aggregate and event type.",
+	}, []string{"aggregate_type", "event_type"})
+
+	eventsRemoved = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "events_removed_total",
+		Help:      "Events removed from the store after failing to be published, by result.",
+	}, []string{"result"})
+
+	publishFailures = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "event_publish_failures_total",
+		Help:      "Events that failed to be published to the bus, by event type.",
+	}, []string{"event_type"})
+
+	consumerDuration = metrics.Factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "consumer_duration_seconds",
+		Help:      "Time taken by consumers to process an event, by consumer.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"consumer"})
+
+	consumerErrors = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "consumer_errors_total",
+		Help:      "Events consumers failed to process, by consumer.",
+	}, []string{"consumer"})
+
+	consumerRetries = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "consumer_retries_total",
+		Help:      "Events processed by consumers after backing off from an error, by consumer.",
+	}, []string{"consumer"})
+
+	consumerLag = metrics.Factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "consumer_lag",
+		Help:      "Messages left in the partition of the last message fetched by consumers, by consumer.",
+	}, []string{"consumer"})
+)
+
+/** Metered Store */
+
+// MeteredStore counts the events persisted to and removed from a store.
+type MeteredStore struct {
+	store Store
+}
+
+func NewMeteredStore(store Store) *MeteredStore {
+	return &MeteredStore{store: store}
+}
+
+func (s *MeteredStore) Persist(ctx context.Context, tx pg.Tx, args PersistEventArgs) (int, error) {
+	eventId, err := s.store.Persist(ctx, tx, args)
+	if err == nil {
+		eventsPersisted.WithLabelValues(args.AggregateType, args.EventType).Inc()
+	}
+	return eventId, err
+}
+
+// Remove is only called to compensate for events that failed to be published.
+func (s *MeteredStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error {
+	err := s.store.Remove(ctx, tx, eventId)
+	eventsRemoved.WithLabelValues(result(err)).Inc()
+	return err
+}
+
+func (s *MeteredStore) ListByAggregateID(ctx context.Context, aggregateId string, aggregateType string) ([]Event, error) {
+	return s.store.ListByAggregateID(ctx, aggregateId, aggregateType)
+}
+
+func (s *MeteredStore) ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error) {
+	return s.store.ListEvents(ctx, args)
+}
+
+/** Metered Bus */
+
+// MeteredBus counts the events a bus failed to publish.
+type MeteredBus struct {
+	bus Bus
+}
+
+func NewMeteredBus(bus Bus) *MeteredBus {
+	return &MeteredBus{bus: bus}
+}
+
+func (b *MeteredBus) Publish(ctx context.Context, args *PublishArgs) error {
+	err := b.bus.Publish(ctx, args)
+	if err != nil {
+		publishFailures.WithLabelValues(args.EventType).Inc()
+	}
+	return err
+}
+
+/** Metered Consumer */
+
+// MeteredConsumer records the processing duration and the errors of a consumer. An event consumed
+// right after an error counts as a retry, as RunKafkaConsumer backs off before trying again.
+type MeteredConsumer struct {
+	consumer Consumer
+	failed   atomic.Bool
+}
+
+func NewMeteredConsumer(consumer Consumer) *MeteredConsumer {
+	return &MeteredConsumer{consumer: consumer}
+}
+
+func (c *MeteredConsumer) Name() string {
+	return c.consumer.Name()
+}
+
+func (c *MeteredConsumer) Consume(ctx context.Context, args ConsumeArgs) error {
+	name := c.consumer.Name()
+	if c.failed.Load() {
+		consumerRetries.WithLabelValues(name).Inc()
+	}
+
+	start := time.Now()
+	err := c.consumer.Consume(ctx, args)
+	consumerDuration.WithLabelValues(name).Observe(metrics.Since(start))
+
+	if err != nil {
+		consumerErrors.WithLabelValues(name).Inc()
+	}
+	c.failed.Store(err != nil)
+	return err
+}
+
+/** Metered Reader */
+
+// MeteredReader records the lag of a consumer, the messages left in the partition of the last
+// message it fetched.
+type MeteredReader struct {
+	reader   Reader
+	consumer string
+}
+
+func NewMeteredReader(reader Reader, consumer string) *MeteredReader {
+	return &MeteredReader{reader: reader, consumer: consumer}
+}
+
+func (r *MeteredReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	msg, err := r.reader.FetchMessage(ctx)
+	if err == nil && msg.HighWaterMark > 0 {
+		consumerLag.WithLabelValues(r.consumer).Set(float64(max(msg.HighWaterMark-msg.Offset-1, 0)))
+	}
+	return msg, err
+}
+
+func (r *MeteredReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	return r.reader.CommitMessages(ctx, msgs...)
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
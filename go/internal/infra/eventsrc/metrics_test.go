@@ -0,0 +1,91 @@
+package eventsrc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// observations returns the number of values observed by a histogram
+func observations(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestMeteredStore(t *testing.T) {
+	persisted := eventsPersisted.WithLabelValues("order", "OrderPlaced")
+	removed := eventsRemoved.WithLabelValues("ok")
+	before, removedBefore := testutil.ToFloat64(persisted), testutil.ToFloat64(removed)
+
+	mockStore := &MockStore{}
+	mockStore.On("Persist", mock.Anything, mock.Anything, mock.Anything).Return(1, nil).Once()
+	mockStore.On("Persist", mock.Anything, mock.Anything, mock.Anything).Return(-1, errors.New("connection refused")).Once()
+	mockStore.On("Remove", mock.Anything, mock.Anything, 1).Return(nil)
+	store := NewMeteredStore(mockStore)
+
+	args := PersistEventArgs{AggregateId: "order-1", AggregateType: "order", EventType: "OrderPlaced"}
+	_, err := store.Persist(context.Background(), nil, args)
+	require.NoError(t, err)
+	_, err = store.Persist(context.Background(), nil, args)
+	require.Error(t, err)
+	require.NoError(t, store.Remove(context.Background(), nil, 1))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(persisted))
+	assert.Equal(t, removedBefore+1, testutil.ToFloat64(removed))
+}
+
+func TestMeteredBus(t *testing.T) {
+	failures := publishFailures.WithLabelValues("OrderShipped")
+	before := testutil.ToFloat64(failures)
+
+	mockBus := &MockBus{}
+	mockBus.On("Publish", mock.Anything, mock.Anything).Return(nil).Once()
+	mockBus.On("Publish", mock.Anything, mock.Anything).Return(errors.New("broker unavailable")).Once()
+	bus := NewMeteredBus(mockBus)
+
+	assert.NoError(t, bus.Publish(context.Background(), &PublishArgs{EventType: "OrderShipped"}))
+	assert.Error(t, bus.Publish(context.Background(), &PublishArgs{EventType: "OrderShipped"}))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(failures))
+}
+
+func TestMeteredConsumer(t *testing.T) {
+	errorsBefore := testutil.ToFloat64(consumerErrors.WithLabelValues("mock-consumer"))
+	retriesBefore := testutil.ToFloat64(consumerRetries.WithLabelValues("mock-consumer"))
+	durationsBefore := observations(t, consumerDuration.WithLabelValues("mock-consumer"))
+
+	mockConsumer := &MockConsumer{}
+	mockConsumer.On("Consume", mock.Anything, mock.Anything).Return(errors.New("projection unavailable")).Once()
+	mockConsumer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+	consumer := NewMeteredConsumer(mockConsumer)
+
+	assert.Equal(t, "mock-consumer", consumer.Name())
+	assert.Error(t, consumer.Consume(context.Background(), ConsumeArgs{EventType: "OrderPlaced"}))
+	assert.NoError(t, consumer.Consume(context.Background(), ConsumeArgs{EventType: "OrderPlaced"}))
+	assert.NoError(t, consumer.Consume(context.Background(), ConsumeArgs{EventType: "OrderPlaced"}))
+
+	assert.Equal(t, errorsBefore+1, testutil.ToFloat64(consumerErrors.WithLabelValues("mock-consumer")))
+	// Only the event consumed after the error is a retry
+	assert.Equal(t, retriesBefore+1, testutil.ToFloat64(consumerRetries.WithLabelValues("mock-consumer")))
+	assert.Equal(t, durationsBefore+3, observations(t, consumerDuration.WithLabelValues("mock-consumer")))
+}
+
+func TestMeteredReader(t *testing.T) {
+	mockReader := &MockReader{}
+	mockReader.On("FetchMessage", mock.Anything).Return(kafka.Message{Offset: 41, HighWaterMark: 50}, nil)
+	reader := NewMeteredReader(mockReader, "lag-consumer")
+
+	_, err := reader.FetchMessage(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, float64(8), testutil.ToFloat64(consumerLag.WithLabelValues("lag-consumer")))
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SendArgs contains the arguments required to send an event.
@@ -20,6 +23,8 @@ type SendArgs struct {
 // Producer is the interface for sending events.
 type Producer interface {
 	Send(ctx context.Context, args *SendArgs) error
+	// SendAll sends events that must be persisted together, e.g. the effects of a single command.
+	SendAll(ctx context.Context, args []*SendArgs) error
 }
 
 // TransactionProducer implements Producer and handles transactional event sending.
@@ -36,47 +41,84 @@ func NewTransactionProducer(store Store, bus Bus, tx pg.Transactor) *Transaction
 
 // Send sends an event transactionally using the configured store and bus.
 func (p *TransactionProducer) Send(ctx context.Context, args *SendArgs) error {
-	var eventId int
+	ctx, span := tracing.Start(ctx, "eventsrc.Producer/Send", trace.WithAttributes(
+		AttributeAggregateID.String(args.AggregateID),
+		AttributeAggregateType.String(args.AggregateType),
+		AttributeEventType.String(args.EventType),
+	))
+	err := p.send(ctx, []*SendArgs{args})
+	tracing.End(span, err)
+	return err
+}
+
+// SendAll persists the events in a single transaction, then publishes them in order.
+func (p *TransactionProducer) SendAll(ctx context.Context, args []*SendArgs) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "eventsrc.Producer/SendAll", trace.WithAttributes(
+		AttributeAggregateID.String(args[0].AggregateID),
+		AttributeAggregateType.String(args[0].AggregateType),
+	))
+	err := p.send(ctx, args)
+	tracing.End(span, err)
+	return err
+}
+
+func (p *TransactionProducer) send(ctx context.Context, args []*SendArgs) error {
+	eventIds := make([]int, 0, len(args))
 
-	// We need to commit our event to the store before publishing it to the bus.
-	// Otherwise the event may be consumed before it is committed to the store.
+	// We need to commit our events to the store before publishing them to the bus.
+	// Otherwise an event may be consumed before it is committed to the store.
 	err := p.tx.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
-		addedEventId, err := p.store.Persist(ctx, tx, PersistEventArgs{
-			SequenceNumber: args.SequenceNumber,
-			AggregateId:    args.AggregateID,
-			AggregateType:  args.AggregateType,
-			EventType:      args.EventType,
-			Data:           args.Value,
-		})
-		if err != nil {
-			return err
+		for _, event := range args {
+			addedEventId, err := p.store.Persist(ctx, tx, PersistEventArgs{
+				SequenceNumber: event.SequenceNumber,
+				AggregateId:    event.AggregateID,
+				AggregateType:  event.AggregateType,
+				EventType:      event.EventType,
+				Data:           event.Value,
+				Actor:          auth.ActorFromContext(ctx),
+			})
+			if err != nil {
+				return err
+			}
+			eventIds = append(eventIds, addedEventId)
 		}
-		eventId = addedEventId
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	err = p.bus.Publish(ctx, &PublishArgs{
-		AggregateID:   args.AggregateID,
-		AggregateType: args.AggregateType,
-		EventType:     args.EventType,
-		Value:         args.Value,
-	})
-
-	// If the event is not published, remove it from the store and return an error.
-	// This is to avoid a race condition where the event is consumed before it is committed to the store.
-	if err != nil {
-		logging.Logger.Info("failed to publish event. attempting to remove it from the store", "error", err)
-		removeErr := p.tx.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
-			return p.store.Remove(ctx, tx, eventId)
+	for i, event := range args {
+		err = p.bus.Publish(ctx, &PublishArgs{
+			AggregateID:   event.AggregateID,
+			AggregateType: event.AggregateType,
+			EventType:     event.EventType,
+			Value:         event.Value,
+			RequestID:     logging.RequestIdFromContext(ctx),
 		})
-		if removeErr != nil {
-			logging.Logger.Error("failed to remove event from store", "error", removeErr)
-		}
 
-		return err
+		// If an event is not published, remove it and the ones after it from the store and return an error.
+		// This is to avoid a race condition where the event is consumed before it is committed to the store.
+		if err != nil {
+			logging.FromContext(ctx).Info("failed to publish event. attempting to remove it from the store", "error", err)
+			removeErr := p.tx.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+				for _, eventId := range eventIds[i:] {
+					if err := p.store.Remove(ctx, tx, eventId); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if removeErr != nil {
+				logging.FromContext(ctx).Error("failed to remove event from store", "error", removeErr)
+			}
+
+			return err
+		}
 	}
 
 	return nil
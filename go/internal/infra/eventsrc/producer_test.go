@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -57,6 +58,11 @@ func (m *MockStore) ListByAggregateID(ctx context.Context, aggregateId string, a
 	return callArgs.Get(0).([]Event), callArgs.Error(1)
 }
 
+func (m *MockStore) ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error) {
+	callArgs := m.Called(ctx, args)
+	return callArgs.Get(0).([]Event), callArgs.Error(1)
+}
+
 func TestNewTransactionProducer(t *testing.T) {
 	store := NewInMemoryStore()
 	bus := NewInMemoryBus()
@@ -103,6 +109,7 @@ func TestTransactionProducer_Send(t *testing.T) {
 	assert.Equal(t, args.EventType, event.EventType)
 	assert.Equal(t, args.Value, event.Data)
 	assert.Equal(t, args.SequenceNumber, event.SequenceNumber)
+	assert.Equal(t, auth.SystemActor, event.Actor)
 
 	// Verify event was published to bus
 	require.Len(t, bus.Events, 1)
@@ -110,6 +117,25 @@ func TestTransactionProducer_Send(t *testing.T) {
 	assert.Equal(t, args.Value, bus.Events[0].Data)
 }
 
+func TestTransactionProducer_Send_RecordsActor(t *testing.T) {
+	store := NewInMemoryStore()
+	producer := NewTransactionProducer(store, NewInMemoryBus(), &pg.TestTransactor{})
+	ctx := auth.WithPrincipal(context.Background(), &auth.Principal{Subject: "customer-1", Role: auth.RoleCustomer})
+
+	err := producer.Send(ctx, &SendArgs{
+		AggregateID:   "order-123",
+		AggregateType: "orders",
+		EventType:     "OrderCancelled",
+		Value:         []byte(`{}`),
+	})
+	require.NoError(t, err)
+
+	events, err := store.ListByAggregateID(ctx, "order-123", "orders")
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "customer:customer-1", events[0].Actor)
+}
+
 func TestTransactionProducer_Send_MultipleEvents(t *testing.T) {
 	store := NewInMemoryStore()
 	bus := NewInMemoryBus()
@@ -293,3 +319,72 @@ func TestTransactionProducer_Send_StorePersistFailure(t *testing.T) {
 	// Verify Remove was NOT called (since Persist failed)
 	mockStore.AssertNotCalled(t, "Remove")
 }
+
+func TestTransactionProducer_SendAll(t *testing.T) {
+	store := NewInMemoryStore()
+	bus := NewInMemoryBus()
+	tx := &pg.TestTransactor{}
+
+	producer := NewTransactionProducer(store, bus, tx)
+	ctx := context.Background()
+
+	args := []*SendArgs{
+		{SequenceNumber: 1, AggregateID: "product-123", AggregateType: "products", EventType: "StockReceived", Value: []byte(`{"quantity": 5}`)},
+		{SequenceNumber: 2, AggregateID: "product-123", AggregateType: "products", EventType: "StockReserved", Value: []byte(`{"quantity": 2}`)},
+	}
+
+	err := producer.SendAll(ctx, args)
+	require.NoError(t, err)
+
+	// Verify both events were stored in a single transaction
+	assert.Equal(t, 1, tx.NumCalls)
+
+	events, err := store.ListByAggregateID(ctx, "product-123", "products")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "StockReceived", events[0].EventType)
+	assert.Equal(t, "StockReserved", events[1].EventType)
+
+	// Verify events were published in order
+	require.Len(t, bus.Events, 2)
+	assert.Equal(t, "StockReceived", bus.Events[0].EventType)
+	assert.Equal(t, "StockReserved", bus.Events[1].EventType)
+}
+
+func TestTransactionProducer_SendAll_Empty(t *testing.T) {
+	tx := &pg.TestTransactor{}
+	producer := NewTransactionProducer(NewInMemoryStore(), NewInMemoryBus(), tx)
+
+	err := producer.SendAll(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, tx.NumCalls)
+}
+
+func TestTransactionProducer_SendAll_BusPublishFailure(t *testing.T) {
+	mockStore := &MockStore{}
+	mockBus := &MockBus{}
+	tx := &pg.TestTransactor{}
+
+	producer := NewTransactionProducer(mockStore, mockBus, tx)
+	ctx := context.Background()
+
+	args := []*SendArgs{
+		{SequenceNumber: 1, AggregateID: "product-123", AggregateType: "products", EventType: "StockReceived"},
+		{SequenceNumber: 2, AggregateID: "product-123", AggregateType: "products", EventType: "StockReserved"},
+	}
+
+	// Configure mocks - the second event fails to publish
+	mockStore.On("Persist", mock.Anything, mock.Anything, mock.MatchedBy(func(a PersistEventArgs) bool { return a.SequenceNumber == 1 })).Return(101, nil)
+	mockStore.On("Persist", mock.Anything, mock.Anything, mock.MatchedBy(func(a PersistEventArgs) bool { return a.SequenceNumber == 2 })).Return(102, nil)
+	mockBus.On("Publish", mock.Anything, mock.MatchedBy(func(a *PublishArgs) bool { return a.EventType == "StockReceived" })).Return(nil)
+	mockBus.On("Publish", mock.Anything, mock.MatchedBy(func(a *PublishArgs) bool { return a.EventType == "StockReserved" })).Return(errors.New("kafka connection failed"))
+	mockStore.On("Remove", mock.Anything, mock.Anything, 102).Return(nil)
+
+	err := producer.SendAll(ctx, args)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "kafka connection failed")
+
+	// Verify only the unpublished event was removed
+	assert.Equal(t, []int{102}, mockStore.removedEventIds)
+}
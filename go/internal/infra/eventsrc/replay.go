@@ -0,0 +1,81 @@
+package eventsrc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+)
+
+const DefaultReplayBatchSize = 500
+
+type ReplayArgs struct {
+	// AggregateType replays only the events of a type of aggregate, if set
+	AggregateType string
+	// AggregateId replays only the events of an aggregate of AggregateType, if set
+	AggregateId string
+	BatchSize   uint
+}
+
+// Replay passes the events of the store to a consumer in the order they were persisted, bypassing the
+// bus, and returns the number of events replayed. Consumers must be idempotent, as they already
+// consumed these events.
+func Replay(ctx context.Context, store Store, consumer Consumer, args ReplayArgs) (int, error) {
+	ctx = logging.With(ctx, "consumer", consumer.Name())
+
+	if args.AggregateId != "" {
+		if args.AggregateType == "" {
+			return 0, fmt.Errorf("replaying an aggregate requires its type")
+		}
+
+		events, err := store.ListByAggregateID(ctx, args.AggregateId, args.AggregateType)
+		if err != nil {
+			return 0, err
+		}
+		for i, event := range events {
+			if err := replayEvent(ctx, consumer, args.AggregateId, event); err != nil {
+				return i, err
+			}
+		}
+		return len(events), nil
+	}
+
+	batchSize := args.BatchSize
+	if batchSize == 0 {
+		batchSize = DefaultReplayBatchSize
+	}
+
+	replayed, lastEventId := 0, 0
+	for {
+		events, err := store.ListEvents(ctx, ListEventsArgs{AggregateType: args.AggregateType, AfterEventId: lastEventId, Limit: batchSize})
+		if err != nil {
+			return replayed, err
+		}
+
+		for _, event := range events {
+			if err := replayEvent(ctx, consumer, event.AggregateId, event); err != nil {
+				return replayed, err
+			}
+			replayed++
+			lastEventId = event.EventId
+		}
+
+		if uint(len(events)) < batchSize {
+			return replayed, nil
+		}
+		logging.FromContext(ctx).Info("Replayed events", "count", replayed, "lastEventId", lastEventId)
+	}
+}
+
+func replayEvent(ctx context.Context, consumer Consumer, aggregateId string, event Event) error {
+	err := consumer.Consume(ctx, ConsumeArgs{
+		AggregateID:   aggregateId,
+		AggregateType: event.AggregateType,
+		EventType:     event.EventType,
+		Data:          event.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replay event %d: %w", event.EventId, err)
+	}
+	return nil
+}
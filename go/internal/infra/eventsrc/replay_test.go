@@ -0,0 +1,64 @@
+package eventsrc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func persistEvents(t *testing.T, store Store, events ...PersistEventArgs) {
+	for _, event := range events {
+		_, err := store.Persist(context.Background(), nil, event)
+		require.NoError(t, err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	store := NewInMemoryStore()
+	persistEvents(t, store,
+		PersistEventArgs{SequenceNumber: 1, AggregateId: "order-1", AggregateType: "order", EventType: "OrderPlaced", Data: []byte("1")},
+		PersistEventArgs{SequenceNumber: 1, AggregateId: "product-1", AggregateType: "inventory", EventType: "StockReceived", Data: []byte("2")},
+		PersistEventArgs{SequenceNumber: 1, AggregateId: "order-2", AggregateType: "order", EventType: "OrderPlaced", Data: []byte("3")},
+		PersistEventArgs{SequenceNumber: 2, AggregateId: "order-1", AggregateType: "order", EventType: "OrderPaid", Data: []byte("4")},
+	)
+
+	t.Run("replays the events of a type of aggregate in order", func(t *testing.T) {
+		consumer := &MockConsumer{}
+		consumer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+
+		replayed, err := Replay(context.Background(), store, consumer, ReplayArgs{AggregateType: "order", BatchSize: 2})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, replayed)
+		consumer.AssertNumberOfCalls(t, "Consume", 3)
+		assert.Equal(t, ConsumeArgs{AggregateID: "order-1", AggregateType: "order", EventType: "OrderPlaced", Data: []byte("1")}, consumer.Calls[0].Arguments.Get(1))
+		assert.Equal(t, "order-2", consumer.Calls[1].Arguments.Get(1).(ConsumeArgs).AggregateID)
+		assert.Equal(t, "OrderPaid", consumer.Calls[2].Arguments.Get(1).(ConsumeArgs).EventType)
+	})
+
+	t.Run("replays the events of an aggregate", func(t *testing.T) {
+		consumer := &MockConsumer{}
+		consumer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+
+		replayed, err := Replay(context.Background(), store, consumer, ReplayArgs{AggregateType: "order", AggregateId: "order-1"})
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, replayed)
+		assert.Equal(t, "order-1", consumer.Calls[1].Arguments.Get(1).(ConsumeArgs).AggregateID)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		consumer := &MockConsumer{}
+		consumer.On("Consume", mock.Anything, mock.Anything).Return(nil).Once()
+		consumer.On("Consume", mock.Anything, mock.Anything).Return(errors.New("projection unavailable"))
+
+		replayed, err := Replay(context.Background(), store, consumer, ReplayArgs{})
+
+		assert.ErrorContains(t, err, "failed to replay event 2")
+		assert.Equal(t, 1, replayed)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -19,6 +20,8 @@ type PersistEventArgs struct {
 	AggregateType  string
 	EventType      string
 	Data           []byte
+	// Actor is who caused the event, see auth.ActorFromContext
+	Actor string
 }
 
 type Event struct {
@@ -28,13 +31,25 @@ type Event struct {
 	AggregateType  string    `db:"aggregate_type"`
 	EventType      string    `db:"event_type"`
 	Data           []byte    `db:"event_data"`
+	Actor          string    `db:"actor"`
 	CreatedAt      time.Time `db:"created_at"`
 }
 
+type ListEventsArgs struct {
+	// AggregateType lists only the events of a type of aggregate, if set
+	AggregateType string
+	// AfterEventId lists the events following an event, to page through the store
+	AfterEventId int
+	Limit        uint
+}
+
 type Store interface {
 	Persist(ctx context.Context, tx pg.Tx, args PersistEventArgs) (int, error)
 	Remove(ctx context.Context, tx pg.Tx, eventId int) error
 	ListByAggregateID(ctx context.Context, aggregateId string, aggregateType string) ([]Event, error)
+
+	// ListEvents lists the events of every aggregate in the order they were persisted, with their aggregate ids.
+	ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error)
 }
 
 func serializeAggregateId(aggregateId string, aggregateType string) string {
@@ -48,6 +63,16 @@ func deserializeAggregateId(aggregateId string) (string, string) {
 
 /** Postgres Store */
 
+// TableSchema returns what the Postgres store expects of its table. Sequence numbers are unique per
+// aggregate, which keeps concurrent commands from appending the same event twice.
+func TableSchema(table string) pg.TableSchema {
+	return pg.TableSchema{
+		Name:    table,
+		Columns: []string{"event_id", "sequence_number", "aggregate_id", "aggregate_type", "event_type", "event_data", "actor", "created_at"},
+		Unique:  [][]string{{"event_id"}, {"sequence_number", "aggregate_id"}},
+	}
+}
+
 type PostgresStore struct {
 	db    *sqlx.DB
 	table string
@@ -60,7 +85,7 @@ func NewPostgresStore(db *sqlx.DB, table string) *PostgresStore {
 func (s *PostgresStore) Persist(ctx context.Context, tx pg.Tx, args PersistEventArgs) (int, error) {
 	// Compile query
 	ds := pg.Dialect.Insert(s.table).Prepared(true).
-		Cols("aggregate_id", "aggregate_type", "event_type", "event_data").
+		Cols("aggregate_id", "aggregate_type", "event_type", "event_data", "actor").
 		Rows([]goqu.Record{
 			{
 				"aggregate_id":    serializeAggregateId(args.AggregateId, args.AggregateType),
@@ -68,6 +93,7 @@ func (s *PostgresStore) Persist(ctx context.Context, tx pg.Tx, args PersistEvent
 				"aggregate_type":  args.AggregateType,
 				"event_type":      args.EventType,
 				"event_data":      args.Data,
+				"actor":           args.Actor,
 			},
 		}).
 		Returning("event_id")
@@ -148,11 +174,51 @@ func (s *PostgresStore) ListByAggregateID(ctx context.Context, aggregateId strin
 	return events, nil
 }
 
+func (s *PostgresStore) ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error) {
+	// Compile query
+	ds := pg.Dialect.From(s.table).Prepared(true).
+		Select(&Event{}).
+		Where(goqu.C("event_id").Gt(args.AfterEventId)).
+		Order(goqu.I("event_id").Asc()).
+		Limit(args.Limit)
+	if args.AggregateType != "" {
+		ds = ds.Where(goqu.Ex{"aggregate_type": args.AggregateType})
+	}
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := s.db.QueryxContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	defer rows.Close()
+
+	events := []Event{}
+	for rows.Next() {
+		var event Event
+		err := rows.StructScan(&event)
+		if err != nil {
+			return nil, pg.ErrorUnmarshal(err)
+		}
+
+		event.AggregateId, _ = deserializeAggregateId(event.AggregateId)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
 /** In-memory Store */
 
 type InMemoryStore struct {
 	Events map[string][]Event
 	mu     sync.RWMutex
+
+	lastEventId int
 }
 
 func NewInMemoryStore() *InMemoryStore {
@@ -165,8 +231,9 @@ func (s *InMemoryStore) Persist(ctx context.Context, tx pg.Tx, args PersistEvent
 
 	aggregateId := serializeAggregateId(args.AggregateId, args.AggregateType)
 
-	// Set event_id to be the length of the events slice
-	eventID := len(s.Events[aggregateId])
+	// Number events across aggregates, like the serial event_id column
+	s.lastEventId++
+	eventID := s.lastEventId
 
 	s.Events[aggregateId] = append(s.Events[aggregateId], Event{
 		EventId:        eventID,
@@ -175,6 +242,7 @@ func (s *InMemoryStore) Persist(ctx context.Context, tx pg.Tx, args PersistEvent
 		AggregateType:  args.AggregateType,
 		EventType:      args.EventType,
 		Data:           args.Data,
+		Actor:          args.Actor,
 		CreatedAt:      time.Now().UTC(),
 	})
 
@@ -186,10 +254,11 @@ func (s *InMemoryStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error
 	defer s.mu.Unlock()
 
 	// Iterate over each block of events and remove the event with the given eventId
-	for _, events := range s.Events {
+	for aggregateId, events := range s.Events {
 		for i, event := range events {
 			if event.EventId == eventId {
-				events = append(events[:i], events[i+1:]...)
+				s.Events[aggregateId] = append(events[:i], events[i+1:]...)
+				return nil
 			}
 		}
 	}
@@ -205,11 +274,32 @@ func (s *InMemoryStore) ListByAggregateID(ctx context.Context, aggregateId strin
 	// Return a copy to prevent external modification
 	result := make([]Event, len(events))
 
-	for idx := range events {
-		aggregateId, _ := deserializeAggregateId(events[idx].AggregateId)
-		events[idx].AggregateId = aggregateId
+	copy(result, events)
+	for idx := range result {
+		result[idx].AggregateId, _ = deserializeAggregateId(result[idx].AggregateId)
+	}
+
+	return result, nil
+}
+
+func (s *InMemoryStore) ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []Event{}
+	for _, events := range s.Events {
+		for _, event := range events {
+			if event.EventId <= args.AfterEventId || args.AggregateType != "" && event.AggregateType != args.AggregateType {
+				continue
+			}
+			event.AggregateId, _ = deserializeAggregateId(event.AggregateId)
+			result = append(result, event)
+		}
 	}
 
-	copy(result, events)
+	slices.SortFunc(result, func(a, b Event) int { return a.EventId - b.EventId })
+	if args.Limit > 0 && uint(len(result)) > args.Limit {
+		result = result[:args.Limit]
+	}
 	return result, nil
 }
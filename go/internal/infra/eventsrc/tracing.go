@@ -0,0 +1,98 @@
+package eventsrc
+
+import (
+	"context"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/tracing"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes
+const (
+	AttributeAggregateID   = attribute.Key("eventsrc.aggregate_id")
+	AttributeAggregateType = attribute.Key("eventsrc.aggregate_type")
+	AttributeEventType     = attribute.Key("eventsrc.event_type")
+)
+
+// headerCarrier carries the trace context of an event in its Kafka headers.
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key string, value string) {
+	for i, header := range *c.headers {
+		if header.Key == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, header := range *c.headers {
+		keys[i] = header.Key
+	}
+	return keys
+}
+
+/** Traced Store */
+
+// TracedStore records a span for every query of a store.
+type TracedStore struct {
+	store Store
+}
+
+func NewTracedStore(store Store) *TracedStore {
+	return &TracedStore{store: store}
+}
+
+func (s *TracedStore) Persist(ctx context.Context, tx pg.Tx, args PersistEventArgs) (int, error) {
+	ctx, span := tracing.Start(ctx, "eventsrc.Store/Persist", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		AttributeAggregateID.String(args.AggregateId),
+		AttributeAggregateType.String(args.AggregateType),
+		AttributeEventType.String(args.EventType),
+	))
+	eventId, err := s.store.Persist(ctx, tx, args)
+	tracing.End(span, err)
+	return eventId, err
+}
+
+func (s *TracedStore) Remove(ctx context.Context, tx pg.Tx, eventId int) error {
+	ctx, span := tracing.Start(ctx, "eventsrc.Store/Remove", trace.WithSpanKind(trace.SpanKindClient))
+	err := s.store.Remove(ctx, tx, eventId)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *TracedStore) ListByAggregateID(ctx context.Context, aggregateId string, aggregateType string) ([]Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsrc.Store/ListByAggregateID", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		AttributeAggregateID.String(aggregateId),
+		AttributeAggregateType.String(aggregateType),
+	))
+	events, err := s.store.ListByAggregateID(ctx, aggregateId, aggregateType)
+	tracing.End(span, err)
+	return events, err
+}
+
+func (s *TracedStore) ListEvents(ctx context.Context, args ListEventsArgs) ([]Event, error) {
+	ctx, span := tracing.Start(ctx, "eventsrc.Store/ListEvents", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		AttributeAggregateType.String(args.AggregateType),
+	))
+	events, err := s.store.ListEvents(ctx, args)
+	tracing.End(span, err)
+	return events, err
+}
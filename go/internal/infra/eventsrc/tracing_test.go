@@ -0,0 +1,78 @@
+package eventsrc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans records the spans ended during a test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return recorder
+}
+
+func TestRunKafkaConsumerOnce_Tracing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	// Publish an event from the span of a request
+	ctx, requestSpan := otel.Tracer("test").Start(context.Background(), "request")
+	headers := []kafka.Header{
+		{Key: KafkaHeaderEventType, Value: []byte("test_event")},
+		{Key: KafkaHeaderAggregateID, Value: []byte("agg_id")},
+		{Key: KafkaHeaderAggregateType, Value: []byte("agg_type")},
+	}
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{headers: &headers})
+	requestSpan.End()
+
+	mockReader := &MockReader{}
+	mockConsumer := &MockConsumer{}
+	mockReader.On("FetchMessage", mock.Anything).Return(kafka.Message{Headers: headers}, nil)
+	mockConsumer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+	mockReader.On("CommitMessages", mock.Anything, mock.Anything).Return(nil)
+
+	err := runKafkaConsumerOnce(context.Background(), mockReader, mockConsumer)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	consumerSpan := spans[1]
+	assert.Equal(t, "mock-consumer process", consumerSpan.Name())
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), consumerSpan.SpanContext().TraceID())
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), consumerSpan.Parent().SpanID())
+}
+
+func TestTracedStore(t *testing.T) {
+	recorder := recordSpans(t)
+
+	mockStore := &MockStore{}
+	mockStore.On("Persist", mock.Anything, mock.Anything, mock.Anything).Return(-1, errors.New("connection refused"))
+	store := NewTracedStore(mockStore)
+
+	_, err := store.Persist(context.Background(), nil, PersistEventArgs{AggregateId: "order-1", AggregateType: "order", EventType: "OrderPlaced"})
+
+	assert.Error(t, err)
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "eventsrc.Store/Persist", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), AttributeAggregateID.String("order-1"))
+}
@@ -0,0 +1,173 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+)
+
+const (
+	DefaultInterval = 10 * time.Second
+	DefaultTimeout  = 3 * time.Second
+)
+
+// Statuses of the checks and of the report
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check checks a dependency of the service, returning an error if it cannot be used.
+type Check func(ctx context.Context) error
+
+// CheckResult is the outcome of a single check.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of the checks of a round, up if every check passed.
+type Report struct {
+	Status    string                 `json:"status"`
+	Checks    map[string]CheckResult `json:"checks"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// Ready reports whether every check passed. The report of a checker that has not run yet is not ready.
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+type CheckerOptions struct {
+	Interval *time.Duration
+	Timeout  *time.Duration
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the readiness checks of the service in rounds, so that probes read the report of the
+// last round instead of hitting the dependencies.
+type Checker struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.RWMutex
+	checks   []namedCheck
+	report   Report
+	watchers []func(Report)
+}
+
+func NewChecker(opts CheckerOptions) *Checker {
+	checker := &Checker{
+		interval: DefaultInterval,
+		timeout:  DefaultTimeout,
+		report:   Report{Status: StatusDown, Checks: map[string]CheckResult{}},
+	}
+
+	// Parse options
+	if opts.Interval != nil {
+		checker.interval = *opts.Interval
+	}
+	if opts.Timeout != nil {
+		checker.timeout = *opts.Timeout
+	}
+
+	return checker
+}
+
+// Add adds a check to the next rounds.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Watch calls fn with the report of every round.
+func (c *Checker) Watch(fn func(Report)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.watchers = append(c.watchers, fn)
+}
+
+// Report returns the report of the last round.
+func (c *Checker) Report() Report {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.report
+}
+
+// Run runs a round of checks right away, then every interval until the context is cancelled.
+func (c *Checker) Run(ctx context.Context) error {
+	logging.FromContext(ctx).Info("Starting health checker", "interval", c.interval.String())
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckNow(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckNow runs every check concurrently, each with the timeout of the checker, and records their report.
+func (c *Checker) CheckNow(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.checks
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = check.check(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]CheckResult, len(checks)), CheckedAt: time.Now().UTC()}
+	for i, check := range checks {
+		if errs[i] != nil {
+			report.Status = StatusDown
+			report.Checks[check.name] = CheckResult{Status: StatusDown, Error: errs[i].Error()}
+			continue
+		}
+		report.Checks[check.name] = CheckResult{Status: StatusUp}
+	}
+
+	c.mu.Lock()
+	previous := c.report
+	c.report = report
+	watchers := c.watchers
+	c.mu.Unlock()
+
+	// Log the first round and the changes of status only, as rounds are frequent
+	if previous.CheckedAt.IsZero() || report.Status != previous.Status {
+		level := slog.LevelInfo
+		if !report.Ready() {
+			level = slog.LevelWarn
+		}
+		logging.FromContext(ctx).Log(ctx, level, "Health changed", "status", report.Status, "checks", report.Checks)
+	}
+
+	for _, watcher := range watchers {
+		watcher(report)
+	}
+
+	return report
+}
@@ -0,0 +1,100 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	t.Run("is not ready before the first round", func(t *testing.T) {
+		checker := NewChecker(CheckerOptions{})
+
+		assert.False(t, checker.Report().Ready())
+	})
+
+	t.Run("reports every check", func(t *testing.T) {
+		checker := NewChecker(CheckerOptions{})
+		checker.Add("postgres", func(ctx context.Context) error { return nil })
+		checker.Add("kafka", func(ctx context.Context) error { return errors.New("connection refused") })
+
+		report := checker.CheckNow(context.Background())
+
+		assert.False(t, report.Ready())
+		assert.Equal(t, map[string]CheckResult{
+			"postgres": {Status: StatusUp},
+			"kafka":    {Status: StatusDown, Error: "connection refused"},
+		}, report.Checks)
+		assert.Equal(t, report, checker.Report())
+	})
+
+	t.Run("times out slow checks", func(t *testing.T) {
+		timeout := 10 * time.Millisecond
+		checker := NewChecker(CheckerOptions{Timeout: &timeout})
+		checker.Add("postgres", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := checker.CheckNow(context.Background())
+
+		assert.Equal(t, StatusDown, report.Checks["postgres"].Status)
+	})
+
+	t.Run("passes the reports to watchers", func(t *testing.T) {
+		checker := NewChecker(CheckerOptions{})
+		checker.Add("postgres", func(ctx context.Context) error { return nil })
+		reports := []Report{}
+		checker.Watch(func(report Report) { reports = append(reports, report) })
+
+		checker.CheckNow(context.Background())
+
+		require.Len(t, reports, 1)
+		assert.True(t, reports[0].Ready())
+	})
+}
+
+func TestReadinessHandler(t *testing.T) {
+	healthy := true
+	checker := NewChecker(CheckerOptions{})
+	checker.Add("postgres", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	get := func() (int, Report) {
+		recorder := httptest.NewRecorder()
+		ReadinessHandler(checker).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReadinessPath, nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &report))
+		return recorder.Code, report
+	}
+
+	checker.CheckNow(context.Background())
+	code, report := get()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusUp, report.Checks["postgres"].Status)
+
+	healthy = false
+	checker.CheckNow(context.Background())
+	code, report = get()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, CheckResult{Status: StatusDown, Error: "connection refused"}, report.Checks["postgres"])
+}
+
+func TestLivenessHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	LivenessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, LivenessPath, nil))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status":"up"}`, recorder.Body.String())
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Pinger is a database that can be pinged, e.g. *sqlx.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DB checks that the database answers pings.
+func DB(db Pinger) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// KafkaBroker checks that the broker can be reached and serves the partitions of the topic.
+func KafkaBroker(broker string, topic string) Check {
+	return func(ctx context.Context) error {
+		conn, err := kafka.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		if deadline, ok := ctx.Deadline(); ok {
+			if err := conn.SetDeadline(deadline); err != nil {
+				return err
+			}
+		}
+
+		partitions, err := conn.ReadPartitions(topic)
+		if err != nil {
+			return fmt.Errorf("failed to read the partitions of %s: %w", topic, err)
+		}
+		if len(partitions) == 0 {
+			return fmt.Errorf("topic %s has no partitions", topic)
+		}
+		return nil
+	}
+}
+
+// WriterStats is a Kafka writer, e.g. *kafka.Writer.
+type WriterStats interface {
+	Stats() kafka.WriterStats
+}
+
+// KafkaWriter checks that the writer did not fail every write since the last check. Writers reset their
+// counters when read, so nothing else may read the stats of the writer.
+func KafkaWriter(writer WriterStats) Check {
+	return func(ctx context.Context) error {
+		stats := writer.Stats()
+		if stats.Errors > 0 && stats.Errors >= stats.Writes {
+			return fmt.Errorf("%d writes failed since the last check", stats.Errors)
+		}
+		return nil
+	}
+}
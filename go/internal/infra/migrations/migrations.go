@@ -0,0 +1,306 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// HistoryTable records the migrations applied to the database
+	HistoryTable = "schema_migration"
+
+	// flywayHistoryTable records the migrations applied by Flyway, which are adopted by the first run
+	flywayHistoryTable = "flyway_schema_history"
+
+	// lockId is the key of the advisory lock that keeps instances from migrating at the same time
+	lockId = 7_316_424_001
+)
+
+// scriptPattern matches the names of the migration scripts, e.g. V0001__event_table.sql, and of the
+// scripts undoing them, e.g. U0001__event_table.sql
+var scriptPattern = regexp.MustCompile(`^([VU])(\d+)__(\w+)\.sql$`)
+
+//go:embed postgres/*.sql
+var postgresScripts embed.FS
+
+type Migration struct {
+	Version     int
+	Description string
+	Script      string
+	SQL         string
+	// DownSQL undoes the migration, if it can be undone
+	DownSQL string
+}
+
+// Postgres returns the migrations of the service, embedded in the binary.
+func Postgres() ([]Migration, error) {
+	fsys, err := fs.Sub(postgresScripts, "postgres")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	return Load(fsys)
+}
+
+// Load loads the migration scripts of a directory in version order, along with the scripts undoing them.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := []Migration{}
+	downScripts := map[int]string{}
+	for _, entry := range entries {
+		match := scriptPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", entry.Name(), err)
+		}
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		if match[1] == "U" {
+			downScripts[version] = string(script)
+			continue
+		}
+		migrations = append(migrations, Migration{
+			Version:     version,
+			Description: strings.ReplaceAll(match[3], "_", " "),
+			Script:      entry.Name(),
+			SQL:         string(script),
+		})
+	}
+
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", migrations[i-1].Script, migrations[i].Script)
+		}
+	}
+
+	for i := range migrations {
+		migrations[i].DownSQL = downScripts[migrations[i].Version]
+		delete(downScripts, migrations[i].Version)
+	}
+	for version := range downScripts {
+		return nil, fmt.Errorf("undo script of version %d has no migration", version)
+	}
+
+	return migrations, nil
+}
+
+// MigrationStatus tells whether a migration was applied to the database.
+type MigrationStatus struct {
+	Migration
+	// AppliedAt is zero for pending migrations
+	AppliedAt time.Time
+}
+
+func (s MigrationStatus) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+// Migrator applies migrations to a Postgres database.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sqlx.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applies the migrations missing from the database in version order, each in a transaction, and
+// returns them.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	migrated := []Migration{}
+	err := m.withLock(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := apply(ctx, conn, migration, migration.SQL, true); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", migration.Script, err)
+			}
+			logging.FromContext(ctx).Info("Applied migration", "version", migration.Version, "script", migration.Script)
+			migrated = append(migrated, migration)
+		}
+		return nil
+	})
+	return migrated, err
+}
+
+// Down undoes the last steps migrations applied to the database in reverse version order, each in a
+// transaction, and returns them. Migrations without an undo script cannot be undone.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	reverted := []Migration{}
+	err := m.withLock(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		versions := make([]int, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, version := range versions[:min(steps, len(versions))] {
+			index := slices.IndexFunc(m.migrations, func(migration Migration) bool { return migration.Version == version })
+			if index < 0 {
+				return fmt.Errorf("migration %d is applied but unknown to this version of the service", version)
+			}
+
+			migration := m.migrations[index]
+			if migration.DownSQL == "" {
+				return fmt.Errorf("migration %s cannot be undone", migration.Script)
+			}
+			if err := apply(ctx, conn, migration, migration.DownSQL, false); err != nil {
+				return fmt.Errorf("failed to undo migration %s: %w", migration.Script, err)
+			}
+			logging.FromContext(ctx).Info("Undid migration", "version", migration.Version, "script", migration.Script)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status returns every migration in version order, with the time it was applied to the database.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	err := m.withLock(ctx, func(conn *sqlx.Conn, applied map[int]time.Time) error {
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: applied[migration.Version]})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// withLock calls fn with the versions applied to the database, holding a session lock so that
+// instances starting together migrate one after the other.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn, applied map[int]time.Time) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockId); err != nil {
+		return pg.ErrorDb(err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", lockId); err != nil {
+			logging.FromContext(ctx).Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, applied)
+}
+
+// appliedVersions creates the history table if needed and returns the versions it records, with the time
+// they were applied. Databases migrated by Flyway have their history adopted.
+func appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int]time.Time, error) {
+	_, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+HistoryTable+` (
+		version     integer PRIMARY KEY,
+		description text NOT NULL,
+		applied_at  timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	var flywayTable sql.NullString
+	if err := conn.QueryRowxContext(ctx, "SELECT to_regclass($1)::text", flywayHistoryTable).Scan(&flywayTable); err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+	if flywayTable.Valid {
+		_, err := conn.ExecContext(ctx, `INSERT INTO `+HistoryTable+` (version, description, applied_at)
+			SELECT version::integer, description, installed_on FROM `+flywayHistoryTable+`
+			WHERE success AND version IS NOT NULL
+			ON CONFLICT (version) DO NOTHING`)
+		if err != nil {
+			return nil, pg.ErrorDb(err)
+		}
+	}
+
+	query, queryArgs, err := pg.Dialect.From(HistoryTable).Prepared(true).Select("version", "applied_at").ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows := []struct {
+		Version   int       `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}{}
+	if err := conn.SelectContext(ctx, &rows, query, queryArgs...); err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// apply runs a script of a migration and, in the same transaction, records the migration as applied if up,
+// or removes it from the history otherwise.
+func apply(ctx context.Context, conn *sqlx.Conn, migration Migration, script string, up bool) error {
+	tx, err := conn.BeginTxx(ctx, &sql.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			logging.FromContext(ctx).Error("failed to rollback", "error", rbErr)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	query, queryArgs, err := pg.Dialect.Insert(HistoryTable).Prepared(true).
+		Rows(goqu.Record{"version": migration.Version, "description": migration.Description}).
+		ToSQL()
+	if !up {
+		query, queryArgs, err = pg.Dialect.Delete(HistoryTable).Prepared(true).
+			Where(goqu.Ex{"version": migration.Version}).
+			ToSQL()
+	}
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+	if _, err := tx.ExecContext(ctx, query, queryArgs...); err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("loads the scripts in version order", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"V0010__order_projection.sql": {Data: []byte("CREATE TABLE order_projection ();")},
+			"V0002__timer_table.sql":      {Data: []byte("CREATE TABLE timer ();")},
+			"V0001__event_table.sql":      {Data: []byte("CREATE TABLE events ();")},
+			"README.md":                   {Data: []byte("not a migration")},
+		}
+
+		migrations, err := Load(fsys)
+
+		require.NoError(t, err)
+		require.Len(t, migrations, 3)
+		assert.Equal(t, Migration{Version: 1, Description: "event table", Script: "V0001__event_table.sql", SQL: "CREATE TABLE events ();"}, migrations[0])
+		assert.Equal(t, 2, migrations[1].Version)
+		assert.Equal(t, 10, migrations[2].Version)
+	})
+
+	t.Run("pairs the migrations with their undo scripts", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"V0001__event_table.sql": {Data: []byte("CREATE TABLE events ();")},
+			"U0001__event_table.sql": {Data: []byte("DROP TABLE events;")},
+			"V0002__timer_table.sql": {Data: []byte("CREATE TABLE timer ();")},
+		}
+
+		migrations, err := Load(fsys)
+
+		require.NoError(t, err)
+		assert.Equal(t, "DROP TABLE events;", migrations[0].DownSQL)
+		assert.Empty(t, migrations[1].DownSQL)
+	})
+
+	t.Run("rejects undo scripts without a migration", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"V0001__event_table.sql": {Data: []byte("")},
+			"U0002__timer_table.sql": {Data: []byte("")},
+		}
+
+		_, err := Load(fsys)
+
+		assert.EqualError(t, err, "undo script of version 2 has no migration")
+	})
+
+	t.Run("rejects scripts with the same version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"V0001__event_table.sql": {Data: []byte("")},
+			"V1__other_table.sql":    {Data: []byte("")},
+		}
+
+		_, err := Load(fsys)
+
+		assert.ErrorContains(t, err, "have the same version")
+	})
+}
+
+func TestPostgres(t *testing.T) {
+	migrations, err := Postgres()
+
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "versions have no gaps")
+		assert.NotEmpty(t, migration.DownSQL, "%s can be undone", migration.Script)
+	}
+}
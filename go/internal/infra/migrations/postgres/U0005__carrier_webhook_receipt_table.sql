@@ -0,0 +1 @@
+DROP TABLE carrier_webhook_receipt;
@@ -0,0 +1,8 @@
+-- Receipts of payment providers did not exist before, and cannot be kept as carrier receipts
+DELETE FROM webhook_receipt WHERE source NOT LIKE 'carrier:%';
+UPDATE webhook_receipt SET source = substr(source, length('carrier:') + 1);
+
+ALTER TABLE webhook_receipt RENAME COLUMN subject_id TO order_id;
+ALTER TABLE webhook_receipt RENAME COLUMN event_id TO carrier_event_id;
+ALTER TABLE webhook_receipt RENAME COLUMN source TO carrier;
+ALTER TABLE webhook_receipt RENAME TO carrier_webhook_receipt;
@@ -0,0 +1,2 @@
+DROP INDEX idx_order_projection_payment_reference;
+ALTER TABLE order_projection DROP COLUMN payment_reference;
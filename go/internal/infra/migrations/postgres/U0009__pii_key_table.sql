@@ -0,0 +1,2 @@
+-- Dropping the keys redacts the personal data of every event
+DROP TABLE pii_key;
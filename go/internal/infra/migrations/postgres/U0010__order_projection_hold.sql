@@ -0,0 +1,4 @@
+DROP INDEX idx_order_projection_on_hold;
+DROP INDEX idx_order_projection_customer_id;
+ALTER TABLE order_projection DROP COLUMN on_hold;
+ALTER TABLE order_projection DROP COLUMN customer_id;
@@ -0,0 +1,2 @@
+ALTER INDEX idx_events_aggregate_id RENAME TO idx_event_aggregate_id;
+ALTER TABLE events RENAME TO event;
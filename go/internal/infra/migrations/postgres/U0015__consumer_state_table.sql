@@ -0,0 +1 @@
+DROP TABLE consumer_state;
@@ -0,0 +1,21 @@
+-- Create the timer table
+-- Durable timers let producers schedule work (a command or an event) for a future point in time.
+-- A poller claims due timers with SELECT ... FOR UPDATE SKIP LOCKED, so several instances can poll safely.
+CREATE TABLE timer (
+    timer_id BIGSERIAL PRIMARY KEY,
+    timer_key VARCHAR(255) NOT NULL,
+    timer_type VARCHAR(255) NOT NULL,
+    aggregate_id VARCHAR(255) NOT NULL,
+    aggregate_type VARCHAR(255) NOT NULL,
+    payload BYTEA,
+    fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(32) NOT NULL DEFAULT 'scheduled',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE (timer_key)
+);
+
+CREATE INDEX idx_timer_due ON timer (fire_at) WHERE status = 'scheduled';
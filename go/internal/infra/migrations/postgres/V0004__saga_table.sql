@@ -0,0 +1,17 @@
+-- Create the saga table
+-- Stores the state of long running process managers (sagas), keyed by saga type and correlation id.
+-- The version column is used for optimistic locking when several consumers update the same saga.
+CREATE TABLE saga (
+    saga_type VARCHAR(255) NOT NULL,
+    correlation_id VARCHAR(255) NOT NULL,
+    step VARCHAR(255) NOT NULL,
+    status VARCHAR(32) NOT NULL,
+    data BYTEA,
+    version INT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (saga_type, correlation_id)
+);
+
+CREATE INDEX idx_saga_status_updated_at ON saga (status, updated_at);
@@ -0,0 +1,11 @@
+-- Create the carrier webhook receipt table
+-- Carriers deliver webhooks at least once. A receipt is recorded for every processed carrier event,
+-- so that redelivered events are not applied twice.
+CREATE TABLE carrier_webhook_receipt (
+    carrier VARCHAR(255) NOT NULL,
+    carrier_event_id VARCHAR(255) NOT NULL,
+    order_id VARCHAR(255) NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (carrier, carrier_event_id)
+);
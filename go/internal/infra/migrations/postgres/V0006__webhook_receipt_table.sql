@@ -0,0 +1,8 @@
+-- Generalize the carrier webhook receipts to every inbound webhook (carriers, payment providers)
+ALTER TABLE carrier_webhook_receipt RENAME TO webhook_receipt;
+ALTER TABLE webhook_receipt RENAME COLUMN carrier TO source;
+ALTER TABLE webhook_receipt RENAME COLUMN carrier_event_id TO event_id;
+ALTER TABLE webhook_receipt RENAME COLUMN order_id TO subject_id;
+
+-- Existing receipts were all sent by carriers
+UPDATE webhook_receipt SET source = 'carrier:' || source;
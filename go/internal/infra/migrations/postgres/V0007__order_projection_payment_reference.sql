@@ -0,0 +1,4 @@
+-- Index the payment reference of orders, used to correlate payment provider notifications with orders
+ALTER TABLE order_projection ADD COLUMN payment_reference VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_order_projection_payment_reference ON order_projection (payment_reference) WHERE payment_reference IS NOT NULL;
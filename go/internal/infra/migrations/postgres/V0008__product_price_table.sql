@@ -0,0 +1,7 @@
+-- Create the product price catalog, used to price orders on the server
+CREATE TABLE product_price (
+    product_id VARCHAR(255) NOT NULL PRIMARY KEY,
+    currency_code CHAR(3) NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- Create the data keys that personal data in events is encrypted with. Deleting a key redacts the
+-- data of its subject (e.g. the shipping address of an order) from the event store.
+CREATE TABLE pii_key (
+    key_id VARCHAR(255) NOT NULL PRIMARY KEY,
+    data_key BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- Index the customer and hold state of orders, used to score the order velocity of customers
+-- and to list the orders held for fraud review
+ALTER TABLE order_projection ADD COLUMN customer_id VARCHAR(255);
+ALTER TABLE order_projection ADD COLUMN on_hold BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_order_projection_customer_id ON order_projection (customer_id, created_at);
+CREATE INDEX idx_order_projection_on_hold ON order_projection (created_at) WHERE on_hold;
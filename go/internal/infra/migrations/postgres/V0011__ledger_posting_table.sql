@@ -0,0 +1,20 @@
+-- Create the double-entry ledger of vendors. Every transaction posts balanced entries to the cash,
+-- vendor_payable and commission accounts; amounts are positive for debits and negative for credits.
+CREATE TABLE ledger_posting (
+    posting_id BIGSERIAL PRIMARY KEY,
+    transaction_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    account VARCHAR(64) NOT NULL,
+    vendor_id VARCHAR(255) NOT NULL,
+    order_id VARCHAR(255),
+    currency_code CHAR(3) NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    description TEXT NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- A transaction posts once per account, which makes replayed events idempotent
+    UNIQUE (transaction_id, account)
+);
+
+CREATE INDEX idx_ledger_posting_vendor_account ON ledger_posting (vendor_id, account, occurred_at);
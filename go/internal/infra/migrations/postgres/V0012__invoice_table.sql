@@ -0,0 +1,35 @@
+-- Create the invoices of paid orders and the credit notes of their refunds. Each vendor numbers
+-- its invoices and credit notes in their own series, without gaps: the next number is taken from
+-- invoice_sequence in the transaction that stores the invoice.
+CREATE TABLE invoice_sequence (
+    vendor_id VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    last_sequence BIGINT NOT NULL,
+
+    PRIMARY KEY (vendor_id, kind)
+);
+
+CREATE TABLE invoice (
+    invoice_id VARCHAR(255) NOT NULL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    vendor_id VARCHAR(255) NOT NULL,
+    sequence BIGINT NOT NULL,
+    order_id VARCHAR(255) NOT NULL,
+    -- What the invoice was issued for, e.g. the payment or a refund of an order
+    source_id VARCHAR(255) NOT NULL UNIQUE,
+    customer_id VARCHAR(255) NOT NULL,
+    currency_code CHAR(3) NOT NULL,
+    line_items JSONB NOT NULL,
+    subtotal_minor BIGINT NOT NULL,
+    discount_minor BIGINT NOT NULL,
+    total_minor BIGINT NOT NULL,
+    credited_invoice_id VARCHAR(255) REFERENCES invoice (invoice_id),
+    credited_invoice_number VARCHAR(32),
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE (vendor_id, kind, sequence)
+);
+
+CREATE INDEX idx_invoice_order_id ON invoice (order_id);
+CREATE INDEX idx_invoice_vendor_id ON invoice (vendor_id, issued_at);
@@ -0,0 +1,3 @@
+-- Record who caused each event: the authenticated principal of the request, e.g. customer:1234,
+-- or system for the events emitted by consumers, sagas and timers
+ALTER TABLE event ADD COLUMN actor VARCHAR(255) NOT NULL DEFAULT 'system';
@@ -0,0 +1,3 @@
+-- Rename the event store to events, the table the service reads and writes by default
+ALTER TABLE event RENAME TO events;
+ALTER INDEX idx_event_aggregate_id RENAME TO idx_events_aggregate_id;
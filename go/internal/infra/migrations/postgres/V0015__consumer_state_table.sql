@@ -0,0 +1,8 @@
+-- Create the state of the consumers set by operators. Every instance of a consumer polls whether it
+-- is paused, and leaves its consumer group while it is.
+CREATE TABLE consumer_state (
+    consumer VARCHAR(255) NOT NULL PRIMARY KEY,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_by VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
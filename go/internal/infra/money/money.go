@@ -0,0 +1,181 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is an exact amount of money, stored as an integer number of minor units of its currency.
+type Money struct {
+	Currency string
+	Amount   int64
+}
+
+// New returns an amount of minor units of a currency.
+func New(currency string, amount int64) (Money, error) {
+	if !IsCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Currency: currency, Amount: amount}, nil
+}
+
+// Zero returns an empty amount of a currency.
+func Zero(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// FromFloat converts an amount in major units (e.g. dollars) into minor units, rounding half away
+// from zero. Only meant for amounts recorded before money was exact.
+func FromFloat(currency string, amount float64) (Money, error) {
+	digits, ok := currencies[currency]
+	if !ok {
+		return Money{}, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return Money{Currency: currency, Amount: int64(math.Round(amount * math.Pow10(digits)))}, nil
+}
+
+// IsCurrency returns true if the code is an active ISO 4217 currency code.
+func IsCurrency(code string) bool {
+	_, ok := currencies[code]
+	return ok
+}
+
+// Digits returns the number of digits of the minor unit of a currency.
+func Digits(currency string) int {
+	return currencies[currency]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Equal returns true if both amounts have the same currency and value.
+func (m Money) Equal(other Money) bool {
+	return m.Currency == other.Currency && m.Amount == other.Amount
+}
+
+// Add returns the sum of two amounts of the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Currency: m.Currency, Amount: m.Amount + other.Amount}, nil
+}
+
+// Sub returns the difference of two amounts of the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if err := m.checkCurrency(other); err != nil {
+		return Money{}, err
+	}
+	return Money{Currency: m.Currency, Amount: m.Amount - other.Amount}, nil
+}
+
+// Mul returns the amount multiplied by a quantity.
+func (m Money) Mul(quantity int64) Money {
+	return Money{Currency: m.Currency, Amount: m.Amount * quantity}
+}
+
+// MulRatio returns the amount multiplied by numerator/denominator, e.g. a percentage in basis
+// points with MulRatio(bps, 10000). The result is rounded half to even, so that rounding errors
+// do not accumulate in one direction over many amounts.
+func (m Money) MulRatio(numerator int64, denominator int64) Money {
+	if denominator == 0 {
+		panic("money: zero denominator")
+	}
+	if denominator < 0 {
+		numerator, denominator = -numerator, -denominator
+	}
+
+	product := m.Amount * numerator
+	quotient, remainder := product/denominator, product%denominator
+
+	// Round half to even, away from the truncated quotient
+	twice := 2 * abs(remainder)
+	if twice > denominator || (twice == denominator && quotient%2 != 0) {
+		if product < 0 {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+
+	return Money{Currency: m.Currency, Amount: quotient}
+}
+
+// Allocate splits the amount between parts in proportion to their weights. The minor units lost
+// to rounding are given to the first parts, so the allocations always add up to the amount.
+func (m Money) Allocate(weights ...int64) []Money {
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+
+	allocations := make([]Money, len(weights))
+	if total == 0 {
+		for i := range allocations {
+			allocations[i] = Zero(m.Currency)
+		}
+		return allocations
+	}
+
+	remainder := m.Amount
+	for i, weight := range weights {
+		allocations[i] = Money{Currency: m.Currency, Amount: m.Amount * weight / total}
+		remainder -= allocations[i].Amount
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(allocations) {
+		allocations[i].Amount += step
+		remainder -= step
+	}
+
+	return allocations
+}
+
+// String formats the amount in major units followed by its currency, e.g. "12.50 USD".
+func (m Money) String() string {
+	digits := currencies[m.Currency]
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if digits == 0 {
+		return fmt.Sprintf("%s%d %s", sign, amount, m.Currency)
+	}
+
+	scale := int64(math.Pow10(digits))
+	minor := fmt.Sprintf("%d", amount%scale)
+	return fmt.Sprintf("%s%d.%s%s %s", sign, amount/scale, strings.Repeat("0", digits-len(minor)), minor, m.Currency)
+}
+
+func (m Money) checkCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
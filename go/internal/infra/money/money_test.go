@@ -0,0 +1,97 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	amount, err := New("EUR", 1250)
+	require.NoError(t, err)
+	assert.Equal(t, Money{Currency: "EUR", Amount: 1250}, amount)
+
+	_, err = New("XYZ", 1250)
+	assert.ErrorIs(t, err, ErrUnknownCurrency)
+}
+
+func TestFromFloat(t *testing.T) {
+	tests := []struct {
+		currency string
+		amount   float64
+		expected int64
+	}{
+		{"USD", 99.99, 9999},
+		{"USD", 0.1 + 0.2, 30},
+		{"USD", 0.005, 1},
+		{"USD", -0.005, -1},
+		{"JPY", 1500.4, 1500},
+		{"KWD", 1.2345, 1235},
+	}
+
+	for _, test := range tests {
+		amount, err := FromFloat(test.currency, test.amount)
+		require.NoError(t, err)
+		assert.Equal(t, Money{Currency: test.currency, Amount: test.expected}, amount)
+	}
+}
+
+func TestMoney_Add(t *testing.T) {
+	sum, err := Money{Currency: "USD", Amount: 1000}.Add(Money{Currency: "USD", Amount: 250})
+	require.NoError(t, err)
+	assert.Equal(t, Money{Currency: "USD", Amount: 1250}, sum)
+
+	_, err = Money{Currency: "USD", Amount: 1000}.Add(Money{Currency: "EUR", Amount: 250})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	_, err = Money{Currency: "USD", Amount: 1000}.Sub(Money{Currency: "EUR", Amount: 250})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMoney_MulRatio(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		numerator   int64
+		denominator int64
+		expected    int64
+	}{
+		{"exact", 1000, 1500, 10000, 150},
+		{"rounds down", 1001, 1, 3, 334},
+		{"rounds up", 1000, 2, 3, 667},
+		{"half to even, down", 25, 1, 10, 2},
+		{"half to even, up", 35, 1, 10, 4},
+		{"negative half to even", -25, 1, 10, -2},
+		{"negative rounds away", -1000, 2, 3, -667},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Money{Currency: "USD", Amount: test.amount}.MulRatio(test.numerator, test.denominator)
+			assert.Equal(t, Money{Currency: "USD", Amount: test.expected}, result)
+		})
+	}
+}
+
+func TestMoney_Allocate(t *testing.T) {
+	allocations := Money{Currency: "USD", Amount: 100}.Allocate(1, 1, 1)
+	assert.Equal(t, []Money{
+		{Currency: "USD", Amount: 34},
+		{Currency: "USD", Amount: 33},
+		{Currency: "USD", Amount: 33},
+	}, allocations)
+
+	allocations = Money{Currency: "USD", Amount: -100}.Allocate(1, 2)
+	assert.Equal(t, []Money{
+		{Currency: "USD", Amount: -34},
+		{Currency: "USD", Amount: -66},
+	}, allocations)
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "12.05 USD", Money{Currency: "USD", Amount: 1205}.String())
+	assert.Equal(t, "-0.50 EUR", Money{Currency: "EUR", Amount: -50}.String())
+	assert.Equal(t, "1500 JPY", Money{Currency: "JPY", Amount: 1500}.String())
+	assert.Equal(t, "1.005 KWD", Money{Currency: "KWD", Amount: 1005}.String())
+}
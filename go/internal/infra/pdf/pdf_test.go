@@ -0,0 +1,51 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Bytes(t *testing.T) {
+	doc := New()
+	page := doc.AddPage()
+	page.Text(50, 50, 12, true, "Invoice (INV-000001)")
+	page.Line(50, 60, 545, 60, 0.5)
+	doc.AddPage().TextRight(545, 50, 10, false, "1500.00 EUR")
+
+	out := doc.Bytes()
+
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(out, []byte("%%EOF\n")))
+	assert.Contains(t, string(out), "/Count 2")
+	assert.Contains(t, string(out), `(Invoice \(INV-000001\)) Tj`)
+
+	// Every entry of the cross-reference table points at its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(out, -1)
+	assert.Len(t, xref, 8)
+	for i, entry := range xref {
+		offset, err := strconv.Atoi(string(entry[1]))
+		assert.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(out[offset:], []byte(fmt.Sprintf("%d 0 obj", i+1))))
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(out)
+	offset, err := strconv.Atoi(string(startxref[1]))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(out[offset:], []byte("xref\n")))
+}
+
+func TestEscape(t *testing.T) {
+	assert.Equal(t, `a\\b\(c\)`, escape(`a\b(c)`))
+	assert.Equal(t, `caf\351`, escape("café"))
+	assert.Equal(t, "100 ?", escape("100 €"))
+}
+
+func TestTextWidth(t *testing.T) {
+	assert.InDelta(t, 5.56, TextWidth("0", 10), 0.001)
+	assert.InDelta(t, 11.66, TextWidth("Wi", 10), 0.001)
+}
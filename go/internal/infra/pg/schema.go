@@ -0,0 +1,122 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+// TableSchema is what the service expects of a table: the columns it reads and writes, and the primary
+// key or unique constraints it relies on, e.g. for optimistic locking or upserts.
+type TableSchema struct {
+	Name    string
+	Columns []string
+	Unique  [][]string
+}
+
+// VerifyTables checks that the tables of the current schema match what the service expects.
+func VerifyTables(ctx context.Context, db *sqlx.DB, schemas ...TableSchema) error {
+	for _, schema := range schemas {
+		columns, err := tableColumns(ctx, db, schema.Name)
+		if err != nil {
+			return err
+		}
+		constraints, err := tableConstraints(ctx, db, schema.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := schema.check(columns, constraints); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check compares the columns and the unique constraints of a table, by their columns, to the schema.
+func (s TableSchema) check(columns []string, constraints [][]string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("table %s does not exist", s.Name)
+	}
+
+	missing := []string{}
+	for _, column := range s.Columns {
+		if !slices.Contains(columns, column) {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("table %s is missing columns %s", s.Name, strings.Join(missing, ", "))
+	}
+
+	for _, unique := range s.Unique {
+		expected := slices.Sorted(slices.Values(unique))
+		found := slices.ContainsFunc(constraints, func(constraint []string) bool {
+			return slices.Equal(slices.Sorted(slices.Values(constraint)), expected)
+		})
+		if !found {
+			return fmt.Errorf("table %s has no primary key or unique constraint on (%s)", s.Name, strings.Join(unique, ", "))
+		}
+	}
+
+	return nil
+}
+
+func tableColumns(ctx context.Context, db *sqlx.DB, table string) ([]string, error) {
+	query, queryArgs, err := Dialect.From(goqu.S("information_schema").Table("columns")).Prepared(true).
+		Select("column_name").
+		Where(goqu.Ex{"table_schema": goqu.L("current_schema()"), "table_name": table}).
+		ToSQL()
+	if err != nil {
+		return nil, ErrorDsl(err)
+	}
+
+	columns := []string{}
+	if err := db.SelectContext(ctx, &columns, query, queryArgs...); err != nil {
+		return nil, ErrorDb(err)
+	}
+	return columns, nil
+}
+
+// tableConstraints returns the columns of each primary key and unique constraint of a table.
+func tableConstraints(ctx context.Context, db *sqlx.DB, table string) ([][]string, error) {
+	query, queryArgs, err := Dialect.From(goqu.S("information_schema").Table("table_constraints").As("tc")).Prepared(true).
+		Join(goqu.S("information_schema").Table("key_column_usage").As("kcu"), goqu.Using("constraint_schema", "constraint_name")).
+		Select(goqu.I("tc.constraint_name"), goqu.I("kcu.column_name")).
+		Where(goqu.Ex{
+			"tc.table_schema":    goqu.L("current_schema()"),
+			"tc.table_name":      table,
+			"tc.constraint_type": []string{"PRIMARY KEY", "UNIQUE"},
+		}).
+		ToSQL()
+	if err != nil {
+		return nil, ErrorDsl(err)
+	}
+
+	rows := []struct {
+		ConstraintName string `db:"constraint_name"`
+		ColumnName     string `db:"column_name"`
+	}{}
+	if err := db.SelectContext(ctx, &rows, query, queryArgs...); err != nil {
+		return nil, ErrorDb(err)
+	}
+
+	names := []string{}
+	columns := map[string][]string{}
+	for _, row := range rows {
+		if _, ok := columns[row.ConstraintName]; !ok {
+			names = append(names, row.ConstraintName)
+		}
+		columns[row.ConstraintName] = append(columns[row.ConstraintName], row.ColumnName)
+	}
+
+	constraints := make([][]string, len(names))
+	for i, name := range names {
+		constraints[i] = columns[name]
+	}
+	return constraints, nil
+}
@@ -0,0 +1,45 @@
+package pg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableSchema_Check(t *testing.T) {
+	schema := TableSchema{
+		Name:    "events",
+		Columns: []string{"event_id", "sequence_number", "aggregate_id"},
+		Unique:  [][]string{{"event_id"}, {"sequence_number", "aggregate_id"}},
+	}
+
+	t.Run("passes when the columns and constraints exist", func(t *testing.T) {
+		err := schema.check(
+			[]string{"aggregate_id", "event_id", "sequence_number", "created_at"},
+			[][]string{{"event_id"}, {"aggregate_id", "sequence_number"}},
+		)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("fails when the table does not exist", func(t *testing.T) {
+		err := schema.check(nil, nil)
+
+		assert.EqualError(t, err, "table events does not exist")
+	})
+
+	t.Run("fails when columns are missing", func(t *testing.T) {
+		err := schema.check([]string{"event_id"}, [][]string{{"event_id"}})
+
+		assert.EqualError(t, err, "table events is missing columns sequence_number, aggregate_id")
+	})
+
+	t.Run("fails when a constraint is missing", func(t *testing.T) {
+		err := schema.check(
+			[]string{"event_id", "sequence_number", "aggregate_id"},
+			[][]string{{"event_id"}, {"sequence_number"}},
+		)
+
+		assert.EqualError(t, err, "table events has no primary key or unique constraint on (sequence_number, aggregate_id)")
+	})
+}
@@ -40,7 +40,7 @@ func (t *DbTranscator) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(
 
 	defer func() {
 		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
-			logging.Logger.Error("failed to rollback", "error", rbErr)
+			logging.FromContext(ctx).Error("failed to rollback", "error", rbErr)
 		}
 	}()
 
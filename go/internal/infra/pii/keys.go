@@ -0,0 +1,160 @@
+package pii
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	KeysTable = "pii_key"
+
+	// keySize is the size of the data keys, for AES-256.
+	keySize = 32
+)
+
+// KeyStore stores the data keys that personal data is encrypted with, one per subject (e.g. an order).
+type KeyStore interface {
+	// GetOrCreate returns the key with the given id, creating it if it does not exist.
+	GetOrCreate(ctx context.Context, keyId string) ([]byte, error)
+
+	// Get returns the key with the given id, or nil if it does not exist or was deleted.
+	Get(ctx context.Context, keyId string) ([]byte, error)
+
+	// Delete deletes a key. The data encrypted with it can no longer be read.
+	Delete(ctx context.Context, keyId string) error
+}
+
+func newDataKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+/** Postgres Store */
+
+type PgKeyStore struct {
+	db *sqlx.DB
+}
+
+func NewPgKeyStore(db *sqlx.DB) *PgKeyStore {
+	return &PgKeyStore{db: db}
+}
+
+func (s *PgKeyStore) GetOrCreate(ctx context.Context, keyId string) ([]byte, error) {
+	dataKey, err := newDataKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert a new key, keeping the existing one on conflict
+	ds := pg.Dialect.Insert(KeysTable).Prepared(true).
+		Rows(goqu.Record{
+			"key_id":     keyId,
+			"data_key":   dataKey,
+			"created_at": time.Now(),
+		}).
+		OnConflict(goqu.DoNothing())
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	return s.Get(ctx, keyId)
+}
+
+func (s *PgKeyStore) Get(ctx context.Context, keyId string) ([]byte, error) {
+
+	ds := pg.Dialect.From(KeysTable).Prepared(true).
+		Select("data_key").
+		Where(goqu.Ex{"key_id": keyId})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	var dataKey []byte
+	err = s.db.QueryRowxContext(ctx, query, queryArgs...).Scan(&dataKey)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	return dataKey, nil
+}
+
+func (s *PgKeyStore) Delete(ctx context.Context, keyId string) error {
+
+	ds := pg.Dialect.Delete(KeysTable).Prepared(true).
+		Where(goqu.Ex{"key_id": keyId})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+/** In-Memory Store */
+
+type InMemoryKeyStore struct {
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func NewInMemoryKeyStore() *InMemoryKeyStore {
+	return &InMemoryKeyStore{keys: make(map[string][]byte)}
+}
+
+func (s *InMemoryKeyStore) GetOrCreate(ctx context.Context, keyId string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := s.keys[keyId]; ok {
+		return key, nil
+	}
+
+	key, err := newDataKey()
+	if err != nil {
+		return nil, err
+	}
+	s.keys[keyId] = key
+	return key, nil
+}
+
+func (s *InMemoryKeyStore) Get(ctx context.Context, keyId string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.keys[keyId], nil
+}
+
+func (s *InMemoryKeyStore) Delete(ctx context.Context, keyId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, keyId)
+	return nil
+}
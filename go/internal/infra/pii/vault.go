@@ -0,0 +1,92 @@
+package pii
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrRedacted is returned when opening data whose key was deleted.
+	ErrRedacted = errors.New("personal data was redacted")
+)
+
+// Vault encrypts personal data before it is recorded in events, which cannot be modified.
+// Each subject gets its own data key, and deleting the key redacts the data of the subject
+// from every event it was recorded in ("crypto-shredding").
+type Vault struct {
+	keys KeyStore
+}
+
+func NewVault(keys KeyStore) *Vault {
+	return &Vault{keys: keys}
+}
+
+// Seal encrypts data with the key of a subject, creating the key if needed.
+func (v *Vault) Seal(ctx context.Context, keyId string, plaintext []byte) ([]byte, error) {
+	key, err := v.keys.GetOrCreate(ctx, keyId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key: %w", err)
+	}
+
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// The nonce is prepended to the ciphertext. The key id is authenticated so that data
+	// cannot be moved between subjects.
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, []byte(keyId)), nil
+}
+
+// Open decrypts data sealed with the key of a subject. Returns ErrRedacted if the key was deleted.
+func (v *Vault) Open(ctx context.Context, keyId string, ciphertext []byte) ([]byte, error) {
+	key, err := v.keys.Get(ctx, keyId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data key: %w", err)
+	}
+	if key == nil {
+		return nil, ErrRedacted
+	}
+
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// Forget deletes the key of a subject, redacting all of its sealed data.
+func (v *Vault) Forget(ctx context.Context, keyId string) error {
+	if err := v.keys.Delete(ctx, keyId); err != nil {
+		return fmt.Errorf("failed to delete data key: %w", err)
+	}
+	return nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
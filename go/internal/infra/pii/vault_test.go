@@ -0,0 +1,50 @@
+package pii
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVault(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("opens sealed data", func(t *testing.T) {
+		vault := NewVault(NewInMemoryKeyStore())
+
+		sealed, err := vault.Seal(ctx, "order-1", []byte("221B Baker Street"))
+		require.NoError(t, err)
+		assert.NotContains(t, string(sealed), "Baker")
+
+		plaintext, err := vault.Open(ctx, "order-1", sealed)
+		require.NoError(t, err)
+		assert.Equal(t, "221B Baker Street", string(plaintext))
+	})
+
+	t.Run("forgotten data is redacted", func(t *testing.T) {
+		vault := NewVault(NewInMemoryKeyStore())
+
+		sealed, err := vault.Seal(ctx, "order-1", []byte("221B Baker Street"))
+		require.NoError(t, err)
+		require.NoError(t, vault.Forget(ctx, "order-1"))
+
+		_, err = vault.Open(ctx, "order-1", sealed)
+		assert.ErrorIs(t, err, ErrRedacted)
+	})
+
+	t.Run("data cannot be opened by another subject", func(t *testing.T) {
+		keys := NewInMemoryKeyStore()
+		vault := NewVault(keys)
+
+		sealed, err := vault.Seal(ctx, "order-1", []byte("221B Baker Street"))
+		require.NoError(t, err)
+
+		// Even with the same key, the key id is authenticated
+		keys.keys["order-2"] = keys.keys["order-1"]
+		_, err = vault.Open(ctx, "order-2", sealed)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrRedacted)
+	})
+}
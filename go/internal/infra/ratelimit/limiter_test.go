@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	t.Run("allows a burst, then one request per refilled token", func(t *testing.T) {
+		limiter := NewLimiter()
+
+		for range 3 {
+			allowed, _ := limiter.Allow("client", limit, now)
+			assert.True(t, allowed)
+		}
+
+		allowed, retryAfter := limiter.Allow("client", limit, now)
+		assert.False(t, allowed)
+		assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+		allowed, _ = limiter.Allow("client", limit, now.Add(500*time.Millisecond))
+		assert.True(t, allowed)
+	})
+
+	t.Run("limits every key on its own", func(t *testing.T) {
+		limiter := NewLimiter()
+
+		for range 3 {
+			limiter.Allow("client-1", limit, now)
+		}
+
+		allowed, _ := limiter.Allow("client-2", limit, now)
+		assert.True(t, allowed)
+	})
+
+	t.Run("lets every request through without a rate", func(t *testing.T) {
+		limiter := NewLimiter()
+
+		for range 100 {
+			allowed, _ := limiter.Allow("client", Limit{}, now)
+			assert.True(t, allowed)
+		}
+	})
+
+	t.Run("drops the buckets that refilled", func(t *testing.T) {
+		limiter := NewLimiter()
+		limiter.Allow("idle", limit, now)
+		limiter.Allow("slow", Limit{Rate: 0.001, Burst: 1}, now)
+
+		limiter.Allow("client", limit, now.Add(2*time.Minute))
+
+		assert.NotContains(t, limiter.buckets, "idle")
+		assert.Contains(t, limiter.buckets, "slow")
+	})
+}
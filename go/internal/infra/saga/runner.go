@@ -0,0 +1,153 @@
+package saga
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+)
+
+const TimerTypePrefix = "saga_timeout:"
+
+// Definition describes a process manager: which events it reacts to and what it does in each step.
+// Commands issued by a definition must be idempotent, since messages are delivered at least once.
+type Definition interface {
+	// Name is the saga type. It is also used as the consumer name.
+	Name() string
+
+	// Correlate returns the correlation id of the saga an event belongs to.
+	// It returns false if the event is not relevant to this saga.
+	Correlate(args eventsrc.ConsumeArgs) (string, bool)
+
+	// Handle reacts to an event. The state is new if no saga exists yet for the correlation id.
+	// A new saga is only persisted if the definition moved it to a step.
+	Handle(ctx context.Context, state *State, args eventsrc.ConsumeArgs) error
+
+	// HandleTimeout reacts to a timeout previously requested with State.ScheduleTimeout.
+	HandleTimeout(ctx context.Context, state *State, name string) error
+}
+
+// Runner loads and persists saga state around a definition.
+// It implements eventsrc.Consumer for events and timers.Handler for timeouts.
+type Runner struct {
+	definition Definition
+	store      Store
+	scheduler  timers.Scheduler
+	transactor pg.Transactor
+}
+
+func NewRunner(definition Definition, store Store, scheduler timers.Scheduler, transactor pg.Transactor) *Runner {
+	return &Runner{definition: definition, store: store, scheduler: scheduler, transactor: transactor}
+}
+
+func (r *Runner) Name() string {
+	return r.definition.Name()
+}
+
+func (r *Runner) TimerType() string {
+	return TimerTypePrefix + r.definition.Name()
+}
+
+func timeoutKey(sagaType string, correlationId string, name string) string {
+	return fmt.Sprintf("saga:%s:%s:%s", sagaType, correlationId, name)
+}
+
+// Consume passes an event to the definition and persists the resulting state.
+func (r *Runner) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	correlationId, ok := r.definition.Correlate(args)
+	if !ok {
+		return nil
+	}
+
+	state, err := r.store.Load(ctx, r.definition.Name(), correlationId)
+	if err != nil {
+		return fmt.Errorf("failed to load saga: %w", err)
+	}
+	if state == nil {
+		state = newState(r.definition.Name(), correlationId)
+	}
+	if state.IsFinished() {
+		return nil
+	}
+
+	previousStep := state.Step
+	if err := r.definition.Handle(ctx, state, args); err != nil {
+		return fmt.Errorf("failed to handle %s in saga %s: %w", args.EventType, r.definition.Name(), err)
+	}
+
+	if err := r.save(ctx, state); err != nil {
+		return err
+	}
+
+	if state.Step != previousStep {
+		logging.FromContext(ctx).Info("Saga moved to step", "saga", r.definition.Name(), "correlationId", correlationId, "step", state.Step, "status", state.Status)
+	}
+
+	return nil
+}
+
+// Handle passes a due timeout to the definition and persists the resulting state.
+func (r *Runner) Handle(ctx context.Context, timer timers.Timer) error {
+	state, err := r.store.Load(ctx, r.definition.Name(), timer.AggregateID)
+	if err != nil {
+		return fmt.Errorf("failed to load saga: %w", err)
+	}
+	if state == nil || state.IsFinished() {
+		return nil
+	}
+
+	name := string(timer.Payload)
+	logging.FromContext(ctx).Info("Saga timeout reached", "saga", r.definition.Name(), "correlationId", state.CorrelationID, "timeout", name, "step", state.Step)
+
+	if err := r.definition.HandleTimeout(ctx, state, name); err != nil {
+		return fmt.Errorf("failed to handle timeout %s in saga %s: %w", name, r.definition.Name(), err)
+	}
+
+	return r.save(ctx, state)
+}
+
+// save persists the state and the requested timer changes in a single transaction.
+func (r *Runner) save(ctx context.Context, state *State) error {
+	if state.IsNew() && state.Step == "" {
+		return nil
+	}
+
+	err := r.transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+		if err := r.store.Save(ctx, tx, state); err != nil {
+			return err
+		}
+
+		for _, name := range state.cancelledTimeouts {
+			if err := r.scheduler.Cancel(ctx, tx, timeoutKey(state.SagaType, state.CorrelationID, name)); err != nil {
+				return err
+			}
+		}
+
+		for _, timeout := range state.scheduledTimeouts {
+			err := r.scheduler.Schedule(ctx, tx, timers.ScheduleArgs{
+				Key:           timeoutKey(state.SagaType, state.CorrelationID, timeout.name),
+				TimerType:     r.TimerType(),
+				AggregateID:   state.CorrelationID,
+				AggregateType: state.SagaType,
+				Payload:       []byte(timeout.name),
+				FireAt:        timeout.fireAt,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save saga: %w", err)
+	}
+
+	state.scheduledTimeouts = nil
+	state.cancelledTimeouts = nil
+	return nil
+}
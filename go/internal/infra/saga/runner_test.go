@@ -0,0 +1,213 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/timers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFireAt = time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+
+// testDefinition is a two step saga: "started" -> "done", with a timeout that compensates.
+type testDefinition struct {
+	handleErr    error
+	compensated  []string
+	handledTypes []string
+}
+
+func (d *testDefinition) Name() string {
+	return "test-saga"
+}
+
+func (d *testDefinition) Correlate(args eventsrc.ConsumeArgs) (string, bool) {
+	if args.AggregateType != "order" {
+		return "", false
+	}
+	return args.AggregateID, true
+}
+
+func (d *testDefinition) Handle(ctx context.Context, state *State, args eventsrc.ConsumeArgs) error {
+	d.handledTypes = append(d.handledTypes, args.EventType)
+	if d.handleErr != nil {
+		return d.handleErr
+	}
+
+	switch args.EventType {
+	case "started":
+		if !state.IsNew() {
+			return nil
+		}
+		state.ScheduleTimeout("deadline", testFireAt)
+		state.TransitionTo("started")
+	case "done":
+		if state.IsNew() {
+			return nil
+		}
+		state.CancelTimeout("deadline")
+		state.TransitionTo("done")
+		state.Complete()
+	}
+
+	return nil
+}
+
+func (d *testDefinition) HandleTimeout(ctx context.Context, state *State, name string) error {
+	d.compensated = append(d.compensated, state.CorrelationID)
+	state.TransitionTo("expired")
+	state.Compensate()
+	return nil
+}
+
+func newTestRunner(definition Definition) (*Runner, *InMemoryStore, *timers.InMemoryStore) {
+	store := NewInMemoryStore()
+	timerStore := timers.NewInMemoryStore()
+	return NewRunner(definition, store, timerStore, &pg.TestTransactor{}), store, timerStore
+}
+
+func consumeArgs(eventType string) eventsrc.ConsumeArgs {
+	return eventsrc.ConsumeArgs{AggregateID: "order-123", AggregateType: "order", EventType: eventType}
+}
+
+func TestRunner_Consume(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("starting event creates the saga and schedules the timeout", func(t *testing.T) {
+		runner, store, timerStore := newTestRunner(&testDefinition{})
+
+		require.NoError(t, runner.Consume(ctx, consumeArgs("started")))
+
+		state, err := store.Load(ctx, "test-saga", "order-123")
+		require.NoError(t, err)
+		require.NotNil(t, state)
+		assert.Equal(t, "started", state.Step)
+		assert.Equal(t, StatusRunning, state.Status)
+		assert.Equal(t, 1, state.Version)
+
+		timer := timerStore.Timers[timeoutKey("test-saga", "order-123", "deadline")]
+		require.NotNil(t, timer)
+		assert.Equal(t, runner.TimerType(), timer.TimerType)
+		assert.Equal(t, testFireAt, timer.FireAt)
+		assert.Equal(t, []byte("deadline"), timer.Payload)
+	})
+
+	t.Run("event without a started saga is not persisted", func(t *testing.T) {
+		runner, store, _ := newTestRunner(&testDefinition{})
+
+		require.NoError(t, runner.Consume(ctx, consumeArgs("done")))
+
+		assert.Empty(t, store.Sagas)
+	})
+
+	t.Run("uncorrelated event is ignored", func(t *testing.T) {
+		definition := &testDefinition{}
+		runner, _, _ := newTestRunner(definition)
+
+		err := runner.Consume(ctx, eventsrc.ConsumeArgs{AggregateID: "vendor-1", AggregateType: "vendor", EventType: "started"})
+
+		require.NoError(t, err)
+		assert.Empty(t, definition.handledTypes)
+	})
+
+	t.Run("completing event finishes the saga and cancels the timeout", func(t *testing.T) {
+		definition := &testDefinition{}
+		runner, store, timerStore := newTestRunner(definition)
+
+		require.NoError(t, runner.Consume(ctx, consumeArgs("started")))
+		require.NoError(t, runner.Consume(ctx, consumeArgs("done")))
+
+		state, err := store.Load(ctx, "test-saga", "order-123")
+		require.NoError(t, err)
+		assert.Equal(t, "done", state.Step)
+		assert.Equal(t, StatusCompleted, state.Status)
+		assert.Equal(t, timers.StatusCancelled, timerStore.Timers[timeoutKey("test-saga", "order-123", "deadline")].Status)
+
+		// Finished sagas ignore further events
+		require.NoError(t, runner.Consume(ctx, consumeArgs("started")))
+		assert.Equal(t, []string{"started", "done"}, definition.handledTypes)
+	})
+
+	t.Run("handler error is returned and nothing is saved", func(t *testing.T) {
+		runner, store, _ := newTestRunner(&testDefinition{handleErr: errors.New("command failed")})
+
+		err := runner.Consume(ctx, consumeArgs("started"))
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "command failed")
+		assert.Empty(t, store.Sagas)
+	})
+
+	t.Run("stale state is rejected", func(t *testing.T) {
+		_, store, _ := newTestRunner(&testDefinition{})
+
+		state := newState("test-saga", "order-123")
+		state.TransitionTo("started")
+		require.NoError(t, store.Save(ctx, nil, state))
+
+		stale := newState("test-saga", "order-123")
+		stale.TransitionTo("started")
+		assert.Equal(t, ErrConcurrentModification, store.Save(ctx, nil, stale))
+	})
+}
+
+func TestRunner_Handle(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("timeout compensates a running saga", func(t *testing.T) {
+		definition := &testDefinition{}
+		runner, store, timerStore := newTestRunner(definition)
+
+		require.NoError(t, runner.Consume(ctx, consumeArgs("started")))
+		timer := timerStore.Timers[timeoutKey("test-saga", "order-123", "deadline")]
+
+		require.NoError(t, runner.Handle(ctx, *timer))
+
+		state, err := store.Load(ctx, "test-saga", "order-123")
+		require.NoError(t, err)
+		assert.Equal(t, "expired", state.Step)
+		assert.Equal(t, StatusCompensated, state.Status)
+		assert.Equal(t, []string{"order-123"}, definition.compensated)
+	})
+
+	t.Run("timeout of a finished saga is ignored", func(t *testing.T) {
+		definition := &testDefinition{}
+		runner, _, timerStore := newTestRunner(definition)
+
+		require.NoError(t, runner.Consume(ctx, consumeArgs("started")))
+		require.NoError(t, runner.Consume(ctx, consumeArgs("done")))
+		timer := timerStore.Timers[timeoutKey("test-saga", "order-123", "deadline")]
+
+		require.NoError(t, runner.Handle(ctx, *timer))
+
+		assert.Empty(t, definition.compensated)
+	})
+}
+
+func TestInMemoryStore_List(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+
+	running := newState("test-saga", "order-1")
+	running.TransitionTo("started")
+	require.NoError(t, store.Save(ctx, nil, running))
+
+	finished := newState("test-saga", "order-2")
+	finished.TransitionTo("done")
+	finished.Complete()
+	require.NoError(t, store.Save(ctx, nil, finished))
+
+	inFlight, err := store.List(ctx, ListArgs{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, inFlight, 1)
+	assert.Equal(t, "order-1", inFlight[0].CorrelationID)
+
+	all, err := store.List(ctx, ListArgs{Limit: 10, IncludeFinished: true})
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
@@ -0,0 +1,76 @@
+package saga
+
+import (
+	"time"
+)
+
+const (
+	// Saga status enum
+	StatusRunning     = "running"
+	StatusCompleted   = "completed"
+	StatusCompensated = "compensated"
+)
+
+type timeoutRequest struct {
+	name   string
+	fireAt time.Time
+}
+
+// State is the persisted state of a single saga instance.
+type State struct {
+	SagaType      string    `db:"saga_type"`
+	CorrelationID string    `db:"correlation_id"`
+	Step          string    `db:"step"`
+	Status        string    `db:"status"`
+	Data          []byte    `db:"data"`
+	Version       int       `db:"version"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+
+	// Timer changes requested while handling a message. They are applied in the same transaction as the state.
+	scheduledTimeouts []timeoutRequest
+	cancelledTimeouts []string
+}
+
+func newState(sagaType string, correlationId string) *State {
+	return &State{
+		SagaType:      sagaType,
+		CorrelationID: correlationId,
+		Status:        StatusRunning,
+	}
+}
+
+// IsNew returns true if the saga has not been persisted yet.
+func (s *State) IsNew() bool {
+	return s.Version == 0
+}
+
+// IsFinished returns true if the saga will not react to any more messages.
+func (s *State) IsFinished() bool {
+	return s.Status != StatusRunning
+}
+
+// TransitionTo moves the saga to the given step.
+func (s *State) TransitionTo(step string) {
+	s.Step = step
+}
+
+// Complete marks the saga as successfully finished.
+func (s *State) Complete() {
+	s.Status = StatusCompleted
+}
+
+// Compensate marks the saga as finished after its compensating actions were issued.
+func (s *State) Compensate() {
+	s.Status = StatusCompensated
+}
+
+// ScheduleTimeout requests a timeout that will be passed back to the definition at fireAt.
+func (s *State) ScheduleTimeout(name string, fireAt time.Time) {
+	s.scheduledTimeouts = append(s.scheduledTimeouts, timeoutRequest{name: name, fireAt: fireAt})
+}
+
+// CancelTimeout cancels a previously scheduled timeout.
+func (s *State) CancelTimeout(name string) {
+	s.cancelledTimeouts = append(s.cancelledTimeouts, name)
+}
@@ -0,0 +1,248 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	SagasTable = "saga"
+)
+
+var ErrConcurrentModification = errors.New("saga was modified concurrently")
+
+type ListArgs struct {
+	// SagaType filters sagas by type. If empty, sagas of every type are returned.
+	SagaType string
+
+	// IncludeFinished also returns sagas that are no longer running.
+	IncludeFinished bool
+
+	Limit  uint
+	Offset uint
+}
+
+type Store interface {
+	// Load returns the saga with the given correlation id, or nil if it does not exist.
+	Load(ctx context.Context, sagaType string, correlationId string) (*State, error)
+
+	// Save inserts or updates the saga. It returns ErrConcurrentModification if the saga was saved
+	// by someone else since it was loaded.
+	Save(ctx context.Context, tx pg.Tx, state *State) error
+
+	List(ctx context.Context, args ListArgs) ([]State, error)
+}
+
+/** Postgres Store */
+
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Load(ctx context.Context, sagaType string, correlationId string) (*State, error) {
+	// Compile query
+	ds := pg.Dialect.From(SagasTable).Prepared(true).
+		Select(&State{}).
+		Where(goqu.Ex{"saga_type": sagaType, "correlation_id": correlationId})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := s.db.QueryxContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, nil
+	}
+
+	var state State
+	if err := rows.StructScan(&state); err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	return &state, nil
+}
+
+func (s *PostgresStore) Save(ctx context.Context, tx pg.Tx, state *State) error {
+	var query string
+	var queryArgs []any
+	var err error
+
+	// Compile query
+	if state.IsNew() {
+		query, queryArgs, err = pg.Dialect.Insert(SagasTable).Prepared(true).
+			Rows(goqu.Record{
+				"saga_type":      state.SagaType,
+				"correlation_id": state.CorrelationID,
+				"step":           state.Step,
+				"status":         state.Status,
+				"data":           state.Data,
+				"version":        state.Version + 1,
+			}).
+			OnConflict(goqu.DoNothing()).
+			ToSQL()
+	} else {
+		query, queryArgs, err = pg.Dialect.Update(SagasTable).Prepared(true).
+			Set(goqu.Record{
+				"step":       state.Step,
+				"status":     state.Status,
+				"data":       state.Data,
+				"version":    state.Version + 1,
+				"updated_at": goqu.L("NOW()"),
+			}).
+			Where(goqu.Ex{
+				"saga_type":      state.SagaType,
+				"correlation_id": state.CorrelationID,
+				"version":        state.Version,
+			}).
+			ToSQL()
+	}
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+	if rowsAffected == 0 {
+		return ErrConcurrentModification
+	}
+
+	state.Version += 1
+	return nil
+}
+
+func (s *PostgresStore) List(ctx context.Context, args ListArgs) ([]State, error) {
+	ds := pg.Dialect.From(SagasTable).Prepared(true).
+		Select(&State{}).
+		Order(goqu.I("updated_at").Desc()).
+		Limit(args.Limit).
+		Offset(args.Offset)
+
+	if args.SagaType != "" {
+		ds = ds.Where(goqu.Ex{"saga_type": args.SagaType})
+	}
+	if !args.IncludeFinished {
+		ds = ds.Where(goqu.Ex{"status": StatusRunning})
+	}
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	states := []State{}
+	err = sqlx.StructScan(rows, &states)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	return states, nil
+}
+
+/** In-memory Store */
+
+type InMemoryStore struct {
+	Sagas map[string]State
+	mu    sync.RWMutex
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{Sagas: make(map[string]State)}
+}
+
+func inMemoryKey(sagaType string, correlationId string) string {
+	return sagaType + ":" + correlationId
+}
+
+func (s *InMemoryStore) Load(ctx context.Context, sagaType string, correlationId string) (*State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	state, ok := s.Sagas[inMemoryKey(sagaType, correlationId)]
+	if !ok {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+func (s *InMemoryStore) Save(ctx context.Context, tx pg.Tx, state *State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := inMemoryKey(state.SagaType, state.CorrelationID)
+	existing, ok := s.Sagas[key]
+	if ok != !state.IsNew() || (ok && existing.Version != state.Version) {
+		return ErrConcurrentModification
+	}
+
+	now := time.Now().UTC()
+	if state.IsNew() {
+		state.CreatedAt = now
+	}
+	state.UpdatedAt = now
+	state.Version += 1
+
+	s.Sagas[key] = State{
+		SagaType:      state.SagaType,
+		CorrelationID: state.CorrelationID,
+		Step:          state.Step,
+		Status:        state.Status,
+		Data:          state.Data,
+		Version:       state.Version,
+		CreatedAt:     state.CreatedAt,
+		UpdatedAt:     state.UpdatedAt,
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) List(ctx context.Context, args ListArgs) ([]State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := []State{}
+	for _, state := range s.Sagas {
+		if args.SagaType != "" && state.SagaType != args.SagaType {
+			continue
+		}
+		if !args.IncludeFinished && state.IsFinished() {
+			continue
+		}
+		states = append(states, state)
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].UpdatedAt.After(states[j].UpdatedAt) })
+
+	start := min(args.Offset, uint(len(states)))
+	end := min(start+args.Limit, uint(len(states)))
+	return states[start:end], nil
+}
@@ -0,0 +1,162 @@
+package timers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+)
+
+const (
+	DefaultPollInterval = 5 * time.Second
+	DefaultBatchSize    = 100
+	DefaultMaxAttempts  = 10
+	DefaultRetryDelay   = 30 * time.Second
+	DefaultClaimLease   = 5 * time.Minute
+)
+
+// Handler fires timers of a single timer type.
+// Timers are fired at least once, so handlers must be idempotent.
+type Handler interface {
+	TimerType() string
+	Handle(ctx context.Context, timer Timer) error
+}
+
+type PollerOptions struct {
+	PollInterval *time.Duration
+	BatchSize    *uint
+	MaxAttempts  *int
+	RetryDelay   *time.Duration
+	ClaimLease   *time.Duration
+}
+
+// Poller periodically claims due timers from the store and passes them to the matching handler.
+type Poller struct {
+	store    Store
+	handlers map[string]Handler
+
+	pollInterval time.Duration
+	batchSize    uint
+	maxAttempts  int
+	retryDelay   time.Duration
+	claimLease   time.Duration
+
+	now func() time.Time
+}
+
+func NewPoller(store Store, handlers []Handler, opts PollerOptions) *Poller {
+	poller := &Poller{
+		store:        store,
+		handlers:     make(map[string]Handler),
+		pollInterval: DefaultPollInterval,
+		batchSize:    DefaultBatchSize,
+		maxAttempts:  DefaultMaxAttempts,
+		retryDelay:   DefaultRetryDelay,
+		claimLease:   DefaultClaimLease,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+
+	// Parse options
+	if opts.PollInterval != nil {
+		poller.pollInterval = *opts.PollInterval
+	}
+	if opts.BatchSize != nil {
+		poller.batchSize = *opts.BatchSize
+	}
+	if opts.MaxAttempts != nil {
+		poller.maxAttempts = *opts.MaxAttempts
+	}
+	if opts.RetryDelay != nil {
+		poller.retryDelay = *opts.RetryDelay
+	}
+	if opts.ClaimLease != nil {
+		poller.claimLease = *opts.ClaimLease
+	}
+
+	for _, handler := range handlers {
+		poller.handlers[handler.TimerType()] = handler
+	}
+
+	return poller
+}
+
+// Run polls for due timers until the context is cancelled.
+func (p *Poller) Run(ctx context.Context) error {
+	logging.FromContext(ctx).Info("Starting timer poller", "pollInterval", p.pollInterval.String())
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := p.pollOnce(ctx); err != nil {
+				logging.FromContext(ctx).Error("error polling timers", "error", err)
+			}
+		}
+	}
+}
+
+// pollOnce fires a single batch of due timers and returns the number of timers that were handled.
+// Each timer is fired and marked on its own, so a failure only affects that timer, which is claimed
+// again once its lease expires.
+func (p *Poller) pollOnce(ctx context.Context) (int, error) {
+	timers, err := p.store.ClaimDue(ctx, p.now(), p.batchSize, p.claimLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim due timers: %w", err)
+	}
+
+	handled := 0
+	errs := []error{}
+	for _, timer := range timers {
+		if err := p.fire(ctx, timer); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		handled += 1
+	}
+
+	return handled, errors.Join(errs...)
+}
+
+// fire passes the timer to its handler and records the outcome.
+// Handler errors are recorded on the timer and retried later; only store errors are returned.
+func (p *Poller) fire(ctx context.Context, timer Timer) error {
+	ctx = logging.With(ctx, "timerKey", timer.Key, "timerType", timer.TimerType)
+
+	handleErr := p.handle(ctx, timer)
+	if handleErr == nil {
+		logging.FromContext(ctx).Debug("Fired timer")
+		return p.store.MarkFired(ctx, timer)
+	}
+
+	attempts := timer.Attempts + 1
+	args := MarkFailedArgs{
+		Timer:    timer,
+		Attempts: attempts,
+		Error:    handleErr.Error(),
+	}
+
+	if attempts < p.maxAttempts {
+		retryAt := p.now().Add(p.retryDelay * time.Duration(attempts))
+		args.RetryAt = &retryAt
+		logging.FromContext(ctx).Warn("error firing timer, will retry", "attempts", attempts, "error", handleErr)
+	} else {
+		logging.FromContext(ctx).Error("error firing timer, giving up", "attempts", attempts, "error", handleErr)
+	}
+
+	return p.store.MarkFailed(ctx, args)
+}
+
+func (p *Poller) handle(ctx context.Context, timer Timer) error {
+	handler, ok := p.handlers[timer.TimerType]
+	if !ok {
+		return fmt.Errorf("no handler registered for timer type: %s", timer.TimerType)
+	}
+
+	return handler.Handle(ctx, timer)
+}
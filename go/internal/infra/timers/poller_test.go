@@ -0,0 +1,233 @@
+package timers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockHandler is a mock implementation of Handler
+type MockHandler struct {
+	mock.Mock
+}
+
+func (m *MockHandler) TimerType() string {
+	return "mock-timer"
+}
+
+func (m *MockHandler) Handle(ctx context.Context, timer Timer) error {
+	callArgs := m.Called(ctx, timer)
+	return callArgs.Error(0)
+}
+
+func newTestPoller(store Store, handler Handler, now time.Time) *Poller {
+	maxAttempts := 2
+	retryDelay := time.Minute
+	poller := NewPoller(store, []Handler{handler}, PollerOptions{
+		MaxAttempts: &maxAttempts,
+		RetryDelay:  &retryDelay,
+	})
+	poller.now = func() time.Time { return now }
+	return poller
+}
+
+func scheduleTestTimer(t *testing.T, store *InMemoryStore, key string, fireAt time.Time) {
+	err := store.Schedule(context.Background(), nil, ScheduleArgs{
+		Key:           key,
+		TimerType:     "mock-timer",
+		AggregateID:   "order-123",
+		AggregateType: "order",
+		FireAt:        fireAt,
+	})
+	require.NoError(t, err)
+}
+
+func TestPoller_PollOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("fires due timers only", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "due", now.Add(-time.Minute))
+		scheduleTestTimer(t, store, "not-due", now.Add(time.Minute))
+
+		handler.On("Handle", mock.Anything, mock.MatchedBy(func(timer Timer) bool {
+			return timer.Key == "due"
+		})).Return(nil)
+
+		handled, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, handled)
+		assert.Equal(t, StatusFired, store.Timers["due"].Status)
+		assert.Equal(t, StatusScheduled, store.Timers["not-due"].Status)
+		handler.AssertExpectations(t)
+	})
+
+	t.Run("cancelled timers are not fired", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "cancelled", now.Add(-time.Minute))
+		require.NoError(t, store.Cancel(context.Background(), nil, "cancelled"))
+
+		handled, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 0, handled)
+		assert.Equal(t, StatusCancelled, store.Timers["cancelled"].Status)
+		handler.AssertNotCalled(t, "Handle")
+	})
+
+	t.Run("scheduling an existing key moves its fire time", func(t *testing.T) {
+		store := NewInMemoryStore()
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+		scheduleTestTimer(t, store, "timer", now.Add(time.Hour))
+
+		assert.Len(t, store.Timers, 1)
+		assert.Equal(t, now.Add(time.Hour), store.Timers["timer"].FireAt)
+	})
+
+	t.Run("a cancelled timer fires once it is scheduled again", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Hour))
+		require.NoError(t, store.Cancel(context.Background(), nil, "timer"))
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+
+		handler.On("Handle", mock.Anything, mock.Anything).Return(nil)
+
+		handled, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, handled)
+		assert.Equal(t, StatusFired, store.Timers["timer"].Status)
+		handler.AssertExpectations(t)
+	})
+
+	t.Run("a timer rescheduled while it fires stays scheduled", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+		store.Timers["timer"].Attempts = 1
+		store.Timers["timer"].LastError = sql.NullString{String: "handler error", Valid: true}
+
+		handler.On("Handle", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			scheduleTestTimer(t, store, "timer", now.Add(time.Hour))
+		}).Return(nil)
+
+		handled, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, handled)
+
+		timer := store.Timers["timer"]
+		assert.Equal(t, StatusScheduled, timer.Status)
+		assert.Equal(t, now.Add(time.Hour), timer.FireAt)
+		assert.Equal(t, 0, timer.Attempts)
+		assert.False(t, timer.LastError.Valid)
+	})
+
+	t.Run("handler error reschedules the timer", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+		handler.On("Handle", mock.Anything, mock.Anything).Return(errors.New("handler error"))
+
+		handled, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, handled)
+
+		timer := store.Timers["timer"]
+		assert.Equal(t, StatusScheduled, timer.Status)
+		assert.Equal(t, 1, timer.Attempts)
+		assert.Equal(t, now.Add(time.Minute), timer.FireAt)
+		assert.Equal(t, "handler error", timer.LastError.String)
+	})
+
+	t.Run("handler error after max attempts marks the timer dead", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+		store.Timers["timer"].Attempts = 1
+		handler.On("Handle", mock.Anything, mock.Anything).Return(errors.New("handler error"))
+
+		_, err := newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusDead, store.Timers["timer"].Status)
+		assert.Equal(t, 2, store.Timers["timer"].Attempts)
+	})
+
+	t.Run("claimed timers are leased until they are marked", func(t *testing.T) {
+		store := NewInMemoryStore()
+		lease := time.Minute
+
+		scheduleTestTimer(t, store, "timer", now.Add(-time.Minute))
+
+		claimed, err := store.ClaimDue(context.Background(), now, 10, lease)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+
+		// The timer is not claimed again while its handler may still be running
+		again, err := store.ClaimDue(context.Background(), now, 10, lease)
+		require.NoError(t, err)
+		assert.Empty(t, again)
+
+		// An instance that stopped before marking the timer leaves it to be claimed once the lease expires
+		expired, err := store.ClaimDue(context.Background(), now.Add(lease), 10, lease)
+		require.NoError(t, err)
+		require.Len(t, expired, 1)
+
+		// The outcome of the stale claim is ignored
+		require.NoError(t, store.MarkFired(context.Background(), claimed[0]))
+		assert.Equal(t, StatusScheduled, store.Timers["timer"].Status)
+
+		require.NoError(t, store.MarkFired(context.Background(), expired[0]))
+		assert.Equal(t, StatusFired, store.Timers["timer"].Status)
+	})
+
+	t.Run("unknown timer type is retried", func(t *testing.T) {
+		store := NewInMemoryStore()
+		handler := &MockHandler{}
+
+		err := store.Schedule(context.Background(), nil, ScheduleArgs{
+			Key:       "unknown",
+			TimerType: "unknown-timer",
+			FireAt:    now.Add(-time.Minute),
+		})
+		require.NoError(t, err)
+
+		_, err = newTestPoller(store, handler, now).pollOnce(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, StatusScheduled, store.Timers["unknown"].Status)
+		assert.Contains(t, store.Timers["unknown"].LastError.String, "no handler registered")
+		handler.AssertNotCalled(t, "Handle")
+	})
+}
+
+func TestPoller_Run(t *testing.T) {
+	t.Run("context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel() // Cancel immediately
+
+		poller := NewPoller(NewInMemoryStore(), []Handler{}, PollerOptions{})
+		err := poller.Run(ctx)
+
+		assert.Equal(t, context.Canceled, err)
+	})
+}
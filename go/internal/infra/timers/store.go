@@ -0,0 +1,371 @@
+package timers
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	TimersTable = "timer"
+
+	// Timer status enum
+	StatusScheduled = "scheduled"
+	StatusFired     = "fired"
+	StatusCancelled = "cancelled"
+	StatusDead      = "dead"
+)
+
+type ScheduleArgs struct {
+	// Key uniquely identifies the timer. Scheduling a key that already exists re-arms it with the new
+	// fire time and payload, even if it was cancelled or has fired.
+	Key           string
+	TimerType     string
+	AggregateID   string
+	AggregateType string
+	Payload       []byte
+	FireAt        time.Time
+}
+
+type Timer struct {
+	TimerId       int64          `db:"timer_id"`
+	Key           string         `db:"timer_key"`
+	TimerType     string         `db:"timer_type"`
+	AggregateID   string         `db:"aggregate_id"`
+	AggregateType string         `db:"aggregate_type"`
+	Payload       []byte         `db:"payload"`
+	FireAt        time.Time      `db:"fire_at"`
+	Status        string         `db:"status"`
+	Attempts      int            `db:"attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	CreatedAt     time.Time      `db:"created_at"`
+	UpdatedAt     time.Time      `db:"updated_at"`
+}
+
+type MarkFailedArgs struct {
+	// Timer is the timer as returned by ClaimDue.
+	Timer    Timer
+	Attempts int
+	Error    string
+
+	// RetryAt is when the timer should fire again. If nil, the timer is marked as dead.
+	RetryAt *time.Time
+}
+
+// Scheduler is the interface used by producers to schedule and cancel timers.
+type Scheduler interface {
+	Schedule(ctx context.Context, tx pg.Tx, args ScheduleArgs) error
+	Cancel(ctx context.Context, tx pg.Tx, key string) error
+}
+
+// Store persists timers and hands due timers to the poller.
+type Store interface {
+	Scheduler
+
+	// ClaimDue leases up to limit timers that are due at now by moving their fire_at to now + lease, and
+	// returns them. A claimed timer that is not marked as fired or failed is claimed again once its lease expires.
+	ClaimDue(ctx context.Context, now time.Time, limit uint, lease time.Duration) ([]Timer, error)
+
+	// MarkFired and MarkFailed record the outcome of a claimed timer. They leave the timer alone if it was
+	// rescheduled or cancelled since it was claimed.
+	MarkFired(ctx context.Context, timer Timer) error
+	MarkFailed(ctx context.Context, args MarkFailedArgs) error
+}
+
+/** Postgres Store */
+
+type PostgresStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Schedule(ctx context.Context, tx pg.Tx, args ScheduleArgs) error {
+	// Compile query
+	ds := pg.Dialect.Insert(TimersTable).Prepared(true).
+		Rows(goqu.Record{
+			"timer_key":      args.Key,
+			"timer_type":     args.TimerType,
+			"aggregate_id":   args.AggregateID,
+			"aggregate_type": args.AggregateType,
+			"payload":        args.Payload,
+			"fire_at":        args.FireAt,
+			"status":         StatusScheduled,
+		}).
+		OnConflict(goqu.DoUpdate("timer_key", goqu.Record{
+			"fire_at":    goqu.L("EXCLUDED.fire_at"),
+			"payload":    goqu.L("EXCLUDED.payload"),
+			"status":     StatusScheduled,
+			"attempts":   0,
+			"last_error": nil,
+			"updated_at": goqu.L("NOW()"),
+		}))
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Cancel(ctx context.Context, tx pg.Tx, key string) error {
+	// Compile query
+	ds := pg.Dialect.Update(TimersTable).Prepared(true).
+		Set(goqu.Record{
+			"status":     StatusCancelled,
+			"updated_at": goqu.L("NOW()"),
+		}).
+		Where(goqu.Ex{"timer_key": key, "status": StatusScheduled})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) ClaimDue(ctx context.Context, now time.Time, limit uint, lease time.Duration) ([]Timer, error) {
+	// Compile query. Due timers are leased in a single statement, so no lock is held while they fire.
+	// SKIP LOCKED lets several pollers claim timers at the same time without claiming the same ones.
+	due := pg.Dialect.From(TimersTable).
+		Select("timer_id").
+		Where(
+			goqu.Ex{"status": StatusScheduled},
+			goqu.C("fire_at").Lte(now),
+		).
+		Order(goqu.I("fire_at").Asc()).
+		Limit(limit).
+		ForUpdate(goqu.SkipLocked)
+
+	ds := pg.Dialect.Update(TimersTable).Prepared(true).
+		Set(goqu.Record{
+			"fire_at":    now.Add(lease),
+			"updated_at": goqu.L("NOW()"),
+		}).
+		Where(goqu.C("timer_id").In(due)).
+		Returning(&Timer{})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return nil, pg.ErrorDsl(err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, pg.ErrorDb(err)
+	}
+
+	timers := []Timer{}
+	err = sqlx.StructScan(rows, &timers)
+	if err != nil {
+		return nil, pg.ErrorUnmarshal(err)
+	}
+
+	sort.Slice(timers, func(i, j int) bool { return timers[i].TimerId < timers[j].TimerId })
+	return timers, nil
+}
+
+// claimedBy matches a timer that is still in the state it was claimed in.
+func claimedBy(timer Timer) goqu.Ex {
+	return goqu.Ex{"timer_id": timer.TimerId, "status": StatusScheduled, "fire_at": timer.FireAt}
+}
+
+func (s *PostgresStore) MarkFired(ctx context.Context, timer Timer) error {
+	// Compile query
+	ds := pg.Dialect.Update(TimersTable).Prepared(true).
+		Set(goqu.Record{
+			"status":     StatusFired,
+			"attempts":   goqu.L("attempts + 1"),
+			"updated_at": goqu.L("NOW()"),
+		}).
+		Where(claimedBy(timer))
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) MarkFailed(ctx context.Context, args MarkFailedArgs) error {
+	record := goqu.Record{
+		"status":     StatusDead,
+		"attempts":   args.Attempts,
+		"last_error": args.Error,
+		"updated_at": goqu.L("NOW()"),
+	}
+	if args.RetryAt != nil {
+		record["status"] = StatusScheduled
+		record["fire_at"] = *args.RetryAt
+	}
+
+	// Compile query
+	ds := pg.Dialect.Update(TimersTable).Prepared(true).
+		Set(record).
+		Where(claimedBy(args.Timer))
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return pg.ErrorDsl(err)
+	}
+
+	_, err = s.db.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return pg.ErrorDb(err)
+	}
+
+	return nil
+}
+
+/** In-memory Store */
+
+type InMemoryStore struct {
+	Timers map[string]*Timer
+	nextId int64
+	mu     sync.Mutex
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{Timers: make(map[string]*Timer)}
+}
+
+func (s *InMemoryStore) Schedule(ctx context.Context, tx pg.Tx, args ScheduleArgs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	if timer, ok := s.Timers[args.Key]; ok {
+		timer.FireAt = args.FireAt
+		timer.Payload = args.Payload
+		timer.Status = StatusScheduled
+		timer.Attempts = 0
+		timer.LastError = sql.NullString{}
+		timer.UpdatedAt = now
+		return nil
+	}
+
+	s.nextId += 1
+	s.Timers[args.Key] = &Timer{
+		TimerId:       s.nextId,
+		Key:           args.Key,
+		TimerType:     args.TimerType,
+		AggregateID:   args.AggregateID,
+		AggregateType: args.AggregateType,
+		Payload:       args.Payload,
+		FireAt:        args.FireAt,
+		Status:        StatusScheduled,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) Cancel(ctx context.Context, tx pg.Tx, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.Timers[key]; ok && timer.Status == StatusScheduled {
+		timer.Status = StatusCancelled
+		timer.UpdatedAt = time.Now().UTC()
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) ClaimDue(ctx context.Context, now time.Time, limit uint, lease time.Duration) ([]Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []*Timer{}
+	for _, timer := range s.Timers {
+		if timer.Status == StatusScheduled && !timer.FireAt.After(now) {
+			due = append(due, timer)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].FireAt.Before(due[j].FireAt) })
+	if uint(len(due)) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]Timer, 0, len(due))
+	for _, timer := range due {
+		timer.FireAt = now.Add(lease)
+		timer.UpdatedAt = time.Now().UTC()
+		claimed = append(claimed, *timer)
+	}
+
+	return claimed, nil
+}
+
+// claimed returns the stored timer if it is still in the state it was claimed in.
+func (s *InMemoryStore) claimed(claim Timer) (*Timer, bool) {
+	for _, timer := range s.Timers {
+		if timer.TimerId == claim.TimerId && timer.Status == StatusScheduled && timer.FireAt.Equal(claim.FireAt) {
+			return timer, true
+		}
+	}
+	return nil, false
+}
+
+func (s *InMemoryStore) MarkFired(ctx context.Context, claim Timer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.claimed(claim); ok {
+		timer.Status = StatusFired
+		timer.Attempts += 1
+		timer.UpdatedAt = time.Now().UTC()
+	}
+
+	return nil
+}
+
+func (s *InMemoryStore) MarkFailed(ctx context.Context, args MarkFailedArgs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timer, ok := s.claimed(args.Timer)
+	if !ok {
+		return nil
+	}
+
+	timer.Attempts = args.Attempts
+	timer.LastError = sql.NullString{String: args.Error, Valid: true}
+	timer.UpdatedAt = time.Now().UTC()
+	if args.RetryAt != nil {
+		timer.Status = StatusScheduled
+		timer.FireAt = *args.RetryAt
+	} else {
+		timer.Status = StatusDead
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInit(t *testing.T) {
+	defer otel.SetTracerProvider(otel.GetTracerProvider())
+
+	t.Run("writes spans to a file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "traces.json")
+		shutdown, err := Init(context.Background(), Options{ServiceName: "test", Exporter: ExporterFile, File: file, SampleRatio: 1})
+		require.NoError(t, err)
+
+		_, span := Start(context.Background(), "test-span")
+		End(span, errors.New("failed"))
+		require.NoError(t, shutdown(context.Background()))
+
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"test-span"`)
+		assert.Contains(t, string(data), `"Description":"failed"`)
+	})
+
+	t.Run("rejects unknown exporters", func(t *testing.T) {
+		_, err := Init(context.Background(), Options{Exporter: "jaeger"})
+
+		assert.Error(t, err)
+	})
+}
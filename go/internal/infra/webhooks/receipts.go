@@ -0,0 +1,118 @@
+package webhooks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+)
+
+const ReceiptsTable = "webhook_receipt"
+
+type RecordReceiptArgs struct {
+	// Source identifies the sender of the webhook, e.g. "carrier:ups". Event ids are unique per source.
+	Source  string
+	EventId string
+	// SubjectId is the entity the webhook is about, e.g. an order id.
+	SubjectId string
+}
+
+// ReceiptStore records the webhook events that were processed.
+// Webhooks are delivered at least once, so a receipt keeps redelivered events from being applied twice.
+type ReceiptStore interface {
+	// Record stores a receipt for a webhook event. Returns false if the event was already recorded.
+	Record(ctx context.Context, tx pg.Tx, args RecordReceiptArgs) (bool, error)
+	// Seen returns whether a receipt was recorded for a webhook event.
+	Seen(ctx context.Context, source string, eventId string) (bool, error)
+}
+
+/** Postgres Store */
+
+type PostgresReceiptStore struct {
+	db *sqlx.DB
+}
+
+func NewPostgresReceiptStore(db *sqlx.DB) *PostgresReceiptStore {
+	return &PostgresReceiptStore{db: db}
+}
+
+func (s *PostgresReceiptStore) Record(ctx context.Context, tx pg.Tx, args RecordReceiptArgs) (bool, error) {
+	// Compile query
+	ds := pg.Dialect.Insert(ReceiptsTable).Prepared(true).
+		Rows(goqu.Record{
+			"source":     args.Source,
+			"event_id":   args.EventId,
+			"subject_id": args.SubjectId,
+		}).
+		OnConflict(goqu.DoNothing())
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return false, pg.ErrorDsl(err)
+	}
+
+	result, err := tx.ExecContext(ctx, query, queryArgs...)
+	if err != nil {
+		return false, pg.ErrorDb(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, pg.ErrorDb(err)
+	}
+
+	return rows > 0, nil
+}
+
+func (s *PostgresReceiptStore) Seen(ctx context.Context, source string, eventId string) (bool, error) {
+	// Compile query
+	ds := pg.Dialect.From(ReceiptsTable).Prepared(true).
+		Select(goqu.L("1")).
+		Where(goqu.Ex{"source": source, "event_id": eventId})
+
+	query, queryArgs, err := ds.ToSQL()
+	if err != nil {
+		return false, pg.ErrorDsl(err)
+	}
+
+	rows := []int{}
+	if err := s.db.SelectContext(ctx, &rows, query, queryArgs...); err != nil {
+		return false, pg.ErrorDb(err)
+	}
+
+	return len(rows) > 0, nil
+}
+
+/** In-Memory Store */
+
+type InMemoryReceiptStore struct {
+	mu       sync.Mutex
+	Receipts map[string]RecordReceiptArgs
+}
+
+func NewInMemoryReceiptStore() *InMemoryReceiptStore {
+	return &InMemoryReceiptStore{Receipts: make(map[string]RecordReceiptArgs)}
+}
+
+func (s *InMemoryReceiptStore) Record(ctx context.Context, tx pg.Tx, args RecordReceiptArgs) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := args.Source + ":" + args.EventId
+	if _, ok := s.Receipts[key]; ok {
+		return false, nil
+	}
+	s.Receipts[key] = args
+
+	return true, nil
+}
+
+func (s *InMemoryReceiptStore) Seen(ctx context.Context, source string, eventId string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.Receipts[source+":"+eventId]
+	return ok, nil
+}
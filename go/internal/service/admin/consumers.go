@@ -0,0 +1,113 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *AdminService) ListConsumerGroups(ctx context.Context, req *pb.ListConsumerGroupsRequest) (*pb.ListConsumerGroupsResponse, error) {
+	groups, err := s.groups.Describe(ctx, s.consumers)
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ListConsumerGroupsResponse](nil, fmt.Errorf("failed to describe consumer groups: %w", err))
+	}
+
+	paused, err := s.states.ListPaused(ctx)
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ListConsumerGroupsResponse](nil, fmt.Errorf("failed to list paused consumers: %w", err))
+	}
+
+	protoGroups := make([]*pb.ConsumerGroupDetails, len(groups))
+	for i, group := range groups {
+		protoGroups[i] = &pb.ConsumerGroupDetails{
+			Consumer:   group.Name,
+			Paused:     slices.Contains(paused, group.Name),
+			State:      group.State,
+			Members:    int32(group.Members),
+			Lag:        group.Lag,
+			Partitions: partitionsToProto(group.Partitions),
+		}
+	}
+
+	return &pb.ListConsumerGroupsResponse{ConsumerGroups: protoGroups}, nil
+}
+
+func (s *AdminService) PauseConsumer(ctx context.Context, req *pb.PauseConsumerRequest) (*pb.PauseConsumerResponse, error) {
+	if err := s.setPaused(ctx, req.Consumer, true); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.PauseConsumerResponse](nil, err)
+	}
+	return &pb.PauseConsumerResponse{}, nil
+}
+
+func (s *AdminService) ResumeConsumer(ctx context.Context, req *pb.ResumeConsumerRequest) (*pb.ResumeConsumerResponse, error) {
+	if err := s.setPaused(ctx, req.Consumer, false); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ResumeConsumerResponse](nil, err)
+	}
+	return &pb.ResumeConsumerResponse{}, nil
+}
+
+func (s *AdminService) setPaused(ctx context.Context, consumer string, paused bool) error {
+	if err := s.checkConsumer(consumer); err != nil {
+		return err
+	}
+
+	if err := s.states.SetPaused(ctx, consumer, paused); err != nil {
+		return fmt.Errorf("failed to update consumer state: %w", err)
+	}
+
+	logging.FromContext(ctx).Info("Updated consumer state", "consumer", consumer, "paused", paused)
+	return nil
+}
+
+// ResetConsumer moves the consumer group of a paused consumer, whose instances have left the group.
+func (s *AdminService) ResetConsumer(ctx context.Context, req *pb.ResetConsumerRequest) (*pb.ResetConsumerResponse, error) {
+	if err := s.checkConsumer(req.Consumer); err != nil {
+		return nil, err
+	}
+
+	paused, err := s.states.Paused(ctx, req.Consumer)
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ResetConsumerResponse](nil, fmt.Errorf("failed to read consumer state: %w", err))
+	}
+	if !paused {
+		return nil, ErrConsumerNotPaused
+	}
+
+	args := eventsrc.ResetArgs{Position: eventsrc.ResetEarliest}
+	switch position := req.Position.(type) {
+	case *pb.ResetConsumerRequest_Latest:
+		args.Position = eventsrc.ResetLatest
+	case *pb.ResetConsumerRequest_Time:
+		args.Position, args.Time = eventsrc.ResetTime, position.Time.AsTime()
+	}
+
+	positions, err := s.groups.Reset(ctx, req.Consumer, args)
+	if errors.Is(err, eventsrc.ErrConsumerGroupActive) {
+		return nil, ErrConsumerGroupBusy
+	}
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ResetConsumerResponse](nil, fmt.Errorf("failed to reset consumer group: %w", err))
+	}
+
+	logging.FromContext(ctx).Info("Reset consumer group", "consumer", req.Consumer, "position", args.Position, "time", args.Time)
+	return &pb.ResetConsumerResponse{Partitions: partitionsToProto(positions)}, nil
+}
+
+func partitionsToProto(positions []eventsrc.PartitionPosition) []*pb.ConsumerPartition {
+	protoPartitions := make([]*pb.ConsumerPartition, len(positions))
+	for i, position := range positions {
+		protoPartitions[i] = &pb.ConsumerPartition{
+			Partition:       int32(position.Partition),
+			CommittedOffset: position.CommittedOffset,
+			HighWaterMark:   position.HighWaterMark,
+			Lag:             position.Lag,
+		}
+	}
+	return protoPartitions
+}
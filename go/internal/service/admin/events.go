@@ -0,0 +1,68 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *AdminService) ListAggregateEvents(ctx context.Context, req *pb.ListAggregateEventsRequest) (*pb.ListAggregateEventsResponse, error) {
+	events, err := s.store.ListByAggregateID(ctx, req.AggregateId, req.AggregateType)
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ListAggregateEventsResponse](nil, fmt.Errorf("failed to list events: %w", err))
+	}
+
+	protoEvents := make([]*pb.StoredEvent, len(events))
+	for i, event := range events {
+		// Aggregate ids are stored along with their type
+		event.AggregateId = req.AggregateId
+		protoEvents[i] = storedEventToProto(event)
+	}
+
+	return &pb.ListAggregateEventsResponse{Events: protoEvents}, nil
+}
+
+// RepublishEvent publishes a stored event like the producer did, with the request id of the admin request.
+func (s *AdminService) RepublishEvent(ctx context.Context, req *pb.RepublishEventRequest) (*pb.RepublishEventResponse, error) {
+	events, err := s.store.ListEvents(ctx, eventsrc.ListEventsArgs{AfterEventId: int(req.EventId) - 1, Limit: 1})
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.RepublishEventResponse](nil, fmt.Errorf("failed to read event: %w", err))
+	}
+	if len(events) == 0 || events[0].EventId != int(req.EventId) {
+		return nil, ErrEventNotFound
+	}
+	event := events[0]
+
+	err = s.bus.Publish(ctx, &eventsrc.PublishArgs{
+		AggregateID:   event.AggregateId,
+		AggregateType: event.AggregateType,
+		EventType:     event.EventType,
+		Value:         event.Data,
+		RequestID:     logging.RequestIdFromContext(ctx),
+	})
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.RepublishEventResponse](nil, fmt.Errorf("failed to republish event: %w", err))
+	}
+
+	logging.FromContext(ctx).Info("Republished event", "eventId", event.EventId, "eventType", event.EventType, "aggregateId", event.AggregateId)
+	return &pb.RepublishEventResponse{Event: storedEventToProto(event)}, nil
+}
+
+func storedEventToProto(event eventsrc.Event) *pb.StoredEvent {
+	return &pb.StoredEvent{
+		EventId:        int64(event.EventId),
+		SequenceNumber: int64(event.SequenceNumber),
+		AggregateType:  event.AggregateType,
+		AggregateId:    event.AggregateId,
+		EventType:      event.EventType,
+		Data:           event.Data,
+		Actor:          event.Actor,
+		CreatedAt:      timestamppb.New(event.CreatedAt),
+	}
+}
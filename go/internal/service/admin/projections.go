@@ -0,0 +1,82 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// RebuildProjections rebuilds the projection of an order right away. The projections of every order are
+// rebuilt in the background, one at a time.
+func (s *AdminService) RebuildProjections(ctx context.Context, req *pb.RebuildProjectionsRequest) (*pb.RebuildProjectionsResponse, error) {
+	args := eventsrc.ReplayArgs{AggregateType: orders.AggregateTypeOrder, AggregateId: req.OrderId}
+
+	if req.OrderId != "" {
+		replayed, err := eventsrc.Replay(ctx, s.store, newIndexOnce(s.projectionIndexer), args)
+		if err != nil {
+			return grpcutils.WrapNonGrpcError[*pb.RebuildProjectionsResponse](nil, fmt.Errorf("failed to rebuild projection: %w", err))
+		}
+		if replayed == 0 {
+			return nil, ErrAggregateNotFound
+		}
+		return &pb.RebuildProjectionsResponse{InBackground: false}, nil
+	}
+
+	if !s.rebuilding.CompareAndSwap(false, true) {
+		return nil, ErrRebuildRunning
+	}
+
+	// Outlive the request but not the server, keeping the logger of the request
+	ctx = logging.WithLogger(s.lifecycle, logging.FromContext(ctx))
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		defer s.rebuilding.Store(false)
+
+		logging.FromContext(ctx).Info("Rebuilding every projection")
+		indexer := newIndexOnce(s.projectionIndexer)
+		replayed, err := eventsrc.Replay(ctx, s.store, indexer, args)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to rebuild projections", "error", err, "events", replayed, "indexed", indexer.count)
+			return
+		}
+		logging.FromContext(ctx).Info("Rebuilt every projection", "events", replayed, "indexed", indexer.count)
+	}()
+
+	return &pb.RebuildProjectionsResponse{InBackground: true}, nil
+}
+
+// indexOnce passes the first event of each run of events of an order to the projection indexer, which
+// rebuilds the projection from every event of the order. Only the last order is tracked, so that memory
+// does not grow with the number of orders. An order whose events are interleaved with those of another
+// is indexed again, which is harmless as indexing is idempotent.
+type indexOnce struct {
+	indexer     eventsrc.Consumer
+	lastIndexed string
+	count       int
+}
+
+func newIndexOnce(indexer eventsrc.Consumer) *indexOnce {
+	return &indexOnce{indexer: indexer}
+}
+
+func (c *indexOnce) Name() string {
+	return c.indexer.Name()
+}
+
+func (c *indexOnce) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	if args.AggregateID == c.lastIndexed {
+		return nil
+	}
+	if err := c.indexer.Consume(ctx, args); err != nil {
+		return err
+	}
+	c.lastIndexed = args.AggregateID
+	c.count++
+	return nil
+}
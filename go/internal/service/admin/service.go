@@ -0,0 +1,67 @@
+package admin
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrConsumerNotFound  = status.Errorf(codes.NotFound, "consumer not found")
+	ErrConsumerNotPaused = status.Errorf(codes.FailedPrecondition, "consumer must be paused first")
+	ErrConsumerGroupBusy = status.Errorf(codes.FailedPrecondition, "consumer group still has members, retry once the paused consumer left it")
+	ErrEventNotFound     = status.Errorf(codes.NotFound, "event not found")
+	ErrAggregateNotFound = status.Errorf(codes.NotFound, "aggregate not found")
+	ErrRebuildRunning    = status.Errorf(codes.FailedPrecondition, "projections of every order are already being rebuilt")
+)
+
+// AdminService serves the operations on the event store and the consumers. It has no policy, as the
+// admin server only lets admins in.
+type AdminService struct {
+	pb.UnimplementedAdminServiceServer
+
+	store             eventsrc.Store
+	bus               eventsrc.Bus
+	states            eventsrc.ConsumerStateStore
+	groups            *eventsrc.ConsumerGroups
+	consumers         []string
+	projectionIndexer eventsrc.Consumer
+
+	// lifecycle is done when the server stops, which stops the work started in the background.
+	lifecycle  context.Context
+	background sync.WaitGroup
+	rebuilding atomic.Bool
+}
+
+// NewAdminService creates the admin service of the consumers with the given names. Projections are
+// rebuilt by replaying the events of orders to the projection indexer, in the background until ctx is done.
+func NewAdminService(ctx context.Context, store eventsrc.Store, bus eventsrc.Bus, states eventsrc.ConsumerStateStore, groups *eventsrc.ConsumerGroups, consumers []string, projectionIndexer eventsrc.Consumer) *AdminService {
+	return &AdminService{
+		store:             store,
+		bus:               bus,
+		states:            states,
+		groups:            groups,
+		consumers:         consumers,
+		projectionIndexer: projectionIndexer,
+		lifecycle:         ctx,
+	}
+}
+
+// Wait waits for the work started in the background to stop, once the context of the service is done.
+func (s *AdminService) Wait() {
+	s.background.Wait()
+}
+
+func (s *AdminService) checkConsumer(consumer string) error {
+	if !slices.Contains(s.consumers, consumer) {
+		return ErrConsumerNotFound
+	}
+	return nil
+}
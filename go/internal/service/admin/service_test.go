@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/eventsrc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockBus struct {
+	mock.Mock
+}
+
+func (m *mockBus) Publish(ctx context.Context, args *eventsrc.PublishArgs) error {
+	return m.Called(ctx, args).Error(0)
+}
+
+type mockConsumer struct {
+	mock.Mock
+}
+
+func (m *mockConsumer) Name() string {
+	return "projection-indexer"
+}
+
+func (m *mockConsumer) Consume(ctx context.Context, args eventsrc.ConsumeArgs) error {
+	return m.Called(ctx, args).Error(0)
+}
+
+func newTestService(t *testing.T) (*AdminService, *mockBus, *mockConsumer) {
+	store := eventsrc.NewInMemoryStore()
+	for _, event := range []eventsrc.PersistEventArgs{
+		{SequenceNumber: 1, AggregateId: "order-1", AggregateType: orders.AggregateTypeOrder, EventType: "OrderPlaced", Data: []byte("1")},
+		{SequenceNumber: 1, AggregateId: "order-2", AggregateType: orders.AggregateTypeOrder, EventType: "OrderPlaced", Data: []byte("2")},
+		{SequenceNumber: 2, AggregateId: "order-1", AggregateType: orders.AggregateTypeOrder, EventType: "OrderPaid", Data: []byte("3")},
+	} {
+		_, err := store.Persist(context.Background(), nil, event)
+		require.NoError(t, err)
+	}
+
+	bus, indexer := &mockBus{}, &mockConsumer{}
+	states := eventsrc.NewInMemoryConsumerStateStore()
+	return NewAdminService(context.Background(), store, bus, states, nil, []string{"projection-indexer"}, indexer), bus, indexer
+}
+
+func TestRepublishEvent(t *testing.T) {
+	t.Run("publishes the stored event", func(t *testing.T) {
+		service, bus, _ := newTestService(t)
+		bus.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+		resp, err := service.RepublishEvent(context.Background(), &pb.RepublishEventRequest{EventId: 3})
+
+		require.NoError(t, err)
+		assert.Equal(t, "OrderPaid", resp.Event.EventType)
+		published := bus.Calls[0].Arguments.Get(1).(*eventsrc.PublishArgs)
+		assert.Equal(t, "order-1", published.AggregateID)
+		assert.Equal(t, []byte("3"), published.Value)
+	})
+
+	t.Run("fails for an unknown event", func(t *testing.T) {
+		service, bus, _ := newTestService(t)
+
+		_, err := service.RepublishEvent(context.Background(), &pb.RepublishEventRequest{EventId: 4})
+
+		assert.ErrorIs(t, err, ErrEventNotFound)
+		bus.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+	})
+}
+
+func TestRebuildProjections(t *testing.T) {
+	t.Run("indexes an order once", func(t *testing.T) {
+		service, _, indexer := newTestService(t)
+		indexer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+
+		resp, err := service.RebuildProjections(context.Background(), &pb.RebuildProjectionsRequest{OrderId: "order-1"})
+
+		require.NoError(t, err)
+		assert.False(t, resp.InBackground)
+		indexer.AssertNumberOfCalls(t, "Consume", 1)
+	})
+
+	t.Run("rebuilds every order in the background", func(t *testing.T) {
+		service, _, indexer := newTestService(t)
+		indexer.On("Consume", mock.Anything, mock.Anything).Return(nil)
+
+		resp, err := service.RebuildProjections(context.Background(), &pb.RebuildProjectionsRequest{})
+		require.NoError(t, err)
+		assert.True(t, resp.InBackground)
+		service.Wait()
+
+		// order-1 is indexed again after the event of order-2 it is interleaved with
+		indexer.AssertNumberOfCalls(t, "Consume", 3)
+		assert.False(t, service.rebuilding.Load())
+	})
+
+	t.Run("fails for an unknown order", func(t *testing.T) {
+		service, _, _ := newTestService(t)
+
+		_, err := service.RebuildProjections(context.Background(), &pb.RebuildProjectionsRequest{OrderId: "order-3"})
+
+		assert.ErrorIs(t, err, ErrAggregateNotFound)
+	})
+}
+
+func TestPauseConsumer(t *testing.T) {
+	service, _, _ := newTestService(t)
+
+	_, err := service.PauseConsumer(context.Background(), &pb.PauseConsumerRequest{Consumer: "projection-indexer"})
+	require.NoError(t, err)
+	paused, err := service.states.Paused(context.Background(), "projection-indexer")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	_, err = service.PauseConsumer(context.Background(), &pb.PauseConsumerRequest{Consumer: "unknown"})
+	assert.ErrorIs(t, err, ErrConsumerNotFound)
+}
@@ -0,0 +1,151 @@
+package carriers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	WebhookPath = "/webhooks/carriers"
+
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body.
+	SignatureHeader = "X-Carrier-Signature"
+
+	maxBodyBytes = 1 << 20
+
+	// Webhook outcomes
+	ResultProcessed = "processed"
+	ResultDuplicate = "duplicate"
+	ResultIgnored   = "ignored"
+)
+
+// ShippingStatusUpdater is the controller command the webhooks are translated into.
+type ShippingStatusUpdater interface {
+	UpdateShippingStatus(ctx context.Context, req *pb.UpdateOrderShippingStatusRequest) (*pb.UpdateOrderShippingStatusResponse, error)
+}
+
+type webhookResponse struct {
+	Result string `json:"result"`
+}
+
+// WebhookHandler accepts carrier tracking webhooks and updates the shipping status of the order.
+type WebhookHandler struct {
+	updater    ShippingStatusUpdater
+	receipts   webhooks.ReceiptStore
+	transactor pg.Transactor
+	secret     string
+}
+
+func NewWebhookHandler(updater ShippingStatusUpdater, receipts webhooks.ReceiptStore, transactor pg.Transactor, secret string) *WebhookHandler {
+	return &WebhookHandler{updater: updater, receipts: receipts, transactor: transactor, secret: secret}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !webhooks.VerifySignature(h.secret, body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event TrackingEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if event.EventId == "" || event.Carrier == "" || event.OrderId == "" {
+		http.Error(w, "event_id, carrier and order_id are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.handle(r.Context(), &event)
+	if err != nil {
+		logging.FromContext(r.Context()).Error("failed to handle carrier webhook", "carrier", event.Carrier, "eventId", event.EventId, "orderId", event.OrderId, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(webhookResponse{Result: result})
+}
+
+// handle applies the event, then records its receipt so that redeliveries are acknowledged as duplicates.
+// The update commits its own transaction, so the receipt is only recorded once the update succeeded: an
+// event whose update failed, or whose receipt was lost in a crash, is applied again when redelivered.
+// Applying an event twice at most repeats its shipping status, which cannot move backwards.
+func (h *WebhookHandler) handle(ctx context.Context, event *TrackingEvent) (string, error) {
+	receipt := webhooks.RecordReceiptArgs{
+		Source:    "carrier:" + event.Carrier,
+		EventId:   event.EventId,
+		SubjectId: event.OrderId,
+	}
+
+	seen, err := h.receipts.Seen(ctx, receipt.Source, receipt.EventId)
+	if err != nil {
+		return "", fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	result := ResultDuplicate
+	if !seen {
+		result, err = h.apply(ctx, event)
+		if err != nil {
+			return "", err
+		}
+
+		err = h.transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+			_, err := h.receipts.Record(ctx, tx, receipt)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to record receipt: %w", err)
+		}
+	}
+
+	logging.FromContext(ctx).Info("Handled carrier webhook", "carrier", event.Carrier, "eventId", event.EventId, "orderId", event.OrderId, "status", event.Status, "result", result)
+
+	return result, nil
+}
+
+func (h *WebhookHandler) apply(ctx context.Context, event *TrackingEvent) (string, error) {
+	shippingStatus := MapCarrierStatus(event.Status)
+	if shippingStatus == pb.ShippingStatus_SHIPPING_STATUS_UNSPECIFIED {
+		return ResultIgnored, nil
+	}
+
+	_, err := h.updater.UpdateShippingStatus(ctx, &pb.UpdateOrderShippingStatusRequest{
+		OrderId: event.OrderId,
+		Status:  shippingStatus,
+	})
+
+	// Events the order cannot accept will not succeed on redelivery either
+	code := status.Code(err)
+	if code == codes.NotFound || code == codes.FailedPrecondition {
+		logging.FromContext(ctx).Warn("Ignoring carrier webhook", "carrier", event.Carrier, "eventId", event.EventId, "orderId", event.OrderId, "reason", status.Convert(err).Message())
+		return ResultIgnored, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to update shipping status: %w", err)
+	}
+
+	return ResultProcessed, nil
+}
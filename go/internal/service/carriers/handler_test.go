@@ -0,0 +1,129 @@
+package carriers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testSecret = "test-secret"
+
+type fakeUpdater struct {
+	requests []*pb.UpdateOrderShippingStatusRequest
+	err      error
+}
+
+func (u *fakeUpdater) UpdateShippingStatus(ctx context.Context, req *pb.UpdateOrderShippingStatusRequest) (*pb.UpdateOrderShippingStatusResponse, error) {
+	if u.err != nil {
+		return nil, u.err
+	}
+	u.requests = append(u.requests, req)
+	return &pb.UpdateOrderShippingStatusResponse{OrderId: req.OrderId}, nil
+}
+
+func newTestHandler(updater ShippingStatusUpdater) (*WebhookHandler, *webhooks.InMemoryReceiptStore) {
+	receipts := webhooks.NewInMemoryReceiptStore()
+	return NewWebhookHandler(updater, receipts, &pg.TestTransactor{}, testSecret), receipts
+}
+
+func post(handler http.Handler, body string, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(body))
+	req.Header.Set(SignatureHeader, signature)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func result(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var resp webhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Result
+}
+
+const deliveredBody = `{"event_id":"evt-1","carrier":"ups","order_id":"order-123","tracking_number":"1Z999","status":"DELIVERED","occurred_at":"2024-01-15T10:30:00Z"}`
+
+func TestWebhookHandler(t *testing.T) {
+	t.Run("signed event updates the shipping status", func(t *testing.T) {
+		updater := &fakeUpdater{}
+		handler, _ := newTestHandler(updater)
+
+		rec := post(handler, deliveredBody, webhooks.Sign(testSecret, []byte(deliveredBody)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ResultProcessed, result(t, rec))
+		require.Len(t, updater.requests, 1)
+		assert.Equal(t, "order-123", updater.requests[0].OrderId)
+		assert.Equal(t, pb.ShippingStatus_SHIPPING_STATUS_DELIVERED, updater.requests[0].Status)
+	})
+
+	t.Run("invalid signature is rejected", func(t *testing.T) {
+		updater := &fakeUpdater{}
+		handler, _ := newTestHandler(updater)
+
+		rec := post(handler, deliveredBody, webhooks.Sign("other-secret", []byte(deliveredBody)))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, updater.requests)
+	})
+
+	t.Run("redelivered event is applied once", func(t *testing.T) {
+		updater := &fakeUpdater{}
+		handler, _ := newTestHandler(updater)
+		signature := webhooks.Sign(testSecret, []byte(deliveredBody))
+
+		post(handler, deliveredBody, signature)
+		rec := post(handler, deliveredBody, signature)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ResultDuplicate, result(t, rec))
+		assert.Len(t, updater.requests, 1)
+	})
+
+	t.Run("unmapped status is ignored", func(t *testing.T) {
+		updater := &fakeUpdater{}
+		handler, _ := newTestHandler(updater)
+		body := `{"event_id":"evt-2","carrier":"ups","order_id":"order-123","status":"label_created"}`
+
+		rec := post(handler, body, webhooks.Sign(testSecret, []byte(body)))
+
+		assert.Equal(t, ResultIgnored, result(t, rec))
+		assert.Empty(t, updater.requests)
+	})
+
+	t.Run("rejected command is ignored", func(t *testing.T) {
+		handler, receipts := newTestHandler(&fakeUpdater{err: status.Errorf(codes.FailedPrecondition, "cannot set shipping status to a lower status")})
+
+		rec := post(handler, deliveredBody, webhooks.Sign(testSecret, []byte(deliveredBody)))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ResultIgnored, result(t, rec))
+		assert.Len(t, receipts.Receipts, 1)
+	})
+
+	t.Run("unexpected error is returned to the carrier without a receipt", func(t *testing.T) {
+		handler, receipts := newTestHandler(&fakeUpdater{err: errors.New("database error")})
+
+		rec := post(handler, deliveredBody, webhooks.Sign(testSecret, []byte(deliveredBody)))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.Empty(t, receipts.Receipts)
+	})
+}
+
+func TestMapCarrierStatus(t *testing.T) {
+	assert.Equal(t, pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT, MapCarrierStatus("out_for_delivery"))
+	assert.Equal(t, pb.ShippingStatus_SHIPPING_STATUS_DELIVERED, MapCarrierStatus("Delivered"))
+	assert.Equal(t, pb.ShippingStatus_SHIPPING_STATUS_UNSPECIFIED, MapCarrierStatus("exception"))
+}
@@ -0,0 +1,31 @@
+package carriers
+
+import (
+	"strings"
+	"time"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+)
+
+// TrackingEvent is the payload of a carrier tracking webhook.
+type TrackingEvent struct {
+	EventId        string    `json:"event_id"`
+	Carrier        string    `json:"carrier"`
+	OrderId        string    `json:"order_id"`
+	TrackingNumber string    `json:"tracking_number"`
+	Status         string    `json:"status"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+// MapCarrierStatus maps a carrier tracking status to a shipping status.
+// Statuses that do not move the order forward, e.g. label_created or exception, map to unspecified.
+func MapCarrierStatus(status string) pb.ShippingStatus {
+	switch strings.ToLower(status) {
+	case "picked_up", "in_transit", "arrived_at_facility", "departed_facility", "out_for_delivery":
+		return pb.ShippingStatus_SHIPPING_STATUS_IN_TRANSIT
+	case "delivered":
+		return pb.ShippingStatus_SHIPPING_STATUS_DELIVERED
+	}
+
+	return pb.ShippingStatus_SHIPPING_STATUS_UNSPECIFIED
+}
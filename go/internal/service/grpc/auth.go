@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var ErrUnauthenticated = status.Errorf(codes.Unauthenticated, "missing or invalid bearer token")
+
+// Policy maps the full method names of RPCs to the roles allowed to call them, besides admins.
+// Admins can call every RPC, and RPCs missing from the policy are reserved to them.
+type Policy map[string][]string
+
+// AuthInterceptor authenticates requests with the bearer token of their authorization metadata,
+// checks the role of the principal against the policies and puts it in the request context. Health
+// checks are not authenticated.
+func AuthInterceptor(verifier *auth.Verifier, policies ...Policy) grpc.UnaryServerInterceptor {
+	allowed := Policy{}
+	for _, policy := range policies {
+		for method, roles := range policy {
+			allowed[method] = append(allowed[method], roles...)
+		}
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, HealthMethodPrefix) {
+			return handler(ctx, req)
+		}
+
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return nil, ErrUnauthenticated
+		}
+
+		principal, err := verifier.Verify(token, time.Now())
+		if err != nil {
+			logging.FromContext(ctx).Info("Rejected token", "error", err)
+			return nil, ErrUnauthenticated
+		}
+
+		if principal.Role != auth.RoleAdmin && !slices.Contains(allowed[info.FullMethod], principal.Role) {
+			return nil, auth.ErrPermissionDenied
+		}
+
+		ctx = auth.WithPrincipal(ctx, principal)
+		ctx = logging.With(ctx, "actor", principal.Actor())
+		return handler(ctx, req)
+	}
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	for _, value := range md.Get("authorization") {
+		scheme, token, found := strings.Cut(value, " ")
+		if found && strings.EqualFold(scheme, "bearer") && token != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
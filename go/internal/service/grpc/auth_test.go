@@ -0,0 +1,81 @@
+package grpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testSecret = []byte("secret")
+
+func testToken(role string, vendorId string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256"}`))
+	claims := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(
+		`{"sub":"user-1","role":%q,"vendor_id":%q,"exp":%d}`, role, vendorId, time.Now().Add(time.Hour).Unix(),
+	)))
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(header + "." + claims))
+	return header + "." + claims + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	interceptor := AuthInterceptor(auth.NewHMACVerifier(testSecret, auth.VerifierOptions{}), Policy{
+		"/test.Service/Customer": {auth.RoleCustomer},
+	})
+
+	call := func(method string, authorization string) (*auth.Principal, error) {
+		ctx := context.Background()
+		if authorization != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", authorization))
+		}
+
+		var principal *auth.Principal
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			principal, _ = auth.PrincipalFromContext(ctx)
+			return nil, nil
+		})
+		return principal, err
+	}
+
+	t.Run("puts the principal of an allowed role in the context", func(t *testing.T) {
+		principal, err := call("/test.Service/Customer", "Bearer "+testToken(auth.RoleCustomer, ""))
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user-1", principal.Subject)
+	})
+
+	t.Run("allows admins on methods missing from the policies", func(t *testing.T) {
+		_, err := call("/test.Service/Other", "Bearer "+testToken(auth.RoleAdmin, ""))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("denies roles missing from the policy of a method", func(t *testing.T) {
+		_, err := call("/test.Service/Customer", "Bearer "+testToken(auth.RoleVendor, "vendor-1"))
+
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("rejects requests without a token", func(t *testing.T) {
+		_, err := call("/test.Service/Customer", "")
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("rejects invalid tokens", func(t *testing.T) {
+		_, err := call("/test.Service/Customer", "Bearer invalid")
+
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
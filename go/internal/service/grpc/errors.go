@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func ErrInternal() error {
+	return status.Errorf(codes.Internal, "Internal error")
+}
+
+// internalError is sent to clients as ErrInternal, and keeps the error it hides for LoggerInterceptor.
+type internalError struct {
+	cause error
+}
+
+func (e *internalError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *internalError) Unwrap() error {
+	return e.cause
+}
+
+func (e *internalError) GRPCStatus() *status.Status {
+	return status.Convert(ErrInternal())
+}
+
+// Checks if the error is a non-grpc error.
+// Returns an internal server error if the error is not a non-grpc error. The unexpected error is
+// logged with the request by LoggerInterceptor.
+func WrapNonGrpcError[T any](payload T, err error) (T, error) {
+	if err == nil {
+		return payload, nil
+	}
+
+	if status.Code(err) == codes.Unknown {
+		return payload, &internalError{cause: err}
+	}
+
+	return payload, err
+}
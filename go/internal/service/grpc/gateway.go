@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+)
+
+// GatewayErrorHandler writes errors like the default handler of the gateway, which maps
+// ResourceExhausted to 429, and adds a Retry-After header to rate limited responses.
+func GatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, marshaler runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	if retryAfter, ok := RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	}
+
+	runtime.DefaultHTTPErrorHandler(ctx, mux, marshaler, w, r, err)
+}
+
+// RequestIdMiddleware gives every HTTP request an id, taken from its X-Request-Id header or generated,
+// and sends it back in the X-Request-Id header of the response. The gateway forwards it to the gRPC
+// server, see GatewayIncomingHeaderMatcher.
+func RequestIdMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(RequestIdHeader)
+		if !ValidRequestId(requestId) {
+			requestId = NewRequestId()
+			r.Header.Set(RequestIdHeader, requestId)
+		}
+		w.Header().Set(RequestIdHeader, requestId)
+
+		ctx := logging.WithRequestId(r.Context(), requestId)
+		ctx = logging.With(ctx, "http.method", r.Method, "http.path", r.URL.Path)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GatewayIncomingHeaderMatcher forwards the X-Request-Id header to the gRPC server, besides the
+// headers forwarded by default.
+func GatewayIncomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, RequestIdHeader) {
+		return RequestIdMetadataKey, true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// GatewayOutgoingHeaderMatcher drops the request id sent back by the gRPC server, as
+// RequestIdMiddleware already sets the X-Request-Id header.
+func GatewayOutgoingHeaderMatcher(key string) (string, bool) {
+	if key == RequestIdMetadataKey {
+		return "", false
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
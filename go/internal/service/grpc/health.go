@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"github.com/cgund98/go-eventsrc-example/internal/infra/health"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthMethodPrefix prefixes the methods of the grpc.health.v1 service, which are open to unauthenticated probes.
+const HealthMethodPrefix = "/grpc.health.v1.Health/"
+
+// NewHealthServer creates a grpc.health.v1 server reporting the status of the last round of the checker,
+// for the server as a whole (the empty service name) and for every service.
+func NewHealthServer(checker *health.Checker, services ...string) *grpchealth.Server {
+	server := grpchealth.NewServer()
+
+	update := func(report health.Report) {
+		status := healthpb.HealthCheckResponse_NOT_SERVING
+		if report.Ready() {
+			status = healthpb.HealthCheckResponse_SERVING
+		}
+		server.SetServingStatus("", status)
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+	}
+	update(checker.Report())
+	checker.Watch(update)
+
+	return server
+}
@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthServer(t *testing.T) {
+	healthy := false
+	checker := health.NewChecker(health.CheckerOptions{})
+	checker.Add("postgres", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	server := NewHealthServer(checker, "events.v1.OrderService")
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check(""))
+
+	healthy = true
+	checker.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check(""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, check("events.v1.OrderService"))
+
+	healthy = false
+	checker.CheckNow(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, check("events.v1.OrderService"))
+}
+
+func TestAuthInterceptor_HealthChecks(t *testing.T) {
+	interceptor := AuthInterceptor(auth.NewHMACVerifier([]byte("secret"), auth.VerifierOptions{}))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: healthpb.Health_Check_FullMethodName}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+
+	assert.NoError(t, err)
+}
@@ -2,14 +2,97 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
-func LoggerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	logging.Logger.Info("Received request", "grpc.method", info.FullMethod, "request.time", time.Now().Format(time.RFC3339))
+const (
+	RequestIdMetadataKey = "x-request-id"
+	RequestIdHeader      = "X-Request-Id"
+
+	maxRequestIdLength = 128
+)
+
+// RequestIdInterceptor gives every request an id, taken from its x-request-id metadata or generated,
+// and a logger that adds it and the method to every log line. The id is sent back in the response
+// headers. It must run first, so that the other interceptors log with the request.
+func RequestIdInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	requestId := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIdMetadataKey); len(values) > 0 {
+			requestId = values[0]
+		}
+	}
+	if !ValidRequestId(requestId) {
+		requestId = NewRequestId()
+	}
+
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIdMetadataKey, requestId)); err != nil {
+		logging.Logger.Debug("failed to set request id header", "error", err)
+	}
+
+	ctx = logging.WithRequestId(ctx, requestId)
+	ctx = logging.With(ctx, "grpc.method", info.FullMethod)
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		ctx = logging.With(ctx, "traceId", span.TraceID().String())
+	}
 
 	return handler(ctx, req)
 }
+
+// LoggerInterceptor logs every request once it is handled, with its status code and duration.
+// Unexpected errors hidden from the client behind an internal error are logged here.
+func LoggerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	attrs := []any{"grpc.code", code.String(), "duration", time.Since(start)}
+	level := slog.LevelInfo
+
+	var internal *internalError
+	switch {
+	case errors.As(err, &internal):
+		level = slog.LevelError
+		attrs = append(attrs, "error", internal.cause)
+	case code == codes.Internal || code == codes.Unknown || code == codes.DataLoss:
+		level = slog.LevelError
+		attrs = append(attrs, "error", err)
+	case err != nil:
+		attrs = append(attrs, "error", status.Convert(err).Message())
+	case strings.HasPrefix(info.FullMethod, HealthMethodPrefix):
+		// Probes check the health every few seconds
+		level = slog.LevelDebug
+	}
+
+	logging.FromContext(ctx).Log(ctx, level, "Handled request", attrs...)
+
+	return resp, err
+}
+
+// ValidRequestId reports whether a request id received from a client can be used as is.
+func ValidRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		return false
+	}
+	for _, r := range requestId {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func NewRequestId() string {
+	return uuid.NewString()
+}
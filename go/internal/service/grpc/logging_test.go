@@ -0,0 +1,80 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequestIdInterceptor(t *testing.T) {
+	call := func(ctx context.Context) string {
+		var requestId string
+		_, _ = RequestIdInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			requestId = logging.RequestIdFromContext(ctx)
+			return nil, nil
+		})
+		return requestId
+	}
+
+	t.Run("propagates the request id of the client", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIdMetadataKey, "request-1"))
+
+		assert.Equal(t, "request-1", call(ctx))
+	})
+
+	t.Run("generates a request id", func(t *testing.T) {
+		assert.NotEmpty(t, call(context.Background()))
+	})
+
+	t.Run("replaces an invalid request id", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIdMetadataKey, "request 1"))
+
+		requestId := call(ctx)
+
+		assert.NotEqual(t, "request 1", requestId)
+		assert.True(t, ValidRequestId(requestId))
+	})
+}
+
+func TestLoggerInterceptor(t *testing.T) {
+	call := func(handlerErr error) (string, error) {
+		var buf bytes.Buffer
+		ctx := logging.WithLogger(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+		ctx = logging.WithRequestId(ctx, "request-1")
+
+		_, err := LoggerInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, handlerErr
+		})
+		return buf.String(), err
+	}
+
+	t.Run("logs the status code of a handled request", func(t *testing.T) {
+		line, err := call(status.Error(codes.NotFound, "order not found"))
+
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.Contains(t, line, "level=INFO")
+		assert.Contains(t, line, "requestId=request-1")
+		assert.Contains(t, line, "grpc.code=NotFound")
+		assert.Contains(t, line, "duration=")
+	})
+
+	t.Run("logs the unexpected error hidden from the client", func(t *testing.T) {
+		_, err := WrapNonGrpcError[any](nil, errors.New("connection refused"))
+
+		line, err := call(err)
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "Internal error", status.Convert(err).Message())
+		assert.Contains(t, line, "level=ERROR")
+		assert.Contains(t, line, "error=\"connection refused\"")
+	})
+}
@@ -0,0 +1,39 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcRequests = metrics.Factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "rpc_requests_total",
+		Help:      "RPCs handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	rpcDuration = metrics.Factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "Time taken to handle RPCs, by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// MetricsInterceptor counts the requests and records their latency by method and status code,
+// including the requests rejected by the interceptors that follow it.
+func MetricsInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err).String()
+	rpcRequests.WithLabelValues(info.FullMethod, code).Inc()
+	rpcDuration.WithLabelValues(info.FullMethod, code).Observe(metrics.Since(start))
+
+	return resp, err
+}
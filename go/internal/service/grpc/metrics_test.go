@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestMetricsInterceptor(t *testing.T) {
+	method := "/events.v1.OrderService/GetOrder"
+	ok := rpcRequests.WithLabelValues(method, "OK")
+	denied := rpcRequests.WithLabelValues(method, "PermissionDenied")
+	okBefore, deniedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(denied)
+
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	_, err := MetricsInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	_, err = MetricsInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, auth.ErrPermissionDenied
+	})
+	assert.ErrorIs(t, err, auth.ErrPermissionDenied)
+
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(ok))
+	assert.Equal(t, deniedBefore+1, testutil.ToFloat64(denied))
+}
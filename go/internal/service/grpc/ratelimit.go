@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// RateLimits are the limits of every client on the RPCs, by method name (e.g. PlaceOrder).
+type RateLimits struct {
+	Default ratelimit.Limit
+	Methods map[string]ratelimit.Limit
+}
+
+func (l RateLimits) limit(fullMethod string) ratelimit.Limit {
+	if limit, ok := l.Methods[path.Base(fullMethod)]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// RateLimitInterceptor limits the rate at which every client calls every RPC. Clients are keyed by
+// principal, or by IP address when the request is not authenticated, so it must run after
+// AuthInterceptor. Rejected requests fail with ResourceExhausted and a RetryInfo detail.
+func RateLimitInterceptor(limiter *ratelimit.Limiter, limits RateLimits) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := clientKey(ctx) + info.FullMethod
+		allowed, retryAfter := limiter.Allow(key, limits.limit(info.FullMethod), time.Now())
+		if !allowed {
+			return nil, errRateLimited(retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func errRateLimited(retryAfter time.Duration) error {
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// RetryAfter returns the retry delay of a rate limited request's error, if it has one.
+func RetryAfter(err error) (time.Duration, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.ResourceExhausted {
+		return 0, false
+	}
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.RetryDelay.AsDuration(), true
+		}
+	}
+	return 0, false
+}
+
+func clientKey(ctx context.Context) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.Actor()
+	}
+	return "ip:" + clientIP(ctx)
+}
+
+// clientIP returns the IP address of the client of a request. Requests proxied by the gateway come
+// from a loopback address, so the address it forwards in x-forwarded-for is used instead.
+func clientIP(ctx context.Context) string {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	if parsed := net.ParseIP(ip); parsed == nil || !parsed.IsLoopback() {
+		return ip
+	}
+
+	// The gateway appends the address of its client to x-forwarded-for, so only the last one is trusted
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(addrs[len(addrs)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	return ip
+}
@@ -0,0 +1,90 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/ratelimit"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext(ip string) context.Context {
+	return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 50000}})
+}
+
+func TestRateLimitInterceptor(t *testing.T) {
+	interceptor := RateLimitInterceptor(ratelimit.NewLimiter(), RateLimits{
+		Default: ratelimit.Limit{Rate: 100, Burst: 100},
+		Methods: map[string]ratelimit.Limit{"PlaceOrder": {Rate: 1, Burst: 1}},
+	})
+
+	call := func(ctx context.Context, method string) error {
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return err
+	}
+	placeOrder := "/events.v1.OrderService/PlaceOrder"
+
+	t.Run("rejects requests beyond the limit of a method with a retry delay", func(t *testing.T) {
+		ctx := peerContext("10.0.0.1")
+
+		assert.NoError(t, call(ctx, placeOrder))
+		err := call(ctx, placeOrder)
+
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		retryAfter, ok := RetryAfter(err)
+		assert.True(t, ok)
+		assert.Greater(t, retryAfter, time.Duration(0))
+		assert.LessOrEqual(t, retryAfter, time.Second)
+	})
+
+	t.Run("uses the default limit of other methods", func(t *testing.T) {
+		ctx := peerContext("10.0.0.2")
+
+		assert.NoError(t, call(ctx, placeOrder))
+		assert.NoError(t, call(ctx, "/events.v1.OrderService/GetOrder"))
+	})
+
+	t.Run("limits principals on their own, wherever they call from", func(t *testing.T) {
+		customer := auth.WithPrincipal(peerContext("10.0.0.3"), &auth.Principal{Subject: "customer-1", Role: auth.RoleCustomer})
+		other := auth.WithPrincipal(peerContext("10.0.0.3"), &auth.Principal{Subject: "customer-2", Role: auth.RoleCustomer})
+		elsewhere := auth.WithPrincipal(peerContext("10.0.0.4"), &auth.Principal{Subject: "customer-1", Role: auth.RoleCustomer})
+
+		assert.NoError(t, call(customer, placeOrder))
+		assert.NoError(t, call(other, placeOrder))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(elsewhere, placeOrder)))
+	})
+
+	t.Run("limits the clients of the gateway by their forwarded address", func(t *testing.T) {
+		forwardedFor := func(addrs string) context.Context {
+			return metadata.NewIncomingContext(peerContext("127.0.0.1"), metadata.Pairs("x-forwarded-for", addrs))
+		}
+
+		assert.NoError(t, call(forwardedFor("10.0.0.5"), placeOrder))
+		assert.NoError(t, call(forwardedFor("10.0.0.5, 10.0.0.6"), placeOrder))
+		assert.Equal(t, codes.ResourceExhausted, status.Code(call(forwardedFor("10.0.0.7, 10.0.0.6"), placeOrder)))
+	})
+}
+
+func TestGatewayErrorHandler(t *testing.T) {
+	mux := runtime.NewServeMux()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/orders", nil)
+
+	GatewayErrorHandler(context.Background(), mux, &runtime.JSONPb{}, rec, req, errRateLimited(1500*time.Millisecond))
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+}
@@ -0,0 +1,12 @@
+package inventory
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *InventoryService) ReceiveStock(ctx context.Context, req *pb.ReceiveStockRequest) (*pb.ReceiveStockResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.ReceiveStock(ctx, req))
+}
@@ -0,0 +1,23 @@
+package inventory
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	inventoryctrl "github.com/cgund98/go-eventsrc-example/internal/entity/inventory/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// Policy lets everyone read stock levels. Receiving stock is reserved to admins.
+var Policy = grpcutils.Policy{
+	pb.InventoryService_GetStockLevel_FullMethodName: {auth.RoleCustomer, auth.RoleVendor},
+}
+
+type InventoryService struct {
+	pb.UnimplementedInventoryServiceServer
+
+	controller *inventoryctrl.Controller
+}
+
+func NewInventoryService(controller *inventoryctrl.Controller) *InventoryService {
+	return &InventoryService{controller: controller}
+}
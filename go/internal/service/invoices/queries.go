@@ -0,0 +1,29 @@
+package invoices
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *InvoiceService) GetInvoice(ctx context.Context, req *pb.GetInvoiceRequest) (*pb.GetInvoiceResponse, error) {
+	resp, err := s.controller.GetInvoice(ctx, req)
+	if err != nil {
+		return grpcutils.WrapNonGrpcError(resp, err)
+	}
+	if err := auth.CheckOwner(ctx, resp.Invoice.CustomerId, resp.Invoice.VendorId); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (s *InvoiceService) ListInvoices(ctx context.Context, req *pb.ListInvoicesRequest) (*pb.ListInvoicesResponse, error) {
+	// Vendors must filter the invoices by their own vendor id
+	if err := auth.CheckOwner(ctx, "", req.VendorId); err != nil {
+		return nil, err
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.ListInvoices(ctx, req))
+}
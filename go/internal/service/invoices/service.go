@@ -0,0 +1,24 @@
+package invoices
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	invoicectrl "github.com/cgund98/go-eventsrc-example/internal/entity/invoices/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// Policy lets customers read their own invoices, and vendors read and list theirs.
+var Policy = grpcutils.Policy{
+	pb.InvoiceService_GetInvoice_FullMethodName:   {auth.RoleCustomer, auth.RoleVendor},
+	pb.InvoiceService_ListInvoices_FullMethodName: {auth.RoleVendor},
+}
+
+type InvoiceService struct {
+	pb.UnimplementedInvoiceServiceServer
+
+	controller *invoicectrl.Controller
+}
+
+func NewInvoiceService(controller *invoicectrl.Controller) *InvoiceService {
+	return &InvoiceService{controller: controller}
+}
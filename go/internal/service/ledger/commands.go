@@ -0,0 +1,12 @@
+package ledger
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *LedgerService) IssuePayouts(ctx context.Context, req *pb.IssuePayoutsRequest) (*pb.IssuePayoutsResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.IssuePayouts(ctx, req))
+}
@@ -0,0 +1,23 @@
+package ledger
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *LedgerService) GetVendorBalance(ctx context.Context, req *pb.GetVendorBalanceRequest) (*pb.GetVendorBalanceResponse, error) {
+	if err := auth.CheckOwner(ctx, "", req.VendorId); err != nil {
+		return nil, err
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.GetVendorBalance(ctx, req))
+}
+
+func (s *LedgerService) GetVendorStatement(ctx context.Context, req *pb.GetVendorStatementRequest) (*pb.GetVendorStatementResponse, error) {
+	if err := auth.CheckOwner(ctx, "", req.VendorId); err != nil {
+		return nil, err
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.GetVendorStatement(ctx, req))
+}
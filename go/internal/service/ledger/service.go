@@ -0,0 +1,24 @@
+package ledger
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	ledgerctrl "github.com/cgund98/go-eventsrc-example/internal/entity/ledger/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// Policy lets vendors read their own balances and statements. Payouts are issued by admins.
+var Policy = grpcutils.Policy{
+	pb.LedgerService_GetVendorBalance_FullMethodName:   {auth.RoleVendor},
+	pb.LedgerService_GetVendorStatement_FullMethodName: {auth.RoleVendor},
+}
+
+type LedgerService struct {
+	pb.UnimplementedLedgerServiceServer
+
+	controller *ledgerctrl.Controller
+}
+
+func NewLedgerService(controller *ledgerctrl.Controller) *LedgerService {
+	return &LedgerService{controller: controller}
+}
@@ -0,0 +1,45 @@
+package orders
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// Policy lets customers place and manage their orders, and vendors ship theirs and handle their
+// returns. Listing orders, sagas and held orders is reserved to admins.
+var Policy = grpcutils.Policy{
+	pb.OrderService_GetOrder_FullMethodName:                  {auth.RoleCustomer, auth.RoleVendor},
+	pb.OrderService_PlaceOrder_FullMethodName:                {auth.RoleCustomer},
+	pb.OrderService_CancelOrder_FullMethodName:               {auth.RoleCustomer},
+	pb.OrderService_AmendOrder_FullMethodName:                {auth.RoleCustomer},
+	pb.OrderService_ChangeOrderAddress_FullMethodName:        {auth.RoleCustomer},
+	pb.OrderService_RequestReturn_FullMethodName:             {auth.RoleCustomer},
+	pb.OrderService_UpdateOrderShippingStatus_FullMethodName: {auth.RoleVendor},
+	pb.OrderService_AttachShipment_FullMethodName:            {auth.RoleVendor},
+	pb.OrderService_UpdateShipmentTracking_FullMethodName:    {auth.RoleVendor},
+	pb.OrderService_ApproveReturn_FullMethodName:             {auth.RoleVendor},
+	pb.OrderService_RejectReturn_FullMethodName:              {auth.RoleVendor},
+	pb.OrderService_ReceiveReturn_FullMethodName:             {auth.RoleVendor},
+	pb.OrderService_RefundReturn_FullMethodName:              {auth.RoleVendor},
+}
+
+// authorizeOrder checks that the principal of the request owns an order, as its customer or its vendor.
+func (s *OrderService) authorizeOrder(ctx context.Context, orderId string) error {
+	if _, ok := auth.PrincipalFromContext(ctx); !ok {
+		return nil
+	}
+
+	proj, _, err := s.controller.GetProjection(ctx, orderId)
+	if err != nil {
+		return err
+	}
+	if proj == nil {
+		return controller.ErrOrderNotFound
+	}
+
+	return auth.CheckOwner(ctx, proj.CustomerId, proj.VendorId)
+}
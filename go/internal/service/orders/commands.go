@@ -4,16 +4,102 @@ import (
 	"context"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
 )
 
 func (s *OrderService) PlaceOrder(ctx context.Context, req *pb.PlaceOrderRequest) (*pb.PlaceOrderResponse, error) {
-	return WrapNonGrpcError(s.controller.PlaceOrder(ctx, req))
+	if err := auth.CheckOwner(ctx, req.CustomerId, ""); err != nil {
+		return nil, err
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.PlaceOrder(ctx, req))
 }
 
 func (s *OrderService) CancelOrder(ctx context.Context, req *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
-	return WrapNonGrpcError(s.controller.CancelOrder(ctx, req))
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.CancelOrderResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.CancelOrder(ctx, req))
+}
+
+func (s *OrderService) AmendOrder(ctx context.Context, req *pb.AmendOrderRequest) (*pb.AmendOrderResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.AmendOrderResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.AmendOrder(ctx, req))
+}
+
+func (s *OrderService) ReleaseHeldOrder(ctx context.Context, req *pb.ReleaseHeldOrderRequest) (*pb.ReleaseHeldOrderResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.ReleaseHeldOrder(ctx, req))
+}
+
+func (s *OrderService) RejectHeldOrder(ctx context.Context, req *pb.RejectHeldOrderRequest) (*pb.RejectHeldOrderResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.RejectHeldOrder(ctx, req))
+}
+
+func (s *OrderService) RedactOrderAddress(ctx context.Context, req *pb.RedactOrderAddressRequest) (*pb.RedactOrderAddressResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.RedactAddress(ctx, req))
+}
+
+func (s *OrderService) ChangeOrderAddress(ctx context.Context, req *pb.ChangeOrderAddressRequest) (*pb.ChangeOrderAddressResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ChangeOrderAddressResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.ChangeAddress(ctx, req))
 }
 
 func (s *OrderService) UpdateOrderShippingStatus(ctx context.Context, req *pb.UpdateOrderShippingStatusRequest) (*pb.UpdateOrderShippingStatusResponse, error) {
-	return WrapNonGrpcError(s.controller.UpdateShippingStatus(ctx, req))
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.UpdateOrderShippingStatusResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.UpdateShippingStatus(ctx, req))
+}
+
+func (s *OrderService) AttachShipment(ctx context.Context, req *pb.AttachShipmentRequest) (*pb.AttachShipmentResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.AttachShipmentResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.AttachShipment(ctx, req))
+}
+
+func (s *OrderService) UpdateShipmentTracking(ctx context.Context, req *pb.UpdateShipmentTrackingRequest) (*pb.UpdateShipmentTrackingResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.UpdateShipmentTrackingResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.UpdateShipmentTracking(ctx, req))
+}
+
+func (s *OrderService) RequestReturn(ctx context.Context, req *pb.RequestReturnRequest) (*pb.RequestReturnResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.RequestReturnResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.RequestReturn(ctx, req))
+}
+
+func (s *OrderService) ApproveReturn(ctx context.Context, req *pb.ApproveReturnRequest) (*pb.ApproveReturnResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ApproveReturnResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.ApproveReturn(ctx, req))
+}
+
+func (s *OrderService) RejectReturn(ctx context.Context, req *pb.RejectReturnRequest) (*pb.RejectReturnResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.RejectReturnResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.RejectReturn(ctx, req))
+}
+
+func (s *OrderService) ReceiveReturn(ctx context.Context, req *pb.ReceiveReturnRequest) (*pb.ReceiveReturnResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ReceiveReturnResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.ReceiveReturn(ctx, req))
+}
+
+func (s *OrderService) RefundReturn(ctx context.Context, req *pb.RefundReturnRequest) (*pb.RefundReturnResponse, error) {
+	if err := s.authorizeOrder(ctx, req.OrderId); err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.RefundReturnResponse](nil, err)
+	}
+	return grpcutils.WrapNonGrpcError(s.controller.RefundReturn(ctx, req))
 }
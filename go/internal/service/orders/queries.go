@@ -2,28 +2,49 @@ package orders
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
 	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
 	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pii"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
 )
 
 func (s *OrderService) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
-	return WrapNonGrpcError(s.controller.ListOrders(ctx, req))
+	return grpcutils.WrapNonGrpcError(s.controller.ListOrders(ctx, req))
+}
+
+func (s *OrderService) ListHeldOrders(ctx context.Context, req *pb.ListHeldOrdersRequest) (*pb.ListHeldOrdersResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.controller.ListHeldOrders(ctx, req))
 }
 
 func (s *OrderService) GetOrder(ctx context.Context, req *pb.GetOrderRequest) (*pb.GetOrderResponse, error) {
 	proj, _, err := s.controller.GetProjection(ctx, req.OrderId)
 	if err != nil {
-		logging.Logger.Error(fmt.Sprintf("failed to get order projection: %v", err))
+		logging.FromContext(ctx).Error(fmt.Sprintf("failed to get order projection: %v", err))
 		var errResp *pb.GetOrderResponse
-		return WrapNonGrpcError(errResp, err)
+		return grpcutils.WrapNonGrpcError(errResp, err)
 	}
 
 	if proj == nil {
 		return nil, controller.ErrOrderNotFound
 	}
+	if err := auth.CheckOwner(ctx, proj.CustomerId, proj.VendorId); err != nil {
+		return nil, err
+	}
+
+	details := proj.ToOrderDetails()
+	details.ShippingAddress, err = s.controller.ShippingAddress(ctx, proj)
+	if errors.Is(err, pii.ErrRedacted) {
+		details.ShippingAddressRedacted = true
+	} else if err != nil {
+		logging.FromContext(ctx).Error(fmt.Sprintf("failed to open order shipping address: %v", err))
+		var errResp *pb.GetOrderResponse
+		return grpcutils.WrapNonGrpcError(errResp, err)
+	}
 
-	return &pb.GetOrderResponse{Order: proj.ToOrderDetails()}, nil
+	return &pb.GetOrderResponse{Order: details}, nil
 }
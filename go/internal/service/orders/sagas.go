@@ -0,0 +1,49 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/saga"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultSagasLimit = 25
+
+func (s *OrderService) ListSagas(ctx context.Context, req *pb.ListSagasRequest) (*pb.ListSagasResponse, error) {
+	var limit uint = defaultSagasLimit
+	var offset uint = 0
+	if req.Limit != nil {
+		limit = uint(*req.Limit)
+	}
+	if req.Offset != nil {
+		offset = uint(*req.Offset)
+	}
+
+	states, err := s.sagas.List(ctx, saga.ListArgs{
+		SagaType:        req.GetSagaType(),
+		IncludeFinished: req.IncludeFinished,
+		Limit:           limit,
+		Offset:          offset,
+	})
+	if err != nil {
+		return grpcutils.WrapNonGrpcError[*pb.ListSagasResponse](nil, fmt.Errorf("failed to list sagas: %w", err))
+	}
+
+	protoSagas := make([]*pb.SagaDetails, len(states))
+	for i, state := range states {
+		protoSagas[i] = &pb.SagaDetails{
+			SagaType:      state.SagaType,
+			CorrelationId: state.CorrelationID,
+			Step:          state.Step,
+			Status:        state.Status,
+			CreatedAt:     timestamppb.New(state.CreatedAt),
+			UpdatedAt:     timestamppb.New(state.UpdatedAt),
+		}
+	}
+
+	return &pb.ListSagasResponse{Sagas: protoSagas}, nil
+}
@@ -3,14 +3,16 @@ package orders
 import (
 	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
 	orderctrl "github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/saga"
 )
 
 type OrderService struct {
 	pb.UnimplementedOrderServiceServer
 
 	controller *orderctrl.Controller
+	sagas      saga.Store
 }
 
-func NewOrderService(controller *orderctrl.Controller) *OrderService {
-	return &OrderService{controller: controller}
+func NewOrderService(controller *orderctrl.Controller, sagas saga.Store) *OrderService {
+	return &OrderService{controller: controller, sagas: sagas}
 }
@@ -0,0 +1,193 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/logging"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	WebhookPath = "/webhooks/payments"
+
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body.
+	SignatureHeader = "X-Payment-Signature"
+
+	receiptSource = "payment-provider"
+	maxBodyBytes  = 1 << 20
+
+	// Webhook outcomes
+	ResultProcessed = "processed"
+	ResultDuplicate = "duplicate"
+	ResultIgnored   = "ignored"
+)
+
+// PaymentNotifier is the set of controller commands the webhooks are translated into.
+type PaymentNotifier interface {
+	FindOrderByPaymentReference(ctx context.Context, paymentReference string) (string, error)
+	ConfirmPayment(ctx context.Context, args controller.PaymentNotificationArgs) error
+	FailPayment(ctx context.Context, args controller.PaymentNotificationArgs) error
+	RefundPayment(ctx context.Context, args controller.PaymentNotificationArgs) error
+}
+
+// retryError is returned for notifications that cannot be applied yet.
+// No receipt is recorded and the provider is asked to redeliver the notification.
+type retryError struct {
+	statusCode int
+	err        error
+}
+
+func (e *retryError) Error() string {
+	return e.err.Error()
+}
+
+type webhookResponse struct {
+	Result string `json:"result"`
+}
+
+// WebhookHandler accepts payment provider notifications and confirms, fails or refunds the payment of the order.
+type WebhookHandler struct {
+	notifier   PaymentNotifier
+	receipts   webhooks.ReceiptStore
+	transactor pg.Transactor
+	secret     string
+}
+
+func NewWebhookHandler(notifier PaymentNotifier, receipts webhooks.ReceiptStore, transactor pg.Transactor, secret string) *WebhookHandler {
+	return &WebhookHandler{notifier: notifier, receipts: receipts, transactor: transactor, secret: secret}
+}
+
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !webhooks.VerifySignature(h.secret, body, r.Header.Get(SignatureHeader)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if notification.EventId == "" || notification.PaymentReference == "" || notification.OccurredAt.IsZero() {
+		http.Error(w, "event_id, payment_reference and occurred_at are required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.handle(r.Context(), &notification)
+
+	var retryErr *retryError
+	if errors.As(err, &retryErr) {
+		logging.FromContext(r.Context()).Info("Payment notification cannot be applied yet", "eventId", notification.EventId, "paymentReference", notification.PaymentReference, "reason", retryErr.Error())
+		http.Error(w, retryErr.Error(), retryErr.statusCode)
+		return
+	} else if err != nil {
+		logging.FromContext(r.Context()).Error("failed to handle payment notification", "eventId", notification.EventId, "paymentReference", notification.PaymentReference, "error", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(webhookResponse{Result: result})
+}
+
+// handle applies the notification, then records its receipt so that redeliveries are acknowledged as
+// duplicates. The command commits its own transaction, so the receipt is only recorded once the command
+// succeeded: a notification that failed, or whose receipt was lost in a crash, is applied again when
+// redelivered. The commands are idempotent, so applying a notification twice is harmless.
+func (h *WebhookHandler) handle(ctx context.Context, notification *Notification) (string, error) {
+	receipt := webhooks.RecordReceiptArgs{
+		Source:    receiptSource,
+		EventId:   notification.EventId,
+		SubjectId: notification.PaymentReference,
+	}
+
+	seen, err := h.receipts.Seen(ctx, receipt.Source, receipt.EventId)
+	if err != nil {
+		return "", fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	result := ResultDuplicate
+	if !seen {
+		result, err = h.apply(ctx, notification)
+		if err != nil {
+			return "", err
+		}
+
+		err = h.transactor.WithTx(ctx, &sql.TxOptions{}, func(tx pg.Tx) error {
+			_, err := h.receipts.Record(ctx, tx, receipt)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to record receipt: %w", err)
+		}
+	}
+
+	logging.FromContext(ctx).Info("Handled payment notification", "eventId", notification.EventId, "type", notification.Type, "paymentReference", notification.PaymentReference, "result", result)
+
+	return result, nil
+}
+
+func (h *WebhookHandler) apply(ctx context.Context, notification *Notification) (string, error) {
+
+	// The payment reference is indexed asynchronously, so a notification may arrive before it can be resolved
+	orderId, err := h.notifier.FindOrderByPaymentReference(ctx, notification.PaymentReference)
+	if errors.Is(err, controller.ErrOrderNotFound) {
+		return "", &retryError{statusCode: http.StatusNotFound, err: fmt.Errorf("unknown payment reference")}
+	} else if err != nil {
+		return "", err
+	}
+
+	args := controller.PaymentNotificationArgs{
+		OrderId:    orderId,
+		Reason:     notification.Reason,
+		NotifiedAt: notification.OccurredAt,
+	}
+
+	switch notification.Type {
+	case NotificationPaymentSucceeded:
+		err = h.notifier.ConfirmPayment(ctx, args)
+	case NotificationPaymentFailed:
+		err = h.notifier.FailPayment(ctx, args)
+	case NotificationPaymentRefunded:
+		err = h.notifier.RefundPayment(ctx, args)
+	default:
+		return ResultIgnored, nil
+	}
+
+	// A refund can overtake the confirmation of the payment
+	if errors.Is(err, controller.ErrPaymentNotPaid) {
+		return "", &retryError{statusCode: http.StatusConflict, err: err}
+	}
+
+	// Notifications the order cannot accept will not succeed on redelivery either
+	if status.Code(err) == codes.FailedPrecondition || status.Code(err) == codes.NotFound {
+		logging.FromContext(ctx).Warn("Ignoring payment notification", "eventId", notification.EventId, "orderId", orderId, "reason", status.Convert(err).Message())
+		return ResultIgnored, nil
+	} else if err != nil {
+		return "", fmt.Errorf("failed to apply payment notification: %w", err)
+	}
+
+	return ResultProcessed, nil
+}
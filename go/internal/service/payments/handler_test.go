@@ -0,0 +1,129 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cgund98/go-eventsrc-example/internal/entity/orders/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/pg"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+type fakeNotifier struct {
+	orders    map[string]string
+	confirmed []controller.PaymentNotificationArgs
+	refundErr error
+}
+
+func (n *fakeNotifier) FindOrderByPaymentReference(ctx context.Context, paymentReference string) (string, error) {
+	orderId, ok := n.orders[paymentReference]
+	if !ok {
+		return "", controller.ErrOrderNotFound
+	}
+	return orderId, nil
+}
+
+func (n *fakeNotifier) ConfirmPayment(ctx context.Context, args controller.PaymentNotificationArgs) error {
+	n.confirmed = append(n.confirmed, args)
+	return nil
+}
+
+func (n *fakeNotifier) FailPayment(ctx context.Context, args controller.PaymentNotificationArgs) error {
+	return controller.ErrStalePaymentNotification
+}
+
+func (n *fakeNotifier) RefundPayment(ctx context.Context, args controller.PaymentNotificationArgs) error {
+	return n.refundErr
+}
+
+func newTestHandler(notifier PaymentNotifier) *WebhookHandler {
+	return NewWebhookHandler(notifier, webhooks.NewInMemoryReceiptStore(), &pg.TestTransactor{}, testSecret)
+}
+
+func post(handler http.Handler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(body))
+	req.Header.Set(SignatureHeader, webhooks.Sign(testSecret, []byte(body)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func result(t *testing.T, rec *httptest.ResponseRecorder) string {
+	var resp webhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.Result
+}
+
+func notification(eventId string, notificationType string) string {
+	return `{"event_id":"` + eventId + `","type":"` + notificationType + `","payment_reference":"pay-123","occurred_at":"2024-01-15T10:30:00Z"}`
+}
+
+func TestWebhookHandler(t *testing.T) {
+	t.Run("succeeded notification confirms the payment once", func(t *testing.T) {
+		notifier := &fakeNotifier{orders: map[string]string{"pay-123": "order-123"}}
+		handler := newTestHandler(notifier)
+
+		rec := post(handler, notification("evt-1", NotificationPaymentSucceeded))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ResultProcessed, result(t, rec))
+
+		rec = post(handler, notification("evt-1", NotificationPaymentSucceeded))
+		assert.Equal(t, ResultDuplicate, result(t, rec))
+
+		require.Len(t, notifier.confirmed, 1)
+		assert.Equal(t, "order-123", notifier.confirmed[0].OrderId)
+		assert.Equal(t, "2024-01-15T10:30:00Z", notifier.confirmed[0].NotifiedAt.Format("2006-01-02T15:04:05Z07:00"))
+	})
+
+	t.Run("unsigned notification is rejected", func(t *testing.T) {
+		notifier := &fakeNotifier{orders: map[string]string{"pay-123": "order-123"}}
+		handler := newTestHandler(notifier)
+
+		req := httptest.NewRequest(http.MethodPost, WebhookPath, strings.NewReader(notification("evt-1", NotificationPaymentSucceeded)))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Empty(t, notifier.confirmed)
+	})
+
+	t.Run("unknown payment reference is retried", func(t *testing.T) {
+		notifier := &fakeNotifier{orders: map[string]string{}}
+		handler := newTestHandler(notifier)
+
+		rec := post(handler, notification("evt-1", NotificationPaymentSucceeded))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		// Once the reference is known, the redelivered notification is applied
+		notifier.orders["pay-123"] = "order-123"
+		rec = post(handler, notification("evt-1", NotificationPaymentSucceeded))
+		assert.Equal(t, ResultProcessed, result(t, rec))
+	})
+
+	t.Run("refund before confirmation is retried", func(t *testing.T) {
+		notifier := &fakeNotifier{orders: map[string]string{"pay-123": "order-123"}, refundErr: controller.ErrPaymentNotPaid}
+		handler := newTestHandler(notifier)
+
+		rec := post(handler, notification("evt-2", NotificationPaymentRefunded))
+
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+
+	t.Run("stale notification is ignored", func(t *testing.T) {
+		notifier := &fakeNotifier{orders: map[string]string{"pay-123": "order-123"}}
+		handler := newTestHandler(notifier)
+
+		rec := post(handler, notification("evt-3", NotificationPaymentFailed))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, ResultIgnored, result(t, rec))
+	})
+}
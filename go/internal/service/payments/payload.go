@@ -0,0 +1,19 @@
+package payments
+
+import "time"
+
+const (
+	// Payment provider notification types
+	NotificationPaymentSucceeded = "payment.succeeded"
+	NotificationPaymentFailed    = "payment.failed"
+	NotificationPaymentRefunded  = "payment.refunded"
+)
+
+// Notification is the payload of a payment provider webhook.
+type Notification struct {
+	EventId          string    `json:"event_id"`
+	Type             string    `json:"type"`
+	PaymentReference string    `json:"payment_reference"`
+	Reason           string    `json:"reason"`
+	OccurredAt       time.Time `json:"occurred_at"`
+}
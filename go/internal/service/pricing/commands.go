@@ -0,0 +1,16 @@
+package pricing
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *PricingService) SetProductPrice(ctx context.Context, req *pb.SetProductPriceRequest) (*pb.SetProductPriceResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.engine.SetProductPrice(ctx, req))
+}
+
+func (s *PricingService) CreateCoupon(ctx context.Context, req *pb.CreateCouponRequest) (*pb.CreateCouponResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.couponController.CreateCoupon(ctx, req))
+}
@@ -0,0 +1,20 @@
+package pricing
+
+import (
+	"context"
+
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+func (s *PricingService) QuoteOrder(ctx context.Context, req *pb.QuoteOrderRequest) (*pb.QuoteOrderResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.engine.QuoteOrder(ctx, req))
+}
+
+func (s *PricingService) GetProductPrice(ctx context.Context, req *pb.GetProductPriceRequest) (*pb.GetProductPriceResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.engine.GetProductPrice(ctx, req))
+}
+
+func (s *PricingService) GetCoupon(ctx context.Context, req *pb.GetCouponRequest) (*pb.GetCouponResponse, error) {
+	return grpcutils.WrapNonGrpcError(s.couponController.GetCoupon(ctx, req))
+}
@@ -0,0 +1,26 @@
+package pricing
+
+import (
+	pb "github.com/cgund98/go-eventsrc-example/api/v1/orders"
+	couponctrl "github.com/cgund98/go-eventsrc-example/internal/entity/coupons/controller"
+	"github.com/cgund98/go-eventsrc-example/internal/entity/pricing"
+	"github.com/cgund98/go-eventsrc-example/internal/infra/auth"
+	grpcutils "github.com/cgund98/go-eventsrc-example/internal/service/grpc"
+)
+
+// Policy lets everyone quote orders and read prices. Prices and coupons are managed by admins.
+var Policy = grpcutils.Policy{
+	pb.PricingService_QuoteOrder_FullMethodName:      {auth.RoleCustomer, auth.RoleVendor},
+	pb.PricingService_GetProductPrice_FullMethodName: {auth.RoleCustomer, auth.RoleVendor},
+}
+
+type PricingService struct {
+	pb.UnimplementedPricingServiceServer
+
+	engine           *pricing.Engine
+	couponController *couponctrl.Controller
+}
+
+func NewPricingService(engine *pricing.Engine, couponController *couponctrl.Controller) *PricingService {
+	return &PricingService{engine: engine, couponController: couponController}
+}